@@ -37,6 +37,44 @@ var BadRequest = Type("BadRequest", func() {
 	})
 })
 
+var Conflict = Type("Conflict", func() {
+	Description("Resource was modified concurrently")
+	Attribute("message", String, "Error message", func() {
+		Example("Resource was modified by another request")
+	})
+})
+
+var MFARequired = Type("MFARequired", func() {
+	Description("Login refused until the user enrolls TOTP, as ENFORCE_2FA_FOR_ADMINS or ENFORCE_2FA_FOR_STAFF requires")
+	Attribute("code", String, "Error code", func() {
+		Enum("MFA_REQUIRED")
+		Example("MFA_REQUIRED")
+	})
+	Attribute("enrollment_url", String, "Endpoint to enroll TOTP at", func() {
+		Example("/api/v1/auth/me/totp/enroll")
+	})
+	Attribute("enrollment_token", String, "Short-lived bearer token accepted only by the TOTP enrollment endpoints")
+	Required("code", "enrollment_url")
+})
+
+var TooManyRequests = Type("TooManyRequests", func() {
+	Description("Request limit reached")
+	Attribute("message", String, "Error message", func() {
+		Example("Too many requests")
+	})
+})
+
+var SubmissionLimited = Type("SubmissionLimited", func() {
+	Description("Submission limit reached")
+	Attribute("message", String, "Error message", func() {
+		Example("Too many submissions. Please try again later")
+	})
+	Attribute("retry_after", Int, "Seconds until another submission is accepted", func() {
+		Example(1800)
+	})
+	Required("message", "retry_after")
+})
+
 // Health check
 var _ = Service("health", func() {
 	Description("Health check service")
@@ -56,6 +94,18 @@ var HealthResult = ResultType("HealthResult", func() {
 	Attribute("service", String, "Service name", func() {
 		Example("Spring Street API")
 	})
+	Attribute("uptime_seconds", Float64, "Seconds since the process started", func() {
+		Example(3600.5)
+	})
+	Attribute("version", String, "Application version", func() {
+		Example("1.0.0")
+	})
+	Attribute("build_time", String, "When the binary was built, or unknown", func() {
+		Example("2024-05-01T12:00:00Z")
+	})
+	Attribute("go_version", String, "Go version the binary was built with", func() {
+		Example("go1.24.11")
+	})
 })
 
 // Authentication service
@@ -64,16 +114,46 @@ var _ = Service("auth", func() {
 	Error("unauthorized", Unauthorized)
 	Error("not_found", NotFound)
 	Error("bad_request", BadRequest)
+	Error("too_many_requests", TooManyRequests)
 
 	Method("login", func() {
-		Description("Authenticate user and return JWT token")
+		Description("Authenticate user and return JWT token. Users with TOTP enabled get a challenge token to pass to verify_totp instead.")
 		Payload(LoginPayload)
 		Result(LoginResult)
 		Error("unauthorized")
+		Error("mfa_required", MFARequired, "The user must enroll TOTP before logging in")
 		HTTP(func() {
 			POST("/api/v1/auth/login")
 			Response(StatusOK)
 			Response("unauthorized", StatusUnauthorized)
+			Response("mfa_required", StatusForbidden)
+		})
+	})
+
+	Method("verify_totp", func() {
+		Description("Complete a login that asked for a second factor, with a TOTP code or an unused backup code")
+		Payload(VerifyTOTPPayload)
+		Result(LoginResult)
+		Error("unauthorized")
+		Error("too_many_requests")
+		HTTP(func() {
+			POST("/api/v1/auth/login/totp")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+			Response("too_many_requests", StatusTooManyRequests)
+		})
+	})
+
+	Method("introspect", func() {
+		Description("Report whether an access token is active (RFC 7662). Expects a form-encoded token and the INTROSPECT_SECRET as a bearer token.")
+		Payload(IntrospectPayload)
+		Result(IntrospectResult)
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/auth/introspect")
+			Header("authorization:Authorization")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
 		})
 	})
 
@@ -101,6 +181,90 @@ var _ = Service("auth", func() {
 		})
 	})
 
+	Method("export_user_data", func() {
+		Description("Download all personal data held about the current user (admins may pass user_id). Limited to once per 24 hours.")
+		Security(JWTAuth)
+		Payload(ExportUserDataPayload)
+		Result(ExportUserDataResult)
+		Error("unauthorized")
+		Error("not_found")
+		Error("too_many_requests")
+		HTTP(func() {
+			GET("/api/v1/auth/me/data-export")
+			Param("user_id")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_disposition:Content-Disposition")
+			})
+			Response("unauthorized", StatusUnauthorized)
+			Response("not_found", StatusNotFound)
+			Response("too_many_requests", StatusTooManyRequests)
+		})
+	})
+
+	Method("enroll_totp", func() {
+		Description("Start TOTP enrollment for the current user. Returns a new secret to add to an authenticator app; TOTP stays off until it is confirmed.")
+		Security(JWTAuth, func() {
+			Scope("totp_enrollment")
+		})
+		Payload(TOTPEnrollPayload)
+		Result(TOTPEnrollmentResult)
+		Error("unauthorized")
+		Error("bad_request")
+		HTTP(func() {
+			POST("/api/v1/auth/me/totp/enroll")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("confirm_totp", func() {
+		Description("Turn on TOTP for the current user with a code from the authenticator app enrolled in enroll_totp")
+		Security(JWTAuth, func() {
+			Scope("totp_enrollment")
+		})
+		Payload(TOTPConfirmPayload)
+		Result(TOTPConfirmResult)
+		Error("unauthorized")
+		Error("bad_request")
+		HTTP(func() {
+			POST("/api/v1/auth/me/totp/confirm")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("generate_backup_codes", func() {
+		Description("Generate account recovery backup codes for the current user. The codes are only shown in this response.")
+		Security(JWTAuth)
+		Payload(BackupCodesPayload)
+		Result(BackupCodesResult)
+		Error("unauthorized")
+		Error("bad_request")
+		HTTP(func() {
+			POST("/api/v1/auth/me/backup-codes")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("regenerate_backup_codes", func() {
+		Description("Invalidate the current user's backup codes and generate a fresh set")
+		Security(JWTAuth)
+		Payload(BackupCodesPayload)
+		Result(BackupCodesResult)
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/auth/me/backup-codes/regenerate")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
 	Method("create_user", func() {
 		Description("Create a new user (Admin only)")
 		Security(JWTAuth, func() {
@@ -184,6 +348,25 @@ var _ = Service("auth", func() {
 			Response("unauthorized", StatusUnauthorized)
 		})
 	})
+
+	Method("erase_user_data", func() {
+		Description("Anonymize a user's personal data across all tables (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(EraseUserDataPayload)
+		Result(ErasureResult)
+		Error("bad_request")
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			DELETE("/api/v1/auth/users/{id}/data")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
 })
 
 // JWT Security
@@ -191,6 +374,8 @@ var JWTAuth = JWTSecurity("jwt", func() {
 	Description("JWT authentication")
 	Scope("admin", "Admin access")
 	Scope("staff", "Staff access")
+	Scope("superadmin", "Organization management, for admins of the default organization")
+	Scope("totp_enrollment", "TOTP enrollment; every user has it, and the enrollment token of a login refused with MFA_REQUIRED has only it")
 })
 
 // Authentication payloads and results
@@ -207,12 +392,65 @@ var LoginPayload = Type("LoginPayload", func() {
 })
 
 var LoginResult = ResultType("LoginResult", func() {
-	Attribute("access_token", String, "JWT access token")
+	Attribute("access_token", String, "JWT access token; absent while a TOTP code is still needed")
 	Attribute("token_type", String, "Token type", func() {
 		Default("bearer")
 		Example("bearer")
 	})
-	Required("access_token", "token_type")
+	Attribute("totp_required", Boolean, "Whether the login must be completed with verify_totp")
+	Attribute("challenge_token", String, "Short-lived token to pass to verify_totp with the code")
+	Required("token_type")
+})
+
+var VerifyTOTPPayload = Type("VerifyTOTPPayload", func() {
+	Attribute("challenge_token", String, "Challenge token returned by login", func() {
+		MinLength(1)
+	})
+	Attribute("code", String, "6 digit TOTP code, or one of the user's backup codes", func() {
+		MinLength(1)
+		Example("123456")
+	})
+	Required("challenge_token", "code")
+})
+
+var TOTPEnrollPayload = Type("TOTPEnrollPayload", func() {
+	Token("token", String, "JWT token")
+})
+
+var TOTPEnrollmentResult = ResultType("TOTPEnrollmentResult", func() {
+	Attribute("secret", String, "Base32 secret to type into an authenticator app")
+	Attribute("otpauth_url", String, "otpauth:// URI to show as a QR code")
+	Required("secret", "otpauth_url")
+})
+
+var TOTPConfirmPayload = Type("TOTPConfirmPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("code", String, "Current 6 digit code from the authenticator app", func() {
+		Example("123456")
+	})
+	Required("code")
+})
+
+var TOTPConfirmResult = ResultType("TOTPConfirmResult", func() {
+	Attribute("message", String, "Result message")
+	Required("message")
+})
+
+var IntrospectPayload = Type("IntrospectPayload", func() {
+	Attribute("authorization", String, "Bearer token holding the introspection secret")
+	Attribute("token", String, "Access token to introspect")
+	Attribute("token_type_hint", String, "Type of the token (only access_token is issued)")
+	Required("token")
+})
+
+var IntrospectResult = ResultType("IntrospectResult", func() {
+	Attribute("active", Boolean, "Whether the token is valid and belongs to an active user")
+	Attribute("username", String, "User the token was issued to")
+	Attribute("is_admin", Boolean, "Whether the user is an admin")
+	Attribute("is_staff", Boolean, "Whether the user is staff")
+	Attribute("exp", Int64, "Expiry time (Unix seconds)")
+	Attribute("iat", Int64, "Issue time (Unix seconds)")
+	Required("active")
 })
 
 var LogoutPayload = Type("LogoutPayload", func() {
@@ -223,6 +461,27 @@ var MePayload = Type("MePayload", func() {
 	Token("token", String, "JWT token")
 })
 
+var ExportUserDataPayload = Type("ExportUserDataPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("user_id", Int, "User to export (admin only; defaults to the current user)")
+})
+
+var ExportUserDataResult = Type("ExportUserDataResult", func() {
+	Attribute("content_type", String, "Content type of the export")
+	Attribute("content_disposition", String, "Download file name")
+	Required("content_type", "content_disposition")
+})
+
+var BackupCodesPayload = Type("BackupCodesPayload", func() {
+	Token("token", String, "JWT token")
+})
+
+var BackupCodesResult = ResultType("BackupCodesResult", func() {
+	Attribute("codes", ArrayOf(String), "Single-use backup codes (shown only once)")
+	Attribute("message", String, "Result message")
+	Required("codes", "message")
+})
+
 var LogoutResult = ResultType("LogoutResult", func() {
 	Attribute("message", String, "Logout message", func() {
 		Example("Successfully logged out")
@@ -237,10 +496,12 @@ var UserResult = ResultType("UserResult", func() {
 	Attribute("is_active", Boolean, "Is user active")
 	Attribute("is_admin", Boolean, "Is user admin")
 	Attribute("is_staff", Boolean, "Is user staff")
+	Attribute("language", String, "Language of emails sent to the user")
 	Attribute("created_at", String, "Creation timestamp")
 	Attribute("updated_at", String, "Update timestamp")
 	Attribute("last_login", String, "Last login timestamp")
-	Required("id", "username", "email", "is_active", "is_admin", "is_staff", "created_at")
+	Attribute("organization_id", Int, "ID of the user's organization")
+	Required("id", "username", "email", "is_active", "is_admin", "is_staff", "language", "created_at", "organization_id")
 })
 
 var CreateUserPayload = Type("CreateUserPayload", func() {
@@ -267,6 +528,12 @@ var CreateUserPayload = Type("CreateUserPayload", func() {
 	Attribute("is_staff", Boolean, "Is user staff", func() {
 		Default(false)
 	})
+	Attribute("language", String, "Language of emails sent to the user (en, hi)", func() {
+		Default("en")
+	})
+	Attribute("organization_id", Int, "Organization of the new user; only superadmins may pick one, others create users in their own organization", func() {
+		Minimum(1)
+	})
 	Required("username", "email", "password")
 })
 
@@ -298,6 +565,7 @@ var UpdateUserPayload = Type("UpdateUserPayload", func() {
 	Attribute("is_active", Boolean, "Is user active")
 	Attribute("is_admin", Boolean, "Is user admin")
 	Attribute("is_staff", Boolean, "Is user staff")
+	Attribute("language", String, "Language of emails sent to the user (en, hi)")
 	Attribute("password", String, "Password")
 	Required("id")
 })
@@ -308,12 +576,30 @@ var DeleteUserPayload = Type("DeleteUserPayload", func() {
 	Required("id")
 })
 
+var EraseUserDataPayload = Type("EraseUserDataPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "User ID")
+	Required("id")
+})
+
+var ErasureResult = ResultType("ErasureResult", func() {
+	Attribute("user_id", Int, "Erased user ID")
+	Attribute("users", Int, "User records anonymized")
+	Attribute("investment_inquiries", Int, "Investment inquiries anonymized")
+	Attribute("investment_inquiry_versions", Int, "Investment inquiry versions deleted from the history")
+	Attribute("contact_inquiries", Int, "Contact inquiries anonymized")
+	Attribute("contact_notes", Int, "Contact notes whose author was anonymized")
+	Attribute("audit_logs", Int, "Audit log entries whose actor was anonymized")
+	Required("user_id", "users", "investment_inquiries", "investment_inquiry_versions", "contact_inquiries", "contact_notes", "audit_logs")
+})
+
 // Investment service
 var _ = Service("investment", func() {
 	Description("Investment inquiry service")
 	Error("not_found", NotFound)
 	Error("bad_request", BadRequest)
 	Error("unauthorized", Unauthorized)
+	Error("conflict", Conflict)
 
 	Method("create", func() {
 		Description("Create a new investment inquiry")
@@ -332,22 +618,26 @@ var _ = Service("investment", func() {
 		Payload(UpdateInquiryByPhonePayload)
 		Result(InvestmentInquiryResult)
 		Error("not_found")
+		Error("conflict")
 		HTTP(func() {
 			PATCH("/api/v1/investment/by-phone/{phone}")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
+			Response("conflict", StatusConflict)
 		})
 	})
 
 	Method("verify", func() {
-		Description("Mark inquiry as verified after OTP verification")
+		Description("Mark inquiry as verified after OTP verification. Each OTP verification can be used once.")
 		Payload(VerifyInquiryPayload)
 		Result(InvestmentInquiryResult)
 		Error("not_found")
+		Error("bad_request")
 		HTTP(func() {
 			POST("/api/v1/investment/verify/{identifier}")
 			Response(StatusOK)
 			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
 		})
 	})
 
@@ -370,137 +660,572 @@ var _ = Service("investment", func() {
 		})
 		Payload(ListInquiriesPayload)
 		Result(ArrayOf(InvestmentInquiryResult))
+		Error("bad_request")
 		Error("unauthorized")
 		HTTP(func() {
 			GET("/api/v1/investment/")
 			Param("skip")
 			Param("limit")
+			Param("min_score")
+			Param("include_archived")
+			Param("q")
+			Param("country")
+			Param("state")
+			Param("lead_source")
+			Param("status")
+			Param("created_after")
+			Param("created_before")
 			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
 			Response("unauthorized", StatusUnauthorized)
 		})
 	})
 
-	Method("get", func() {
-		Description("Get specific investment inquiry by ID (Staff/Admin only)")
+	Method("list_archived", func() {
+		Description("List archived investment inquiries, newest first (Staff/Admin only)")
 		Security(JWTAuth, func() {
 			Scope("staff")
 		})
-		Payload(GetInquiryPayload)
-		Result(InvestmentInquiryResult)
-		Error("not_found")
+		Payload(ListArchivedInquiriesPayload)
+		Result(ArrayOf(InvestmentInquiryResult))
 		Error("unauthorized")
 		HTTP(func() {
-			GET("/api/v1/investment/{id}")
+			GET("/api/v1/investment/archived")
+			Param("skip")
+			Param("limit")
 			Response(StatusOK)
-			Response("not_found", StatusNotFound)
 			Response("unauthorized", StatusUnauthorized)
 		})
 	})
-})
-
-var InvestmentInquiryResult = ResultType("InvestmentInquiryResult", func() {
-	Attribute("id", Int, "Inquiry ID")
-	Attribute("first_name", String, "First name")
-	Attribute("last_name", String, "Last name")
-	Attribute("phone", String, "Phone number")
-	Attribute("email", String, "Email address")
-	Attribute("investment_size", String, "Investment size")
-	Attribute("current_exposure", String, "Current exposure (comma-separated for multiple selections: direct-stocks, mutual-funds, sip)")
-	Attribute("verified", Boolean, "Verification status")
-	Attribute("exit_type", String, "Exit type")
-	Attribute("created_at", String, "Creation timestamp")
-	Attribute("updated_at", String, "Update timestamp")
-	Required("id", "verified", "created_at")
-})
-
-var InvestmentInquiryCreatePayload = Type("InvestmentInquiryCreatePayload", func() {
-	Attribute("phone", String, "Phone number")
-	Attribute("first_name", String, "First name")
-	Attribute("last_name", String, "Last name")
-	Attribute("email", String, "Email address")
-	Attribute("investment_size", String, "Investment size")
-	Attribute("current_exposure", String, "Current exposure (comma-separated for multiple selections: direct-stocks, mutual-funds, sip)")
-	Attribute("exit_type", String, "Exit type", func() {
-		Default("abandoned")
-		Example("abandoned")
-	})
-})
-
-var UpdateInquiryByPhonePayload = Type("UpdateInquiryByPhonePayload", func() {
-	Attribute("phone", String, "Phone number")
-	Attribute("first_name", String, "First name")
-	Attribute("last_name", String, "Last name")
-	Attribute("email", String, "Email address")
-	Attribute("investment_size", String, "Investment size")
-	Attribute("current_exposure", String, "Current exposure (comma-separated for multiple selections: direct-stocks, mutual-funds, sip)")
-	Required("phone")
-})
-
-var VerifyInquiryPayload = Type("VerifyInquiryPayload", func() {
-	Attribute("identifier", String, "Phone number or email")
-	Required("identifier")
-})
-
-var GetInquiryByPhonePayload = Type("GetInquiryByPhonePayload", func() {
-	Attribute("phone", String, "Phone number")
-	Required("phone")
-})
-
-var ListInquiriesPayload = Type("ListInquiriesPayload", func() {
-	Token("token", String, "JWT token")
-	Attribute("skip", Int, "Skip records", func() {
-		Default(0)
-		Minimum(0)
-	})
-	Attribute("limit", Int, "Limit records", func() {
-		Default(100)
-		Minimum(1)
-		Maximum(500)
-	})
-})
-
-var GetInquiryPayload = Type("GetInquiryPayload", func() {
-	Token("token", String, "JWT token")
-	Attribute("id", Int, "Inquiry ID")
-	Required("id")
-})
-
-// OTP service
-var _ = Service("otp", func() {
-	Description("OTP (One-Time Password) service")
-	Error("bad_request", BadRequest)
 
-	Method("send", func() {
-		Description("Send OTP to phone number or email")
-		Payload(SendOTPPayload)
-		Result(SendOTPResult)
+	Method("export_xlsx", func() {
+		Description("Download investment inquiries as an Excel workbook (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(ExportInquiriesPayload)
+		Result(ExportInquiriesResult)
 		Error("bad_request")
+		Error("unauthorized")
 		HTTP(func() {
-			POST("/api/v1/otp/send")
-			Response(StatusOK)
+			GET("/api/v1/investment/export.xlsx")
+			Param("min_score")
+			Param("include_archived")
+			Param("q")
+			Param("country")
+			Param("state")
+			Param("lead_source")
+			Param("status")
+			Param("created_after")
+			Param("created_before")
+			SkipResponseBodyEncodeDecode()
+			Response(StatusOK, func() {
+				Header("content_type:Content-Type")
+				Header("content_disposition:Content-Disposition")
+			})
 			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
 		})
 	})
 
-	Method("verify", func() {
-		Description("Verify OTP code")
-		Payload(VerifyOTPPayload)
-		Result(VerifyOTPResult)
-		Error("bad_request")
+	Method("stats_by_country", func() {
+		Description("Count investment inquiries by country, most first (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(InquiryStatsByCountryPayload)
+		Result(ArrayOf(CountryCountResult))
+		Error("unauthorized")
 		HTTP(func() {
-			POST("/api/v1/otp/verify")
+			GET("/api/v1/investment/stats/by-country")
+			Param("include_archived")
 			Response(StatusOK)
-			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
 		})
 	})
 
-	Method("check", func() {
-		Description("Check verification status")
-		Payload(CheckVerificationPayload)
-		Result(CheckVerificationResult)
-		HTTP(func() {
+	Method("stats_by_source", func() {
+		Description("Count investment inquiries and verifications by lead source, most first (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(InquiryStatsBySourcePayload)
+		Result(ArrayOf(LeadSourceStatsResult))
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/investment/stats/by-source")
+			Param("include_archived")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("get", func() {
+		Description("Get specific investment inquiry by ID (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(GetInquiryPayload)
+		Result(InvestmentInquiryResult)
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/investment/{id}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("history", func() {
+		Description("List the earlier versions of an investment inquiry, newest first (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(GetInquiryPayload)
+		Result(ArrayOf(InquiryVersionResult))
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/investment/{id}/history")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("restore_version", func() {
+		Description("Revert an investment inquiry to an earlier version (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(RestoreInquiryVersionPayload)
+		Result(InvestmentInquiryResult)
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/investment/{id}/history/{version_id}/restore")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("set_follow_up", func() {
+		Description("Schedule a follow-up reminder email for an investment inquiry, replacing any earlier one (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(SetFollowUpPayload)
+		Result(InvestmentInquiryResult)
+		Error("not_found")
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			PATCH("/api/v1/investment/{id}/follow-up")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("bulk_update_status", func() {
+		Description("Update the status of up to 100 investment inquiries at once (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(BulkUpdateInquiryStatusPayload)
+		Result(BulkUpdateResult)
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/investment/bulk/status")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("archive_inquiry", func() {
+		Description("Archive up to 100 investment inquiries; they are kept but hidden from the list (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(ArchiveInquiriesPayload)
+		Result(BulkUpdateResult)
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/investment/archive")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("unarchive_inquiry", func() {
+		Description("Return up to 100 archived investment inquiries to the list (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(ArchiveInquiriesPayload)
+		Result(BulkUpdateResult)
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/investment/unarchive")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("stream", func() {
+		Description("Stream newly created investment inquiries as Server-Sent Events (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(StreamInquiriesPayload)
+		StreamingResult(InvestmentInquiryEvent)
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/investment/stream")
+			ServerSentEvents()
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+})
+
+var InvestmentInquiryResult = ResultType("InvestmentInquiryResult", func() {
+	Attribute("id", Int, "Inquiry ID")
+	Attribute("first_name", String, "First name")
+	Attribute("last_name", String, "Last name")
+	Attribute("phone", String, "Phone number")
+	Attribute("email", String, "Email address")
+	Attribute("investment_size", String, "Investment size")
+	Attribute("current_exposure", String, "Current exposure (comma-separated for multiple selections: direct-stocks, mutual-funds, sip)")
+	Attribute("verified", Boolean, "Verification status")
+	Attribute("exit_type", String, "Exit type")
+	Attribute("status", String, "Staff workflow status (new, contacted, qualified, disqualified, converted)")
+	Attribute("lead_score", Int, "Lead score from 0 to 100; higher is more promising")
+	Attribute("created_at", String, "Creation timestamp")
+	Attribute("updated_at", String, "Update timestamp")
+	Attribute("version", Int, "Row version for optimistic locking")
+	Attribute("archived", Boolean, "Whether the inquiry is archived")
+	Attribute("archived_at", String, "Archive timestamp")
+	Attribute("follow_up_at", String, "When a follow-up reminder is due")
+	Attribute("follow_up_notified", Boolean, "Whether the follow-up reminder has been sent")
+	Attribute("custom_fields", MapOf(String, Any), "Deployment-specific fields, typed as in CUSTOM_FIELD_SCHEMA")
+	Attribute("country", String, "ISO 3166-1 alpha-2 country code")
+	Attribute("state", String, "State or region")
+	Attribute("lead_source", String, "Marketing channel the lead came from (organic, paid_search, social, referral, email_campaign, direct, unknown)")
+	Attribute("referrer_url", String, "Page that referred the visitor to the form")
+	Required("id", "verified", "status", "lead_score", "created_at", "version", "archived", "follow_up_notified", "country", "lead_source")
+})
+
+var ArchiveInquiriesPayload = Type("ArchiveInquiriesPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("ids", ArrayOf(Int), "Investment inquiry IDs", func() {
+		MinLength(1)
+		MaxLength(100)
+	})
+	Required("ids")
+})
+
+var BulkUpdateInquiryStatusPayload = Type("BulkUpdateInquiryStatusPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("ids", ArrayOf(Int), "Investment inquiry IDs", func() {
+		MinLength(1)
+		MaxLength(100)
+	})
+	Attribute("status", String, "New status", func() {
+		Enum("new", "contacted", "qualified", "disqualified", "converted")
+	})
+	Required("ids", "status")
+})
+
+var BulkUpdateResult = ResultType("BulkUpdateResult", func() {
+	Attribute("updated_count", Int, "Number of inquiries updated")
+	Attribute("failed_ids", ArrayOf(Int), "IDs that were not found, cannot move to the requested status or were changed by another request meanwhile")
+	Required("updated_count", "failed_ids")
+})
+
+var InvestmentInquiryCreatePayload = Type("InvestmentInquiryCreatePayload", func() {
+	Attribute("phone", String, "Phone number")
+	Attribute("first_name", String, "First name")
+	Attribute("last_name", String, "Last name")
+	Attribute("email", String, "Email address")
+	Attribute("investment_size", String, "Investment size")
+	Attribute("current_exposure", String, "Current exposure (comma-separated for multiple selections: direct-stocks, mutual-funds, sip)")
+	Attribute("exit_type", String, "Exit type", func() {
+		Default("abandoned")
+		Example("abandoned")
+	})
+	Attribute("captcha_token", String, "hCaptcha or Turnstile response token; required when CAPTCHA is enabled")
+	Attribute("country", String, "ISO 3166-1 alpha-2 country code; detected from the phone number when omitted, otherwise IN", func() {
+		Pattern("^[A-Za-z]{2}$")
+		Example("IN")
+	})
+	Attribute("state", String, "State or region", func() {
+		MaxLength(100)
+		Example("Maharashtra")
+	})
+	Attribute("lead_source", String, "Marketing channel the lead came from; detected from referrer_url when omitted", func() {
+		Enum("organic", "paid_search", "social", "referral", "email_campaign", "direct", "unknown")
+	})
+	Attribute("referrer_url", String, "Page that referred the visitor to the form (document.referrer)", func() {
+		Format(FormatURI)
+		MaxLength(2048)
+		Example("https://www.google.com/")
+	})
+	Attribute("custom_fields", MapOf(String, String, func() {
+		Elem(func() {
+			MaxLength(1000)
+		})
+	}), "Deployment-specific fields; the allowed keys and their types come from CUSTOM_FIELD_SCHEMA", func() {
+		MaxLength(50)
+	})
+})
+
+var UpdateInquiryByPhonePayload = Type("UpdateInquiryByPhonePayload", func() {
+	Attribute("phone", String, "Phone number")
+	Attribute("first_name", String, "First name")
+	Attribute("last_name", String, "Last name")
+	Attribute("email", String, "Email address")
+	Attribute("investment_size", String, "Investment size")
+	Attribute("current_exposure", String, "Current exposure (comma-separated for multiple selections: direct-stocks, mutual-funds, sip)")
+	Attribute("custom_fields", MapOf(String, String, func() {
+		Elem(func() {
+			MaxLength(1000)
+		})
+	}), "Custom fields to set, as in create; an empty value removes the field", func() {
+		MaxLength(50)
+	})
+	Attribute("version", Int, "Version of the inquiry the update is based on")
+	Required("phone", "version")
+})
+
+var VerifyInquiryPayload = Type("VerifyInquiryPayload", func() {
+	Attribute("identifier", String, "Phone number or email")
+	Required("identifier")
+})
+
+var GetInquiryByPhonePayload = Type("GetInquiryByPhonePayload", func() {
+	Attribute("phone", String, "Phone number")
+	Required("phone")
+})
+
+var ListInquiriesPayload = Type("ListInquiriesPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("skip", Int, "Skip records", func() {
+		Default(0)
+		Minimum(0)
+	})
+	Attribute("limit", Int, "Limit records", func() {
+		Default(100)
+		Minimum(1)
+		Maximum(500)
+	})
+	inquiryFilterAttributes()
+})
+
+// inquiryFilterAttributes are the filters shared by the inquiry list and export
+func inquiryFilterAttributes() {
+	Attribute("min_score", Int, "Only include inquiries with at least this lead score", func() {
+		Minimum(0)
+		Maximum(100)
+	})
+	Attribute("include_archived", Boolean, "Include archived inquiries", func() {
+		Default(false)
+	})
+	Attribute("q", String, "Search term matched against name, email and phone; results are ranked by relevance on PostgreSQL", func() {
+		MaxLength(200)
+		Example("rahul")
+	})
+	Attribute("country", String, "Only include inquiries from this country (ISO 3166-1 alpha-2)", func() {
+		Pattern("^[A-Za-z]{2}$")
+	})
+	Attribute("state", String, "Only include inquiries from this state or region", func() {
+		MaxLength(100)
+	})
+	Attribute("lead_source", String, "Only include inquiries from this lead source", func() {
+		Enum("organic", "paid_search", "social", "referral", "email_campaign", "direct", "unknown")
+	})
+	Attribute("status", String, "Only include inquiries with this status", func() {
+		Enum("new", "contacted", "qualified", "disqualified", "converted")
+	})
+	Attribute("created_after", String, "Only inquiries created at or after this date (YYYY-MM-DD or RFC3339)", func() {
+		Example("2024-04-01")
+	})
+	Attribute("created_before", String, "Only inquiries created before this date (YYYY-MM-DD or RFC3339)", func() {
+		Example("2024-05-01")
+	})
+}
+
+var ListArchivedInquiriesPayload = Type("ListArchivedInquiriesPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("skip", Int, "Skip records", func() {
+		Default(0)
+		Minimum(0)
+	})
+	Attribute("limit", Int, "Limit records", func() {
+		Default(100)
+		Minimum(1)
+		Maximum(500)
+	})
+})
+
+var ExportInquiriesPayload = Type("ExportInquiriesPayload", func() {
+	Token("token", String, "JWT token")
+	inquiryFilterAttributes()
+})
+
+var InquiryStatsByCountryPayload = Type("InquiryStatsByCountryPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("include_archived", Boolean, "Include archived inquiries", func() {
+		Default(false)
+	})
+})
+
+var CountryCountResult = Type("CountryCountResult", func() {
+	Attribute("country", String, "ISO 3166-1 alpha-2 country code")
+	Attribute("count", Int64, "Number of inquiries")
+	Required("country", "count")
+})
+
+var InquiryStatsBySourcePayload = Type("InquiryStatsBySourcePayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("include_archived", Boolean, "Include archived inquiries", func() {
+		Default(false)
+	})
+})
+
+var LeadSourceStatsResult = Type("LeadSourceStatsResult", func() {
+	Attribute("lead_source", String, "Lead source")
+	Attribute("total", Int64, "Number of inquiries")
+	Attribute("verified", Int64, "Number of verified inquiries")
+	Attribute("conversion_rate", Float64, "Share of inquiries that were verified, from 0 to 1")
+	Required("lead_source", "total", "verified", "conversion_rate")
+})
+
+var ExportInquiriesResult = Type("ExportInquiriesResult", func() {
+	Attribute("content_type", String, "Content type of the export")
+	Attribute("content_disposition", String, "Download file name")
+	Required("content_type", "content_disposition")
+})
+
+var StreamInquiriesPayload = Type("StreamInquiriesPayload", func() {
+	Token("token", String, "JWT token")
+})
+
+// InvestmentInquiryEvent carries the same fields as InvestmentInquiryResult.
+// It is a plain type because Goa's SSE generator does not support viewed result types.
+var InvestmentInquiryEvent = Type("InvestmentInquiryEvent", func() {
+	Extend(InvestmentInquiryResult)
+})
+
+var GetInquiryPayload = Type("GetInquiryPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Inquiry ID")
+	Required("id")
+})
+
+var RestoreInquiryVersionPayload = Type("RestoreInquiryVersionPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Inquiry ID")
+	Attribute("version_id", Int, "Version to restore")
+	Required("id", "version_id")
+})
+
+var SetFollowUpPayload = Type("SetFollowUpPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Inquiry ID")
+	Attribute("follow_up_at", String, "When to send the reminder (RFC3339, in the future)", func() {
+		Format(FormatDateTime)
+		Example("2024-05-03T10:00:00+05:30")
+	})
+	Required("id", "follow_up_at")
+})
+
+var InquiryVersionResult = ResultType("InquiryVersionResult", func() {
+	Attribute("version_id", Int, "Version ID")
+	Attribute("changed_at", String, "When the inquiry was changed away from this version")
+	Attribute("changed_by_username", String, "Staff user who made the change (absent for changes through the public form)")
+	Attribute("snapshot", MapOf(String, Any), "Full inquiry as it was before the change")
+	Required("version_id", "changed_at", "snapshot")
+})
+
+// OTP service
+var _ = Service("otp", func() {
+	Description("OTP (One-Time Password) service")
+	Error("bad_request", BadRequest)
+	Error("unauthorized", Unauthorized)
+
+	Method("send", func() {
+		Description("Send OTP to phone number or email")
+		Payload(SendOTPPayload)
+		Result(SendOTPResult)
+		Error("bad_request")
+		HTTP(func() {
+			POST("/api/v1/otp/send")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("resend", func() {
+		Description("Resend the current OTP code, or send a new one if there is no live session")
+		Payload(SendOTPPayload)
+		Result(ResendOTPResult)
+		Error("bad_request")
+		HTTP(func() {
+			POST("/api/v1/otp/resend")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("verify", func() {
+		Description("Verify OTP code")
+		Payload(VerifyOTPPayload)
+		Result(VerifyOTPResult)
+		Error("bad_request")
+		HTTP(func() {
+			POST("/api/v1/otp/verify")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("check", func() {
+		Description("Check verification status")
+		Payload(CheckVerificationPayload)
+		Result(CheckVerificationResult)
+		Error("bad_request")
+		HTTP(func() {
 			POST("/api/v1/otp/check")
 			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+		})
+	})
+
+	Method("deliveries", func() {
+		Description("List OTP SMS deliveries by delivery status, for support (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(ListOTPDeliveriesPayload)
+		Result(ArrayOf(OTPDeliveryResult))
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/otp/deliveries")
+			Param("status")
+			Param("active_only")
+			Param("limit")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
 		})
 	})
 })
@@ -508,6 +1233,12 @@ var _ = Service("otp", func() {
 var SendOTPPayload = Type("SendOTPPayload", func() {
 	Attribute("phone_number", String, "Phone number")
 	Attribute("email", String, "Email address")
+	Attribute("channel", String, "Deliver only through this channel; by default the OTP goes to every contact method provided", func() {
+		Enum("sms", "whatsapp", "email")
+	})
+	Attribute("locale", String, "Language of the SMS and email text (e.g. en, hi); defaults to the recipient's language or English", func() {
+		Example("hi")
+	})
 })
 
 var SendOTPResult = ResultType("SendOTPResult", func() {
@@ -516,15 +1247,30 @@ var SendOTPResult = ResultType("SendOTPResult", func() {
 	Attribute("expires_in_minutes", Int, "OTP expiration in minutes", func() {
 		Default(10)
 	})
-	Required("message", "phone_number", "expires_in_minutes")
+	Attribute("delivery_channels", ArrayOf(String), "Channels the code was delivered through (email, sms, whatsapp)", func() {
+		Example([]string{"sms"})
+	})
+	Required("message", "phone_number", "expires_in_minutes", "delivery_channels")
+})
+
+var ResendOTPResult = ResultType("ResendOTPResult", func() {
+	Attribute("message", String, "Response message")
+	Attribute("phone_number", String, "Phone number")
+	Attribute("expires_in_minutes", Int, "Minutes until the OTP expires")
+	Attribute("seconds_until_next_resend", Int, "Seconds to wait before the code can be resent again")
+	Attribute("resent", Boolean, "Whether the existing code was resent (false means a new code was generated)")
+	Attribute("delivery_channels", ArrayOf(String), "Channels the code was delivered through (email, sms, whatsapp)", func() {
+		Example([]string{"sms"})
+	})
+	Required("message", "phone_number", "expires_in_minutes", "seconds_until_next_resend", "resent", "delivery_channels")
 })
 
 var VerifyOTPPayload = Type("VerifyOTPPayload", func() {
 	Attribute("phone_number", String, "Phone number")
 	Attribute("email", String, "Email address")
-	Attribute("otp_code", String, "6-digit OTP code", func() {
-		MinLength(6)
-		MaxLength(6)
+	Attribute("otp_code", String, "OTP code: 6 digits when sent by SMS or WhatsApp, 8 letters and digits (case-insensitive) when sent only by email, unless configured otherwise", func() {
+		MinLength(4)
+		MaxLength(12)
 		Example("123456")
 	})
 	Required("otp_code")
@@ -544,45 +1290,223 @@ var CheckVerificationPayload = Type("CheckVerificationPayload", func() {
 		MinLength(10)
 		MaxLength(20)
 	})
-	Required("phone_number")
+	Attribute("email", String, "Email address")
 })
 
 var CheckVerificationResult = ResultType("CheckVerificationResult", func() {
-	Attribute("phone_number", String, "Phone number")
+	Attribute("identifier", String, "Normalized phone number or email that was checked")
+	Attribute("phone_number", String, "Same as identifier; kept for backward compatibility")
 	Attribute("verified", Boolean, "Verification status")
-	Required("phone_number", "verified")
+	Required("identifier", "phone_number", "verified")
 })
 
-// Contact service
-var _ = Service("contact", func() {
-	Description("Contact form service")
-	Error("bad_request", BadRequest)
-	Error("unauthorized", Unauthorized)
+var ListOTPDeliveriesPayload = Type("ListOTPDeliveriesPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("status", String, "Delivery status reported by the SMS provider", func() {
+		Enum("queued", "sent", "delivered", "undelivered", "failed")
+		Default("failed")
+	})
+	Attribute("active_only", Boolean, "Only include deliveries to numbers with an unexpired OTP session", func() {
+		Default(true)
+	})
+	Attribute("limit", Int, "Limit records", func() {
+		Default(100)
+		Minimum(1)
+		Maximum(500)
+	})
+})
+
+var OTPDeliveryResult = ResultType("OTPDeliveryResult", func() {
+	Attribute("message_sid", String, "Provider message SID")
+	Attribute("provider", String, "SMS provider")
+	Attribute("phone_number", String, "Normalized phone number")
+	Attribute("status", String, "Delivery status")
+	Attribute("error_code", String, "Provider error code for failed deliveries")
+	Attribute("session_active", Boolean, "Whether the number has an unexpired OTP session")
+	Attribute("created_at", String, "When the message was sent")
+	Attribute("updated_at", String, "When the status was last reported")
+	Required("message_sid", "provider", "phone_number", "status", "session_active", "created_at", "updated_at")
+})
+
+// Contact service
+var _ = Service("contact", func() {
+	Description("Contact form service")
+	Error("bad_request", BadRequest)
+	Error("not_found", NotFound)
+	Error("unauthorized", Unauthorized)
+
+	Method("submit", func() {
+		Description("Submit contact form")
+		Payload(ContactSubmitPayload)
+		Result(ContactSubmitResult)
+		Error("bad_request")
+		Error("too_many_requests", SubmissionLimited)
+		HTTP(func() {
+			POST("/api/v1/contact/submit")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("too_many_requests", StatusTooManyRequests, func() {
+				Header("retry_after:Retry-After")
+			})
+		})
+	})
+
+	Method("list", func() {
+		Description("List all contact inquiries (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(ListContactInquiriesPayload)
+		Result(ArrayOf(ContactInquiryResult))
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/contact/")
+			Param("skip")
+			Param("limit")
+			Param("include_spam")
+			Param("q")
+			Param("status")
+			Param("category")
+			Param("created_after")
+			Param("created_before")
+			Param("unread_only")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("get", func() {
+		Description("Get a contact inquiry, recording the first time staff read it (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(GetContactInquiryPayload)
+		Result(ContactInquiryResult)
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/contact/{id}")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("add_note", func() {
+		Description("Add an internal note to a contact inquiry (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(AddContactNotePayload)
+		Result(ContactNoteResult)
+		Error("bad_request")
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/contact/{id}/notes")
+			Response(StatusCreated)
+			Response("bad_request", StatusBadRequest)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("list_notes", func() {
+		Description("List internal notes on a contact inquiry (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(ListContactNotesPayload)
+		Result(ArrayOf(ContactNoteResult))
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/contact/{id}/notes")
+			Response(StatusOK)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("delete_note", func() {
+		Description("Delete an internal note (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(DeleteContactNotePayload)
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			DELETE("/api/v1/contact/{id}/notes/{note_id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("delete", func() {
+		Description("Soft-delete a contact inquiry (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(DeleteContactInquiryPayload)
+		Error("not_found")
+		Error("unauthorized")
+		HTTP(func() {
+			DELETE("/api/v1/contact/{id}")
+			Response(StatusNoContent)
+			Response("not_found", StatusNotFound)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("bulk_update_status", func() {
+		Description("Update the status of up to 100 contact inquiries at once (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(BulkUpdateContactStatusPayload)
+		Result(BulkOperationResult)
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/contact/bulk/status")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
 
-	Method("submit", func() {
-		Description("Submit contact form")
-		Payload(ContactSubmitPayload)
-		Result(ContactSubmitResult)
+	Method("bulk_delete", func() {
+		Description("Soft-delete up to 100 contact inquiries at once (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(BulkDeleteContactsPayload)
+		Result(BulkOperationResult)
 		Error("bad_request")
+		Error("unauthorized")
 		HTTP(func() {
-			POST("/api/v1/contact/submit")
+			POST("/api/v1/contact/bulk/delete")
 			Response(StatusOK)
 			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
 		})
 	})
 
-	Method("list", func() {
-		Description("List all contact inquiries (Staff/Admin only)")
+	Method("purge", func() {
+		Description("Permanently delete contact inquiries soft-deleted more than N days ago (Admin only)")
 		Security(JWTAuth, func() {
-			Scope("staff")
+			Scope("admin")
 		})
-		Payload(ListContactInquiriesPayload)
-		Result(ArrayOf(ContactInquiryResult))
+		Payload(PurgeContactInquiriesPayload)
+		Result(PurgeContactInquiriesResult)
 		Error("unauthorized")
 		HTTP(func() {
-			GET("/api/v1/contact/")
-			Param("skip")
-			Param("limit")
+			POST("/api/v1/contact/purge")
+			Param("older_than_days")
 			Response(StatusOK)
 			Response("unauthorized", StatusUnauthorized)
 		})
@@ -605,6 +1529,16 @@ var ContactSubmitPayload = Type("ContactSubmitPayload", func() {
 		MaxLength(5000)
 		Example("I'm interested in learning more about global investing.")
 	})
+	Attribute("subject", String, "Subject (optional)", func() {
+		MaxLength(200)
+		Example("Question about portfolio rebalancing")
+	})
+	Attribute("category", String, "Category", func() {
+		Enum("general", "support", "partnership", "press", "complaint")
+		Default("general")
+	})
+	Attribute("website", String, "Honeypot field; must be left empty")
+	Attribute("captcha_token", String, "hCaptcha or Turnstile response token; required when CAPTCHA is enabled")
 	Required("name", "email", "message")
 })
 
@@ -625,6 +1559,34 @@ var ListContactInquiriesPayload = Type("ListContactInquiriesPayload", func() {
 		Minimum(1)
 		Maximum(500)
 	})
+	Attribute("include_spam", Boolean, "Include submissions flagged as spam", func() {
+		Default(false)
+	})
+	Attribute("q", String, "Search term matched against name, email and message", func() {
+		MaxLength(200)
+		Example("rahul@")
+	})
+	Attribute("status", String, "Filter by status", func() {
+		Enum("new", "read", "replied")
+	})
+	Attribute("category", String, "Filter by category", func() {
+		Enum("general", "support", "partnership", "press", "complaint")
+	})
+	Attribute("created_after", String, "Only inquiries created at or after this date (YYYY-MM-DD or RFC3339)", func() {
+		Example("2024-04-01")
+	})
+	Attribute("created_before", String, "Only inquiries created before this date (YYYY-MM-DD or RFC3339)", func() {
+		Example("2024-05-01")
+	})
+	Attribute("unread_only", Boolean, "Only inquiries no staff member has opened yet", func() {
+		Default(false)
+	})
+})
+
+var GetContactInquiryPayload = Type("GetContactInquiryPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Contact inquiry ID")
+	Required("id")
 })
 
 var ContactInquiryResult = ResultType("ContactInquiryResult", func() {
@@ -632,9 +1594,388 @@ var ContactInquiryResult = ResultType("ContactInquiryResult", func() {
 	Attribute("name", String, "Full name")
 	Attribute("email", String, "Email address")
 	Attribute("phone", String, "Phone number")
+	Attribute("subject", String, "Subject")
+	Attribute("category", String, "Category")
 	Attribute("message", String, "Message content")
 	Attribute("status", String, "Status (new, read, replied)")
+	Attribute("is_spam", Boolean, "Flagged as spam")
+	Attribute("spam_score", Int, "Spam heuristic score (0-100)")
+	Attribute("notes_count", Int, "Number of internal notes")
+	Attribute("first_read_at", String, "When a staff member first opened the inquiry")
+	Attribute("first_read_by_username", String, "Username of the staff member who first opened the inquiry")
 	Attribute("created_at", String, "Creation timestamp")
 	Attribute("updated_at", String, "Update timestamp")
-	Required("id", "name", "email", "message", "status", "created_at")
+	Required("id", "name", "email", "category", "message", "status", "is_spam", "spam_score", "notes_count", "created_at")
+})
+
+var AddContactNotePayload = Type("AddContactNotePayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Contact inquiry ID")
+	Attribute("text", String, "Note text", func() {
+		MinLength(1)
+		MaxLength(2000)
+		Example("Left voicemail 2024-05-03")
+	})
+	Required("id", "text")
+})
+
+var ListContactNotesPayload = Type("ListContactNotesPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Contact inquiry ID")
+	Required("id")
+})
+
+var DeleteContactNotePayload = Type("DeleteContactNotePayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Contact inquiry ID")
+	Attribute("note_id", Int, "Note ID")
+	Required("id", "note_id")
+})
+
+var DeleteContactInquiryPayload = Type("DeleteContactInquiryPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("id", Int, "Contact inquiry ID")
+	Required("id")
+})
+
+var BulkUpdateContactStatusPayload = Type("BulkUpdateContactStatusPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("ids", ArrayOf(Int), "Contact inquiry IDs", func() {
+		MinLength(1)
+		MaxLength(100)
+	})
+	Attribute("status", String, "New status", func() {
+		Enum("new", "read", "replied")
+	})
+	Required("ids", "status")
+})
+
+var BulkDeleteContactsPayload = Type("BulkDeleteContactsPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("ids", ArrayOf(Int), "Contact inquiry IDs", func() {
+		MinLength(1)
+		MaxLength(100)
+	})
+	Required("ids")
+})
+
+var BulkOperationResult = ResultType("BulkOperationResult", func() {
+	Attribute("success_count", Int, "Number of inquiries changed")
+	Attribute("failure_count", Int, "Number of inquiries skipped")
+	Attribute("failed_ids", ArrayOf(Int), "IDs that were skipped")
+	Attribute("errors", ArrayOf(String), "Reason for each skipped ID, in the same order as failed_ids")
+	Required("success_count", "failure_count", "failed_ids", "errors")
+})
+
+var PurgeContactInquiriesPayload = Type("PurgeContactInquiriesPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("older_than_days", Int, "Purge inquiries deleted more than this many days ago", func() {
+		Default(30)
+		Minimum(0)
+	})
+})
+
+var PurgeContactInquiriesResult = ResultType("PurgeContactInquiriesResult", func() {
+	Attribute("purged", Int, "Number of contact inquiries permanently deleted")
+	Attribute("message", String, "Response message")
+	Required("purged", "message")
+})
+
+var ContactNoteResult = ResultType("ContactNoteResult", func() {
+	Attribute("id", Int, "Note ID")
+	Attribute("contact_inquiry_id", Int, "Contact inquiry ID")
+	Attribute("author", String, "Username of the note author")
+	Attribute("text", String, "Note text")
+	Attribute("created_at", String, "Creation timestamp")
+	Required("id", "contact_inquiry_id", "author", "text", "created_at")
+})
+
+// Admin service
+var _ = Service("admin", func() {
+	Description("Administrative operations service")
+	Error("bad_request", BadRequest)
+	Error("unauthorized", Unauthorized)
+	Error("too_many_requests", TooManyRequests)
+
+	Method("features", func() {
+		Description("List current feature flag values (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(AdminFeaturesPayload)
+		Result(ArrayOf(FeatureFlagResult))
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/admin/features")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("audit_logs", func() {
+		Description("List audit log entries, newest first, one page at a time (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(AuditLogsPayload)
+		Result(AuditLogPageResult)
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/admin/audit-logs")
+			Param("actor_id")
+			Param("resource_type")
+			Param("resource_id")
+			Param("action")
+			Param("date_from")
+			Param("date_to")
+			Param("cursor")
+			Param("limit")
+			Response(StatusOK)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("email_logs", func() {
+		Description("List outbound email deliveries, newest first (Staff/Admin only)")
+		Security(JWTAuth, func() {
+			Scope("staff")
+		})
+		Payload(EmailLogsPayload)
+		Result(ArrayOf(EmailLogResult))
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/admin/email-logs")
+			Param("type")
+			Param("status")
+			Param("recipient")
+			Param("limit")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("test_email", func() {
+		Description("Send a test email through the live email configuration and report the provider's answer (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(TestEmailPayload)
+		Result(TestDeliveryResult)
+		Error("unauthorized")
+		Error("too_many_requests")
+		HTTP(func() {
+			POST("/api/v1/admin/test-email")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+			Response("too_many_requests", StatusTooManyRequests)
+		})
+	})
+
+	Method("test_sms", func() {
+		Description("Send a test SMS through the live SMS configuration and report the provider's answer (Admin only)")
+		Security(JWTAuth, func() {
+			Scope("admin")
+		})
+		Payload(TestSMSPayload)
+		Result(TestDeliveryResult)
+		Error("unauthorized")
+		Error("too_many_requests")
+		HTTP(func() {
+			POST("/api/v1/admin/test-sms")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+			Response("too_many_requests", StatusTooManyRequests)
+		})
+	})
+})
+
+var AdminFeaturesPayload = Type("AdminFeaturesPayload", func() {
+	Token("token", String, "JWT token")
+})
+
+var AuditLogsPayload = Type("AuditLogsPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("actor_id", Int, "Only entries made by this user")
+	Attribute("resource_type", String, "Only entries for this kind of resource", func() {
+		Enum("user", "investment_inquiry", "contact_inquiry")
+	})
+	Attribute("resource_id", Int, "Only entries for this resource (use with resource_type)")
+	Attribute("action", String, "Only entries with this action", func() {
+		Example("status_change")
+	})
+	Attribute("date_from", String, "Only entries created at or after this date (YYYY-MM-DD or RFC3339)", func() {
+		Example("2024-04-01")
+	})
+	Attribute("date_to", String, "Only entries created before this date (YYYY-MM-DD or RFC3339)", func() {
+		Example("2024-05-01")
+	})
+	Attribute("cursor", String, "next_cursor from the previous page; omit for the first page")
+	Attribute("limit", Int, "Entries per page", func() {
+		Default(50)
+		Minimum(1)
+		Maximum(200)
+	})
+})
+
+var AuditLogResult = ResultType("AuditLogResult", func() {
+	Attribute("id", Int, "Audit log entry ID")
+	Attribute("actor_user_id", Int, "ID of the user who made the change (absent for system actions)")
+	Attribute("actor", String, "Username at the time of the change")
+	Attribute("action", String, "Action performed")
+	Attribute("resource_type", String, "Kind of resource changed (user, investment_inquiry, contact_inquiry)")
+	Attribute("resource_id", Int, "ID of the resource changed")
+	Attribute("details", String, "Details of the change")
+	Attribute("created_at", String, "When the change was made")
+	Required("id", "actor", "action", "resource_type", "resource_id", "created_at")
+})
+
+var AuditLogPageResult = ResultType("AuditLogPageResult", func() {
+	Attribute("items", ArrayOf(AuditLogResult), "Audit log entries, newest first")
+	Attribute("next_cursor", String, "Pass as cursor to get the next page; absent on the last page")
+	Attribute("total_count", Int, "Entries matching the filters (first page only)")
+	Required("items")
+})
+
+var EmailLogsPayload = Type("EmailLogsPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("type", String, "Only emails of this type", func() {
+		Enum("otp", "contact_notification", "follow_up_reminder", "generic", "test", "marketing")
+	})
+	Attribute("status", String, "Only emails with this outcome", func() {
+		Enum("sent", "failed")
+	})
+	Attribute("recipient", String, "Only emails to this address", func() {
+		Format(FormatEmail)
+	})
+	Attribute("limit", Int, "Limit records", func() {
+		Default(100)
+		Minimum(1)
+		Maximum(500)
+	})
+})
+
+var EmailLogResult = ResultType("EmailLogResult", func() {
+	Attribute("id", Int, "Email log entry ID")
+	Attribute("recipient", String, "Recipient address, partially masked", func() {
+		Example("ra***@example.com")
+	})
+	Attribute("type", String, "Email type (otp, contact_notification, follow_up_reminder, generic, test, marketing)")
+	Attribute("provider", String, "Email provider the email was sent through")
+	Attribute("status", String, "sent or failed")
+	Attribute("error", String, "Why sending failed")
+	Attribute("created_at", String, "When the email was sent or given up on")
+	Required("id", "recipient", "type", "provider", "status", "created_at")
+})
+
+var TestEmailPayload = Type("TestEmailPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("to", String, "Address to send the test email to", func() {
+		Format(FormatEmail)
+	})
+	Required("to")
+})
+
+var TestSMSPayload = Type("TestSMSPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("phone_number", String, "Number to send the test SMS to", func() {
+		MinLength(10)
+		MaxLength(20)
+		Example("+919876543210")
+	})
+	Required("phone_number")
+})
+
+var TestDeliveryResult = ResultType("TestDeliveryResult", func() {
+	Attribute("provider", String, "Provider the message was sent through")
+	Attribute("sent", Boolean, "Whether the provider accepted the message")
+	Attribute("response", String, "Provider's answer when it accepted the message")
+	Attribute("error", String, "Why sending failed, with credentials removed")
+	Required("provider", "sent")
+})
+
+var FeatureFlagResult = ResultType("FeatureFlagResult", func() {
+	Attribute("name", String, "Feature name", func() {
+		Example("email")
+	})
+	Attribute("enabled", Boolean, "Whether the feature is enabled")
+	Required("name", "enabled")
+})
+
+// Organization service
+var _ = Service("organization", func() {
+	Description("Organization (tenant) management service")
+	Error("bad_request", BadRequest)
+	Error("unauthorized", Unauthorized)
+
+	Method("create", func() {
+		Description("Create an organization (Superadmin only)")
+		Security(JWTAuth, func() {
+			Scope("superadmin")
+		})
+		Payload(CreateOrganizationPayload)
+		Result(OrganizationResult)
+		Error("bad_request")
+		Error("unauthorized")
+		HTTP(func() {
+			POST("/api/v1/organizations")
+			Response(StatusCreated)
+			Response("bad_request", StatusBadRequest)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+
+	Method("list", func() {
+		Description("List organizations, oldest first (Superadmin only)")
+		Security(JWTAuth, func() {
+			Scope("superadmin")
+		})
+		Payload(ListOrganizationsPayload)
+		Result(ArrayOf(OrganizationResult))
+		Error("unauthorized")
+		HTTP(func() {
+			GET("/api/v1/organizations")
+			Param("skip")
+			Param("limit")
+			Response(StatusOK)
+			Response("unauthorized", StatusUnauthorized)
+		})
+	})
+})
+
+var CreateOrganizationPayload = Type("CreateOrganizationPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("name", String, "Organization name", func() {
+		MinLength(1)
+		MaxLength(200)
+		Example("Acme Capital")
+	})
+	Attribute("slug", String, "URL-safe identifier, sent in the X-Organization header", func() {
+		Pattern("^[a-z0-9]+(-[a-z0-9]+)*$")
+		MaxLength(100)
+		Example("acme-capital")
+	})
+	Required("name", "slug")
+})
+
+var ListOrganizationsPayload = Type("ListOrganizationsPayload", func() {
+	Token("token", String, "JWT token")
+	Attribute("skip", Int, "Skip records", func() {
+		Default(0)
+		Minimum(0)
+	})
+	Attribute("limit", Int, "Limit records", func() {
+		Default(100)
+		Minimum(1)
+		Maximum(500)
+	})
+})
+
+var OrganizationResult = ResultType("OrganizationResult", func() {
+	Attribute("id", Int, "Organization ID")
+	Attribute("name", String, "Organization name")
+	Attribute("slug", String, "URL-safe identifier")
+	Attribute("created_at", String, "Creation timestamp")
+	Required("id", "name", "slug", "created_at")
 })
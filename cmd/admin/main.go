@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// listPageSize is how many users list-users fetches per query
+const listPageSize = 100
+
+const usage = `Usage: admin <command> [flags]
+
+Manages users directly in the database, without going through the API.
+
+Commands:
+  list-users [--organization=ID]                 List all users of an organization (default 1)
+  delete-user --username=NAME                    Delete a user, after confirmation
+  reset-password --username=NAME --password=PW   Set a user's password
+  toggle-active --username=NAME                  Activate or deactivate a user
+`
+
+// operator stands in for the logged-in user that AuthService expects on requests.
+// It has no ID, so it never matches a real user.
+var operator = &domain.User{Username: "admin-cli", IsAdmin: true, IsStaff: true}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	var username, password string
+	var orgID uint
+	switch command {
+	case "list-users":
+		flags.UintVar(&orgID, "organization", domain.DefaultOrganizationID, "organization to list, with MULTI_TENANT on")
+	case "delete-user", "toggle-active":
+		flags.StringVar(&username, "username", "", "username of the user to change")
+	case "reset-password":
+		flags.StringVar(&username, "username", "", "username of the user to change")
+		flags.StringVar(&password, "password", "", "new password")
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+	_ = flags.Parse(args)
+
+	// Load configuration
+	if _, err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	if err := database.Init(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	authSvc := services.NewAuthService(database.GetDB(), config.Get().Database.QueryTimeout())
+	ctx := context.WithValue(context.Background(), "user", operator)
+
+	var err error
+	switch command {
+	case "list-users":
+		err = listUsers(services.WithOrganization(ctx, orgID), authSvc)
+	case "delete-user":
+		err = deleteUser(ctx, authSvc, username)
+	case "reset-password":
+		err = resetPassword(ctx, authSvc, username, password)
+	case "toggle-active":
+		err = toggleActive(ctx, authSvc, username)
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", command, err)
+	}
+}
+
+// listUsers prints every user as an aligned table
+func listUsers(ctx context.Context, authSvc *services.AuthService) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tFULL NAME\tACTIVE\tADMIN\tSTAFF\tORG\tCREATED")
+
+	total := 0
+	for {
+		users, err := authSvc.ListUsers(ctx, &auth.ListUsersPayload{Skip: total, Limit: listPageSize})
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			fullName := ""
+			if u.FullName != nil {
+				fullName = *u.FullName
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%v\t%v\t%v\t%d\t%s\n", u.ID, u.Username, u.Email, fullName, u.IsActive, u.IsAdmin, u.IsStaff, u.OrganizationID, u.CreatedAt)
+		}
+		total += len(users)
+		if len(users) < listPageSize {
+			break
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d users\n", total)
+	return nil
+}
+
+// deleteUser deletes the user once the operator has typed its username again
+func deleteUser(ctx context.Context, authSvc *services.AuthService, username string) error {
+	user, err := findUser(username)
+	if err != nil {
+		return err
+	}
+	ctx = services.WithOrganization(ctx, user.OrganizationID)
+
+	fmt.Printf("About to delete user %s (id=%d, email=%s). This cannot be undone.\n", user.Username, user.ID, user.Email)
+	fmt.Print("Type the username to confirm: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.TrimSpace(answer) != user.Username {
+		fmt.Println("Username did not match; nothing was deleted.")
+		return nil
+	}
+
+	if err := authSvc.DeleteUser(ctx, &auth.DeleteUserPayload{ID: int(user.ID)}); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted user %s\n", user.Username)
+	return nil
+}
+
+// resetPassword sets the user's password, subject to the same policy as the API
+func resetPassword(ctx context.Context, authSvc *services.AuthService, username, password string) error {
+	if password == "" {
+		return errors.New("--password is required")
+	}
+	user, err := findUser(username)
+	if err != nil {
+		return err
+	}
+	ctx = services.WithOrganization(ctx, user.OrganizationID)
+
+	if _, err := authSvc.UpdateUser(ctx, &auth.UpdateUserPayload{ID: int(user.ID), Password: &password}); err != nil {
+		return err
+	}
+	fmt.Printf("Password reset for user %s\n", user.Username)
+	return nil
+}
+
+// toggleActive activates an inactive user and deactivates an active one
+func toggleActive(ctx context.Context, authSvc *services.AuthService, username string) error {
+	user, err := findUser(username)
+	if err != nil {
+		return err
+	}
+	ctx = services.WithOrganization(ctx, user.OrganizationID)
+
+	active := !user.IsActive
+	result, err := authSvc.UpdateUser(ctx, &auth.UpdateUserPayload{ID: int(user.ID), IsActive: &active})
+	if err != nil {
+		return err
+	}
+	state := "deactivated"
+	if result.IsActive {
+		state = "activated"
+	}
+	fmt.Printf("User %s %s\n", result.Username, state)
+	return nil
+}
+
+// findUser looks up a user by username in any organization; AuthService only takes IDs and,
+// with MULTI_TENANT on, only finds users of the context's organization, so callers switch the
+// context to the user's organization
+func findUser(username string) (*domain.User, error) {
+	if username == "" {
+		return nil, errors.New("--username is required")
+	}
+	var user domain.User
+	if err := database.GetDB().Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user %q not found", username)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
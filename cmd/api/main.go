@@ -2,42 +2,72 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	goahttp "goa.design/goa/v3/http"
 	"goa.design/goa/v3/http/middleware"
+	"golang.org/x/sync/errgroup"
 
+	admin "springstreet/gen/admin"
 	auth "springstreet/gen/auth"
 	contact "springstreet/gen/contact"
 	health "springstreet/gen/health"
+	adminsvr "springstreet/gen/http/admin/server"
 	authsvr "springstreet/gen/http/auth/server"
 	contactsvr "springstreet/gen/http/contact/server"
 	healthsvr "springstreet/gen/http/health/server"
 	investmentsvr "springstreet/gen/http/investment/server"
+	organizationsvr "springstreet/gen/http/organization/server"
 	otpsvr "springstreet/gen/http/otp/server"
 	investment "springstreet/gen/investment"
+	organization "springstreet/gen/organization"
 	otp "springstreet/gen/otp"
 
 	"springstreet/internal/config"
 	"springstreet/internal/database"
 	"springstreet/internal/metrics"
+	appmiddleware "springstreet/internal/middleware"
 	"springstreet/internal/services"
-	"strings"
+	"springstreet/internal/util"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
-	shutdownTimeout = 30 * time.Second
-	readTimeout     = 15 * time.Second
-	writeTimeout    = 15 * time.Second
-	idleTimeout     = 60 * time.Second
+	dependencyRetryInterval = 2 * time.Second
+	dependencyMaxWait       = 60 * time.Second
+
+	replicaCheckInterval       = 30 * time.Second
+	metricsReportInterval      = 15 * time.Second
+	idempotencyCleanupInterval = time.Hour
+
+	investmentStreamPath = "/api/v1/investment/stream"
+
+	envFile = ".env"
+)
+
+// corsConfig holds the active CORS settings; replaced on config reload
+var corsConfig atomic.Pointer[config.CORSConfig]
+
+var (
+	// startTime is when the process started, for the uptime in health checks
+	startTime = time.Now()
+
+	// buildTime is set at build time with -ldflags "-X main.buildTime=..."
+	buildTime = "unknown"
 )
 
 func main() {
@@ -56,31 +86,162 @@ func main() {
 		log.Fatalf("Configuration validation failed: %v", err)
 	}
 
+	metrics.Init(cfg.Metrics.HTTPLatencyBuckets, cfg.Metrics.DBLatencyBuckets, cfg.Metrics.MaxPathLabels)
+
 	log.Printf("Starting %s v%s", cfg.App.Name, cfg.App.Version)
-	log.Printf("Environment: debug=%v, port=%s, host=%s", cfg.App.Debug, cfg.App.Port, cfg.App.Host)
+	log.Printf("Environment: %s, debug=%v, port=%s, host=%s", cfg.App.Env, cfg.App.Debug, cfg.App.Port, cfg.App.Host)
+	for _, name := range config.FeatureNames() {
+		log.Printf("Feature flag: %s=%v", name, config.FeatureFlag(name))
+	}
 
-	// Initialize database
+	// Initialize database, waiting for it to accept connections first; under Docker Compose
+	// it is often still starting
 	log.Println("Initializing database connection...")
-	if err := database.Init(); err != nil {
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := database.WaitForDependency(context.Background(), "database", database.HealthCheck, dependencyRetryInterval, dependencyMaxWait); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := database.Migrate(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer func() {
-		log.Println("Closing database connections...")
-		if sqlDB, err := database.GetDB().DB(); err == nil {
-			if closeErr := sqlDB.Close(); closeErr != nil {
-				log.Printf("Error closing database: %v", closeErr)
+
+	// The OTP service falls back to a local session store if Redis is still unreachable
+	if cfg.Redis.URL != "" {
+		if err := database.WaitForDependency(context.Background(), "Redis", func() error {
+			client, err := util.ConnectRedis(cfg.Redis.URL)
+			if err == nil {
+				client.Close()
 			}
+			return err
+		}, dependencyRetryInterval, dependencyMaxWait); err != nil {
+			log.Printf("Warning: %v", err)
 		}
-	}()
+	}
 
 	// Create service instances
 	log.Println("Initializing services...")
-	healthSvc := services.NewHealthService()
-	authSvc := services.NewAuthService(database.GetDB())
-	investmentSvc := services.NewInvestmentService(database.GetDB())
-	otpSvc := services.NewOTPService(cfg)
-	emailSvc := services.NewEmailService(&cfg.Email)
-	contactSvc := services.NewContactService(database.GetDB(), emailSvc)
+	util.LoadDisposableDomains(cfg.Email.DisposableDomains)
+	if err := services.LoadOTPMessageTemplates(cfg.OTP.TemplateDir, cfg.OTP.BrandName); err != nil {
+		log.Fatalf("Failed to load OTP message templates: %v", err)
+	}
+	if err := services.LoadEmailTemplates(cfg.Email.TemplateDir); err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+	queryTimeout := cfg.Database.QueryTimeout()
+	healthSvc := services.NewHealthService(startTime, services.BuildInfo{Version: cfg.App.Version, BuildTime: buildTime})
+	authSvc := services.NewAuthService(database.GetDB(), queryTimeout)
+	inquiryBus := services.NewInquiryEventBus()
+	workerPool := services.NewWorkerPool(cfg.Workers.PoolSize, cfg.Workers.QueueSize)
+	notifier := services.NewNotifier(&cfg.Notify, workerPool)
+	hubspot := services.NewHubSpotClient(&cfg.HubSpot, workerPool)
+	captcha := services.NewCaptchaValidator(&cfg.Captcha)
+	investmentSvc := services.NewInvestmentService(database.GetDB(), inquiryBus, notifier, hubspot, captcha, queryTimeout)
+	emailSvc := services.NewEmailService(&cfg.Email, database.GetDB())
+	otpSvc := services.NewOTPService(cfg, database.GetDB(), emailSvc)
+	contactSvc := services.NewContactService(database.GetDB(), emailSvc, notifier, &cfg.Contact, captcha, queryTimeout)
+	adminSMS := services.NewSMSService(&cfg.SMS, database.GetDB())
+	adminSvc := services.NewAdminService(database.GetDB(), emailSvc, adminSMS, queryTimeout)
+	organizationSvc := services.NewOrganizationService(database.GetDB(), queryTimeout)
+	graphqlSvc := services.NewGraphQLService(authSvc, investmentSvc, cfg.App.Debug)
+
+	// Background tasks are stopped when main returns
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Shutdown waits for these to stop before closing the database they query
+	var background errgroup.Group
+	runInBackground := func(task func()) {
+		background.Go(func() error {
+			task()
+			return nil
+		})
+	}
+
+	// Start background cleanup of expired OTP sessions
+	runInBackground(func() {
+		otpSvc.RunSessionCleanup(backgroundCtx, time.Duration(cfg.OTP.CleanupIntervalSec)*time.Second)
+	})
+
+	// Sample connection pool and goroutine metrics, warning about goroutine leaks
+	runInBackground(func() {
+		database.ReportMetrics(backgroundCtx, metricsReportInterval, cfg.App.GoroutineLeakThreshold)
+	})
+
+	// Fail reads over to the primary while the read replica is unreachable
+	runInBackground(func() {
+		database.MonitorReadReplica(backgroundCtx, replicaCheckInterval)
+	})
+
+	// Components stopped once the HTTP server has shut down, each after those it depends on
+	shutdownManager := util.NewShutdownManager()
+	shutdownManager.Register("background tasks", func(ctx context.Context) error {
+		stopBackground()
+		return waitContext(ctx, background.Wait)
+	})
+	// Let queued notifications and HubSpot syncs finish
+	shutdownManager.Register("worker pool", workerPool.Shutdown, "background tasks")
+	// Follow-up reminders and queued notifications send email, so keep the SMTP connection
+	// until the background tasks and the worker pool have stopped
+	shutdownManager.Register("email queue", func(ctx context.Context) error {
+		err := notifier.Flush(ctx)
+		emailSvc.Close()
+		return err
+	}, "worker pool")
+	// Everything above queries the database or writes to the email log
+	shutdownManager.Register("database", func(ctx context.Context) error {
+		sqlDB, err := database.GetDB().DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	}, "email queue")
+
+	// Score inquiries created before lead scoring was added
+	runInBackground(func() {
+		investmentSvc.BackfillLeadScores(backgroundCtx)
+	})
+
+	// Drop stored Idempotency-Key responses once they can no longer be replayed
+	runInBackground(func() {
+		services.RunIdempotencyKeyCleanup(backgroundCtx, database.GetDB(), idempotencyCleanupInterval)
+	})
+
+	// Email staff the follow-up reminders they scheduled on investment inquiries
+	runInBackground(func() {
+		services.RunFollowUpReminders(backgroundCtx, database.GetDB(), emailSvc, time.Duration(cfg.Workers.ReminderIntervalMin)*time.Minute)
+	})
+
+	// Watch .env for changes to non-critical settings (CORS, email)
+	corsConfig.Store(&cfg.CORS)
+	if watcher, err := config.NewConfigWatcher(envFile); err != nil {
+		log.Printf("Config hot-reload disabled: %v", err)
+	} else {
+		defer watcher.Close()
+		watcher.OnReload(func(newCfg *config.Config) {
+			corsConfig.Store(&newCfg.CORS)
+			emailSvc.UpdateConfig(&newCfg.Email)
+		})
+		runInBackground(func() {
+			watcher.Start(backgroundCtx)
+		})
+	}
+
+	// Pick up rotated secrets from AWS Secrets Manager / Vault
+	runInBackground(func() {
+		config.RefreshSecrets(backgroundCtx, func() {
+			newCfg, err := config.ReloadSecrets()
+			if err != nil {
+				log.Printf("Config reload after secret rotation failed: %v", err)
+				return
+			}
+			emailSvc.UpdateConfig(&newCfg.Email)
+			otpSvc.UpdateConfig(newCfg)
+			adminSMS.UpdateConfig(&newCfg.SMS)
+			hubspot.UpdateConfig(&newCfg.HubSpot)
+		})
+	})
 
 	// Create service endpoints
 	healthEndpoints := health.NewEndpoints(healthSvc)
@@ -88,13 +249,15 @@ func main() {
 	investmentEndpoints := investment.NewEndpoints(investmentSvc)
 	otpEndpoints := otp.NewEndpoints(otpSvc)
 	contactEndpoints := contact.NewEndpoints(contactSvc)
+	adminEndpoints := admin.NewEndpoints(adminSvc)
+	organizationEndpoints := organization.NewEndpoints(organizationSvc)
 
 	// Create HTTP mux
 	mux := goahttp.NewMuxer()
 
 	// Create error handler that logs errors
 	errorHandler := func(ctx context.Context, w http.ResponseWriter, err error) {
-		log.Printf("[ERROR] %v", err)
+		log.Printf("[ERROR] [%s] %v", cfg.App.Env, err)
 	}
 
 	// Mount HTTP handlers with middleware and error handler
@@ -104,7 +267,7 @@ func main() {
 	healthServer.Use(middleware.PopulateRequestContext())
 	healthServer.Mount(mux)
 
-	authServer := authsvr.New(authEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, errorHandler, nil)
+	authServer := authsvr.New(authEndpoints, mux, requestDecoder, goahttp.ResponseEncoder, errorHandler, nil)
 	authServer.Use(middleware.RequestID())
 	authServer.Use(middleware.PopulateRequestContext())
 	authServer.Mount(mux)
@@ -124,28 +287,94 @@ func main() {
 	contactServer.Use(middleware.PopulateRequestContext())
 	contactServer.Mount(mux)
 
-	// Create a wrapper handler that routes /metrics to Prometheus and everything else to Goa mux
+	adminServer := adminsvr.New(adminEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, errorHandler, nil)
+	adminServer.Use(middleware.RequestID())
+	adminServer.Use(middleware.PopulateRequestContext())
+	adminServer.Mount(mux)
+
+	organizationServer := organizationsvr.New(organizationEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, errorHandler, nil)
+	organizationServer.Use(middleware.RequestID())
+	organizationServer.Use(middleware.PopulateRequestContext())
+	organizationServer.Mount(mux)
+
+	// Profiling is off unless PPROF_ENABLED is set
+	var pprofHandler http.Handler
+	if cfg.App.PprofEnabled {
+		pprofHandler = adminSvc.PprofHandler()
+		if cfg.App.PprofSecret == "" {
+			log.Printf("Warning: pprof is enabled at %s without PPROF_SECRET; only super admins can use it, but don't run it like this in production", services.PprofPathPrefix)
+		} else {
+			log.Printf("Warning: pprof is enabled at %s; keep PPROF_SECRET private", services.PprofPathPrefix)
+		}
+	}
+
+	// Create a wrapper handler that routes /metrics to Prometheus (and its JSON form to the admin service), Twilio delivery callbacks to the
+	// OTP service, unsubscribe links to the email service, goroutine dumps and profiles to the
+	// admin service, GraphQL to its handler and everything else to Goa mux
 	rootHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/metrics" {
 			promhttp.Handler().ServeHTTP(w, r)
 			return
 		}
+		if r.URL.Path == services.MetricsJSONPath {
+			adminSvc.HandleMetricsJSON(w, r)
+			return
+		}
+		if r.URL.Path == services.OTPDeliveryCallbackPath {
+			otpSvc.HandleDeliveryCallback(w, r)
+			return
+		}
+		if r.URL.Path == services.EmailUnsubscribePath {
+			emailSvc.HandleUnsubscribe(w, r)
+			return
+		}
+		if r.URL.Path == services.OTPPeekPath {
+			otpSvc.HandlePeek(w, r)
+			return
+		}
+		if r.URL.Path == services.GoroutineDumpPath {
+			adminSvc.HandleGoroutineDump(w, r)
+			return
+		}
+		if pprofHandler != nil && strings.HasPrefix(r.URL.Path, services.PprofPathPrefix) {
+			pprofHandler.ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path == services.GraphQLPath {
+			graphqlSvc.ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path == services.GraphQLPlaygroundPath {
+			graphqlSvc.ServePlayground(w, r)
+			return
+		}
+		if r.URL.Path == investmentStreamPath {
+			// Event streams stay open well past the server write timeout
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		}
 		mux.ServeHTTP(w, r)
 	})
 
-	// Setup middleware chain: Prometheus -> Security -> CORS -> Logging -> Handler
-	handler := setupSecurityHeaders(setupCORS(requestLogging(metrics.PrometheusMiddleware(rootHandler)), cfg), cfg)
+	// Setup middleware chain: Security -> CSP -> CORS -> Logging -> Prometheus -> Body limit -> Batch -> Tenant -> Idempotency -> Handler
+	idempotentHandler := services.IdempotencyMiddleware(rootHandler, database.GetDB(), cfg.HTTP.IdempotentPaths)
+	tenantHandler := services.TenantMiddleware(idempotentHandler, database.GetDB())
+	batchHandler := services.BatchMiddleware(tenantHandler, "/metrics", services.MetricsJSONPath, investmentStreamPath)
+	handler := setupSecurityHeaders(setupCSP(setupCORS(requestLogging(metrics.PrometheusMiddleware(appmiddleware.MaxBodyByPathMiddleware(batchHandler, cfg.HTTP.BodyLimit)), cfg), cfg), cfg), cfg)
 
 	// Create HTTP server with timeouts
 	addr := fmt.Sprintf("%s:%s", cfg.App.Host, cfg.App.Port)
 	httpServer := &http.Server{
-		Addr:         addr,
-		Handler:      handler,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
-		ErrorLog:     log.New(os.Stderr, "[HTTP] ", log.LstdFlags),
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ErrorLog:          log.New(os.Stderr, "[HTTP] ", log.LstdFlags),
 	}
+	// Shutdown waits for active requests, so end open event streams first
+	httpServer.RegisterOnShutdown(inquiryBus.Close)
 
 	// Start server in goroutine
 	serverErrors := make(chan error, 1)
@@ -167,8 +396,8 @@ func main() {
 		log.Printf("Received signal: %v. Starting graceful shutdown...", sig)
 	}
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	// Graceful shutdown: stop taking requests first, since handlers use the components below
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := httpServer.Shutdown(ctx); err != nil {
@@ -179,9 +408,27 @@ func main() {
 		}
 	}
 
+	if err := shutdownManager.Shutdown(ctx); err != nil {
+		log.Printf("Error during graceful shutdown: %v", err)
+	}
+
 	log.Println("Server shutdown complete")
 }
 
+// waitContext calls wait, or returns ctx's error if ctx expires before wait returns
+func waitContext(ctx context.Context, wait func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // validateConfig validates critical configuration values
 func validateConfig(cfg *config.Config) error {
 	if cfg.Auth.SecretKey == "" || cfg.Auth.SecretKey == "your-secret-key-change-in-production" {
@@ -193,11 +440,19 @@ func validateConfig(cfg *config.Config) error {
 	if cfg.App.Port == "" {
 		return fmt.Errorf("PORT must be set")
 	}
+	if cfg.App.IsProduction() && cfg.Auth.SecretKey == composeSecretKey {
+		return fmt.Errorf("SECRET_KEY must not be the docker-compose.yml fallback when APP_ENV is production")
+	}
 	return nil
 }
 
+// composeSecretKey is the fallback SECRET_KEY in docker-compose.yml. Unlike the other examples
+// it is long enough to pass the length check.
+const composeSecretKey = "your-super-secret-key-change-in-production"
+
 // setupSecurityHeaders adds security headers to responses
 func setupSecurityHeaders(handler http.Handler, cfg *config.Config) http.Handler {
+	hsts := hstsHeader(&cfg.Security)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Security headers
 		w.Header().Set("X-Content-Type-Options", "nosniff")
@@ -209,47 +464,84 @@ func setupSecurityHeaders(handler http.Handler, cfg *config.Config) http.Handler
 		// Remove server identification
 		w.Header().Set("Server", "")
 
-		// HSTS (only in production with HTTPS)
-		if !cfg.App.Debug && r.TLS != nil {
-			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		// HSTS (only in production with HTTPS, unless forced because TLS ends at a proxy)
+		if cfg.Security.ForceHSTS || (!cfg.App.Debug && r.TLS != nil) {
+			w.Header().Set("Strict-Transport-Security", hsts)
 		}
 
 		handler.ServeHTTP(w, r)
 	})
 }
 
+// setupCSP adds the Content-Security-Policy header.
+// In debug mode the policy is report-only, so violations are reported without blocking anything.
+func setupCSP(handler http.Handler, cfg *config.Config) http.Handler {
+	policy := cfg.Security.CSPPolicy
+	if cfg.Security.CSPReportURI != "" {
+		policy += "; report-uri " + cfg.Security.CSPReportURI
+	}
+
+	header := "Content-Security-Policy"
+	if cfg.App.Debug {
+		header = "Content-Security-Policy-Report-Only"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if policy != "" {
+			w.Header().Set(header, policy)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// hstsHeader builds the Strict-Transport-Security value from the security config
+func hstsHeader(sec *config.SecurityConfig) string {
+	value := fmt.Sprintf("max-age=%d", sec.HSTSMaxAge)
+	if sec.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if sec.HSTSPreload {
+		value += "; preload"
+	}
+	return value
+}
+
 // setupCORS configures CORS based on environment
 func setupCORS(handler http.Handler, cfg *config.Config) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
+		cors := corsConfig.Load()
+		anyOrigin := slices.Contains(cors.AllowedOrigins, "*")
 
 		// In production, validate against allowed origins
-		if !cfg.App.Debug && len(cfg.CORS.AllowedOrigins) > 0 && cfg.CORS.AllowedOrigins[0] != "*" {
-			allowed := false
-			for _, allowedOrigin := range cfg.CORS.AllowedOrigins {
-				if origin == allowedOrigin {
-					allowed = true
-					break
-				}
-			}
-			if !allowed && origin != "" {
+		if !cfg.App.Debug && !anyOrigin {
+			if origin != "" && !originAllowed(origin, cors) {
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
 
-		// Set CORS headers
-		if origin != "" {
+		// Set CORS headers. A specific origin is echoed back, so caches must keep the response per origin.
+		if anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin != "" {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
 		} else if cfg.App.Debug {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.CORS.AllowedMethods, ", "))
-		w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.CORS.AllowedHeaders, ", "))
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Type, Authorization, X-Request-ID")
-		w.Header().Set("Access-Control-Max-Age", fmt.Sprintf("%d", cfg.CORS.MaxAge))
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders, ", "))
+		if len(cors.ExposedHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
+		}
+		if cors.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cors.MaxAge))
+		}
+		if cors.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -261,6 +553,76 @@ func setupCORS(handler http.Handler, cfg *config.Config) http.Handler {
 	})
 }
 
+// originAllowed reports whether origin matches one of the allowed origins.
+// With wildcard subdomains enabled, an entry such as "*.springstreet.in" (optionally with a
+// scheme, e.g. "https://*.springstreet.in") matches any subdomain of springstreet.in but not
+// springstreet.in itself or look-alikes such as evilspringstreet.in.
+func originAllowed(origin string, cors *config.CORSConfig) bool {
+	for _, allowedOrigin := range cors.AllowedOrigins {
+		if origin == allowedOrigin {
+			return true
+		}
+		if cors.WildcardSubdomains && matchWildcardOrigin(origin, allowedOrigin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcardOrigin matches origin against a "*.domain" pattern
+func matchWildcardOrigin(origin, pattern string) bool {
+	scheme, host, found := strings.Cut(pattern, "://")
+	if !found {
+		scheme, host = "", pattern
+	}
+	if !strings.HasPrefix(host, "*.") {
+		return false
+	}
+	suffix := host[1:] // Keep the dot so only whole labels match
+
+	originScheme, originHost, found := strings.Cut(origin, "://")
+	if !found || (scheme != "" && !strings.EqualFold(scheme, originScheme)) {
+		return false
+	}
+	originHost = strings.ToLower(originHost)
+	suffix = strings.ToLower(suffix)
+	return strings.HasSuffix(originHost, suffix) && len(originHost) > len(suffix)
+}
+
+// requestDecoder is Goa's request decoder plus application/x-www-form-urlencoded bodies, which
+// RFC 7662 token introspection requires. Form fields are decoded into the generated body type
+// by name, so only string attributes can be sent this way.
+func requestDecoder(r *http.Request) goahttp.Decoder {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+		return formDecoder{request: r}
+	}
+	return goahttp.RequestDecoder(r)
+}
+
+// formDecoder decodes a form-encoded request body
+type formDecoder struct {
+	request *http.Request
+}
+
+func (d formDecoder) Decode(v any) error {
+	if err := d.request.ParseForm(); err != nil {
+		return err
+	}
+	if len(d.request.PostForm) == 0 {
+		return io.EOF
+	}
+
+	fields := make(map[string]string, len(d.request.PostForm))
+	for name, values := range d.request.PostForm {
+		fields[name] = values[0]
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -272,8 +634,15 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// requestLogging logs all incoming requests and their responses
-func requestLogging(handler http.Handler) http.Handler {
+// Unwrap exposes the underlying writer to http.ResponseController (needed for flushing streams)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// requestLogging logs all incoming requests and their responses, tagged with the environment
+// so logs shipped from several deployments stay apart
+func requestLogging(handler http.Handler, cfg *config.Config) http.Handler {
+	env := cfg.App.Env
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -287,7 +656,7 @@ func requestLogging(handler http.Handler) http.Handler {
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
 		// Log request start
-		log.Printf("[REQUEST] %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		log.Printf("[REQUEST] [%s] %s %s from %s", env, r.Method, r.URL.Path, r.RemoteAddr)
 
 		// Handle request
 		handler.ServeHTTP(wrapped, r)
@@ -298,6 +667,6 @@ func requestLogging(handler http.Handler) http.Handler {
 		if wrapped.statusCode >= 400 {
 			statusText = "ERROR"
 		}
-		log.Printf("[RESPONSE] %s %s -> %d %s (%v)", r.Method, r.URL.Path, wrapped.statusCode, statusText, duration)
+		log.Printf("[RESPONSE] [%s] %s %s -> %d %s (%v)", env, r.Method, r.URL.Path, wrapped.statusCode, statusText, duration)
 	})
 }
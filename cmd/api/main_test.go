@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"springstreet/internal/config"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	cors := &config.CORSConfig{
+		AllowedOrigins:     []string{"https://springstreet.in", "*.springstreet.in", "https://*.partner.example"},
+		WildcardSubdomains: true,
+	}
+
+	for _, tc := range []struct {
+		origin string
+		want   bool
+	}{
+		// Exact matches
+		{"https://springstreet.in", true},
+		{"http://springstreet.in", false},
+
+		// Wildcard matches
+		{"https://app.springstreet.in", true},
+		{"https://staging.springstreet.in", true},
+		{"http://a.b.springstreet.in", true},
+		{"https://APP.SpringStreet.in", true},
+		{"https://app.partner.example", true},
+		{"http://app.partner.example", false},
+
+		// Non-matches
+		{"https://evilspringstreet.in", false},
+		{"https://springstreet.in.evil.example", false},
+		{"https://.springstreet.in", false},
+		{"https://partner.example", false},
+		{"app.springstreet.in", false},
+		{"", false},
+	} {
+		if got := originAllowed(tc.origin, cors); got != tc.want {
+			t.Errorf("originAllowed(%q) = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+}
+
+func TestOriginAllowedWildcardsNeedOptIn(t *testing.T) {
+	cors := &config.CORSConfig{AllowedOrigins: []string{"https://springstreet.in", "*.springstreet.in"}}
+
+	if originAllowed("https://app.springstreet.in", cors) {
+		t.Error("a wildcard entry matched with CORS_WILDCARD_SUBDOMAINS off")
+	}
+	if !originAllowed("https://springstreet.in", cors) {
+		t.Error("an exact entry did not match with CORS_WILDCARD_SUBDOMAINS off")
+	}
+}
+
+// securityHeaders returns the response headers setupSecurityHeaders adds to a plain HTTP request,
+// with the configuration loaded from the environment
+func securityHeaders(t *testing.T) http.Header {
+	t.Helper()
+	t.Setenv("SECRET_KEY", "test-secret-key-that-is-long-enough-0123456789")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+
+	ts := httptest.NewServer(setupSecurityHeaders(http.NotFoundHandler(), cfg))
+	t.Cleanup(ts.Close)
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	return resp.Header
+}
+
+func TestForceHSTSWithoutTLS(t *testing.T) {
+	t.Setenv("FORCE_HSTS", "true")
+	t.Setenv("HSTS_MAX_AGE", "63072000")
+	t.Setenv("HSTS_INCLUDE_SUBDOMAINS", "true")
+	t.Setenv("HSTS_PRELOAD", "true")
+
+	want := "max-age=63072000; includeSubDomains; preload"
+	if got := securityHeaders(t).Get("Strict-Transport-Security"); got != want {
+		t.Fatalf("Strict-Transport-Security = %q, want %q", got, want)
+	}
+}
+
+func TestNoHSTSWithoutTLSByDefault(t *testing.T) {
+	if got := securityHeaders(t).Get("Strict-Transport-Security"); got != "" {
+		t.Fatalf("Strict-Transport-Security = %q on plain HTTP without FORCE_HSTS", got)
+	}
+}
+
+func TestHSTSHeader(t *testing.T) {
+	for _, tc := range []struct {
+		sec  config.SecurityConfig
+		want string
+	}{
+		{config.SecurityConfig{HSTSMaxAge: 31536000}, "max-age=31536000"},
+		{config.SecurityConfig{HSTSMaxAge: 300, HSTSIncludeSubdomains: true}, "max-age=300; includeSubDomains"},
+		{config.SecurityConfig{HSTSMaxAge: 300, HSTSPreload: true}, "max-age=300; preload"},
+	} {
+		if got := hstsHeader(&tc.sec); got != tc.want {
+			t.Errorf("hstsHeader(%+v) = %q, want %q", tc.sec, got, tc.want)
+		}
+	}
+}
+
+func TestValidateConfigRejectsComposeSecretKeyInProduction(t *testing.T) {
+	for _, tc := range []struct {
+		env     string
+		wantErr bool
+	}{
+		{config.EnvDevelopment, false},
+		{config.EnvStaging, false},
+		{config.EnvProduction, true},
+	} {
+		cfg := &config.Config{
+			App:  config.AppConfig{Env: tc.env, Port: "8000"},
+			Auth: config.AuthConfig{SecretKey: composeSecretKey},
+		}
+		if err := validateConfig(cfg); (err != nil) != tc.wantErr {
+			t.Errorf("APP_ENV=%s: validateConfig = %v, want error %v", tc.env, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/services"
+)
+
+// Archives investment inquiries older than ARCHIVE_AFTER_DAYS; intended to be run from cron
+func main() {
+	defaultDays := 365
+	if value := os.Getenv("ARCHIVE_AFTER_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("ARCHIVE_AFTER_DAYS must be a number of days: %v", err)
+		}
+		defaultDays = days
+	}
+	afterDays := flag.Int("after-days", defaultDays, "archive inquiries created more than this many days ago (default from ARCHIVE_AFTER_DAYS)")
+	flag.Parse()
+
+	if *afterDays < 1 {
+		log.Fatal("--after-days must be at least 1")
+	}
+
+	// Load configuration
+	if _, err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	if err := database.Init(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	archived, err := services.ArchiveInquiriesOlderThan(database.GetDB(), time.Duration(*afterDays)*24*time.Hour)
+	if err != nil {
+		log.Fatalf("Failed to archive investment inquiries: %v", err)
+	}
+
+	fmt.Printf("Archived %d investment inquiries (created more than %d days ago)\n", archived, *afterDays)
+}
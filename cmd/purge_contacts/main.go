@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/services"
+)
+
+// Permanently deletes soft-deleted contact inquiries; intended to be run from cron
+func main() {
+	olderThanDays := flag.Int("older-than-days", 30, "purge inquiries deleted more than this many days ago")
+	flag.Parse()
+
+	if *olderThanDays < 0 {
+		log.Fatal("--older-than-days must not be negative")
+	}
+
+	// Load configuration
+	if _, err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	if err := database.Init(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	purged, err := services.PurgeDeletedContactInquiries(database.GetDB(), time.Duration(*olderThanDays)*24*time.Hour)
+	if err != nil {
+		log.Fatalf("Failed to purge contact inquiries: %v", err)
+	}
+
+	fmt.Printf("Permanently deleted %d contact inquiries (deleted more than %d days ago)\n", purged, *olderThanDays)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"gorm.io/gorm"
+)
+
+// seedPassword is the password given to every seeded user
+const seedPassword = "password"
+
+var (
+	firstNames = []string{"Aarav", "Vivaan", "Aditya", "Ananya", "Diya", "Ishaan", "Kavya", "Rahul", "Priya", "Rohan", "Sneha", "Arjun", "Meera", "Karan", "Neha"}
+	lastNames  = []string{"Sharma", "Verma", "Iyer", "Patel", "Reddy", "Gupta", "Nair", "Mehta", "Singh", "Kapoor", "Joshi", "Rao"}
+
+	investmentSizes = []string{"under-5l", "5l-25l", "25l-1cr", "1cr-5cr", "above-5cr"}
+	exposures       = []string{"direct-stocks", "mutual-funds", "sip"}
+	exitTypes       = []string{"abandoned", "abandoned", "completed", "verified"}
+
+	contactStatuses = []string{"new", "new", "read", "replied"}
+	contactMessages = []string{
+		"I'd like to understand how global diversification works for NRIs.",
+		"Can someone call me back about the portfolio review?",
+		"What are your fees for a 50L portfolio?",
+		"Interested in a partnership for our wealth advisory firm.",
+		"I was charged twice, please look into this.",
+		"Do you support SIPs into US index funds?",
+	}
+)
+
+func main() {
+	users := flag.Int("users", 10, "number of staff users to create")
+	inquiries := flag.Int("inquiries", 100, "number of investment inquiries to create")
+	contacts := flag.Int("contacts", 50, "number of contact inquiries to create")
+	clean := flag.Bool("clean", false, "delete existing data before seeding (dev only)")
+	seed := flag.Int64("seed", 42, "random seed for reproducible data")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	if err := database.Init(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	db := database.GetDB()
+	rng := rand.New(rand.NewSource(*seed))
+
+	if *clean {
+		// PostgreSQL is treated as a shared/production database
+		if cfg.Database.IsPostgres() && os.Getenv("ALLOW_SEED_PROD") == "" {
+			log.Fatal("Refusing to --clean a PostgreSQL database; set ALLOW_SEED_PROD=1 to override")
+		}
+		if err := cleanData(db); err != nil {
+			log.Fatalf("Failed to clean data: %v", err)
+		}
+		fmt.Println("Existing data removed (admin user kept)")
+	}
+
+	if err := seedUsers(db, rng, *users); err != nil {
+		log.Fatalf("Failed to seed users: %v", err)
+	}
+	if err := seedInquiries(db, rng, *inquiries); err != nil {
+		log.Fatalf("Failed to seed investment inquiries: %v", err)
+	}
+	if err := seedContacts(db, rng, *contacts); err != nil {
+		log.Fatalf("Failed to seed contact inquiries: %v", err)
+	}
+
+	fmt.Printf("Seeded %d users, %d investment inquiries, %d contact inquiries\n", *users, *inquiries, *contacts)
+	fmt.Printf("Seeded users log in with password: %s\n", seedPassword)
+}
+
+// cleanData deletes all seeded tables, keeping the admin user and its credentials. Rows that
+// refer to others go first.
+func cleanData(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{
+			&domain.InvestmentInquiryVersion{}, &domain.ContactNote{},
+			&domain.ContactInquiry{}, &domain.InvestmentInquiry{}, &domain.OTPSession{},
+			&domain.AuditLog{}, &domain.EmailLog{}, &domain.IdempotencyKey{},
+		} {
+			if err := tx.Unscoped().Where("1 = 1").Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		admin := tx.Model(&domain.User{}).Where("username = ?", "admin").Select("id")
+		for _, model := range []any{&domain.PasswordHistory{}, &domain.BackupCode{}} {
+			if err := tx.Where("user_id NOT IN (?)", admin).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("username <> ?", "admin").Delete(&domain.User{}).Error
+	})
+}
+
+// seedUsers creates staff users named staff1..staffN, skipping any that already exist
+func seedUsers(db *gorm.DB, rng *rand.Rand, count int) error {
+	hashedPassword, err := util.HashPassword(seedPassword)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= count; i++ {
+		fullName := randomName(rng)
+		user := domain.User{
+			Username:       fmt.Sprintf("staff%d", i),
+			Email:          fmt.Sprintf("staff%d@springstreet.com", i),
+			HashedPassword: hashedPassword,
+			FullName:       &fullName,
+			IsActive:       rng.Intn(10) > 0,
+			IsStaff:        true,
+		}
+		if err := db.Where("username = ?", user.Username).FirstOrCreate(&user).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedInquiries creates investment inquiries spread over the last 90 days
+func seedInquiries(db *gorm.DB, rng *rand.Rand, count int) error {
+	for i := 0; i < count; i++ {
+		first := pick(rng, firstNames)
+		last := pick(rng, lastNames)
+		phone := fmt.Sprintf("9%09d", rng.Intn(1000000000))
+		email := fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), i)
+		size := pick(rng, investmentSizes)
+		exposure := randomExposure(rng)
+		exitType := pick(rng, exitTypes)
+
+		inquiry := domain.InvestmentInquiry{
+			FirstName:       &first,
+			LastName:        &last,
+			Phone:           &phone,
+			Email:           &email,
+			InvestmentSize:  &size,
+			CurrentExposure: &exposure,
+			Verified:        exitType == "verified",
+			ExitType:        &exitType,
+		}
+		if err := db.Create(&inquiry).Error; err != nil {
+			return err
+		}
+		if err := backdate(db, &inquiry, rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedContacts creates contact inquiries spread over the last 90 days
+func seedContacts(db *gorm.DB, rng *rand.Rand, count int) error {
+	for i := 0; i < count; i++ {
+		name := randomName(rng)
+		inquiry := domain.ContactInquiry{
+			Name:     name,
+			Email:    fmt.Sprintf("contact%d@example.com", i),
+			Category: pick(rng, domain.ContactCategories),
+			Message:  pick(rng, contactMessages),
+			Status:   pick(rng, contactStatuses),
+		}
+		if rng.Intn(2) == 0 {
+			phone := fmt.Sprintf("+91 9%09d", rng.Intn(1000000000))
+			inquiry.Phone = &phone
+		}
+		if err := db.Create(&inquiry).Error; err != nil {
+			return err
+		}
+		if err := backdate(db, &inquiry, rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backdate moves created_at into the last 90 days (the BeforeCreate hook always sets it to now)
+func backdate(db *gorm.DB, model any, rng *rand.Rand) error {
+	createdAt := time.Now().Add(-time.Duration(rng.Int63n(int64(90 * 24 * time.Hour))))
+	return db.Model(model).UpdateColumn("created_at", createdAt).Error
+}
+
+func randomName(rng *rand.Rand) string {
+	return pick(rng, firstNames) + " " + pick(rng, lastNames)
+}
+
+func randomExposure(rng *rand.Rand) string {
+	var selected []string
+	for _, e := range exposures {
+		if rng.Intn(2) == 0 {
+			selected = append(selected, e)
+		}
+	}
+	if len(selected) == 0 {
+		selected = append(selected, pick(rng, exposures))
+	}
+	return strings.Join(selected, ",")
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
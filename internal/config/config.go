@@ -1,118 +1,733 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/nyaruka/phonenumbers"
 )
 
+// customFieldKeyPattern matches the keys allowed in InvestmentConfig.CustomFieldSchema
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
 // Config holds application configuration
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	CORS     CORSConfig
-	Email    EmailConfig
-	SMS      SMSConfig
+	App        AppConfig        `yaml:"app"`
+	HTTP       HTTPConfig       `yaml:"http"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Auth       AuthConfig       `yaml:"auth"`
+	CORS       CORSConfig       `yaml:"cors"`
+	Email      EmailConfig      `yaml:"email"`
+	SMS        SMSConfig        `yaml:"sms"`
+	WhatsApp   WhatsAppConfig   `yaml:"whatsapp"`
+	OTP        OTPConfig        `yaml:"otp"`
+	Contact    ContactConfig    `yaml:"contact"`
+	Investment InvestmentConfig `yaml:"investment"`
+	Notify     NotifyConfig     `yaml:"notify"`
+	HubSpot    HubSpotConfig    `yaml:"hubspot"`
+	Captcha    CaptchaConfig    `yaml:"captcha"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Security   SecurityConfig   `yaml:"security"`
+	Breaker    BreakerConfig    `yaml:"circuit_breaker"`
+	Workers    WorkerConfig     `yaml:"workers"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Features   FeatureFlags     `yaml:"features"`
 }
 
+// Deployment environments, set with APP_ENV
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
 // AppConfig holds application-level configuration
 type AppConfig struct {
-	Name    string
-	Version string
-	Debug   bool
-	Port    string
-	Host    string
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Env     string `yaml:"env"` // development, staging or production; production is validated more strictly
+	Debug   bool   `yaml:"debug"`
+	Port    string `yaml:"port"`
+	Host    string `yaml:"host"`
+
+	GoroutineLeakThreshold int `yaml:"goroutine_leak_threshold"` // Goroutine growth between two samples that is logged as a possible leak
+
+	PprofEnabled bool   `yaml:"pprof_enabled"` // Serve net/http/pprof at /debug/pprof/
+	PprofSecret  string `yaml:"pprof_secret"`  // Bearer token accepted by /debug/pprof/ besides an admin's JWT
+
+	MultiTenant bool `yaml:"multi_tenant"` // Scope users and inquiries to organizations; when off everything belongs to the default organization
+}
+
+// HTTPConfig holds HTTP request handling configuration
+type HTTPConfig struct {
+	MaxBodyBytes       int            `yaml:"max_body_bytes"`         // Request body limit (0 disables)
+	MaxBodyBytesByPath map[string]int `yaml:"max_body_bytes_by_path"` // Limits for path prefixes; the longest matching prefix wins
+	IdempotentPaths    []string       `yaml:"idempotent_paths"`       // POST paths that honor the Idempotency-Key header
+	TrustedProxies     []string       `yaml:"trusted_proxies"`        // Proxy IPs or CIDRs whose X-Forwarded-For and X-Real-IP headers are believed
+}
+
+// ServerConfig holds the HTTP server's timeouts and limits. Durations are written like "15s"
+// or "2m"; 0 means no timeout.
+type ServerConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout"`        // Reading a whole request, body included
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"` // Reading request headers; 0 uses ReadTimeout
+	WriteTimeout      time.Duration `yaml:"write_timeout"`       // From the end of the request headers to the end of the response
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`        // Keep-alive connections waiting for the next request
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`    // Graceful shutdown, for requests and background components together
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`    // Largest accepted request headers
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	URL string
+	URL            string `yaml:"url"`
+	ReadReplicaURL string `yaml:"read_replica_url"` // Optional replica for list/get queries
+	QueryTimeoutMS int    `yaml:"query_timeout_ms"` // Deadline for the queries of one service call (0 disables)
+
+	AllowSQLiteInProduction bool `yaml:"allow_sqlite_in_production"` // Otherwise APP_ENV=production requires PostgreSQL
 }
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	SecretKey          string
-	TokenExpiryMinutes int
-	Algorithm          string
+	SecretKey          string `yaml:"secret_key"`
+	TokenExpiryMinutes int    `yaml:"token_expiry_minutes"`
+	Algorithm          string `yaml:"algorithm"`
+	PasswordMinLength  int    `yaml:"password_min_length"`    // Used when the password_policy feature is on
+	PasswordHistory    int    `yaml:"password_history_count"` // Previous passwords that can't be reused (0 disables)
+	IntrospectSecret   string `yaml:"introspect_secret"`      // Bearer token services use to call /api/v1/auth/introspect; empty disables it
+
+	Enforce2FAForAdmins bool `yaml:"enforce_2fa_for_admins"` // Admins can't log in until they enroll TOTP
+	Enforce2FAForStaff  bool `yaml:"enforce_2fa_for_staff"`  // Staff can't log in until they enroll TOTP
 }
 
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
-	AllowedOrigins []string
-	AllowedMethods []string
-	AllowedHeaders []string
-	MaxAge         int
+	AllowedOrigins     []string `yaml:"allowed_origins"` // "*" allows any origin
+	AllowedMethods     []string `yaml:"allowed_methods"`
+	AllowedHeaders     []string `yaml:"allowed_headers"`
+	ExposedHeaders     []string `yaml:"exposed_headers"`     // Response headers scripts may read
+	AllowCredentials   bool     `yaml:"allow_credentials"`   // Let browsers send cookies; can't be combined with "*"
+	MaxAge             int      `yaml:"max_age"`             // Seconds browsers may cache a preflight response (0 omits the header)
+	WildcardSubdomains bool     `yaml:"wildcard_subdomains"` // Allow entries like *.springstreet.in to match any subdomain
 }
 
 // EmailConfig holds email service configuration
 type EmailConfig struct {
-	Enabled  bool
-	SMTPHost string
-	SMTPPort int
-	Username string
-	Password string
-	FromEmail string
-	FromName string
+	Provider string `yaml:"provider"` // smtp, sendgrid, ses or console (log only)
+
+	SMTPHost  string `yaml:"smtp_host"`
+	SMTPPort  int    `yaml:"smtp_port"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	FromEmail string `yaml:"from_email"`
+	FromName  string `yaml:"from_name"`
+	ReplyTo   string `yaml:"reply_to"` // Optional default Reply-To, e.g. a monitored support address when FromEmail is a no-reply one
+
+	FromByType     map[string]string `yaml:"from_by_type"`    // Sender per email type (e.g. otp, marketing), as an address or "Name <address>"
+	UnsubscribeURL string            `yaml:"unsubscribe_url"` // Public URL of /api/v1/email/unsubscribe; required to send marketing email
+
+	AdminEmail   []string `yaml:"admin_email"`    // Staff addresses that receive contact form notifications
+	AdminEmailCc []string `yaml:"admin_email_cc"` // Copied on contact form notifications
+
+	SMTPMode           string `yaml:"smtp_mode"`                 // starttls (upgrade required), tls (implicit TLS, usually port 465) or plain
+	DialTimeoutSec     int    `yaml:"dial_timeout_seconds"`      // Connecting to the SMTP server, including the TLS handshake for tls
+	SendTimeoutSec     int    `yaml:"send_timeout_seconds"`      // Whole SMTP conversation, from the greeting to the end of DATA
+	SMTPIdleTimeoutSec int    `yaml:"smtp_idle_timeout_seconds"` // Keep the connection open this long for the next email; 0 opens one per email
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`      // Accept any server certificate (local development only)
+
+	SendGridAPIKey     string   `yaml:"sendgrid_api_key"`
+	SendGridSandbox    bool     `yaml:"sendgrid_sandbox"`    // Validate requests without delivering them (staging)
+	SendGridCategories []string `yaml:"sendgrid_categories"` // Added to every email, after its type, for SendGrid statistics
+
+	SESRegion           string `yaml:"ses_region"`            // e.g. ap-south-1; credentials come from the default AWS chain
+	SESConfigurationSet string `yaml:"ses_configuration_set"` // Optional, for SES event publishing
+	SESFeedbackAddress  string `yaml:"ses_feedback_address"`  // Receives bounces and complaints instead of FromEmail
+	SESMaxSendRate      int    `yaml:"ses_max_send_rate"`     // Emails per second; 0 uses the account's maximum send rate
+
+	RetryMaxAttempts int `yaml:"retry_max_attempts"`  // Attempts per email when the server fails temporarily; 1 disables retries
+	RetryBaseDelayMS int `yaml:"retry_base_delay_ms"` // Wait before the first retry, doubled for each further retry
+	RetryJitterMS    int `yaml:"retry_jitter_ms"`     // Up to this much random delay is added to each wait
+
+	TemplateDir string `yaml:"template_dir"` // Directory of {lang}/{name}.html|.txt files overriding the built-in email templates
+
+	MaxAttachmentBytes int `yaml:"max_attachment_bytes"` // Combined size of an email's attachments, before encoding; 0 for no limit
+
+	MaxPerMinute        int `yaml:"max_per_minute"`         // Emails sent per minute across all recipients; 0 for no limit
+	MaxPerHour          int `yaml:"max_per_hour"`           // Emails sent per hour across all recipients; 0 for no limit
+	RecipientMaxPerHour int `yaml:"recipient_max_per_hour"` // Emails per hour to one address, OTP emails aside; 0 for no limit
+
+	DisposableDomains []string `yaml:"disposable_domains"` // Extra domains to reject on top of the built-in disposable list
 }
 
 // SMSConfig holds SMS service configuration
 type SMSConfig struct {
-	Enabled    bool
-	Provider   string // "twilio", "aws", "console" (for development)
-	TwilioSID  string
-	TwilioAuth string
-	TwilioFrom string
+	Provider        string `yaml:"provider"` // "twilio", "msg91", "aws", "console" (for development)
+	TwilioSID       string `yaml:"twilio_sid"`
+	TwilioAuth      string `yaml:"twilio_auth"`
+	TwilioFrom      string `yaml:"twilio_from"`
+	TwilioCallback  string `yaml:"twilio_status_callback_url"` // Public URL of /api/v1/otp/delivery-callback; empty disables delivery tracking
+	TwilioVerifySID string `yaml:"twilio_verify_service_sid"`  // Twilio Verify service used by the twilio-verify OTP backend
+	MSG91AuthKey    string `yaml:"msg91_auth_key"`
+	MSG91SenderID   string `yaml:"msg91_sender_id"`   // DLT-approved 6 character sender ID
+	MSG91TemplateID string `yaml:"msg91_template_id"` // DLT-registered template with an ##otp## variable
+	DefaultRegion   string `yaml:"default_region"`    // ISO 3166 country for numbers entered without a country code
+}
+
+// WhatsAppConfig holds WhatsApp delivery configuration.
+// The Twilio provider reuses the Twilio account credentials from SMSConfig.
+type WhatsAppConfig struct {
+	Provider           string `yaml:"provider"`             // "twilio", "meta", "console" (for development)
+	TwilioFrom         string `yaml:"twilio_from"`          // WhatsApp-enabled Twilio sender number
+	TwilioContentSID   string `yaml:"twilio_content_sid"`   // Approved Twilio content template with the code as variable {{1}}
+	MetaPhoneNumberID  string `yaml:"meta_phone_number_id"` // WhatsApp Cloud API sender phone number ID
+	MetaAccessToken    string `yaml:"meta_access_token"`
+	TemplateName       string `yaml:"template_name"`        // Approved Meta authentication template
+	TemplateLanguage   string `yaml:"template_language"`    // Language code the template was approved in
+	DefaultCountryCode string `yaml:"default_country_code"` // Prepended to 10-digit numbers
+	FallbackToSMS      bool   `yaml:"fallback_to_sms"`      // Send by SMS when WhatsApp delivery fails
+}
+
+// OTPConfig holds OTP delivery configuration
+type OTPConfig struct {
+	ValidityMin        int    `yaml:"validity_min"`         // Minutes a code stays valid; reloadable
+	ResendCooldownSec  int    `yaml:"resend_cooldown_sec"`  // Minimum seconds between sends of the same code
+	MaxResends         int    `yaml:"max_resends"`          // Times a code can be resent before a new one is needed
+	LogPlaintext       bool   `yaml:"log_plaintext"`        // Write codes to the logs; only honored when DEBUG is on
+	VerifiedTTLMin     int    `yaml:"verified_ttl_min"`     // Minutes a successful verification stays usable
+	CleanupIntervalSec int    `yaml:"cleanup_interval_sec"` // Seconds between sweeps of expired sessions and rate limit entries
+	DailyLimit         int    `yaml:"daily_limit"`          // New codes one identifier may request per rolling 24 hours (0 disables)
+	Backend            string `yaml:"backend"`              // "local" (codes generated and stored here) or "twilio-verify"
+	MaxSessions        int    `yaml:"max_sessions"`         // Sessions the in-memory store holds before evicting unverified ones closest to expiry
+	PhoneCodeLength    int    `yaml:"phone_code_length"`    // Length of codes delivered by SMS or WhatsApp
+	PhoneCodeAlphabet  string `yaml:"phone_code_alphabet"`  // Characters codes delivered by SMS or WhatsApp are drawn from
+	EmailCodeLength    int    `yaml:"email_code_length"`    // Length of codes delivered only by email
+	EmailCodeAlphabet  string `yaml:"email_code_alphabet"`  // Characters codes delivered only by email are drawn from
+	TemplateDir        string `yaml:"template_dir"`         // Directory of {locale}/{channel}.txt files overriding the built-in message templates
+	BrandName          string `yaml:"brand_name"`           // Brand shown in OTP messages
+}
+
+// ContactConfig holds contact form spam protection configuration
+type ContactConfig struct {
+	SpamKeywords       []string `yaml:"spam_keywords"`        // Case-insensitive phrases that raise the spam score
+	SpamMaxURLs        int      `yaml:"spam_max_urls"`        // Messages with more links than this are rejected as spam
+	SpamScoreThreshold int      `yaml:"spam_score_threshold"` // Submissions scoring at or above this are flagged as spam
+	RateLimitPerIP     int      `yaml:"rate_limit_per_ip"`    // Max submissions per hour from one IP (0 disables)
+	RateLimitPerEmail  int      `yaml:"rate_limit_per_email"` // Max submissions per hour from one email (0 disables)
+	DuplicateWindowMin int      `yaml:"duplicate_window_min"` // Identical resubmissions within this many minutes are not stored again (0 disables)
+}
+
+// Custom field types (InvestmentConfig.CustomFieldSchema values)
+const (
+	CustomFieldString  = "string"
+	CustomFieldNumber  = "number"
+	CustomFieldInteger = "integer"
+	CustomFieldBoolean = "boolean"
+)
+
+// InvestmentConfig holds investment inquiry configuration
+type InvestmentConfig struct {
+	CustomFieldSchema map[string]string `yaml:"custom_field_schema"` // Allowed custom field keys and their types (string, number, integer or boolean)
+}
+
+// NotifyConfig holds staff notification configuration
+type NotifyConfig struct {
+	SlackWebhookURL    string   `yaml:"slack_webhook_url"`   // Slack Incoming Webhook; empty disables Slack
+	DashboardURL       string   `yaml:"dashboard_url"`       // Base URL for deep links to the admin dashboard
+	WebhookURL         string   `yaml:"webhook_url"`         // Generic outbound webhook; empty disables it
+	WebhookSecret      string   `yaml:"webhook_secret"`      // HMAC-SHA256 key for the X-Springstreet-Signature header
+	ContactChannels    []string `yaml:"contact_channels"`    // Channels for new contact inquiries (email, slack, webhook)
+	InvestmentChannels []string `yaml:"investment_channels"` // Channels for new investment inquiries (slack, webhook)
+}
+
+// HubSpotConfig holds HubSpot CRM sync configuration.
+// Syncing is switched on with the hubspot feature flag (HUBSPOT_ENABLED).
+type HubSpotConfig struct {
+	APIKey string `yaml:"api_key"` // Private app access token with the crm.objects.contacts.write scope
+}
+
+// CaptchaConfig holds CAPTCHA verification configuration for the public forms.
+// Verification is switched on with the captcha feature flag (CAPTCHA_ENABLED).
+type CaptchaConfig struct {
+	Provider  string `yaml:"provider"`   // hcaptcha or turnstile
+	SecretKey string `yaml:"secret_key"` // Site secret used to verify tokens with the provider
+}
+
+// RedisConfig holds Redis configuration
+type RedisConfig struct {
+	URL       string `yaml:"url"`        // e.g. redis://:password@host:6379/0; empty disables Redis
+	KeyPrefix string `yaml:"key_prefix"` // Prepended to every key so instances can share a Redis database
+}
+
+// SecurityConfig holds security header configuration
+type SecurityConfig struct {
+	ForceHSTS             bool   `yaml:"force_hsts"`              // Send HSTS on plain HTTP too, e.g. behind a TLS-terminating proxy
+	HSTSMaxAge            int    `yaml:"hsts_max_age"`            // Seconds browsers should only use HTTPS
+	HSTSIncludeSubdomains bool   `yaml:"hsts_include_subdomains"` // Apply the policy to all subdomains
+	HSTSPreload           bool   `yaml:"hsts_preload"`            // Opt in to browser preload lists
+	CSPPolicy             string `yaml:"csp_policy"`              // Content-Security-Policy directives
+	CSPReportURI          string `yaml:"csp_report_uri"`          // Where browsers report violations; empty disables reporting
 }
 
-var globalConfig *Config
+// BreakerConfig holds circuit breaker settings for calls to external providers
+type BreakerConfig struct {
+	FailureThreshold int `yaml:"failure_threshold"` // Consecutive failures that open the circuit
+	ResetTimeoutSec  int `yaml:"reset_timeout_sec"` // Seconds an open circuit waits before probing again
+	HalfOpenProbes   int `yaml:"half_open_probes"`  // Successful probes needed to close the circuit
+}
+
+// WorkerConfig holds background worker pool configuration
+type WorkerConfig struct {
+	PoolSize  int `yaml:"pool_size"`  // Goroutines running background tasks
+	QueueSize int `yaml:"queue_size"` // Tasks that can wait for a worker before new ones are dropped
+
+	ReminderIntervalMin int `yaml:"reminder_check_interval_minutes"` // How often due follow-up reminders are sent
+}
+
+// MetricsConfig holds Prometheus metrics settings. They shape the registered metrics, so
+// they take effect at startup only.
+type MetricsConfig struct {
+	HTTPLatencyBuckets []float64 `yaml:"http_latency_buckets"` // Bucket bounds in seconds for http_request_duration_seconds
+	DBLatencyBuckets   []float64 `yaml:"db_latency_buckets"`   // Bucket bounds in seconds for db_query_duration_seconds
+	MaxPathLabels      int       `yaml:"max_path_labels"`      // Distinct endpoint labels before further paths are labeled "other"
+}
+
+// OTP code length bounds, matching the otp_code limits in the API design
+const (
+	MinOTPCodeLength = 4
+	MaxOTPCodeLength = 12
+)
+
+var (
+	globalConfig *Config
+	configMu     sync.RWMutex
+)
 
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
-	// Try to load .env file (ignore error if it doesn't exist)
+	// Try to load .env file (ignore error if it doesn't exist). Variables already set in the
+	// environment win, and keep winning when .env is reloaded.
+	recordProcessEnv()
 	_ = godotenv.Load()
 
-	config := &Config{
+	// *_FILE variables are read as the configuration asks for them; an unreadable file is
+	// reported ahead of the errors it causes, e.g. a secret provider without VAULT_TOKEN
+	takeEnvFileErrors()
+	err := initSecretProvider()
+	if fileErr := takeEnvFileErrors(); fileErr != nil {
+		return nil, fileErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret provider: %w", err)
+	}
+
+	config, err := loadFromEnv()
+	if fileErr := takeEnvFileErrors(); fileErr != nil {
+		return nil, fileErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	setGlobal(config)
+	return config, nil
+}
+
+// defaultConfig returns the built-in configuration defaults
+func defaultConfig() *Config {
+	return &Config{
 		App: AppConfig{
-			Name:    getEnv("APP_NAME", "Spring Street API"),
-			Version: getEnv("APP_VERSION", "1.0.0"),
-			Debug:   getEnvAsBool("DEBUG", false), // Default to false for security (no SQL query logging)
-			Port:    getEnv("PORT", "8000"),
-			Host:    getEnv("HOST", "0.0.0.0"),
+			Name:    "Spring Street API",
+			Version: "1.0.0",
+			Env:     EnvDevelopment,
+			Debug:   false, // Default to false for security (no SQL query logging)
+			Port:    "8000",
+			Host:    "0.0.0.0",
+
+			GoroutineLeakThreshold: 100,
+		},
+		HTTP: HTTPConfig{
+			MaxBodyBytes: 1 << 20, // 1 MB
+			MaxBodyBytesByPath: map[string]int{
+				"/api/v1/contact/submit": 10 << 20, // 10 MB
+			},
+			IdempotentPaths: []string{"/api/v1/investment/", "/api/v1/contact/submit"},
+		},
+		Server: ServerConfig{
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			MaxHeaderBytes:  1 << 20, // 1 MB
 		},
 		Database: DatabaseConfig{
-			URL: getEnv("DATABASE_URL", "sqlite:///./spring_street.db"),
+			URL:            "sqlite:///./spring_street.db",
+			QueryTimeoutMS: 5000,
 		},
 		Auth: AuthConfig{
-			SecretKey:          getEnv("SECRET_KEY", "your-secret-key-change-in-production"),
-			TokenExpiryMinutes: getEnvAsInt("ACCESS_TOKEN_EXPIRE_MINUTES", 30),
-			Algorithm:          getEnv("ALGORITHM", "HS256"),
+			SecretKey:          "your-secret-key-change-in-production",
+			TokenExpiryMinutes: 30,
+			Algorithm:          "HS256",
+			PasswordMinLength:  8,
+			PasswordHistory:    5,
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: getEnvAsSlice("ALLOWED_HOSTS", []string{"*"}),
+			AllowedOrigins: []string{"*"},
 			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"},
 			AllowedHeaders: []string{"*"},
+			ExposedHeaders: []string{"Content-Type", "Content-Disposition", "Authorization", "X-Request-ID", "Idempotent-Replayed"},
 			MaxAge:         86400,
 		},
 		Email: EmailConfig{
-			Enabled:  getEnvAsBool("EMAIL_ENABLED", false),
-			SMTPHost: getEnv("SMTP_HOST", "smtp.gmail.com"),
-			SMTPPort: getEnvAsInt("SMTP_PORT", 587),
-			Username: getEnv("SMTP_USERNAME", ""),
-			Password: getEnv("SMTP_PASSWORD", ""),
-			FromEmail: getEnv("EMAIL_FROM", "noreply@springstreet.com"),
-			FromName:  getEnv("EMAIL_FROM_NAME", "Spring Street"),
+			Provider:  "smtp",
+			SMTPHost:  "smtp.gmail.com",
+			SMTPPort:  587,
+			FromEmail: "noreply@springstreet.com",
+			FromName:  "Spring Street",
+
+			SMTPMode:           "starttls",
+			DialTimeoutSec:     10,
+			SendTimeoutSec:     30,
+			SMTPIdleTimeoutSec: 30,
+
+			RetryMaxAttempts: 3,
+			RetryBaseDelayMS: 1000,
+			RetryJitterMS:    500,
+
+			MaxAttachmentBytes: 10 << 20, // 10 MB, the lowest limit among the providers
+
+			MaxPerMinute:        60,
+			MaxPerHour:          1000,
+			RecipientMaxPerHour: 20,
 		},
 		SMS: SMSConfig{
-			Enabled:    getEnvAsBool("SMS_ENABLED", false),
-			Provider:   getEnv("SMS_PROVIDER", "console"), // console for development
-			TwilioSID:  getEnv("TWILIO_ACCOUNT_SID", ""),
-			TwilioAuth: getEnv("TWILIO_AUTH_TOKEN", ""),
-			TwilioFrom: getEnv("TWILIO_PHONE_NUMBER", ""),
+			Provider:      "console", // console for development
+			DefaultRegion: "IN",
+		},
+		WhatsApp: WhatsAppConfig{
+			Provider:           "console",
+			TemplateLanguage:   "en",
+			DefaultCountryCode: "91",
+			FallbackToSMS:      true,
+		},
+		OTP: OTPConfig{
+			ValidityMin:        10,
+			ResendCooldownSec:  30,
+			MaxResends:         3,
+			VerifiedTTLMin:     15,
+			CleanupIntervalSec: 60,
+			DailyLimit:         10,
+			Backend:            "local",
+			MaxSessions:        10000,
+			PhoneCodeLength:    6,
+			PhoneCodeAlphabet:  "0123456789",
+			EmailCodeLength:    8,
+			EmailCodeAlphabet:  "ABCDEFGHJKLMNPQRSTUVWXYZ23456789", // No 0/O or 1/I look-alikes
+			BrandName:          "Spring Street",
+		},
+		Contact: ContactConfig{
+			SpamKeywords: []string{
+				"seo services", "backlinks", "guest post", "rank your website", "web design services",
+				"casino", "viagra", "payday loan", "buy followers",
+			},
+			SpamMaxURLs:        3,
+			SpamScoreThreshold: 50,
+			RateLimitPerIP:     5,
+			RateLimitPerEmail:  3,
+			DuplicateWindowMin: 10,
+		},
+		Notify: NotifyConfig{
+			DashboardURL:       "https://springstreet.in/admin",
+			ContactChannels:    []string{"email"},
+			InvestmentChannels: []string{"slack"},
+		},
+		Captcha: CaptchaConfig{
+			Provider: "hcaptcha",
+		},
+		Redis: RedisConfig{
+			KeyPrefix: "springstreet:",
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            31536000,
+			HSTSIncludeSubdomains: true,
+			CSPPolicy:             "default-src 'none'; script-src 'self'; connect-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'",
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			ResetTimeoutSec:  30,
+			HalfOpenProbes:   1,
+		},
+		Workers: WorkerConfig{
+			PoolSize:  10,
+			QueueSize: 1000,
+
+			ReminderIntervalMin: 15,
+		},
+		Metrics: MetricsConfig{
+			HTTPLatencyBuckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			DBLatencyBuckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			MaxPathLabels:      200,
+		},
+		Features: FeatureFlags{
+			IdempotencyKeys:       true,
+			BlockDisposableEmails: true,
+		},
+	}
+}
+
+// loadFromEnv builds and validates a configuration.
+// Precedence is environment variables, then the YAML config file, then defaults.
+// Sensitive fields are resolved through the configured SecretProvider.
+func loadFromEnv() (*Config, error) {
+	base := defaultConfig()
+	otpPersistentSet, err := loadYAML(base)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{
+		App: AppConfig{
+			Name:    getEnv("APP_NAME", base.App.Name),
+			Version: getEnv("APP_VERSION", base.App.Version),
+			Env:     strings.ToLower(getEnv("APP_ENV", base.App.Env)),
+			Debug:   getEnvAsBool("DEBUG", base.App.Debug),
+			Port:    getEnv("PORT", base.App.Port),
+			Host:    getEnv("HOST", base.App.Host),
+
+			GoroutineLeakThreshold: getEnvAsInt("GOROUTINE_LEAK_THRESHOLD", base.App.GoroutineLeakThreshold),
+
+			PprofEnabled: getEnvAsBool("PPROF_ENABLED", base.App.PprofEnabled),
+			PprofSecret:  getSecret("PPROF_SECRET", base.App.PprofSecret),
+
+			MultiTenant: getEnvAsBool("MULTI_TENANT", base.App.MultiTenant),
+		},
+		HTTP: HTTPConfig{
+			MaxBodyBytes:       getEnvAsInt("MAX_REQUEST_BODY_BYTES", base.HTTP.MaxBodyBytes),
+			MaxBodyBytesByPath: base.HTTP.MaxBodyBytesByPath,
+			IdempotentPaths:    getEnvAsSlice("IDEMPOTENT_PATHS", base.HTTP.IdempotentPaths),
+			TrustedProxies:     getEnvAsSlice("TRUSTED_PROXIES", base.HTTP.TrustedProxies),
+		},
+		Database: DatabaseConfig{
+			URL:            getSecret("DATABASE_URL", base.Database.URL),
+			ReadReplicaURL: getSecret("DATABASE_READ_REPLICA_URL", base.Database.ReadReplicaURL),
+			QueryTimeoutMS: getEnvAsInt("DB_QUERY_TIMEOUT_MS", base.Database.QueryTimeoutMS),
+
+			AllowSQLiteInProduction: getEnvAsBool("ALLOW_SQLITE_IN_PRODUCTION", base.Database.AllowSQLiteInProduction),
 		},
+		Auth: AuthConfig{
+			SecretKey:          getSecret("SECRET_KEY", base.Auth.SecretKey),
+			TokenExpiryMinutes: getEnvAsInt("ACCESS_TOKEN_EXPIRE_MINUTES", base.Auth.TokenExpiryMinutes),
+			Algorithm:          getEnv("ALGORITHM", base.Auth.Algorithm),
+			PasswordMinLength:  getEnvAsInt("PASSWORD_MIN_LENGTH", base.Auth.PasswordMinLength),
+			PasswordHistory:    getEnvAsInt("PASSWORD_HISTORY_COUNT", base.Auth.PasswordHistory),
+			IntrospectSecret:   getSecret("INTROSPECT_SECRET", base.Auth.IntrospectSecret),
+
+			Enforce2FAForAdmins: getEnvAsBool("ENFORCE_2FA_FOR_ADMINS", base.Auth.Enforce2FAForAdmins),
+			Enforce2FAForStaff:  getEnvAsBool("ENFORCE_2FA_FOR_STAFF", base.Auth.Enforce2FAForStaff),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:     trimSlice(getEnvAsSlice("ALLOWED_HOSTS", base.CORS.AllowedOrigins)),
+			AllowedMethods:     trimSlice(getEnvAsSlice("CORS_ALLOWED_METHODS", base.CORS.AllowedMethods)),
+			AllowedHeaders:     trimSlice(getEnvAsSlice("CORS_ALLOWED_HEADERS", base.CORS.AllowedHeaders)),
+			ExposedHeaders:     trimSlice(getEnvAsSlice("CORS_EXPOSED_HEADERS", base.CORS.ExposedHeaders)),
+			AllowCredentials:   getEnvAsBool("CORS_ALLOW_CREDENTIALS", base.CORS.AllowCredentials),
+			MaxAge:             getEnvAsInt("CORS_MAX_AGE", base.CORS.MaxAge),
+			WildcardSubdomains: getEnvAsBool("CORS_WILDCARD_SUBDOMAINS", base.CORS.WildcardSubdomains),
+		},
+		Email: EmailConfig{
+			Provider:  strings.ToLower(getEnv("EMAIL_PROVIDER", base.Email.Provider)),
+			SMTPHost:  getEnv("SMTP_HOST", base.Email.SMTPHost),
+			SMTPPort:  getEnvAsInt("SMTP_PORT", base.Email.SMTPPort),
+			Username:  getEnv("SMTP_USERNAME", base.Email.Username),
+			Password:  getSecret("SMTP_PASSWORD", base.Email.Password),
+			FromEmail: getEnv("EMAIL_FROM", base.Email.FromEmail),
+			FromName:  getEnv("EMAIL_FROM_NAME", base.Email.FromName),
+			ReplyTo:   getEnv("EMAIL_REPLY_TO", base.Email.ReplyTo),
+
+			UnsubscribeURL: getEnv("EMAIL_UNSUBSCRIBE_URL", base.Email.UnsubscribeURL),
+
+			AdminEmail:   trimSlice(getEnvAsSlice("ADMIN_EMAIL", base.Email.AdminEmail)),
+			AdminEmailCc: trimSlice(getEnvAsSlice("ADMIN_EMAIL_CC", base.Email.AdminEmailCc)),
+
+			SMTPMode:           strings.ToLower(getEnv("SMTP_MODE", base.Email.SMTPMode)),
+			DialTimeoutSec:     getEnvAsInt("SMTP_DIAL_TIMEOUT_SECONDS", base.Email.DialTimeoutSec),
+			SendTimeoutSec:     getEnvAsInt("SMTP_SEND_TIMEOUT_SECONDS", base.Email.SendTimeoutSec),
+			SMTPIdleTimeoutSec: getEnvAsInt("SMTP_IDLE_TIMEOUT_SECONDS", base.Email.SMTPIdleTimeoutSec),
+			InsecureSkipVerify: getEnvAsBool("SMTP_INSECURE_SKIP_VERIFY", base.Email.InsecureSkipVerify),
+
+			SendGridAPIKey:     getSecret("SENDGRID_API_KEY", base.Email.SendGridAPIKey),
+			SendGridSandbox:    getEnvAsBool("SENDGRID_SANDBOX_MODE", base.Email.SendGridSandbox),
+			SendGridCategories: trimSlice(getEnvAsSlice("SENDGRID_CATEGORIES", base.Email.SendGridCategories)),
+
+			SESRegion:           getEnv("SES_REGION", base.Email.SESRegion),
+			SESConfigurationSet: getEnv("SES_CONFIGURATION_SET", base.Email.SESConfigurationSet),
+			SESFeedbackAddress:  getEnv("SES_FEEDBACK_ADDRESS", base.Email.SESFeedbackAddress),
+			SESMaxSendRate:      getEnvAsInt("SES_MAX_SEND_RATE", base.Email.SESMaxSendRate),
+
+			RetryMaxAttempts: getEnvAsInt("EMAIL_RETRY_MAX_ATTEMPTS", base.Email.RetryMaxAttempts),
+			RetryBaseDelayMS: getEnvAsInt("EMAIL_RETRY_BASE_DELAY_MS", base.Email.RetryBaseDelayMS),
+			RetryJitterMS:    getEnvAsInt("EMAIL_RETRY_JITTER_MS", base.Email.RetryJitterMS),
+			TemplateDir:      getEnv("EMAIL_TEMPLATE_DIR", base.Email.TemplateDir),
+
+			MaxAttachmentBytes: getEnvAsInt("EMAIL_MAX_ATTACHMENT_BYTES", base.Email.MaxAttachmentBytes),
+
+			MaxPerMinute:        getEnvAsInt("EMAIL_MAX_PER_MINUTE", base.Email.MaxPerMinute),
+			MaxPerHour:          getEnvAsInt("EMAIL_MAX_PER_HOUR", base.Email.MaxPerHour),
+			RecipientMaxPerHour: getEnvAsInt("EMAIL_RECIPIENT_MAX_PER_HOUR", base.Email.RecipientMaxPerHour),
+
+			DisposableDomains: getEnvAsSlice("DISPOSABLE_EMAIL_DOMAINS", base.Email.DisposableDomains),
+		},
+		SMS: SMSConfig{
+			Provider:        getEnv("SMS_PROVIDER", base.SMS.Provider),
+			TwilioSID:       getSecret("TWILIO_ACCOUNT_SID", base.SMS.TwilioSID),
+			TwilioAuth:      getSecret("TWILIO_AUTH_TOKEN", base.SMS.TwilioAuth),
+			TwilioFrom:      getEnv("TWILIO_PHONE_NUMBER", base.SMS.TwilioFrom),
+			TwilioCallback:  getEnv("TWILIO_STATUS_CALLBACK_URL", base.SMS.TwilioCallback),
+			TwilioVerifySID: getEnv("TWILIO_VERIFY_SERVICE_SID", base.SMS.TwilioVerifySID),
+			MSG91AuthKey:    getSecret("MSG91_AUTH_KEY", base.SMS.MSG91AuthKey),
+			MSG91SenderID:   getEnv("MSG91_SENDER_ID", base.SMS.MSG91SenderID),
+			MSG91TemplateID: getEnv("MSG91_TEMPLATE_ID", base.SMS.MSG91TemplateID),
+			DefaultRegion:   strings.ToUpper(getEnv("SMS_DEFAULT_REGION", base.SMS.DefaultRegion)),
+		},
+		WhatsApp: WhatsAppConfig{
+			Provider:           getEnv("WHATSAPP_PROVIDER", base.WhatsApp.Provider),
+			TwilioFrom:         getEnv("WHATSAPP_TWILIO_FROM", base.WhatsApp.TwilioFrom),
+			TwilioContentSID:   getEnv("WHATSAPP_TWILIO_CONTENT_SID", base.WhatsApp.TwilioContentSID),
+			MetaPhoneNumberID:  getEnv("WHATSAPP_META_PHONE_NUMBER_ID", base.WhatsApp.MetaPhoneNumberID),
+			MetaAccessToken:    getSecret("WHATSAPP_META_ACCESS_TOKEN", base.WhatsApp.MetaAccessToken),
+			TemplateName:       getEnv("WHATSAPP_TEMPLATE_NAME", base.WhatsApp.TemplateName),
+			TemplateLanguage:   getEnv("WHATSAPP_TEMPLATE_LANGUAGE", base.WhatsApp.TemplateLanguage),
+			DefaultCountryCode: getEnv("WHATSAPP_DEFAULT_COUNTRY_CODE", base.WhatsApp.DefaultCountryCode),
+			FallbackToSMS:      getEnvAsBool("WHATSAPP_FALLBACK_TO_SMS", base.WhatsApp.FallbackToSMS),
+		},
+		OTP: OTPConfig{
+			ValidityMin:        getEnvAsInt("OTP_EXPIRY_MINUTES", base.OTP.ValidityMin),
+			ResendCooldownSec:  getEnvAsInt("OTP_RESEND_COOLDOWN_SECONDS", base.OTP.ResendCooldownSec),
+			MaxResends:         getEnvAsInt("OTP_MAX_RESENDS", base.OTP.MaxResends),
+			LogPlaintext:       getEnvAsBool("OTP_LOG_PLAINTEXT", base.OTP.LogPlaintext),
+			VerifiedTTLMin:     getEnvAsInt("OTP_VERIFIED_TTL_MINUTES", base.OTP.VerifiedTTLMin),
+			CleanupIntervalSec: getEnvAsInt("OTP_CLEANUP_INTERVAL_SECONDS", base.OTP.CleanupIntervalSec),
+			DailyLimit:         getEnvAsInt("OTP_DAILY_LIMIT", base.OTP.DailyLimit),
+			Backend:            getEnv("OTP_BACKEND", base.OTP.Backend),
+			MaxSessions:        getEnvAsInt("OTP_MAX_SESSIONS", base.OTP.MaxSessions),
+			PhoneCodeLength:    getEnvAsInt("OTP_PHONE_CODE_LENGTH", base.OTP.PhoneCodeLength),
+			PhoneCodeAlphabet:  strings.ToUpper(getEnv("OTP_PHONE_CODE_ALPHABET", base.OTP.PhoneCodeAlphabet)),
+			EmailCodeLength:    getEnvAsInt("OTP_EMAIL_CODE_LENGTH", base.OTP.EmailCodeLength),
+			EmailCodeAlphabet:  strings.ToUpper(getEnv("OTP_EMAIL_CODE_ALPHABET", base.OTP.EmailCodeAlphabet)),
+			TemplateDir:        getEnv("OTP_TEMPLATE_DIR", base.OTP.TemplateDir),
+			BrandName:          getEnv("OTP_BRAND_NAME", base.OTP.BrandName),
+		},
+		Contact: ContactConfig{
+			SpamKeywords:       getEnvAsSlice("SPAM_KEYWORDS", getEnvAsSlice("CONTACT_SPAM_KEYWORDS", base.Contact.SpamKeywords)),
+			SpamMaxURLs:        getEnvAsInt("CONTACT_SPAM_MAX_URLS", base.Contact.SpamMaxURLs),
+			SpamScoreThreshold: getEnvAsInt("CONTACT_SPAM_THRESHOLD", base.Contact.SpamScoreThreshold),
+			RateLimitPerIP:     getEnvAsInt("CONTACT_RATE_LIMIT_PER_IP", base.Contact.RateLimitPerIP),
+			RateLimitPerEmail:  getEnvAsInt("CONTACT_RATE_LIMIT_PER_EMAIL", base.Contact.RateLimitPerEmail),
+			DuplicateWindowMin: getEnvAsInt("CONTACT_DUPLICATE_WINDOW_MINUTES", base.Contact.DuplicateWindowMin),
+		},
+		Notify: NotifyConfig{
+			SlackWebhookURL:    getSecret("SLACK_WEBHOOK_URL", base.Notify.SlackWebhookURL),
+			DashboardURL:       getEnv("DASHBOARD_URL", base.Notify.DashboardURL),
+			WebhookURL:         getEnv("WEBHOOK_URL", base.Notify.WebhookURL),
+			WebhookSecret:      getSecret("WEBHOOK_SECRET", base.Notify.WebhookSecret),
+			ContactChannels:    getEnvAsSlice("NOTIFY_CONTACT_CHANNELS", base.Notify.ContactChannels),
+			InvestmentChannels: getEnvAsSlice("NOTIFY_INVESTMENT_CHANNELS", base.Notify.InvestmentChannels),
+		},
+		Redis: RedisConfig{
+			URL:       getSecret("REDIS_URL", base.Redis.URL),
+			KeyPrefix: getEnv("REDIS_KEY_PREFIX", base.Redis.KeyPrefix),
+		},
+		Security: SecurityConfig{
+			ForceHSTS:             getEnvAsBool("FORCE_HSTS", base.Security.ForceHSTS),
+			HSTSMaxAge:            getEnvAsInt("HSTS_MAX_AGE", base.Security.HSTSMaxAge),
+			HSTSIncludeSubdomains: getEnvAsBool("HSTS_INCLUDE_SUBDOMAINS", base.Security.HSTSIncludeSubdomains),
+			HSTSPreload:           getEnvAsBool("HSTS_PRELOAD", base.Security.HSTSPreload),
+			CSPPolicy:             getEnv("CSP_POLICY", base.Security.CSPPolicy),
+			CSPReportURI:          getEnv("CSP_REPORT_URI", base.Security.CSPReportURI),
+		},
+		HubSpot: HubSpotConfig{
+			APIKey: getSecret("HUBSPOT_API_KEY", base.HubSpot.APIKey),
+		},
+		Captcha: CaptchaConfig{
+			Provider:  strings.ToLower(getEnv("CAPTCHA_PROVIDER", base.Captcha.Provider)),
+			SecretKey: getSecret("CAPTCHA_SECRET_KEY", base.Captcha.SecretKey),
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: getEnvAsInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD", base.Breaker.FailureThreshold),
+			ResetTimeoutSec:  getEnvAsInt("CIRCUIT_BREAKER_RESET_SECONDS", base.Breaker.ResetTimeoutSec),
+			HalfOpenProbes:   getEnvAsInt("CIRCUIT_BREAKER_HALF_OPEN_PROBES", base.Breaker.HalfOpenProbes),
+		},
+		Workers: WorkerConfig{
+			PoolSize:  getEnvAsInt("WORKER_POOL_SIZE", base.Workers.PoolSize),
+			QueueSize: getEnvAsInt("WORKER_QUEUE_SIZE", base.Workers.QueueSize),
+
+			ReminderIntervalMin: getEnvAsInt("REMINDER_CHECK_INTERVAL", base.Workers.ReminderIntervalMin),
+		},
+	}
+
+	// Feature flags keep honoring the legacy *_ENABLED variables.
+	// Persistent OTP sessions default to on for PostgreSQL deployments.
+	otpPersistent := config.Database.IsPostgres()
+	if otpPersistentSet {
+		otpPersistent = base.Features.OTPPersistent
+	}
+	config.Features = FeatureFlags{
+		EmailEnabled:          getEnvAsBool("EMAIL_ENABLED", base.Features.EmailEnabled),
+		SMSEnabled:            getEnvAsBool("SMS_ENABLED", base.Features.SMSEnabled),
+		WhatsAppEnabled:       getEnvAsBool("WHATSAPP_ENABLED", base.Features.WhatsAppEnabled),
+		WebhooksEnabled:       getEnvAsBool("WEBHOOKS_ENABLED", base.Features.WebhooksEnabled),
+		ReengagementEnabled:   getEnvAsBool("REENGAGEMENT_ENABLED", base.Features.ReengagementEnabled),
+		MagicLinksEnabled:     getEnvAsBool("MAGIC_LINKS_ENABLED", base.Features.MagicLinksEnabled),
+		OTPPersistent:         getEnvAsBool("OTP_PERSISTENT", otpPersistent),
+		PasswordPolicy:        getEnvAsBool("PASSWORD_POLICY_ENABLED", base.Features.PasswordPolicy),
+		IdempotencyKeys:       getEnvAsBool("IDEMPOTENCY_KEY_ENABLED", base.Features.IdempotencyKeys),
+		BlockDisposableEmails: getEnvAsBool("BLOCK_DISPOSABLE_EMAILS", base.Features.BlockDisposableEmails),
+		HubSpotEnabled:        getEnvAsBool("HUBSPOT_ENABLED", base.Features.HubSpotEnabled),
+		CaptchaEnabled:        getEnvAsBool("CAPTCHA_ENABLED", base.Features.CaptchaEnabled),
+	}
+
+	customFieldSchema, err := getEnvAsStringMap("CUSTOM_FIELD_SCHEMA", base.Investment.CustomFieldSchema)
+	if err != nil {
+		return nil, err
+	}
+	config.Investment = InvestmentConfig{CustomFieldSchema: customFieldSchema}
+
+	if config.Email.FromByType, err = getEnvAsStringMap("EMAIL_FROM_BY_TYPE", base.Email.FromByType); err != nil {
+		return nil, err
+	}
+
+	config.Server.MaxHeaderBytes = getEnvAsInt("SERVER_MAX_HEADER_BYTES", base.Server.MaxHeaderBytes)
+	for _, d := range []struct {
+		key          string
+		value        *time.Duration
+		defaultValue time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", &config.Server.ReadTimeout, base.Server.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", &config.Server.ReadHeaderTimeout, base.Server.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", &config.Server.WriteTimeout, base.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", &config.Server.IdleTimeout, base.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", &config.Server.ShutdownTimeout, base.Server.ShutdownTimeout},
+	} {
+		if *d.value, err = getEnvAsDuration(d.key, d.defaultValue); err != nil {
+			return nil, err
+		}
+	}
+
+	config.Metrics.MaxPathLabels = getEnvAsInt("METRICS_MAX_PATH_LABELS", base.Metrics.MaxPathLabels)
+	if config.Metrics.HTTPLatencyBuckets, err = getEnvAsFloatSlice("HTTP_LATENCY_BUCKETS", base.Metrics.HTTPLatencyBuckets); err != nil {
+		return nil, err
+	}
+	if config.Metrics.DBLatencyBuckets, err = getEnvAsFloatSlice("DB_LATENCY_BUCKETS", base.Metrics.DBLatencyBuckets); err != nil {
+		return nil, err
 	}
 
 	// Validate configuration
@@ -120,15 +735,44 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
-	globalConfig = config
 	return config, nil
 }
 
 // validateConfig validates the configuration
 func validateConfig(cfg *Config) error {
+	switch cfg.App.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		return fmt.Errorf("APP_ENV must be development, staging or production")
+	}
 	if cfg.App.Port == "" {
 		return fmt.Errorf("PORT must be set")
 	}
+	if cfg.App.GoroutineLeakThreshold < 1 {
+		return fmt.Errorf("GOROUTINE_LEAK_THRESHOLD must be at least 1")
+	}
+	if cfg.Server.ReadTimeout < 0 || cfg.Server.ReadHeaderTimeout < 0 || cfg.Server.WriteTimeout < 0 || cfg.Server.IdleTimeout < 0 {
+		return fmt.Errorf("SERVER_*_TIMEOUT must not be negative")
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SERVER_SHUTDOWN_TIMEOUT must be positive")
+	}
+	// The write deadline is set when the request headers are read, so a shorter one cuts off
+	// responses to requests that are still allowed to be uploading
+	if cfg.Server.WriteTimeout > 0 && cfg.Server.WriteTimeout < cfg.Server.ReadTimeout {
+		return fmt.Errorf("SERVER_WRITE_TIMEOUT (%v) must be at least SERVER_READ_TIMEOUT (%v)", cfg.Server.WriteTimeout, cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.MaxHeaderBytes < 0 {
+		return fmt.Errorf("SERVER_MAX_HEADER_BYTES must not be negative")
+	}
+	for _, proxy := range cfg.HTTP.TrustedProxies {
+		if _, ok := parseProxy(proxy); !ok {
+			return fmt.Errorf("TRUSTED_PROXIES entry %q must be an IP address or CIDR", proxy)
+		}
+	}
+	if err := validateCORS(&cfg.CORS); err != nil {
+		return err
+	}
 	if cfg.Database.URL == "" {
 		return fmt.Errorf("DATABASE_URL must be set")
 	}
@@ -138,29 +782,197 @@ func validateConfig(cfg *Config) error {
 	if cfg.Auth.TokenExpiryMinutes <= 0 {
 		return fmt.Errorf("ACCESS_TOKEN_EXPIRE_MINUTES must be greater than 0")
 	}
+	switch cfg.Email.Provider {
+	case "smtp", "sendgrid", "ses", "console":
+	default:
+		return fmt.Errorf("EMAIL_PROVIDER must be smtp, sendgrid, ses or console")
+	}
+	if cfg.Features.EmailEnabled && cfg.Email.Provider == "sendgrid" && cfg.Email.SendGridAPIKey == "" {
+		return fmt.Errorf("SENDGRID_API_KEY must be set when EMAIL_PROVIDER is sendgrid")
+	}
+	if cfg.Email.Provider == "ses" && cfg.Email.SESRegion == "" {
+		return fmt.Errorf("SES_REGION must be set when EMAIL_PROVIDER is ses")
+	}
+	if cfg.Email.SESMaxSendRate < 0 {
+		return fmt.Errorf("SES_MAX_SEND_RATE must not be negative")
+	}
+	if cfg.Email.SMTPMode != "starttls" && cfg.Email.SMTPMode != "tls" && cfg.Email.SMTPMode != "plain" {
+		return fmt.Errorf("SMTP_MODE must be starttls, tls or plain")
+	}
+	if cfg.Features.EmailEnabled && len(cfg.Email.AdminEmail) == 0 {
+		return fmt.Errorf("ADMIN_EMAIL must be set when EMAIL_ENABLED is true")
+	}
+	for _, addr := range append(append([]string{cfg.Email.ReplyTo}, cfg.Email.AdminEmail...), cfg.Email.AdminEmailCc...) {
+		if parsed, err := mail.ParseAddress(addr); addr != "" && (err != nil || parsed.Address != addr) {
+			return fmt.Errorf("invalid email address %q in EMAIL_REPLY_TO, ADMIN_EMAIL or ADMIN_EMAIL_CC", addr)
+		}
+	}
+	for emailType, sender := range cfg.Email.FromByType {
+		if _, err := mail.ParseAddress(sender); err != nil {
+			return fmt.Errorf("invalid sender %q for %s in EMAIL_FROM_BY_TYPE", sender, emailType)
+		}
+	}
+	if cfg.Email.UnsubscribeURL != "" {
+		if u, err := url.Parse(cfg.Email.UnsubscribeURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("EMAIL_UNSUBSCRIBE_URL must be an absolute http(s) URL")
+		}
+	}
+	if cfg.Email.DialTimeoutSec <= 0 || cfg.Email.SendTimeoutSec <= 0 {
+		return fmt.Errorf("SMTP_DIAL_TIMEOUT_SECONDS and SMTP_SEND_TIMEOUT_SECONDS must be greater than 0")
+	}
+	if cfg.Email.SMTPIdleTimeoutSec < 0 {
+		return fmt.Errorf("SMTP_IDLE_TIMEOUT_SECONDS must not be negative")
+	}
+	if cfg.Email.RetryMaxAttempts < 1 {
+		return fmt.Errorf("EMAIL_RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.Email.RetryBaseDelayMS < 0 || cfg.Email.RetryJitterMS < 0 {
+		return fmt.Errorf("EMAIL_RETRY_BASE_DELAY_MS and EMAIL_RETRY_JITTER_MS must not be negative")
+	}
+	if cfg.Email.MaxAttachmentBytes < 0 {
+		return fmt.Errorf("EMAIL_MAX_ATTACHMENT_BYTES must not be negative")
+	}
+	if cfg.Email.MaxPerMinute < 0 || cfg.Email.MaxPerHour < 0 || cfg.Email.RecipientMaxPerHour < 0 {
+		return fmt.Errorf("EMAIL_MAX_PER_MINUTE, EMAIL_MAX_PER_HOUR and EMAIL_RECIPIENT_MAX_PER_HOUR must not be negative")
+	}
+	for key, fieldType := range cfg.Investment.CustomFieldSchema {
+		if !customFieldKeyPattern.MatchString(key) {
+			return fmt.Errorf("CUSTOM_FIELD_SCHEMA key %q must be lowercase letters, digits and underscores, starting with a letter", key)
+		}
+		switch fieldType {
+		case CustomFieldString, CustomFieldNumber, CustomFieldInteger, CustomFieldBoolean:
+		default:
+			return fmt.Errorf("CUSTOM_FIELD_SCHEMA type of %q must be string, number, integer or boolean", key)
+		}
+	}
+	if cfg.OTP.ValidityMin <= 0 {
+		return fmt.Errorf("OTP_EXPIRY_MINUTES must be greater than 0")
+	}
+	if cfg.OTP.CleanupIntervalSec <= 0 {
+		return fmt.Errorf("OTP_CLEANUP_INTERVAL_SECONDS must be greater than 0")
+	}
+	if cfg.Workers.ReminderIntervalMin <= 0 {
+		return fmt.Errorf("REMINDER_CHECK_INTERVAL must be greater than 0")
+	}
+	if cfg.Metrics.MaxPathLabels < 1 {
+		return fmt.Errorf("METRICS_MAX_PATH_LABELS must be at least 1")
+	}
+	if err := validateBuckets(cfg.Metrics.HTTPLatencyBuckets); err != nil {
+		return fmt.Errorf("invalid HTTP_LATENCY_BUCKETS: %w", err)
+	}
+	if err := validateBuckets(cfg.Metrics.DBLatencyBuckets); err != nil {
+		return fmt.Errorf("invalid DB_LATENCY_BUCKETS: %w", err)
+	}
+	if cfg.OTP.MaxSessions <= 0 {
+		return fmt.Errorf("OTP_MAX_SESSIONS must be greater than 0")
+	}
+	if cfg.OTP.Backend != "local" && cfg.OTP.Backend != "twilio-verify" {
+		return fmt.Errorf("OTP_BACKEND must be local or twilio-verify")
+	}
+	if cfg.OTP.PhoneCodeLength < MinOTPCodeLength || cfg.OTP.PhoneCodeLength > MaxOTPCodeLength {
+		return fmt.Errorf("OTP_PHONE_CODE_LENGTH must be between %d and %d", MinOTPCodeLength, MaxOTPCodeLength)
+	}
+	if cfg.OTP.EmailCodeLength < MinOTPCodeLength || cfg.OTP.EmailCodeLength > MaxOTPCodeLength {
+		return fmt.Errorf("OTP_EMAIL_CODE_LENGTH must be between %d and %d", MinOTPCodeLength, MaxOTPCodeLength)
+	}
+	if !validOTPAlphabet(cfg.OTP.PhoneCodeAlphabet) {
+		return fmt.Errorf("OTP_PHONE_CODE_ALPHABET must be at least 2 distinct letters or digits")
+	}
+	if !validOTPAlphabet(cfg.OTP.EmailCodeAlphabet) {
+		return fmt.Errorf("OTP_EMAIL_CODE_ALPHABET must be at least 2 distinct letters or digits")
+	}
+	if phonenumbers.GetCountryCodeForRegion(cfg.SMS.DefaultRegion) == 0 {
+		return fmt.Errorf("SMS_DEFAULT_REGION must be an ISO 3166 country code such as IN")
+	}
+	if cfg.Captcha.Provider != "hcaptcha" && cfg.Captcha.Provider != "turnstile" {
+		return fmt.Errorf("CAPTCHA_PROVIDER must be hcaptcha or turnstile")
+	}
+	if cfg.Features.CaptchaEnabled && cfg.Captcha.SecretKey == "" {
+		return fmt.Errorf("CAPTCHA_SECRET_KEY must be set when CAPTCHA_ENABLED is true")
+	}
+	if cfg.App.IsProduction() {
+		return validateProduction(cfg)
+	}
+	return nil
+}
+
+// validateProduction applies the checks that only hold in production: settings that are
+// convenient while developing but unsafe, or that leave a feature switched on without a way
+// to deliver anything
+func validateProduction(cfg *Config) error {
+	if cfg.App.Debug {
+		return fmt.Errorf("DEBUG must be false when APP_ENV is production")
+	}
+	if slices.Contains(cfg.CORS.AllowedOrigins, "*") {
+		return fmt.Errorf("ALLOWED_HOSTS must list origins instead of * when APP_ENV is production")
+	}
+	if cfg.Auth.SecretKey == defaultConfig().Auth.SecretKey {
+		return fmt.Errorf("SECRET_KEY must be changed from the default when APP_ENV is production")
+	}
+	if !cfg.Database.IsPostgres() && !cfg.Database.AllowSQLiteInProduction {
+		return fmt.Errorf("DATABASE_URL must be PostgreSQL when APP_ENV is production; set ALLOW_SQLITE_IN_PRODUCTION=true to use SQLite anyway")
+	}
+	if cfg.Features.EmailEnabled {
+		switch cfg.Email.Provider {
+		case "console":
+			return fmt.Errorf("EMAIL_PROVIDER must not be console when EMAIL_ENABLED is true and APP_ENV is production")
+		case "smtp":
+			if cfg.Email.SMTPHost == "" {
+				return fmt.Errorf("SMTP_HOST must be set when EMAIL_PROVIDER is smtp and APP_ENV is production")
+			}
+		}
+		if cfg.Email.FromEmail == "" {
+			return fmt.Errorf("EMAIL_FROM must be set when EMAIL_ENABLED is true and APP_ENV is production")
+		}
+	}
+	if cfg.Features.SMSEnabled {
+		switch cfg.SMS.Provider {
+		case "console":
+			return fmt.Errorf("SMS_PROVIDER must not be console when SMS_ENABLED is true and APP_ENV is production")
+		case "twilio":
+			if cfg.SMS.TwilioSID == "" || cfg.SMS.TwilioAuth == "" || cfg.SMS.TwilioFrom == "" {
+				return fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_PHONE_NUMBER must be set when SMS_PROVIDER is twilio and APP_ENV is production")
+			}
+		case "msg91":
+			if cfg.SMS.MSG91AuthKey == "" || cfg.SMS.MSG91SenderID == "" || cfg.SMS.MSG91TemplateID == "" {
+				return fmt.Errorf("MSG91_AUTH_KEY, MSG91_SENDER_ID and MSG91_TEMPLATE_ID must be set when SMS_PROVIDER is msg91 and APP_ENV is production")
+			}
+		}
+	}
 	return nil
 }
 
 // Get returns the global configuration
 func Get() *Config {
-	if globalConfig == nil {
+	configMu.RLock()
+	config := globalConfig
+	configMu.RUnlock()
+
+	if config == nil {
 		// Load default config if not loaded
 		config, _ := Load()
 		return config
 	}
-	return globalConfig
+	return config
+}
+
+// setGlobal replaces the global configuration
+func setGlobal(config *Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	globalConfig = config
 }
 
 // Helper functions
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvAsBool(key string, defaultValue bool) bool {
-	valueStr := os.Getenv(key)
+	valueStr := lookupEnv(key)
 	if valueStr == "" {
 		return defaultValue
 	}
@@ -172,7 +984,7 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
+	valueStr := lookupEnv(key)
 	if valueStr == "" {
 		return defaultValue
 	}
@@ -184,13 +996,121 @@ func getEnvAsInt(key string, defaultValue int) int {
 }
 
 func getEnvAsSlice(key string, defaultValue []string) []string {
-	valueStr := os.Getenv(key)
+	valueStr := lookupEnv(key)
 	if valueStr == "" {
 		return defaultValue
 	}
 	return strings.Split(valueStr, ",")
 }
 
+// validateCORS checks the CORS settings. Browsers take "*" literally in responses to
+// requests with credentials, so it can't be combined with CORS_ALLOW_CREDENTIALS.
+func validateCORS(cors *CORSConfig) error {
+	if cors.MaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+	if !cors.AllowCredentials {
+		return nil
+	}
+	for _, list := range []struct {
+		name   string
+		values []string
+	}{
+		{"ALLOWED_HOSTS", cors.AllowedOrigins},
+		{"CORS_ALLOWED_METHODS", cors.AllowedMethods},
+		{"CORS_ALLOWED_HEADERS", cors.AllowedHeaders},
+		{"CORS_EXPOSED_HEADERS", cors.ExposedHeaders},
+	} {
+		for _, value := range list.values {
+			if value == "*" {
+				return fmt.Errorf("%s must list values instead of * when CORS_ALLOW_CREDENTIALS is true", list.name)
+			}
+		}
+	}
+	return nil
+}
+
+// getEnvAsDuration reads a duration such as "15s" or "1m30s"
+func getEnvAsDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr := lookupEnv(key)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 15s or 2m: %w", key, err)
+	}
+	return value, nil
+}
+
+// getEnvAsFloatSlice reads comma-separated numbers, e.g. "0.005,0.01,0.025"
+func getEnvAsFloatSlice(key string, defaultValue []float64) ([]float64, error) {
+	valueStr := lookupEnv(key)
+	if strings.TrimSpace(valueStr) == "" {
+		return defaultValue, nil
+	}
+	parts := strings.Split(valueStr, ",")
+	values := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be comma-separated numbers: %q is not a number", key, strings.TrimSpace(part))
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// validateBuckets checks histogram bucket bounds, which must be positive and increasing
+func validateBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("must list at least one bucket bound")
+	}
+	for i, bound := range buckets {
+		if bound <= 0 {
+			return fmt.Errorf("bucket bounds must be positive, got %v", bound)
+		}
+		if i > 0 && bound <= buckets[i-1] {
+			return fmt.Errorf("bucket bounds must be in increasing order, got %v after %v", bound, buckets[i-1])
+		}
+	}
+	return nil
+}
+
+// getEnvAsStringMap reads a JSON object of strings, e.g. {"referral_source":"string"}
+func getEnvAsStringMap(key string, defaultValue map[string]string) (map[string]string, error) {
+	valueStr := lookupEnv(key)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	var value map[string]string
+	if err := json.Unmarshal([]byte(valueStr), &value); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON object of strings: %w", key, err)
+	}
+	return value, nil
+}
+
+// trimSlice trims every value and drops the empty ones, e.g. from "a@x.com, b@x.com,"
+func trimSlice(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
+
+// IsProduction reports whether APP_ENV is production
+func (c *AppConfig) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// IsDevelopment reports whether APP_ENV is development
+func (c *AppConfig) IsDevelopment() bool {
+	return c.Env == EnvDevelopment
+}
+
 // IsPostgres checks if the database URL is for PostgreSQL
 func (c *DatabaseConfig) IsPostgres() bool {
 	url := c.URL
@@ -298,6 +1218,64 @@ func (c *DatabaseConfig) GetPostgresDSN() string {
 	return dsn
 }
 
+// BodyLimit returns the request body limit in bytes for a path, or 0 for no limit
+func (c *HTTPConfig) BodyLimit(path string) int64 {
+	limit, matched := c.MaxBodyBytes, ""
+	for prefix, prefixLimit := range c.MaxBodyBytesByPath {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+			limit, matched = prefixLimit, prefix
+		}
+	}
+	return int64(limit)
+}
+
+// IsTrustedProxy reports whether ip is one of TrustedProxies, whose forwarding headers can be
+// believed
+func (c *HTTPConfig) IsTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	for _, proxy := range c.TrustedProxies {
+		if prefix, ok := parseProxy(proxy); ok && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProxy parses a TRUSTED_PROXIES entry, a single address or a CIDR
+func parseProxy(proxy string) (netip.Prefix, bool) {
+	proxy = strings.TrimSpace(proxy)
+	if prefix, err := netip.ParsePrefix(proxy); err == nil {
+		return prefix.Masked(), true
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+// validOTPAlphabet reports whether alphabet holds at least two distinct upper case letters or
+// digits. Codes are compared upper-cased, so other characters could never be typed back.
+func validOTPAlphabet(alphabet string) bool {
+	seen := make(map[rune]bool, len(alphabet))
+	for _, r := range alphabet {
+		if seen[r] || !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+		seen[r] = true
+	}
+	return len(seen) >= 2
+}
+
+// QueryTimeout returns the query deadline as a duration
+func (c *DatabaseConfig) QueryTimeout() time.Duration {
+	return time.Duration(c.QueryTimeoutMS) * time.Millisecond
+}
+
 // GetSQLitePath extracts SQLite database path from URL
 func (c *DatabaseConfig) GetSQLitePath() string {
 	url := c.URL
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setBaseEnv sets the environment every Load needs
+func setBaseEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("SECRET_KEY", "test-secret-key-that-is-long-enough-0123456789")
+}
+
+func TestAdminEmailList(t *testing.T) {
+	setBaseEnv(t)
+	t.Setenv("ADMIN_EMAIL", " ops@springstreet.in, sales@springstreet.in ,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"ops@springstreet.in", "sales@springstreet.in"}
+	if !slices.Equal(cfg.Email.AdminEmail, want) {
+		t.Fatalf("AdminEmail = %q, want %q", cfg.Email.AdminEmail, want)
+	}
+}
+
+func TestAdminEmailRequiredWhenEmailEnabled(t *testing.T) {
+	setBaseEnv(t)
+	t.Setenv("EMAIL_ENABLED", "true")
+	t.Setenv("ADMIN_EMAIL", "")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ADMIN_EMAIL") {
+		t.Fatalf("Load = %v, want ADMIN_EMAIL to be required", err)
+	}
+
+	t.Setenv("ADMIN_EMAIL", "ops@springstreet.in, not an address")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "not an address") {
+		t.Fatalf("Load = %v, want the invalid address reported", err)
+	}
+}
+
+// setDeployableEnv sets a configuration that passes validation in every APP_ENV, with
+// email and SMS off so each case can switch on just what it tests
+func setDeployableEnv(t *testing.T, env string) {
+	t.Helper()
+	setBaseEnv(t)
+	t.Setenv("APP_ENV", env)
+	t.Setenv("DEBUG", "false")
+	t.Setenv("ALLOWED_HOSTS", "https://springstreet.in")
+	t.Setenv("DATABASE_URL", "postgres://springstreet:secret@db:5432/springstreet")
+	t.Setenv("EMAIL_ENABLED", "false")
+	t.Setenv("SMS_ENABLED", "false")
+}
+
+func TestLoadEnvironmentValidationMatrix(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string // Error expected in production; development and staging accept the config
+	}{
+		{"baseline", nil, ""},
+		{"debug", map[string]string{"DEBUG": "true"}, "DEBUG must be false"},
+		{"wildcard CORS", map[string]string{"ALLOWED_HOSTS": "*"}, "ALLOWED_HOSTS must list origins"},
+		{"default secret key", map[string]string{"SECRET_KEY": ""}, "SECRET_KEY must be changed"},
+		{"sqlite", map[string]string{"DATABASE_URL": "sqlite:///./spring_street.db"}, "DATABASE_URL must be PostgreSQL"},
+		{"sqlite with override", map[string]string{"DATABASE_URL": "sqlite:///./spring_street.db", "ALLOW_SQLITE_IN_PRODUCTION": "true"}, ""},
+		{"console email", map[string]string{"EMAIL_ENABLED": "true", "ADMIN_EMAIL": "ops@springstreet.in", "EMAIL_PROVIDER": "console"}, "EMAIL_PROVIDER must not be console"},
+		{"smtp", map[string]string{"EMAIL_ENABLED": "true", "ADMIN_EMAIL": "ops@springstreet.in", "EMAIL_PROVIDER": "smtp", "SMTP_HOST": "smtp.example.com"}, ""},
+		{"console SMS", map[string]string{"SMS_ENABLED": "true", "SMS_PROVIDER": "console"}, "SMS_PROVIDER must not be console"},
+		{"twilio without credentials", map[string]string{"SMS_ENABLED": "true", "SMS_PROVIDER": "twilio"}, "TWILIO_ACCOUNT_SID"},
+		{"msg91 without credentials", map[string]string{"SMS_ENABLED": "true", "SMS_PROVIDER": "msg91"}, "MSG91_AUTH_KEY"},
+		{"twilio", map[string]string{"SMS_ENABLED": "true", "SMS_PROVIDER": "twilio", "TWILIO_ACCOUNT_SID": "AC123", "TWILIO_AUTH_TOKEN": "token", "TWILIO_PHONE_NUMBER": "+15005550006"}, ""},
+	} {
+		for _, env := range []string{EnvDevelopment, EnvStaging, EnvProduction} {
+			t.Run(tc.name+"/"+env, func(t *testing.T) {
+				setDeployableEnv(t, env)
+				for key, value := range tc.env {
+					t.Setenv(key, value)
+				}
+
+				_, err := Load()
+				want := ""
+				if env == EnvProduction {
+					want = tc.want
+				}
+				switch {
+				case want == "" && err != nil:
+					t.Fatalf("Load: %v", err)
+				case want != "" && (err == nil || !strings.Contains(err.Error(), want)):
+					t.Fatalf("Load = %v, want an error containing %q", err, want)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadAppEnv(t *testing.T) {
+	setDeployableEnv(t, "Production")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.App.IsProduction() || cfg.App.IsDevelopment() {
+		t.Fatalf("APP_ENV=Production gives Env %q, want production", cfg.App.Env)
+	}
+
+	t.Setenv("APP_ENV", "")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.App.IsDevelopment() {
+		t.Fatalf("without APP_ENV, Env = %q, want development", cfg.App.Env)
+	}
+
+	t.Setenv("APP_ENV", "prod")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "APP_ENV must be") {
+		t.Fatalf("Load = %v, want an unknown APP_ENV rejected", err)
+	}
+}
+
+func TestSpamKeywords(t *testing.T) {
+	setBaseEnv(t)
+	t.Setenv("CONTACT_SPAM_KEYWORDS", "casino,backlinks")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{"casino", "backlinks"}; !slices.Equal(cfg.Contact.SpamKeywords, want) {
+		t.Fatalf("with CONTACT_SPAM_KEYWORDS, SpamKeywords = %q, want %q", cfg.Contact.SpamKeywords, want)
+	}
+
+	t.Setenv("SPAM_KEYWORDS", "crypto giveaway")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{"crypto giveaway"}; !slices.Equal(cfg.Contact.SpamKeywords, want) {
+		t.Fatalf("with SPAM_KEYWORDS, SpamKeywords = %q, want %q", cfg.Contact.SpamKeywords, want)
+	}
+}
+
+func TestLoadMetricsConfig(t *testing.T) {
+	setBaseEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Metrics.HTTPLatencyBuckets) == 0 || len(cfg.Metrics.DBLatencyBuckets) == 0 || cfg.Metrics.MaxPathLabels != 200 {
+		t.Fatalf("defaults = %+v, want both bucket lists and 200 path labels", cfg.Metrics)
+	}
+
+	// The YAML file, then the environment and *_FILE variables, override the defaults
+	t.Setenv("CONFIG_FILE", writeSecretFile(t, "config.yaml", "metrics:\n  db_latency_buckets: [0.5, 1]\n  max_path_labels: 50\n"))
+	t.Setenv("HTTP_LATENCY_BUCKETS", "0.01, 0.1,1")
+	t.Setenv("METRICS_MAX_PATH_LABELS_FILE", writeSecretFile(t, "max_path_labels", "75\n"))
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []float64{0.01, 0.1, 1}; !slices.Equal(cfg.Metrics.HTTPLatencyBuckets, want) {
+		t.Errorf("HTTPLatencyBuckets = %v, want %v", cfg.Metrics.HTTPLatencyBuckets, want)
+	}
+	if want := []float64{0.5, 1}; !slices.Equal(cfg.Metrics.DBLatencyBuckets, want) {
+		t.Errorf("DBLatencyBuckets = %v, want %v from the YAML file", cfg.Metrics.DBLatencyBuckets, want)
+	}
+	if cfg.Metrics.MaxPathLabels != 75 {
+		t.Errorf("MaxPathLabels = %d, want 75 from the file", cfg.Metrics.MaxPathLabels)
+	}
+}
+
+func TestLoadRejectsInvalidMetricsConfig(t *testing.T) {
+	for _, tc := range []struct {
+		key, value, want string
+	}{
+		{"HTTP_LATENCY_BUCKETS", "-0.1,0.5", "must be positive"},
+		{"HTTP_LATENCY_BUCKETS", "0,0.5", "must be positive"},
+		{"DB_LATENCY_BUCKETS", "0.5,0.1,1", "increasing order"},
+		{"DB_LATENCY_BUCKETS", "0.1,0.1", "increasing order"},
+		{"HTTP_LATENCY_BUCKETS", "0.1,,0.5", "is not a number"},
+		{"DB_LATENCY_BUCKETS", "0.1,fast", "is not a number"},
+		{"METRICS_MAX_PATH_LABELS", "0", "METRICS_MAX_PATH_LABELS must be at least 1"},
+	} {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			setBaseEnv(t)
+			t.Setenv(tc.key, tc.value)
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tc.key) || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Load = %v, want an error naming %s and containing %q", err, tc.key, tc.want)
+			}
+		})
+	}
+
+	t.Run("empty YAML list", func(t *testing.T) {
+		setBaseEnv(t)
+		t.Setenv("CONFIG_FILE", writeSecretFile(t, "config.yaml", "metrics:\n  http_latency_buckets: []\n"))
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "at least one bucket") {
+			t.Fatalf("Load = %v, want the empty bucket list rejected", err)
+		}
+	})
+}
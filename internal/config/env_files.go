@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// envFileSuffix marks a variable naming a file that holds the value of the variable without
+// the suffix, e.g. SECRET_KEY_FILE=/run/secrets/secret_key for secrets mounted as files by
+// Docker or Kubernetes
+const envFileSuffix = "_FILE"
+
+// processEnv holds the names of the variables set before Load first read .env
+var (
+	processEnv     map[string]bool
+	processEnvOnce sync.Once
+)
+
+// recordProcessEnv remembers, once per process, which variables were set before .env was loaded
+func recordProcessEnv() {
+	processEnvOnce.Do(func() {
+		processEnv = make(map[string]bool)
+		for _, entry := range os.Environ() {
+			name, _, _ := strings.Cut(entry, "=")
+			processEnv[name] = true
+		}
+	})
+}
+
+// envFileErr collects the *_FILE variables lookupEnv failed to read, for Load to report
+var (
+	envFileErr   error
+	envFileErrMu sync.Mutex
+)
+
+// takeEnvFileErrors returns and clears the errors lookupEnv recorded
+func takeEnvFileErrors() error {
+	envFileErrMu.Lock()
+	defer envFileErrMu.Unlock()
+	err := envFileErr
+	envFileErr = nil
+	return err
+}
+
+// lookupEnv returns the value of the environment variable key. The precedence is:
+//  1. key itself, when set and not empty
+//  2. the trimmed contents of the file named by key_FILE
+//
+// and otherwise "", leaving callers to fall back to their default. Only the keys the
+// configuration asks for are resolved, so unrelated variables such as SSL_CERT_FILE are
+// never read. The file is read on every lookup, so rotated files are picked up on reload;
+// a file that can't be read is recorded for Load to fail with.
+func lookupEnv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	name := key + envFileSuffix
+	path := os.Getenv(name)
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		envFileErrMu.Lock()
+		envFileErr = errors.Join(envFileErr, fmt.Errorf("failed to read %s: %w", name, err))
+		envFileErrMu.Unlock()
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
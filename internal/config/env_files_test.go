@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSecretFile writes contents to a file in the test's temporary directory and returns its path
+func writeSecretFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadReadsFileVariables(t *testing.T) {
+	t.Setenv("SECRET_KEY", "")
+	t.Setenv("SECRET_KEY_FILE", writeSecretFile(t, "secret_key", "file-secret-key-that-is-long-enough-0123456789\n"))
+	t.Setenv("SMTP_PASSWORD_FILE", writeSecretFile(t, "smtp_password", "  smtp-from-file \n"))
+	t.Setenv("SMTP_PORT_FILE", writeSecretFile(t, "smtp_port", "2525\n"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Auth.SecretKey != "file-secret-key-that-is-long-enough-0123456789" {
+		t.Errorf("SecretKey = %q, want the trimmed file contents", cfg.Auth.SecretKey)
+	}
+	if cfg.Email.Password != "smtp-from-file" {
+		t.Errorf("Email.Password = %q, want the trimmed file contents", cfg.Email.Password)
+	}
+	if cfg.Email.SMTPPort != 2525 {
+		t.Errorf("SMTPPort = %d, want 2525 from the file", cfg.Email.SMTPPort)
+	}
+}
+
+func TestLoadFileVariablePrecedence(t *testing.T) {
+	setBaseEnv(t)
+	t.Setenv("TWILIO_AUTH_TOKEN_FILE", writeSecretFile(t, "twilio_auth_token", "token-from-file"))
+
+	// The variable itself beats the file
+	t.Setenv("TWILIO_AUTH_TOKEN", "token-from-env")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SMS.TwilioAuth != "token-from-env" {
+		t.Errorf("with both set, TwilioAuth = %q, want the environment variable", cfg.SMS.TwilioAuth)
+	}
+
+	// The file beats the default
+	t.Setenv("TWILIO_AUTH_TOKEN", "")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SMS.TwilioAuth != "token-from-file" {
+		t.Errorf("with only the file, TwilioAuth = %q, want the file contents", cfg.SMS.TwilioAuth)
+	}
+}
+
+func TestLoadRereadsFileVariables(t *testing.T) {
+	setBaseEnv(t)
+	path := writeSecretFile(t, "smtp_password", "first")
+	t.Setenv("SMTP_PASSWORD_FILE", path)
+
+	if cfg, err := Load(); err != nil || cfg.Email.Password != "first" {
+		t.Fatalf("Load = %v, want the first password", err)
+	}
+	if err := os.WriteFile(path, []byte("rotated"), 0o600); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Email.Password != "rotated" {
+		t.Fatalf("Email.Password = %q after the file changed, want the rotated value", cfg.Email.Password)
+	}
+}
+
+func TestLoadFailsOnUnreadableFileVariable(t *testing.T) {
+	setBaseEnv(t)
+	t.Setenv("DATABASE_URL_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "DATABASE_URL_FILE") {
+		t.Fatalf("Load = %v, want an error naming DATABASE_URL_FILE", err)
+	}
+}
+
+func TestLoadIgnoresUnrelatedFileVariables(t *testing.T) {
+	setBaseEnv(t)
+	// Variables the configuration doesn't ask for are not read, even if they point nowhere
+	missing := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("SSL_CERT_FILE", missing)
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", missing)
+	t.Setenv("CONFIG_FILE", writeSecretFile(t, "config.yaml", "app:\n  name: test\n"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.App.Name != "test" {
+		t.Fatalf("App.Name = %q, want the name from the YAML file", cfg.App.Name)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Feature flag names accepted by FeatureFlag
+const (
+	FeatureEmail          = "email"
+	FeatureSMS            = "sms"
+	FeatureWhatsApp       = "whatsapp"
+	FeatureWebhooks       = "webhooks"
+	FeatureReengagement   = "reengagement"
+	FeatureMagicLinks     = "magic_links"
+	FeatureOTPPersistent  = "otp_persistent"
+	FeaturePasswordPolicy = "password_policy"
+	FeatureIdempotency    = "idempotency_keys"
+	FeatureDisposableMail = "block_disposable_emails"
+	FeatureHubSpot        = "hubspot"
+	FeatureCaptcha        = "captcha"
+)
+
+// FeatureFlags holds toggles for optional capabilities
+type FeatureFlags struct {
+	EmailEnabled          bool `yaml:"email"`
+	SMSEnabled            bool `yaml:"sms"`
+	WhatsAppEnabled       bool `yaml:"whatsapp"`
+	WebhooksEnabled       bool `yaml:"webhooks"`
+	ReengagementEnabled   bool `yaml:"reengagement"`
+	MagicLinksEnabled     bool `yaml:"magic_links"`
+	OTPPersistent         bool `yaml:"otp_persistent"`
+	PasswordPolicy        bool `yaml:"password_policy"`         // Enforce ValidatePasswordStrength when passwords are set
+	IdempotencyKeys       bool `yaml:"idempotency_keys"`        // Honor Idempotency-Key headers on HTTPConfig.IdempotentPaths
+	BlockDisposableEmails bool `yaml:"block_disposable_emails"` // Reject disposable email addresses on public forms
+	HubSpotEnabled        bool `yaml:"hubspot"`                 // Push verified investment inquiries to HubSpot as contacts
+	CaptchaEnabled        bool `yaml:"captcha"`                 // Require a valid CAPTCHA token on the contact and investment forms
+}
+
+// All returns every flag keyed by name
+func (f FeatureFlags) All() map[string]bool {
+	return map[string]bool{
+		FeatureEmail:          f.EmailEnabled,
+		FeatureSMS:            f.SMSEnabled,
+		FeatureWhatsApp:       f.WhatsAppEnabled,
+		FeatureWebhooks:       f.WebhooksEnabled,
+		FeatureReengagement:   f.ReengagementEnabled,
+		FeatureMagicLinks:     f.MagicLinksEnabled,
+		FeatureOTPPersistent:  f.OTPPersistent,
+		FeaturePasswordPolicy: f.PasswordPolicy,
+		FeatureIdempotency:    f.IdempotencyKeys,
+		FeatureDisposableMail: f.BlockDisposableEmails,
+		FeatureHubSpot:        f.HubSpotEnabled,
+		FeatureCaptcha:        f.CaptchaEnabled,
+	}
+}
+
+// FeatureNames returns all known flag names in sorted order
+func FeatureNames() []string {
+	names := make([]string, 0, len(FeatureFlags{}.All()))
+	for name := range (FeatureFlags{}).All() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FeatureFlag reports whether the named feature is enabled.
+// A FEATURE_<NAME>=true|false environment variable overrides the configured value,
+// which makes toggling easy in Kubernetes without touching other settings.
+func FeatureFlag(name string) bool {
+	name = strings.ToLower(name)
+	if value := os.Getenv("FEATURE_" + strings.ToUpper(name)); value != "" {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			return enabled
+		}
+	}
+	return Get().Features.All()[name]
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// ErrSecretNotFound is returned when a provider has no value for the requested key
+var ErrSecretNotFound = errors.New("secret not found")
+
+const (
+	defaultSecretCacheTTL = 5 * time.Minute
+	secretRequestTimeout  = 10 * time.Second
+)
+
+// SecretProvider resolves sensitive configuration values by key
+type SecretProvider interface {
+	Get(key string) (string, error)
+}
+
+// secretDocument is a remote secret holding every key at once, as AWS Secrets Manager and
+// Vault store them
+type secretDocument interface {
+	// Fetch returns all of the secret's string values
+	Fetch() (map[string]string, error)
+}
+
+// lookupSecret fetches doc and returns the value stored under key
+func lookupSecret(doc secretDocument, key string) (string, error) {
+	values, err := doc.Fetch()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+var (
+	secretProvider     SecretProvider
+	secretProviderOnce sync.Once
+	secretProviderErr  error
+)
+
+// initSecretProvider selects the provider from SECRETS_PROVIDER (env, aws-secretsmanager,
+// vault) once per process. SECRET_PROVIDER is the older name and is still read.
+func initSecretProvider() error {
+	secretProviderOnce.Do(func() {
+		provider, err := newSecretProvider(strings.ToLower(getEnv("SECRETS_PROVIDER", getEnv("SECRET_PROVIDER", "env"))))
+		if err != nil {
+			secretProviderErr = err
+			return
+		}
+		secretProvider = provider
+	})
+	return secretProviderErr
+}
+
+// newSecretProvider builds the named provider. Remote providers are wrapped in a TTL cache
+// and must answer once before the server starts, so a wrong address or expired credentials
+// stop startup instead of silently falling back to the environment.
+func newSecretProvider(name string) (SecretProvider, error) {
+	ttl := time.Duration(getEnvAsInt("SECRET_CACHE_TTL_SECONDS", int(defaultSecretCacheTTL/time.Second))) * time.Second
+
+	var provider secretDocument
+	switch name {
+	case "", "env":
+		return envSecretProvider{}, nil
+	case "aws-secretsmanager", "aws":
+		aws, err := newAWSSecretProvider(getEnv("AWS_SECRET_ID", ""))
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[CONFIG] Loading secrets from AWS Secrets Manager")
+		provider = aws
+	case "vault":
+		vault, err := newVaultSecretProvider(getEnv("VAULT_ADDR", ""), getEnv("VAULT_TOKEN", ""), getEnv("VAULT_SECRET_PATH", ""))
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[CONFIG] Loading secrets from Vault at %s", vault.addr)
+		provider = vault
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q (expected env, aws-secretsmanager or vault)", name)
+	}
+
+	cached := newCachedSecretProvider(provider, ttl)
+	if _, err := cached.document(); err != nil && !errors.Is(err, ErrSecretNotFound) {
+		return nil, fmt.Errorf("secrets provider %s is unreachable: %w", name, err)
+	}
+	return cached, nil
+}
+
+// getSecret resolves a sensitive value through the secret provider.
+// Values missing from the provider fall back to the environment and then to defaultValue.
+func getSecret(key, defaultValue string) string {
+	if secretProvider == nil {
+		return getEnv(key, defaultValue)
+	}
+
+	value, err := secretProvider.Get(key)
+	if err == nil && value != "" {
+		return value
+	}
+	if err != nil && !errors.Is(err, ErrSecretNotFound) {
+		log.Printf("[CONFIG] Warning: failed to load secret %s: %v", key, err)
+	}
+	return getEnv(key, defaultValue)
+}
+
+// RefreshSecrets periodically re-fetches cached secrets so rotated values are picked up.
+// onChange is called after a refresh that changed at least one value.
+// It returns immediately when the active provider does not cache.
+func RefreshSecrets(ctx context.Context, onChange func()) {
+	cached, ok := secretProvider.(*cachedSecretProvider)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(cached.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if cached.Refresh() && onChange != nil {
+				log.Println("[CONFIG] Secrets rotated, reloading configuration")
+				onChange()
+			}
+		}
+	}
+}
+
+// envSecretProvider reads secrets from environment variables (the default)
+type envSecretProvider struct{}
+
+// Get returns the environment variable named key, or the contents of the file named by key_FILE
+func (envSecretProvider) Get(key string) (string, error) {
+	if value := lookupEnv(key); value != "" {
+		return value, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+// awsSecretProvider reads keys from a JSON secret in AWS Secrets Manager
+type awsSecretProvider struct {
+	client   *secretsmanager.Client
+	secretID string
+}
+
+// newAWSSecretProvider creates a provider using the default AWS credential chain
+func newAWSSecretProvider(secretID string) (*awsSecretProvider, error) {
+	if secretID == "" {
+		return nil, fmt.Errorf("AWS_SECRET_ID must be set when SECRETS_PROVIDER=aws-secretsmanager")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &awsSecretProvider{
+		client:   secretsmanager.NewFromConfig(awsCfg),
+		secretID: secretID,
+	}, nil
+}
+
+// Get fetches the secret and returns the value stored under key
+func (p *awsSecretProvider) Get(key string) (string, error) {
+	return lookupSecret(p, key)
+}
+
+// Fetch returns the secret's key/value pairs
+func (p *awsSecretProvider) Fetch() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretRequestTimeout)
+	defer cancel()
+
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: &p.secretID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", p.secretID, err)
+	}
+	if out.SecretString == nil {
+		return nil, ErrSecretNotFound
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal([]byte(*out.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object of strings: %w", p.secretID, err)
+	}
+	return values, nil
+}
+
+// vaultSecretProvider reads keys from a HashiCorp Vault KV secret over the HTTP API
+type vaultSecretProvider struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+}
+
+// newVaultSecretProvider creates a provider for the KV secret at path (e.g. "secret/data/springstreet")
+func newVaultSecretProvider(addr, token, path string) (*vaultSecretProvider, error) {
+	if addr == "" || token == "" || path == "" {
+		return nil, fmt.Errorf("VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH must be set when SECRETS_PROVIDER=vault")
+	}
+
+	return &vaultSecretProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: secretRequestTimeout},
+	}, nil
+}
+
+// Get reads the secret and returns the value stored under key
+func (p *vaultSecretProvider) Get(key string) (string, error) {
+	return lookupSecret(p, key)
+}
+
+// Fetch reads the secret and returns its string values; other values are skipped.
+// Both KV v2 ({"data":{"data":{...}}}) and KV v1 ({"data":{...}}) responses are supported.
+func (p *vaultSecretProvider) Fetch() (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s", p.addr, p.path), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	data := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		data = nil
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode vault secret data: %w", err)
+		}
+	}
+
+	values := make(map[string]string, len(data))
+	for key, raw := range data {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			log.Printf("[CONFIG] Warning: vault value for %s is not a string, ignoring it", key)
+			continue
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// cachedSecretProvider caches a remote secret document for ttl, so loading the config fetches
+// it once rather than once per key
+type cachedSecretProvider struct {
+	source    secretDocument
+	ttl       time.Duration
+	mu        sync.Mutex
+	values    map[string]string
+	err       error
+	fetchedAt time.Time
+}
+
+// newCachedSecretProvider wraps source with a TTL cache
+func newCachedSecretProvider(source secretDocument, ttl time.Duration) *cachedSecretProvider {
+	if ttl <= 0 {
+		ttl = defaultSecretCacheTTL
+	}
+	return &cachedSecretProvider{source: source, ttl: ttl}
+}
+
+// Get returns the value for key from the cached document, fetching it when missing or stale
+func (c *cachedSecretProvider) Get(key string) (string, error) {
+	values, err := c.document()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+// document returns the cached document, fetching it when missing or stale. The lock is held
+// while fetching so concurrent lookups share one request.
+func (c *cachedSecretProvider) document() (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetchedAt.IsZero() && time.Since(c.fetchedAt) < c.ttl {
+		return c.values, c.err
+	}
+	c.fetchLocked()
+	return c.values, c.err
+}
+
+// fetchLocked re-fetches the document. While the backend is unavailable the last good
+// document keeps being served. Callers must hold mu.
+func (c *cachedSecretProvider) fetchLocked() {
+	values, err := c.source.Fetch()
+	if err != nil && !errors.Is(err, ErrSecretNotFound) && c.values != nil {
+		log.Printf("[CONFIG] Warning: secret refresh failed, using cached values: %v", err)
+		c.fetchedAt = time.Now()
+		return
+	}
+	c.values, c.err, c.fetchedAt = values, err, time.Now()
+}
+
+// Refresh re-fetches the document and reports whether any value changed
+func (c *cachedSecretProvider) Refresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.values
+	c.fetchLocked()
+	return !maps.Equal(previous, c.values)
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSecretDocument is an in-memory secretDocument that counts fetches
+type fakeSecretDocument struct {
+	values  map[string]string
+	err     error
+	fetches int
+}
+
+func (f *fakeSecretDocument) Fetch() (map[string]string, error) {
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	values := make(map[string]string, len(f.values))
+	for key, value := range f.values {
+		values[key] = value
+	}
+	return values, nil
+}
+
+func TestCachedSecretProviderFetchesDocumentOnce(t *testing.T) {
+	doc := &fakeSecretDocument{values: map[string]string{"SECRET_KEY": "key", "SMTP_PASSWORD": "smtp"}}
+	cached := newCachedSecretProvider(doc, time.Minute)
+
+	for _, key := range []string{"SECRET_KEY", "SMTP_PASSWORD", "TWILIO_AUTH_TOKEN", "SECRET_KEY"} {
+		_, _ = cached.Get(key)
+	}
+	if doc.fetches != 1 {
+		t.Fatalf("fetches = %d, want 1", doc.fetches)
+	}
+
+	if value, err := cached.Get("SMTP_PASSWORD"); err != nil || value != "smtp" {
+		t.Fatalf("Get(SMTP_PASSWORD) = %q, %v", value, err)
+	}
+	if _, err := cached.Get("TWILIO_AUTH_TOKEN"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("Get(TWILIO_AUTH_TOKEN) error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestCachedSecretProviderRefetchesAfterTTL(t *testing.T) {
+	doc := &fakeSecretDocument{values: map[string]string{"SECRET_KEY": "old"}}
+	cached := newCachedSecretProvider(doc, time.Minute)
+	_, _ = cached.Get("SECRET_KEY")
+
+	doc.values["SECRET_KEY"] = "new"
+	cached.fetchedAt = time.Now().Add(-2 * time.Minute)
+
+	if value, _ := cached.Get("SECRET_KEY"); value != "new" {
+		t.Fatalf("Get(SECRET_KEY) = %q after TTL, want new", value)
+	}
+	if doc.fetches != 2 {
+		t.Fatalf("fetches = %d, want 2", doc.fetches)
+	}
+}
+
+func TestCachedSecretProviderKeepsValuesWhileBackendDown(t *testing.T) {
+	doc := &fakeSecretDocument{values: map[string]string{"SECRET_KEY": "key"}}
+	cached := newCachedSecretProvider(doc, time.Minute)
+	_, _ = cached.Get("SECRET_KEY")
+
+	doc.err = errors.New("connection refused")
+	if cached.Refresh() {
+		t.Fatal("Refresh reported a change while the backend was down")
+	}
+	if value, err := cached.Get("SECRET_KEY"); err != nil || value != "key" {
+		t.Fatalf("Get(SECRET_KEY) = %q, %v, want the cached value", value, err)
+	}
+}
+
+func TestCachedSecretProviderRefreshReportsRotation(t *testing.T) {
+	doc := &fakeSecretDocument{values: map[string]string{"SECRET_KEY": "key"}}
+	cached := newCachedSecretProvider(doc, time.Minute)
+	_, _ = cached.Get("SECRET_KEY")
+
+	if cached.Refresh() {
+		t.Fatal("Refresh reported a change when nothing rotated")
+	}
+	doc.values["SECRET_KEY"] = "rotated"
+	if !cached.Refresh() {
+		t.Fatal("Refresh did not report the rotated secret")
+	}
+	if value, _ := cached.Get("SECRET_KEY"); value != "rotated" {
+		t.Fatalf("Get(SECRET_KEY) = %q, want rotated", value)
+	}
+}
+
+func TestNewSecretProviderReadsVault(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"SECRET_KEY":"from-vault","PORT_NUMBER":8080}}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+	t.Setenv("VAULT_SECRET_PATH", "secret/data/springstreet")
+
+	provider, err := newSecretProvider("vault")
+	if err != nil {
+		t.Fatalf("newSecretProvider: %v", err)
+	}
+	if value, err := provider.Get("SECRET_KEY"); err != nil || value != "from-vault" {
+		t.Fatalf("Get(SECRET_KEY) = %q, %v", value, err)
+	}
+	if _, err := provider.Get("PORT_NUMBER"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("Get(PORT_NUMBER) error = %v, want ErrSecretNotFound for a non-string value", err)
+	}
+	if requests != 1 {
+		t.Fatalf("vault requests = %d, want 1", requests)
+	}
+}
+
+func TestNewSecretProviderFailsFastWhenUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+	t.Setenv("VAULT_SECRET_PATH", "secret/data/springstreet")
+
+	if _, err := newSecretProvider("vault"); err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Fatalf("newSecretProvider = %v, want an unreachable vault reported", err)
+	}
+}
+
+func TestNewSecretProviderRejectsUnknownName(t *testing.T) {
+	if _, err := newSecretProvider("keychain"); err == nil || !strings.Contains(err.Error(), "unknown SECRETS_PROVIDER") {
+		t.Fatalf("newSecretProvider = %v, want an unknown provider rejected", err)
+	}
+}
+
+func TestLoadPrefersSecretProvider(t *testing.T) {
+	original := secretProvider
+	secretProvider = newCachedSecretProvider(&fakeSecretDocument{values: map[string]string{
+		"SECRET_KEY":    "provider-secret-key-that-is-long-enough-012345",
+		"SMTP_PASSWORD": "",
+	}}, time.Minute)
+	t.Cleanup(func() { secretProvider = original })
+
+	setBaseEnv(t)
+	t.Setenv("SMTP_PASSWORD", "smtp-from-env")
+	t.Setenv("TWILIO_AUTH_TOKEN", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Auth.SecretKey != "provider-secret-key-that-is-long-enough-012345" {
+		t.Errorf("SecretKey = %q, want the provider's value over the environment", cfg.Auth.SecretKey)
+	}
+	// Keys the provider doesn't have, or has empty, fall back to the environment and then the default
+	if cfg.Email.Password != "smtp-from-env" {
+		t.Errorf("Email.Password = %q, want the environment's value", cfg.Email.Password)
+	}
+	if cfg.SMS.TwilioAuth != defaultConfig().SMS.TwilioAuth {
+		t.Errorf("SMS.TwilioAuth = %q, want the default", cfg.SMS.TwilioAuth)
+	}
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/joho/godotenv"
+
+	"springstreet/internal/metrics"
+)
+
+// ConfigWatcher reloads non-critical settings when the .env file changes
+type ConfigWatcher struct {
+	path      string
+	watcher   *fsnotify.Watcher
+	mu        sync.Mutex
+	callbacks []func(*Config)
+}
+
+// NewConfigWatcher creates a watcher for the given .env file.
+// The parent directory is watched so editors that replace the file on save are handled.
+func NewConfigWatcher(path string) (*ConfigWatcher, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", filepath.Dir(absPath), err)
+	}
+
+	return &ConfigWatcher{
+		path:    absPath,
+		watcher: watcher,
+	}, nil
+}
+
+// OnReload registers a callback invoked with the new configuration after a successful reload
+func (w *ConfigWatcher) OnReload(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Start processes file events until ctx is cancelled
+func (w *ConfigWatcher) Start(ctx context.Context) {
+	log.Printf("[CONFIG] Watching %s for changes", w.path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := w.reload(); err != nil {
+				log.Printf("[CONFIG] Reload failed: %v", err)
+				metrics.RecordConfigReload(false)
+				continue
+			}
+			metrics.RecordConfigReload(true)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[CONFIG] Watcher error: %v", err)
+		}
+	}
+}
+
+// Close stops watching the file
+func (w *ConfigWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// reload re-reads the .env file and applies non-critical settings. As at startup, variables
+// set in the process environment (by Docker or Kubernetes) beat the file.
+func (w *ConfigWatcher) reload() error {
+	values, err := godotenv.Read(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", w.path, err)
+	}
+	for key, value := range values {
+		if processEnv[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	next, err := Reload()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[CONFIG] Configuration reloaded from %s", w.path)
+
+	w.mu.Lock()
+	callbacks := append([]func(*Config){}, w.callbacks...)
+	w.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(next)
+	}
+	return nil
+}
+
+// Reload rebuilds the configuration from the environment and secret provider and
+// applies the reloadable sections to the global configuration
+func Reload() (*Config, error) {
+	loaded, err := loadFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	next := mergeReloadable(Get(), loaded)
+	setGlobal(next)
+	return next, nil
+}
+
+// ReloadSecrets is Reload after a secret rotation. Besides the reloadable sections it applies
+// the provider credentials that services can swap at runtime (SMS, WhatsApp, HubSpot) and the
+// secrets read on each request (PPROF_SECRET, INTROSPECT_SECRET).
+func ReloadSecrets() (*Config, error) {
+	loaded, err := loadFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	current := Get()
+	next := mergeReloadable(current, loaded)
+	mergeSecrets(next, current, loaded)
+	setGlobal(next)
+	return next, nil
+}
+
+// mergeSecrets copies the rotatable secrets from loaded into next. CAPTCHA, Slack and webhook
+// secrets are built into their clients at startup and require a restart.
+func mergeSecrets(next, current, loaded *Config) {
+	if loaded.Captcha != current.Captcha {
+		log.Println("[CONFIG] Warning: CAPTCHA_SECRET_KEY changed; restart required to apply")
+	}
+	if loaded.Notify.SlackWebhookURL != current.Notify.SlackWebhookURL || loaded.Notify.WebhookSecret != current.Notify.WebhookSecret {
+		log.Println("[CONFIG] Warning: SLACK_WEBHOOK_URL or WEBHOOK_SECRET changed; restart required to apply")
+	}
+
+	next.SMS = loaded.SMS
+	next.WhatsApp = loaded.WhatsApp
+	next.HubSpot = loaded.HubSpot
+	next.App.PprofSecret = loaded.App.PprofSecret
+	next.Auth.IntrospectSecret = loaded.Auth.IntrospectSecret
+}
+
+// mergeReloadable returns a copy of current with the reloadable sections taken from loaded.
+// Critical settings (SECRET_KEY, DATABASE_URL, PORT, SERVER_*) require a restart and are ignored.
+func mergeReloadable(current, loaded *Config) *Config {
+	if loaded.Auth.SecretKey != current.Auth.SecretKey {
+		log.Println("[CONFIG] Warning: SECRET_KEY changed; restart required to apply")
+	}
+	if loaded.Database.URL != current.Database.URL {
+		log.Println("[CONFIG] Warning: DATABASE_URL changed; restart required to apply")
+	}
+	if loaded.App.Port != current.App.Port {
+		log.Println("[CONFIG] Warning: PORT changed; restart required to apply")
+	}
+	if loaded.Server != current.Server {
+		log.Println("[CONFIG] Warning: SERVER_* settings changed; restart required to apply")
+	}
+	if loaded.App.Env != current.App.Env {
+		log.Println("[CONFIG] Warning: APP_ENV changed; restart required to apply")
+	}
+	if loaded.App.MultiTenant != current.App.MultiTenant {
+		log.Println("[CONFIG] Warning: MULTI_TENANT changed; restart required to apply")
+	}
+
+	next := *current
+	next.CORS = loaded.CORS
+	next.Email = loaded.Email
+	next.Features.EmailEnabled = loaded.Features.EmailEnabled
+	next.OTP.ValidityMin = loaded.OTP.ValidityMin
+	next.Auth.Enforce2FAForAdmins = loaded.Auth.Enforce2FAForAdmins
+	next.Auth.Enforce2FAForStaff = loaded.Auth.Enforce2FAForStaff
+	return &next
+}
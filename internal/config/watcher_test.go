@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestReloadKeepsProcessEnvironmentPrecedence(t *testing.T) {
+	t.Setenv("ALLOWED_HOSTS", "https://env.example.com")
+	// Unset, and unset again after the test, so only .env provides it
+	t.Setenv("OTP_EXPIRY_MINUTES", "")
+	os.Unsetenv("OTP_EXPIRY_MINUTES")
+
+	// Record the environment as it is now, as if the process had just started
+	processEnvOnce = sync.Once{}
+	t.Cleanup(func() { processEnvOnce = sync.Once{} })
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("ALLOWED_HOSTS=https://file.example.com\nOTP_EXPIRY_MINUTES=5\n"), 0o600); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	w := &ConfigWatcher{path: path}
+	if err := w.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	cfg := Get()
+	if want := []string{"https://env.example.com"}; !slices.Equal(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("AllowedOrigins = %q after reload, want the environment's %q", cfg.CORS.AllowedOrigins, want)
+	}
+	if cfg.OTP.ValidityMin != 5 {
+		t.Errorf("ValidityMin = %d after reload, want 5 from .env", cfg.OTP.ValidityMin)
+	}
+}
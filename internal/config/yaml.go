@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is not set
+const defaultConfigFile = "./config.yaml"
+
+// loadYAML overlays the YAML config file onto cfg.
+// The file path comes from CONFIG_FILE (default ./config.yaml); a missing default file is ignored.
+// It reports whether features.otp_persistent was set, since its default depends on the database.
+//
+// Example config.yaml (keys mirror the Config struct, every section is optional):
+//
+//	app:
+//	  name: Spring Street API
+//	  debug: true
+//	  port: "8000"
+//	database:
+//	  url: sqlite:///./spring_street.db
+//	auth:
+//	  token_expiry_minutes: 60
+//	cors:
+//	  allowed_origins:
+//	    - http://localhost:3000
+//	email:
+//	  provider: smtp
+//	  smtp_host: smtp.gmail.com
+//	  smtp_port: 587
+//	  from_email: noreply@springstreet.com
+//	sms:
+//	  provider: console
+//	contact:
+//	  spam_score_threshold: 50
+//	  rate_limit_per_ip: 5
+//	investment:
+//	  custom_field_schema:
+//	    referral_source: string
+//	    years_investing: integer
+//	features:
+//	  email: false
+//	  otp_persistent: false
+//
+// Secrets such as auth.secret_key or email.password are better supplied through the
+// environment or a SecretProvider than committed to this file.
+func loadYAML(cfg *Config) (bool, error) {
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !explicit {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return false, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var presence struct {
+		Features struct {
+			OTPPersistent *bool `yaml:"otp_persistent"`
+		} `yaml:"features"`
+	}
+	if err := yaml.Unmarshal(data, &presence); err != nil {
+		return false, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return presence.Features.OTPPersistent != nil, nil
+}
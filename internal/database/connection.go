@@ -29,26 +29,150 @@ const (
 	pingTimeout     = 5 * time.Second
 )
 
-// Init initializes the database connection with connection pooling
+// Init initializes the database connection with connection pooling and migrates the schema
 func Init() error {
+	if err := Connect(); err != nil {
+		return err
+	}
+	return Migrate()
+}
+
+// Connect sets up the database connection pool. It doesn't wait for the database to answer,
+// so callers can wait for it with WaitForDependency and HealthCheck before calling Migrate.
+func Connect() error {
 	cfg := config.Get()
 	var err error
-	var dialector gorm.Dialector
 
 	log.SetPrefix("[DB] ")
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
-	// Determine database type
+	db, err = open(cfg.Database)
+	return err
+}
+
+// Migrate checks the connection made by Connect, migrates the schema and connects to the
+// read replica if one is configured
+func Migrate() error {
+	cfg := config.Get()
+
+	// Test connection
+	if err := testConnection(); err != nil {
+		return fmt.Errorf("database connection test failed: %w", err)
+	}
+
+	// Auto-migrate models
+	log.Println("Running database migrations...")
+	err := db.AutoMigrate(
+		&domain.Organization{},
+		&domain.User{},
+		&domain.InvestmentInquiry{},
+		&domain.InvestmentInquiryVersion{},
+		&domain.ContactInquiry{},
+		&domain.ContactNote{},
+		&domain.OTPSession{},
+		&domain.AuditLog{},
+		&domain.GDPRErasureLog{},
+		&domain.PasswordHistory{},
+		&domain.IdempotencyKey{},
+		&domain.OTPDelivery{},
+		&domain.BackupCode{},
+		&domain.EmailLog{},
+		&domain.EmailOptOut{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := migrateDefaultOrganization(db, cfg.Database.IsPostgres()); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	// Idempotency keys were unique per caller before organizations, now per organization and caller
+	if db.Migrator().HasIndex(&domain.IdempotencyKey{}, "idx_idempotency_keys_scope") {
+		if err := db.Migrator().DropIndex(&domain.IdempotencyKey{}, "idx_idempotency_keys_scope"); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
 	if cfg.Database.IsPostgres() {
+		if err := migrateInquirySearch(db); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if err := migrateOrganizationKeys(db); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_investment_inquiries_custom_fields ON investment_inquiries USING GIN (custom_fields)").Error; err != nil {
+			return fmt.Errorf("failed to index investment inquiry custom fields: %w", err)
+		}
+	}
+
+	log.Println("Database connected and migrated successfully")
+
+	// Connect to the read replica if configured
+	if cfg.Database.ReadReplicaURL != "" {
+		initReadReplica(config.DatabaseConfig{URL: cfg.Database.ReadReplicaURL})
+	}
+	return nil
+}
+
+// migrateInquirySearch adds the full-text search column for investment inquiries and its GIN
+// index (PostgreSQL only). The column is generated, so it isn't part of the GORM model and
+// AutoMigrate leaves it alone.
+func migrateInquirySearch(db *gorm.DB) error {
+	if err := db.Exec(`ALTER TABLE investment_inquiries ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('english',
+			coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, '') || ' ' || coalesce(phone, ''))) STORED`).Error; err != nil {
+		return fmt.Errorf("failed to add investment inquiry search column: %w", err)
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_investment_inquiries_search_vector ON investment_inquiries USING GIN (search_vector)").Error; err != nil {
+		return fmt.Errorf("failed to index investment inquiry search column: %w", err)
+	}
+	return nil
+}
+
+// migrateDefaultOrganization creates the default organization, which owns every record when
+// multi-tenancy is off and all records that predate it
+func migrateDefaultOrganization(db *gorm.DB, postgres bool) error {
+	org := domain.Organization{ID: domain.DefaultOrganizationID}
+	if err := db.Where(&org).Attrs(domain.Organization{Name: "Default", Slug: "default"}).FirstOrCreate(&org).Error; err != nil {
+		return fmt.Errorf("failed to create default organization: %w", err)
+	}
+	if postgres {
+		// The default organization is inserted with an explicit ID, which doesn't advance the sequence
+		if err := db.Exec("SELECT setval(pg_get_serial_sequence('organizations', 'id'), (SELECT MAX(id) FROM organizations))").Error; err != nil {
+			return fmt.Errorf("failed to reset organization ID sequence: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrateOrganizationKeys adds the organization foreign keys (PostgreSQL only; SQLite can't add
+// constraints to existing tables). The models carry only the ID, so AutoMigrate doesn't create them.
+func migrateOrganizationKeys(db *gorm.DB) error {
+	for _, table := range []string{"users", "investment_inquiries", "contact_inquiries", "audit_logs", "email_logs"} {
+		name := "fk_" + table + "_organization"
+		if db.Migrator().HasConstraint(table, name) {
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (organization_id) REFERENCES organizations(id)", table, name)).Error; err != nil {
+			return fmt.Errorf("failed to add %s organization foreign key: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// open connects to the database described by dbCfg and configures its connection pool
+func open(dbCfg config.DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+
+	// Determine database type
+	if dbCfg.IsPostgres() {
 		log.Println("Connecting to PostgreSQL database...")
-		dsn := cfg.Database.GetPostgresDSN()
+		dsn := dbCfg.GetPostgresDSN()
 		dialector = postgres.Open(dsn)
 	} else {
 		log.Println("Connecting to SQLite database...")
-		dbPath := cfg.Database.GetSQLitePath()
+		dbPath := dbCfg.GetSQLitePath()
 		sqlDB, err := sql.Open("sqlite", dbPath)
 		if err != nil {
-			return fmt.Errorf("failed to open SQLite database: %w", err)
+			return nil, fmt.Errorf("failed to open SQLite database: %w", err)
 		}
 		dialector = sqlite.Dialector{
 			DriverName: "sqlite",
@@ -67,22 +191,27 @@ func Init() error {
 
 	gormConfig := &gorm.Config{
 		Logger: gormLogger,
+		// Connect only checks the configuration; Migrate and HealthCheck ping the database
+		DisableAutomaticPing: true,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
 	}
 
-	// Connect to database
-	db, err = gorm.Open(dialector, gormConfig)
+	conn, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := registerTimeoutCallbacks(conn); err != nil {
+		return nil, fmt.Errorf("failed to register query timeout callbacks: %w", err)
 	}
 
 	// Configure connection pool (PostgreSQL only)
-	if cfg.Database.IsPostgres() {
-		sqlDB, err := db.DB()
+	if dbCfg.IsPostgres() {
+		sqlDB, err := conn.DB()
 		if err != nil {
-			return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+			return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 		}
 
 		sqlDB.SetMaxOpenConns(maxOpenConns)
@@ -93,24 +222,7 @@ func Init() error {
 		log.Printf("Connection pool configured: maxOpen=%d, maxIdle=%d", maxOpenConns, maxIdleConns)
 	}
 
-	// Test connection
-	if err := testConnection(); err != nil {
-		return fmt.Errorf("database connection test failed: %w", err)
-	}
-
-	// Auto-migrate models
-	log.Println("Running database migrations...")
-	err = db.AutoMigrate(
-		&domain.User{},
-		&domain.InvestmentInquiry{},
-		&domain.ContactInquiry{},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
-	}
-
-	log.Println("Database connected and migrated successfully")
-	return nil
+	return conn, nil
 }
 
 // testConnection tests the database connection
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+var (
+	readDB         *gorm.DB
+	replicaHealthy atomic.Bool
+)
+
+// initReadReplica connects to the read replica. Failures are logged and reads stay on the primary.
+func initReadReplica(dbCfg config.DatabaseConfig) {
+	log.Println("Connecting to read replica...")
+	conn, err := open(dbCfg)
+	if err != nil {
+		log.Printf("Warning: read replica unavailable, using primary for reads: %v", err)
+		metrics.SetReadReplicaAvailable(false)
+		return
+	}
+
+	readDB = conn
+	checkReadReplica()
+}
+
+// GetReadDB returns the read replica when it is configured and healthy, otherwise the primary.
+// Use it only for reads; writes must always go through GetDB.
+func GetReadDB() *gorm.DB {
+	if readDB != nil && replicaHealthy.Load() {
+		return readDB
+	}
+	return GetDB()
+}
+
+// MonitorReadReplica pings the replica every interval and fails reads over to the primary while it is down
+func MonitorReadReplica(ctx context.Context, interval time.Duration) {
+	if readDB == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkReadReplica()
+		}
+	}
+}
+
+// checkReadReplica pings the replica and updates its health state
+func checkReadReplica() {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	healthy := false
+	if sqlDB, err := readDB.DB(); err == nil {
+		if err := sqlDB.PingContext(ctx); err == nil {
+			healthy = true
+		} else {
+			log.Printf("Warning: read replica ping failed: %v", err)
+		}
+	}
+
+	if previous := replicaHealthy.Swap(healthy); previous != healthy {
+		if healthy {
+			log.Println("Read replica available, routing reads to replica")
+		} else {
+			log.Println("Read replica unavailable, routing reads to primary")
+		}
+	}
+	metrics.SetReadReplicaAvailable(healthy)
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"springstreet/internal/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// openTestDB opens a SQLite database in the test's temporary directory
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	conn, err := open(config.DatabaseConfig{URL: "sqlite:///" + filepath.Join(t.TempDir(), name)})
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
+
+// replicaGauge returns the value of the db_read_replica_available gauge
+func replicaGauge(t *testing.T) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "db_read_replica_available" {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatal("db_read_replica_available is not registered")
+	return 0
+}
+
+func TestGetReadDBFailsOverToPrimary(t *testing.T) {
+	primary := openTestDB(t, "primary.db")
+	replica := openTestDB(t, "replica.db")
+	db, readDB = primary, replica
+	t.Cleanup(func() {
+		db, readDB = nil, nil
+		replicaHealthy.Store(false)
+	})
+
+	checkReadReplica()
+	if GetReadDB() != replica {
+		t.Fatal("GetReadDB did not return the healthy replica")
+	}
+	if replicaGauge(t) != 1 {
+		t.Fatal("db_read_replica_available != 1 while the replica is healthy")
+	}
+
+	// A closed connection pool fails its ping, like a replica that went away
+	sqlDB, err := replica.DB()
+	if err != nil {
+		t.Fatalf("DB: %v", err)
+	}
+	sqlDB.Close()
+
+	checkReadReplica()
+	if GetReadDB() != primary {
+		t.Fatal("GetReadDB did not fail over to the primary when the replica's ping failed")
+	}
+	if replicaGauge(t) != 0 {
+		t.Fatal("db_read_replica_available != 0 while the replica is down")
+	}
+}
+
+func TestGetReadDBWithoutReplica(t *testing.T) {
+	primary := openTestDB(t, "primary.db")
+	db, readDB = primary, nil
+	t.Cleanup(func() { db = nil })
+
+	if GetReadDB() != primary {
+		t.Fatal("GetReadDB did not return the primary when no replica is configured")
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"time"
+
+	"springstreet/internal/metrics"
+)
+
+// ReportMetrics samples the connection pool and the goroutine count every interval until ctx
+// is done. A goroutine count that grew by more than leakThreshold since the previous sample
+// is logged as a possible leak.
+func ReportMetrics(ctx context.Context, interval time.Duration, leakThreshold int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := runtime.NumGoroutine()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stats, err := GetStats(); err == nil {
+				metrics.UpdateDBConnections(stats.InUse, stats.Idle)
+			}
+
+			count := runtime.NumGoroutine()
+			metrics.SetGoroutines(count)
+			if count-previous > leakThreshold {
+				log.Printf("Warning: goroutines grew from %d to %d in %s, possible goroutine leak (see /debug/goroutines)", previous, count, interval)
+			}
+			previous = count
+		}
+	}
+}
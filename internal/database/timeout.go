@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"springstreet/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+// WithTimeout returns ctx with a deadline of timeout from now, for use with db.WithContext.
+// A non-positive timeout returns ctx unchanged. The deadline's resources are released
+// when it expires or when ctx (normally the request context) is done.
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	_ = cancel // released by the deadline or the parent context
+	return ctx
+}
+
+// registerTimeoutCallbacks counts queries that fail because their context deadline passed
+func registerTimeoutCallbacks(conn *gorm.DB) error {
+	callbacks := conn.Callback()
+	processors := map[string]interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}{
+		"create": callbacks.Create().After("gorm:create"),
+		"query":  callbacks.Query().After("gorm:query"),
+		"update": callbacks.Update().After("gorm:update"),
+		"delete": callbacks.Delete().After("gorm:delete"),
+		"row":    callbacks.Row().After("gorm:row"),
+		"raw":    callbacks.Raw().After("gorm:raw"),
+	}
+
+	for operation, processor := range processors {
+		operation := operation
+		if err := processor.Register("metrics:query_timeout", func(db *gorm.DB) {
+			if db.Statement.Error == nil {
+				return
+			}
+			// Some drivers report an interrupted query instead of the context error
+			if errors.Is(db.Statement.Error, context.DeadlineExceeded) || errors.Is(db.Statement.Context.Err(), context.DeadlineExceeded) {
+				metrics.RecordDBQueryTimeout(operation)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryTimeouts returns the value of db_query_timeout_total for operation
+func queryTimeouts(t *testing.T, operation string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "db_query_timeout_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "operation" && label.GetValue() == operation {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestSlowQueryCountsAsTimeout(t *testing.T) {
+	conn := openTestDB(t, "slow.db")
+	before := queryTimeouts(t, "raw")
+
+	// Counting to a billion takes far longer than the deadline
+	ctx := WithTimeout(context.Background(), 50*time.Millisecond)
+	err := conn.WithContext(ctx).Exec(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1000000000) SELECT count(*) FROM n`).Error
+	if err == nil {
+		t.Fatal("slow query finished before its deadline")
+	}
+
+	if got := queryTimeouts(t, "raw"); got != before+1 {
+		t.Fatalf("db_query_timeout_total{operation=raw} = %v, want %v", got, before+1)
+	}
+}
+
+func TestFastQueryIsNotATimeout(t *testing.T) {
+	conn := openTestDB(t, "fast.db")
+	before := queryTimeouts(t, "raw")
+
+	ctx := WithTimeout(context.Background(), time.Minute)
+	if err := conn.WithContext(ctx).Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if got := queryTimeouts(t, "raw"); got != before {
+		t.Fatalf("db_query_timeout_total{operation=raw} = %v, want %v", got, before)
+	}
+}
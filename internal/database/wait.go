@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WaitForDependency calls check every retryInterval until it succeeds, for dependencies such
+// as the database that may still be starting when the server does (e.g. under Docker Compose).
+// It gives up once maxWait has passed or ctx is done, returning check's last error.
+func WaitForDependency(ctx context.Context, name string, check func() error, retryInterval, maxWait time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		err := check()
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("%s is ready after %d attempts", name, attempt)
+			}
+			return nil
+		}
+		log.Printf("Waiting for %s (attempt %d, retrying in %v): %v", name, attempt, retryInterval, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not ready after %v: %w", name, maxWait, err)
+		case <-time.After(retryInterval):
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// Audit log resource types
+const (
+	AuditResourceUser              = "user"
+	AuditResourceInvestmentInquiry = "investment_inquiry"
+	AuditResourceContactInquiry    = "contact_inquiry"
+)
+
+// AuditLog records a change made by a staff user
+type AuditLog struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;default:1;index" json:"organization_id"`
+	ActorUserID    *uint     `gorm:"index:idx_audit_logs_actor_created,priority:1" json:"actor_user_id"` // Nil for system actions
+	Actor          string    `gorm:"not null" json:"actor"`                                              // Username at the time of the action
+	Action         string    `gorm:"not null;index" json:"action"`
+	ResourceType   string    `gorm:"not null;index:idx_audit_logs_resource,priority:1" json:"resource_type"` // user, investment_inquiry, contact_inquiry
+	ResourceID     uint      `gorm:"index:idx_audit_logs_resource,priority:2" json:"resource_id"`
+	Details        *string   `gorm:"type:text" json:"details"`
+	CreatedAt      time.Time `gorm:"index:idx_audit_logs_actor_created,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for AuditLog
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// BeforeCreate hook
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	return nil
+}
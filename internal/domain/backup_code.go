@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// BackupCode is a single-use account recovery code. Only its bcrypt hash is stored;
+// the plaintext is shown to the user once, when the codes are generated.
+type BackupCode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	CodeHash  string    `gorm:"not null" json:"-"`
+	Used      bool      `gorm:"not null;default:false" json:"used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for BackupCode
+func (BackupCode) TableName() string {
+	return "backup_codes"
+}
+
+// BeforeCreate hook
+func (c *BackupCode) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now()
+	return nil
+}
@@ -5,16 +5,56 @@ import (
 	"gorm.io/gorm"
 )
 
+// ContactCategoryGeneral is the category used when none is given
+const ContactCategoryGeneral = "general"
+
+// ContactCategories lists the accepted contact inquiry categories
+var ContactCategories = []string{ContactCategoryGeneral, "support", "partnership", "press", "complaint"}
+
+// Contact inquiry statuses
+const (
+	ContactStatusNew     = "new"
+	ContactStatusRead    = "read"
+	ContactStatusReplied = "replied"
+)
+
+// contactStatusTransitions lists the statuses each status may move to
+var contactStatusTransitions = map[string][]string{
+	ContactStatusNew:     {ContactStatusRead, ContactStatusReplied},
+	ContactStatusRead:    {ContactStatusNew, ContactStatusReplied},
+	ContactStatusReplied: {ContactStatusRead},
+}
+
+// CanTransitionContactStatus reports whether an inquiry may move from one status to another
+func CanTransitionContactStatus(from, to string) bool {
+	for _, next := range contactStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 // ContactInquiry represents a contact form submission
 type ContactInquiry struct {
-	ID        uint       `gorm:"primaryKey" json:"id"`
-	Name      string     `gorm:"not null" json:"name"`
-	Email     string     `gorm:"not null;index" json:"email"`
-	Phone     *string    `json:"phone"`
-	Message   string     `gorm:"type:text;not null" json:"message"`
-	Status    string     `gorm:"default:'new'" json:"status"` // new, read, replied
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"not null" json:"name"`
+	Email       string         `gorm:"not null;index" json:"email"`
+	Phone       *string        `json:"phone"`
+	Subject     *string        `json:"subject"`
+	Category    string         `gorm:"default:'general';index" json:"category"` // general, support, partnership, press, complaint
+	Message     string         `gorm:"type:text;not null" json:"message"`
+	MessageHash string         `gorm:"size:64;index" json:"-"`      // SHA-256 of the trimmed message, for duplicate detection
+	Status      string         `gorm:"default:'new'" json:"status"` // new, read, replied
+	IsSpam      bool           `gorm:"default:false;index" json:"is_spam"`
+	SpamScore   int            `gorm:"default:0" json:"spam_score"`
+	FirstReadAt *time.Time     `gorm:"index" json:"first_read_at"` // When a staff member first opened the inquiry
+	FirstReadBy *uint          `json:"first_read_by"`              // User ID of that staff member
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   *time.Time     `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete; purged after a retention period
+
+	OrganizationID uint `gorm:"not null;default:1;index" json:"organization_id"`
 }
 
 // TableName specifies the table name for ContactInquiry
@@ -28,6 +68,9 @@ func (c *ContactInquiry) BeforeCreate(tx *gorm.DB) error {
 	if c.Status == "" {
 		c.Status = "new"
 	}
+	if c.Category == "" {
+		c.Category = ContactCategoryGeneral
+	}
 	return nil
 }
 
@@ -37,7 +80,3 @@ func (c *ContactInquiry) BeforeUpdate(tx *gorm.DB) error {
 	c.UpdatedAt = &now
 	return nil
 }
-
-
-
-
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// ContactNote is an internal staff note on a contact inquiry.
+// Notes are append-only; only admins may delete them.
+type ContactNote struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	ContactInquiryID uint      `gorm:"not null;index" json:"contact_inquiry_id"`
+	Author           string    `gorm:"not null" json:"author"`
+	Text             string    `gorm:"type:text;not null" json:"text"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for ContactNote
+func (ContactNote) TableName() string {
+	return "contact_notes"
+}
+
+// BeforeCreate hook
+func (n *ContactNote) BeforeCreate(tx *gorm.DB) error {
+	n.CreatedAt = time.Now()
+	return nil
+}
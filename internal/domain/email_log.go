@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// Email log statuses
+const (
+	EmailLogSent   = "sent"   // Accepted by the provider
+	EmailLogFailed = "failed" // Given up on after retries, or rejected outright
+)
+
+// EmailLog records the outcome of one outbound email for one of its recipients. The address
+// is only kept masked and as a keyed hash, so the log can be searched by address without
+// storing it.
+type EmailLog struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;default:1;index" json:"organization_id"`
+	RecipientHash  string    `gorm:"size:64;index;not null" json:"recipient_hash"` // HMAC-SHA256 of the lowercased address
+	Recipient      string    `gorm:"not null" json:"recipient"`                    // Masked, e.g. ra***@example.com
+	Type           string    `gorm:"index;not null" json:"type"`                   // otp, contact_notification, follow_up_reminder, generic
+	Provider       string    `gorm:"not null" json:"provider"`
+	Status         string    `gorm:"index;not null" json:"status"`
+	Error          *string   `gorm:"type:text" json:"error"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for EmailLog
+func (EmailLog) TableName() string {
+	return "email_logs"
+}
+
+// BeforeCreate hook
+func (l *EmailLog) BeforeCreate(tx *gorm.DB) error {
+	l.CreatedAt = time.Now()
+	return nil
+}
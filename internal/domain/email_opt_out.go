@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// EmailOptOut is an address that unsubscribed from marketing email. Only the keyed hash of
+// the address is stored, the same hash the email log uses.
+type EmailOptOut struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	AddressHash string    `gorm:"size:64;uniqueIndex;not null" json:"address_hash"` // HMAC-SHA256 of the lowercased address
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for EmailOptOut
+func (EmailOptOut) TableName() string {
+	return "email_opt_outs"
+}
+
+// BeforeCreate hook
+func (o *EmailOptOut) BeforeCreate(tx *gorm.DB) error {
+	o.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// GDPRErasureLog records that a user's personal data was erased.
+// Entries are kept as proof of compliance and must not themselves hold personal data.
+type GDPRErasureLog struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"not null;index" json:"user_id"`
+	ErasedByUserID uint      `gorm:"not null" json:"erased_by_user_id"`
+	Details        string    `gorm:"type:text" json:"details"` // JSON counts of erased records per table
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GDPRErasureLog
+func (GDPRErasureLog) TableName() string {
+	return "gdpr_erasure_log"
+}
+
+// BeforeCreate hook
+func (g *GDPRErasureLog) BeforeCreate(tx *gorm.DB) error {
+	g.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey stores the response to a request sent with an Idempotency-Key header so that
+// retries of the same request get the original response instead of repeating it.
+// A StatusCode of 0 means the original request is still being processed.
+type IdempotencyKey struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;default:1;uniqueIndex:idx_idempotency_keys_org_scope" json:"organization_id"`
+	Key            string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_org_scope" json:"key"`
+	Path           string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_org_scope" json:"path"`
+	Caller         string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_org_scope" json:"caller"` // "user:<username>" or "ip:<address>"
+	RequestHash    string    `gorm:"size:64;not null" json:"-"`                                                  // SHA-256 of the request body
+	StatusCode     int       `gorm:"not null;default:0" json:"status_code"`
+	ContentType    string    `json:"content_type"`
+	ResponseBody   string    `gorm:"type:text" json:"response_body"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for IdempotencyKey
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// BeforeCreate hook
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	k.CreatedAt = time.Now()
+	return nil
+}
@@ -1,23 +1,130 @@
 package domain
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 	"gorm.io/gorm"
 )
 
+// Investment inquiry statuses (staff workflow, independent of the form's exit type)
+const (
+	InvestmentStatusNew          = "new"
+	InvestmentStatusContacted    = "contacted"
+	InvestmentStatusQualified    = "qualified"
+	InvestmentStatusDisqualified = "disqualified"
+	InvestmentStatusConverted    = "converted"
+)
+
+// InvestmentStatuses lists the accepted investment inquiry statuses
+var InvestmentStatuses = []string{InvestmentStatusNew, InvestmentStatusContacted, InvestmentStatusQualified, InvestmentStatusDisqualified, InvestmentStatusConverted}
+
+// investmentStatusTransitions lists the statuses each status may move to
+var investmentStatusTransitions = map[string][]string{
+	InvestmentStatusNew:          {InvestmentStatusContacted, InvestmentStatusQualified, InvestmentStatusDisqualified},
+	InvestmentStatusContacted:    {InvestmentStatusQualified, InvestmentStatusDisqualified},
+	InvestmentStatusQualified:    {InvestmentStatusConverted, InvestmentStatusDisqualified},
+	InvestmentStatusDisqualified: {InvestmentStatusNew},
+}
+
+// CanTransitionInvestmentStatus reports whether an inquiry may move from one status to another
+func CanTransitionInvestmentStatus(from, to string) bool {
+	for _, next := range investmentStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InvestmentStatusesMovingTo lists the statuses an inquiry may move to status from
+func InvestmentStatusesMovingTo(status string) []string {
+	var from []string
+	for _, candidate := range InvestmentStatuses {
+		if CanTransitionInvestmentStatus(candidate, status) {
+			from = append(from, candidate)
+		}
+	}
+	return from
+}
+
+// Lead sources, the marketing channel an inquiry came from
+const (
+	LeadSourceOrganic       = "organic"
+	LeadSourcePaidSearch    = "paid_search"
+	LeadSourceSocial        = "social"
+	LeadSourceReferral      = "referral"
+	LeadSourceEmailCampaign = "email_campaign"
+	LeadSourceDirect        = "direct"
+	LeadSourceUnknown       = "unknown"
+)
+
 // InvestmentInquiry represents an investment inquiry
 type InvestmentInquiry struct {
-	ID              uint       `gorm:"primaryKey" json:"id"`
-	FirstName       *string    `json:"first_name"`
-	LastName        *string    `json:"last_name"`
-	Phone           *string    `gorm:"index" json:"phone"`
-	Email           *string    `gorm:"index" json:"email"`
-	InvestmentSize  *string    `json:"investment_size"`
-	CurrentExposure *string    `json:"current_exposure"`
-	Verified        bool       `gorm:"default:false" json:"verified"`
-	ExitType        *string    `gorm:"default:'abandoned'" json:"exit_type"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       *time.Time `json:"updated_at"`
+	ID               uint         `gorm:"primaryKey" json:"id"`
+	FirstName        *string      `json:"first_name"`
+	LastName         *string      `json:"last_name"`
+	Phone            *string      `gorm:"index" json:"phone"`
+	Email            *string      `gorm:"index" json:"email"`
+	InvestmentSize   *string      `json:"investment_size"`
+	CurrentExposure  *string      `json:"current_exposure"`
+	Verified         bool         `gorm:"default:false" json:"verified"`
+	ExitType         *string      `gorm:"default:'abandoned'" json:"exit_type"`
+	Status           string       `gorm:"default:'new';index" json:"status"`          // new, contacted, qualified, disqualified, converted
+	LeadScore        int          `gorm:"not null;default:0;index" json:"lead_score"` // 0-100, see services.ComputeLeadScore
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        *time.Time   `json:"updated_at"`
+	Version          int          `gorm:"not null;default:1" json:"version"`                         // Incremented on every update (optimistic locking)
+	HubSpotContactID *string      `gorm:"column:hubspot_contact_id;index" json:"hubspot_contact_id"` // Set once the inquiry is synced to HubSpot
+	Archived         bool         `gorm:"not null;default:false;index" json:"archived"`              // Hidden from the default list but retained
+	ArchivedAt       *time.Time   `json:"archived_at"`
+	FollowUpAt       *time.Time   `gorm:"index" json:"follow_up_at"`                         // When staff want a reminder to follow up
+	FollowUpBy       *uint        `json:"follow_up_by"`                                      // Staff user who set the reminder, and who receives it
+	FollowUpNotified bool         `gorm:"not null;default:false" json:"follow_up_notified"`  // Set once the reminder email has gone out
+	CustomFields     CustomFields `gorm:"type:jsonb" json:"custom_fields"`                   // Deployment-specific fields, see config.InvestmentConfig
+	Country          string       `gorm:"size:2;not null;default:'IN';index" json:"country"` // ISO 3166-1 alpha-2
+	State            *string      `json:"state"`
+	LeadSource       string       `gorm:"size:20;not null;default:'unknown';index" json:"lead_source"` // See the LeadSource constants
+	ReferrerURL      *string      `gorm:"size:2048" json:"referrer_url"`
+	OrganizationID   uint         `gorm:"not null;default:1;index" json:"organization_id"`
+}
+
+// DefaultInvestmentCountry is the country of inquiries that give none and whose phone
+// number doesn't tell
+const DefaultInvestmentCountry = "IN"
+
+// CustomFields holds an inquiry's custom field values by key, stored as a JSON object.
+// Values are strings, float64 numbers or booleans, as given by the custom field schema.
+type CustomFields map[string]interface{}
+
+// Value implements driver.Valuer
+func (c CustomFields) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (c *CustomFields) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into CustomFields", value)
+	}
+	return json.Unmarshal(data, c)
 }
 
 // TableName specifies the table name for InvestmentInquiry
@@ -33,6 +140,18 @@ func (i *InvestmentInquiry) BeforeCreate(tx *gorm.DB) error {
 		defaultExitType := "abandoned"
 		i.ExitType = &defaultExitType
 	}
+	if i.Status == "" {
+		i.Status = InvestmentStatusNew
+	}
+	if i.Country == "" {
+		i.Country = DefaultInvestmentCountry
+	}
+	if i.LeadSource == "" {
+		i.LeadSource = LeadSourceUnknown
+	}
+	if i.Version == 0 {
+		i.Version = 1
+	}
 	return nil
 }
 
@@ -40,7 +159,6 @@ func (i *InvestmentInquiry) BeforeCreate(tx *gorm.DB) error {
 func (i *InvestmentInquiry) BeforeUpdate(tx *gorm.DB) error {
 	now := time.Now()
 	i.UpdatedAt = &now
+	i.Version++
 	return nil
 }
-
-
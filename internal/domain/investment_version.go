@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// InvestmentInquiryVersion is the full state of an inquiry before one of its updates.
+// Versions are append-only; restoring a version records the state it replaces as a new version.
+type InvestmentInquiryVersion struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	InquiryID uint      `gorm:"not null;index" json:"inquiry_id"`
+	Snapshot  string    `gorm:"type:jsonb;not null" json:"snapshot"` // JSON of the InvestmentInquiry
+	ChangedBy *uint     `json:"changed_by"`                          // Nil for changes made through the public form
+	ChangedAt time.Time `gorm:"index" json:"changed_at"`
+}
+
+// TableName specifies the table name for InvestmentInquiryVersion
+func (InvestmentInquiryVersion) TableName() string {
+	return "investment_inquiry_versions"
+}
+
+// BeforeCreate hook
+func (v *InvestmentInquiryVersion) BeforeCreate(tx *gorm.DB) error {
+	v.ChangedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// DefaultOrganizationID is the organization every record belongs to when multi-tenancy is off,
+// and the one holding records created before it was added
+const DefaultOrganizationID uint = 1
+
+// Organization is a tenant. Users and inquiries belong to exactly one organization.
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:200;not null" json:"name"`
+	Slug      string    `gorm:"size:100;uniqueIndex;not null" json:"slug"` // Lowercase letters, digits and hyphens; sent in the X-Organization header
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for Organization
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// BeforeCreate hook
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	o.CreatedAt = time.Now()
+	return nil
+}
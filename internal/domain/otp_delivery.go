@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// OTP delivery statuses reported by the SMS provider
+const (
+	OTPDeliveryQueued      = "queued"
+	OTPDeliverySent        = "sent"
+	OTPDeliveryDelivered   = "delivered"
+	OTPDeliveryUndelivered = "undelivered"
+	OTPDeliveryFailed      = "failed"
+)
+
+// OTPDelivery tracks whether an OTP SMS reached the handset, keyed by the provider's message SID
+type OTPDelivery struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	MessageSID string    `gorm:"column:message_sid;size:64;uniqueIndex;not null" json:"message_sid"`
+	Provider   string    `gorm:"not null" json:"provider"`
+	Recipient  string    `gorm:"index;not null" json:"recipient"` // Normalized phone number, as used for OTP sessions
+	Status     string    `gorm:"index;not null" json:"status"`
+	ErrorCode  string    `json:"error_code"` // Provider error code for failed deliveries
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for OTPDelivery
+func (OTPDelivery) TableName() string {
+	return "otp_deliveries"
+}
+
+// IsFinal reports whether status is one the provider will not change again
+func (d *OTPDelivery) IsFinal() bool {
+	switch d.Status {
+	case OTPDeliveryDelivered, OTPDeliveryUndelivered, OTPDeliveryFailed:
+		return true
+	}
+	return false
+}
+
+// BeforeCreate hook
+func (d *OTPDelivery) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate hook
+func (d *OTPDelivery) BeforeUpdate(tx *gorm.DB) error {
+	d.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// OTPSession represents a persisted OTP verification session
+type OTPSession struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Identifier  string     `gorm:"uniqueIndex;not null" json:"identifier"` // Normalized primary identifier
+	Email       *string    `gorm:"index" json:"email"`
+	Phone       *string    `gorm:"index" json:"phone"`
+	OTPHash     string     `gorm:"column:otp;not null" json:"-"` // HMAC-SHA256 of the code, never the code itself
+	OTPCipher   string     `gorm:"column:otp_cipher" json:"-"`   // AES-GCM encrypted code, used only for resends
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	Verified    bool       `gorm:"default:false" json:"verified"`
+	ResendCount int        `gorm:"default:0" json:"resend_count"`
+	LastSentAt  *time.Time `json:"last_sent_at"`
+	ExpiresAt   time.Time  `gorm:"index;not null" json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for OTPSession
+func (OTPSession) TableName() string {
+	return "otp_sessions"
+}
+
+// BeforeCreate hook
+func (o *OTPSession) BeforeCreate(tx *gorm.DB) error {
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate hook
+func (o *OTPSession) BeforeUpdate(tx *gorm.DB) error {
+	o.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// PasswordHistory keeps hashes of a user's previous passwords to prevent reuse
+type PasswordHistory struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"not null;index" json:"user_id"`
+	HashedPassword string    `gorm:"not null" json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for PasswordHistory
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+// BeforeCreate hook
+func (h *PasswordHistory) BeforeCreate(tx *gorm.DB) error {
+	h.CreatedAt = time.Now()
+	return nil
+}
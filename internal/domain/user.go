@@ -15,9 +15,14 @@ type User struct {
 	IsActive       bool       `gorm:"default:true" json:"is_active"`
 	IsAdmin        bool       `gorm:"default:false" json:"is_admin"`
 	IsStaff        bool       `gorm:"default:false" json:"is_staff"`
+	Language       string     `gorm:"not null;default:en" json:"language"` // Language of emails sent to the user
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 	LastLogin      *time.Time `json:"last_login"`
+	OrganizationID uint       `gorm:"not null;default:1;index" json:"organization_id"`
+	TOTPSecret     string     `json:"-"`                                          // Base32 authenticator secret sealed with AES-GCM; set at enrollment, used once TOTPEnabled
+	TOTPEnabled    bool       `gorm:"not null;default:false" json:"totp_enabled"` // Login asks for a TOTP or backup code
+	TOTPLastStep   int64      `gorm:"not null;default:0" json:"-"`                // Time step of the last accepted code, which can't be replayed
 }
 
 // TableName specifies the table name for User
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// jsonCacheTTL is how long a JSON snapshot is served before the registry is gathered again,
+// so dashboards polling rapidly don't re-collect every metric on each request
+const jsonCacheTTL = 5 * time.Second
+
+// jsonSnapshot is the document served at /metrics/json
+type jsonSnapshot struct {
+	LastScrapedAt time.Time          `json:"last_scraped_at"`
+	Families      []jsonMetricFamily `json:"metrics"`
+}
+
+// jsonMetricFamily is one metric name and its series
+type jsonMetricFamily struct {
+	Name   string       `json:"name"`
+	Help   string       `json:"help"`
+	Type   string       `json:"type"` // counter, gauge, histogram, gaugehistogram, summary or untyped
+	Series []jsonSeries `json:"series"`
+}
+
+// jsonSeries is one labeled series. Counters, gauges and untyped metrics have a value;
+// histograms have count, sum and cumulative buckets; summaries have count, sum and quantiles.
+type jsonSeries struct {
+	Labels    map[string]string `json:"labels"`
+	Value     *jsonFloat        `json:"value,omitempty"`
+	Count     *uint64           `json:"count,omitempty"`
+	Sum       *jsonFloat        `json:"sum,omitempty"`
+	Buckets   []jsonBucket      `json:"buckets,omitempty"`
+	Quantiles []jsonQuantile    `json:"quantiles,omitempty"`
+}
+
+// jsonBucket is a histogram bucket; Count includes every lower bucket
+type jsonBucket struct {
+	UpperBound jsonFloat `json:"upper_bound"`
+	Count      uint64    `json:"count"`
+}
+
+// jsonQuantile is a summary quantile
+type jsonQuantile struct {
+	Quantile jsonFloat `json:"quantile"`
+	Value    jsonFloat `json:"value"`
+}
+
+// jsonFloat encodes NaN and infinities as the strings "NaN", "+Inf" and "-Inf", as the
+// Prometheus HTTP API does, since JSON numbers can't represent them
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+}
+
+var (
+	jsonCacheMu   sync.Mutex
+	jsonCache     []byte
+	jsonCacheTime time.Time
+)
+
+// JSON returns the metrics of the default Prometheus registry encoded as JSON, gathering them
+// at most once every jsonCacheTTL
+func JSON() ([]byte, error) {
+	jsonCacheMu.Lock()
+	defer jsonCacheMu.Unlock()
+
+	if jsonCache != nil && time.Since(jsonCacheTime) < jsonCacheTTL {
+		return jsonCache, nil
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		// Gather still returns every family it could collect
+		log.Printf("[METRICS] Warning: gathering metrics for JSON failed in part: %v", err)
+	}
+
+	now := time.Now()
+	snapshot := jsonSnapshot{LastScrapedAt: now.UTC(), Families: make([]jsonMetricFamily, 0, len(families))}
+	for _, family := range families {
+		snapshot.Families = append(snapshot.Families, convertMetricFamily(family))
+	}
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonCache, jsonCacheTime = body, now
+	return body, nil
+}
+
+// convertMetricFamily converts a gathered metric family to its JSON form
+func convertMetricFamily(family *dto.MetricFamily) jsonMetricFamily {
+	out := jsonMetricFamily{
+		Name:   family.GetName(),
+		Help:   family.GetHelp(),
+		Type:   jsonMetricType(family.GetType()),
+		Series: make([]jsonSeries, 0, len(family.GetMetric())),
+	}
+
+	for _, metric := range family.GetMetric() {
+		series := jsonSeries{Labels: make(map[string]string, len(metric.GetLabel()))}
+		for _, label := range metric.GetLabel() {
+			series.Labels[label.GetName()] = label.GetValue()
+		}
+
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			series.Value = floatPtr(metric.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			series.Value = floatPtr(metric.GetGauge().GetValue())
+		case dto.MetricType_UNTYPED:
+			series.Value = floatPtr(metric.GetUntyped().GetValue())
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			histogram := metric.GetHistogram()
+			count := histogram.GetSampleCount()
+			series.Count = &count
+			series.Sum = floatPtr(histogram.GetSampleSum())
+			for _, bucket := range histogram.GetBucket() {
+				series.Buckets = append(series.Buckets, jsonBucket{
+					UpperBound: jsonFloat(bucket.GetUpperBound()),
+					Count:      bucket.GetCumulativeCount(),
+				})
+			}
+			// The +Inf bucket is implicit in the exposition format; spell it out
+			series.Buckets = append(series.Buckets, jsonBucket{UpperBound: jsonFloat(math.Inf(1)), Count: count})
+		case dto.MetricType_SUMMARY:
+			summary := metric.GetSummary()
+			count := summary.GetSampleCount()
+			series.Count = &count
+			series.Sum = floatPtr(summary.GetSampleSum())
+			for _, quantile := range summary.GetQuantile() {
+				series.Quantiles = append(series.Quantiles, jsonQuantile{
+					Quantile: jsonFloat(quantile.GetQuantile()),
+					Value:    jsonFloat(quantile.GetValue()),
+				})
+			}
+		}
+		out.Series = append(out.Series, series)
+	}
+	return out
+}
+
+// jsonMetricType names a metric type as the Prometheus text format does
+func jsonMetricType(t dto.MetricType) string {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return "counter"
+	case dto.MetricType_GAUGE:
+		return "gauge"
+	case dto.MetricType_SUMMARY:
+		return "summary"
+	case dto.MetricType_HISTOGRAM:
+		return "histogram"
+	case dto.MetricType_GAUGE_HISTOGRAM:
+		return "gaugehistogram"
+	default:
+		return "untyped"
+	}
+}
+
+func floatPtr(v float64) *jsonFloat {
+	f := jsonFloat(v)
+	return &f
+}
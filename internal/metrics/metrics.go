@@ -1,14 +1,123 @@
 package metrics
 
 import (
+	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// Latency histograms. Their buckets are configurable, so they are created by Init.
+var (
+	httpRequestDuration *prometheus.HistogramVec
+	dbQueryDuration     *prometheus.HistogramVec
+	initOnce            sync.Once
+)
+
+// defaultMaxPathLabels is the number of distinct endpoint labels kept until Init sets the
+// configured limit
+const defaultMaxPathLabels = 200
+
+// otherPathLabel replaces the endpoint label of paths beyond the limit
+const otherPathLabel = "other"
+
+var (
+	numericSegment = regexp.MustCompile(`^\d+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// pathLabels caps the number of distinct endpoint labels, so paths the normalization
+// doesn't cover (e.g. probes for random URLs) can't create unbounded time series
+var pathLabels = &pathLabelSet{max: defaultMaxPathLabels, seen: make(map[string]bool)}
+
+// pathLabelSet records the endpoint labels in use
+type pathLabelSet struct {
+	mu     sync.Mutex
+	max    int
+	seen   map[string]bool
+	warned bool
+}
+
+// setMax sets the number of distinct labels kept
+func (s *pathLabelSet) setMax(max int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.max = max
+}
+
+// label returns path if it is already in use or there is room for it, and "other" otherwise
+func (s *pathLabelSet) label(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[path] {
+		return path
+	}
+	if len(s.seen) >= s.max {
+		if !s.warned {
+			log.Printf("[METRICS] Warning: more than %d distinct request paths, further paths are labeled %q", s.max, otherPathLabel)
+			s.warned = true
+		}
+		return otherPathLabel
+	}
+	s.seen[path] = true
+	return path
+}
+
+// NormalizePath replaces the numeric segments of path with {id} and the UUID segments with
+// {uuid}, so e.g. /api/v1/investment/123 becomes /api/v1/investment/{id}
+func NormalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case numericSegment.MatchString(segment):
+			segments[i] = "{id}"
+		case uuidSegment.MatchString(segment):
+			segments[i] = "{uuid}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// endpointLabel returns the endpoint label for a request path
+func endpointLabel(path string) string {
+	return pathLabels.label(NormalizePath(path))
+}
+
+// Init creates the latency histograms with the given bucket bounds and sets the endpoint
+// label limit, from the config.MetricsConfig that config.Load has validated (config imports
+// this package, so it can't be passed itself). A histogram can't be registered again with
+// other buckets, so only the first call has an effect. Until then latencies are not
+// recorded, which suits the command-line tools that never serve metrics.
+func Init(httpBuckets, dbBuckets []float64, maxPathLabels int) {
+	initOnce.Do(func() {
+		pathLabels.setMax(maxPathLabels)
+
+		httpRequestDuration = promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "HTTP request duration in seconds",
+				Buckets: httpBuckets,
+			},
+			[]string{"method", "endpoint", "status_code"},
+		)
+
+		dbQueryDuration = promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "db_query_duration_seconds",
+				Help:    "Database query duration in seconds",
+				Buckets: dbBuckets,
+			},
+			[]string{"operation"},
+		)
+	})
+}
+
 var (
 	// HTTP metrics
 	httpRequestsTotal = promauto.NewCounterVec(
@@ -19,15 +128,6 @@ var (
 		[]string{"method", "endpoint", "status_code"},
 	)
 
-	httpRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "endpoint", "status_code"},
-	)
-
 	httpRequestSize = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_request_size_bytes",
@@ -46,6 +146,22 @@ var (
 		[]string{"method", "endpoint"},
 	)
 
+	requestBodySizeExceededTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "request_body_size_exceeded_total",
+			Help: "Total number of requests rejected for exceeding the body size limit",
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	// Runtime metrics
+	processGoroutines = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "process_goroutines",
+			Help: "Number of goroutines, sampled every 15 seconds",
+		},
+	)
+
 	// Database metrics
 	dbConnectionsActive = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -61,6 +177,13 @@ var (
 		},
 	)
 
+	dbReadReplicaAvailable = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "db_read_replica_available",
+			Help: "Whether the read replica is reachable (1) or reads fall back to the primary (0)",
+		},
+	)
+
 	dbQueriesTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "db_queries_total",
@@ -69,13 +192,12 @@ var (
 		[]string{"operation", "status"},
 	)
 
-	dbQueryDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "db_query_duration_seconds",
-			Help:    "Database query duration in seconds",
-			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	dbQueryTimeoutsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_query_timeout_total",
+			Help: "Total number of database queries cancelled by the query timeout",
 		},
-		[]string{"operation"},
+		[]string{"operation"}, // query, create, update, delete, row, raw
 	)
 
 	// Business metrics
@@ -87,6 +209,21 @@ var (
 		[]string{"status"}, // success, failure
 	)
 
+	backupCodesUsedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "backup_codes_used_total",
+			Help: "Total number of account recovery backup codes redeemed",
+		},
+	)
+
+	mfaEnforcementBlocksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mfa_enforcement_blocks_total",
+			Help: "Total number of logins refused because the user must enroll TOTP first",
+		},
+		[]string{"role"}, // admin, staff
+	)
+
 	investmentInquiriesTotal = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Name: "investment_inquiries_total",
@@ -94,6 +231,38 @@ var (
 		},
 	)
 
+	investmentInquiriesBySource = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "investment_inquiries_by_source",
+			Help: "Total number of investment inquiries by lead source",
+		},
+		[]string{"source"}, // organic, paid_search, social, referral, email_campaign, direct, unknown
+	)
+
+	investmentLeadScore = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "investment_lead_score_histogram",
+			Help:    "Lead scores assigned to investment inquiries when they are created or updated",
+			Buckets: prometheus.LinearBuckets(10, 10, 10),
+		},
+	)
+
+	investmentBulkUpdatesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "investment_bulk_updates_total",
+			Help: "Total number of investment inquiries processed by bulk status updates",
+		},
+		[]string{"result"}, // updated, failed
+	)
+
+	investmentArchivedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "investment_inquiries_archived_total",
+			Help: "Total number of investment inquiries archived",
+		},
+		[]string{"source"}, // manual, auto
+	)
+
 	contactSubmissionsTotal = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Name: "contact_submissions_total",
@@ -101,12 +270,57 @@ var (
 		},
 	)
 
+	contactSpamBlockedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "contact_spam_blocked_total",
+			Help: "Total number of contact form submissions rejected or flagged as spam",
+		},
+		[]string{"reason"}, // keywords, honeypot, urls, score
+	)
+
+	captchaValidationTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "captcha_validation_total",
+			Help: "Total number of CAPTCHA token validations on public forms",
+		},
+		[]string{"provider", "status"}, // status: success, failed, missing
+	)
+
+	followUpRemindersSentTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "follow_up_reminders_sent_total",
+			Help: "Total number of investment inquiry follow-up reminder emails sent to staff",
+		},
+	)
+
+	contactFirstReadTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "contact_inquiries_first_read_total",
+			Help: "Total number of contact inquiries opened by staff for the first time",
+		},
+	)
+
+	contactDuplicatesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "contact_duplicates_suppressed_total",
+			Help: "Total number of repeated contact form submissions that were not stored again",
+		},
+	)
+
 	otpGeneratedTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "otp_generated_total",
 			Help: "Total number of OTP codes generated",
 		},
-		[]string{"method"}, // email, sms
+		[]string{"method"}, // email, sms, whatsapp
+	)
+
+	otpDeliveriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "otp_deliveries_total",
+			Help: "Total number of OTP codes delivered, by channel and whether it was the requested channel or a fallback",
+		},
+		[]string{"channel", "route"}, // route: primary, fallback
 	)
 
 	otpVerifiedTotal = promauto.NewCounterVec(
@@ -116,15 +330,184 @@ var (
 		},
 		[]string{"status"}, // success, failure
 	)
+
+	otpDeliveryStatusTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "otp_delivery_status_total",
+			Help: "Total number of OTP SMS delivery status reports from the provider",
+		},
+		[]string{"status"}, // queued, sent, delivered, undelivered, failed
+	)
+
+	otpActiveSessions = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "otp_active_sessions",
+			Help: "Number of unexpired OTP sessions",
+		},
+	)
+
+	otpSessionsEvictedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "otp_sessions_evicted_total",
+			Help: "Total number of unexpired OTP sessions evicted because the in-memory store was full",
+		},
+	)
+
+	otpDailyLimitRejectionsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "otp_daily_limit_rejections_total",
+			Help: "Total number of OTP requests rejected by the daily per-identifier cap",
+		},
+	)
+
+	otpRateLimitKeys = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "otp_rate_limit_keys",
+			Help: "Number of identifiers tracked by the OTP rate limiter",
+		},
+	)
+
+	circuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state per external provider (0=closed, 1=open, 2=half-open)",
+		},
+		[]string{"provider"},
+	)
+
+	workerPoolQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "worker_pool_queue_depth",
+			Help: "Number of background tasks waiting for a worker",
+		},
+	)
+
+	workerPoolTasksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_pool_tasks_total",
+			Help: "Total number of background tasks by outcome",
+		},
+		[]string{"status"}, // completed, panicked, dropped
+	)
+
+	graphqlRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "graphql_requests_total",
+			Help: "Total number of GraphQL operations executed",
+		},
+		[]string{"operation"}, // sorted top-level fields (e.g. investmentStats,me), introspection, invalid
+	)
+
+	batchSubrequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "batch_subrequests_total",
+			Help: "Total number of sub-requests run through the batch endpoint",
+		},
+		[]string{"status_code"},
+	)
+
+	hubspotSyncTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hubspot_sync_total",
+			Help: "Total number of investment inquiry syncs to HubSpot",
+		},
+		[]string{"status"}, // created, updated, failed, dropped
+	)
+
+	slackNotificationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slack_notifications_sent_total",
+			Help: "Total number of Slack notifications sent",
+		},
+		[]string{"status"}, // success, failure
+	)
+
+	emailSendAttemptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "email_send_attempts_total",
+			Help: "Total number of SMTP send attempts, retries included",
+		},
+		[]string{"type"}, // otp, contact_notification, follow_up_reminder, generic
+	)
+
+	emailSendRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "email_send_retries_total",
+			Help: "Total number of emails retried after a temporary SMTP failure",
+		},
+		[]string{"type"},
+	)
+
+	emailSendFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "email_send_failures_total",
+			Help: "Total number of emails that could not be sent after all attempts",
+		},
+		[]string{"type", "provider", "reason"}, // reason: permanent, exhausted
+	)
+
+	emailThrottledTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "email_throttled_total",
+			Help: "Total number of emails held back by the outbound email limits",
+		},
+		[]string{"type", "limit"}, // limit: per_minute, per_hour, per_recipient
+	)
+
+	emailsSentTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "emails_sent_total",
+			Help: "Total number of emails accepted by the email provider",
+		},
+		[]string{"type", "provider"}, // provider: smtp, sendgrid, ses, console
+	)
+
+	emailSendDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "email_send_duration_seconds",
+			Help:    "Time to send an email in seconds, retries included",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"type", "provider", "status"}, // status: sent, failed
+	)
+
+	notificationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notifications_sent_total",
+			Help: "Total number of staff notifications delivered",
+		},
+		[]string{"channel", "status"}, // email, slack, webhook; success, failure
+	)
+
+	// Configuration metrics
+	configReloadTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "config_reload_total",
+			Help: "Total number of configuration reload attempts",
+		},
+	)
+
+	configReloadErrorsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "config_reload_errors_total",
+			Help: "Total number of failed configuration reloads",
+		},
+	)
 )
 
+// streamingPaths are long-lived Server-Sent Events endpoints that would otherwise
+// be recorded as a single very slow request
+var streamingPaths = map[string]bool{
+	"/api/v1/investment/stream": true,
+}
+
 // PrometheusMiddleware creates a middleware that records Prometheus metrics
 func PrometheusMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		// Skip metrics endpoint itself
-		if r.URL.Path == "/metrics" {
+		// Skip metrics endpoint itself and long-lived event streams
+		if r.URL.Path == "/metrics" || streamingPaths[r.URL.Path] {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -135,9 +518,11 @@ func PrometheusMiddleware(next http.Handler) http.Handler {
 			statusCode:     http.StatusOK,
 		}
 
+		endpoint := endpointLabel(r.URL.Path)
+
 		// Record request size
 		if r.ContentLength > 0 {
-			httpRequestSize.WithLabelValues(r.Method, r.URL.Path).Observe(float64(r.ContentLength))
+			httpRequestSize.WithLabelValues(r.Method, endpoint).Observe(float64(r.ContentLength))
 		}
 
 		// Handle request
@@ -147,9 +532,11 @@ func PrometheusMiddleware(next http.Handler) http.Handler {
 		duration := time.Since(start).Seconds()
 		statusCode := strconv.Itoa(wrapped.statusCode)
 
-		httpRequestsTotal.WithLabelValues(r.Method, r.URL.Path, statusCode).Inc()
-		httpRequestDuration.WithLabelValues(r.Method, r.URL.Path, statusCode).Observe(duration)
-		httpResponseSize.WithLabelValues(r.Method, r.URL.Path).Observe(float64(wrapped.size))
+		httpRequestsTotal.WithLabelValues(r.Method, endpoint, statusCode).Inc()
+		if httpRequestDuration != nil {
+			httpRequestDuration.WithLabelValues(r.Method, endpoint, statusCode).Observe(duration)
+		}
+		httpResponseSize.WithLabelValues(r.Method, endpoint).Observe(float64(wrapped.size))
 	})
 }
 
@@ -171,6 +558,16 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController (needed for flushing streams)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// RecordRequestBodySizeExceeded records a request to path rejected with 413
+func RecordRequestBodySizeExceeded(method, path string) {
+	requestBodySizeExceededTotal.WithLabelValues(method, endpointLabel(path)).Inc()
+}
+
 // RecordAuthAttempt records an authentication attempt
 func RecordAuthAttempt(success bool) {
 	status := "failure"
@@ -180,9 +577,36 @@ func RecordAuthAttempt(success bool) {
 	authAttemptsTotal.WithLabelValues(status).Inc()
 }
 
-// RecordInvestmentInquiry records a new investment inquiry
-func RecordInvestmentInquiry() {
+// RecordBackupCodeUsed records a backup code redeemed in place of a 2FA code
+func RecordBackupCodeUsed() {
+	backupCodesUsedTotal.Inc()
+}
+
+// RecordMFAEnforcementBlock records a login refused until a user of role enrolls TOTP
+func RecordMFAEnforcementBlock(role string) {
+	mfaEnforcementBlocksTotal.WithLabelValues(role).Inc()
+}
+
+// RecordInvestmentInquiry records a new investment inquiry from source
+func RecordInvestmentInquiry(source string) {
 	investmentInquiriesTotal.Inc()
+	investmentInquiriesBySource.WithLabelValues(source).Inc()
+}
+
+// ObserveInvestmentLeadScore records a computed lead score
+func ObserveInvestmentLeadScore(score int) {
+	investmentLeadScore.Observe(float64(score))
+}
+
+// RecordInvestmentBulkUpdate records the outcome of a bulk status update
+func RecordInvestmentBulkUpdate(updated, failed int) {
+	investmentBulkUpdatesTotal.WithLabelValues("updated").Add(float64(updated))
+	investmentBulkUpdatesTotal.WithLabelValues("failed").Add(float64(failed))
+}
+
+// RecordInquiriesArchived records inquiries archived by staff (manual) or by age (auto)
+func RecordInquiriesArchived(source string, count int) {
+	investmentArchivedTotal.WithLabelValues(source).Add(float64(count))
 }
 
 // RecordContactSubmission records a new contact form submission
@@ -190,11 +614,45 @@ func RecordContactSubmission() {
 	contactSubmissionsTotal.Inc()
 }
 
+// RecordContactDuplicate records a suppressed duplicate contact form submission
+func RecordContactDuplicate() {
+	contactDuplicatesTotal.Inc()
+}
+
+// RecordFollowUpReminderSent records a follow-up reminder email sent to staff
+func RecordFollowUpReminderSent() {
+	followUpRemindersSentTotal.Inc()
+}
+
+// RecordContactFirstRead records a contact inquiry opened by staff for the first time
+func RecordContactFirstRead() {
+	contactFirstReadTotal.Inc()
+}
+
+// RecordContactSpamBlocked records a contact submission rejected or flagged as spam
+func RecordContactSpamBlocked(reason string) {
+	contactSpamBlockedTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordCaptchaValidation records the outcome of a CAPTCHA token validation
+func RecordCaptchaValidation(provider, status string) {
+	captchaValidationTotal.WithLabelValues(provider, status).Inc()
+}
+
 // RecordOTPGenerated records OTP generation
 func RecordOTPGenerated(method string) {
 	otpGeneratedTotal.WithLabelValues(method).Inc()
 }
 
+// RecordOTPDelivery records an OTP delivered through channel, either as requested or as a fallback
+func RecordOTPDelivery(channel string, fallback bool) {
+	route := "primary"
+	if fallback {
+		route = "fallback"
+	}
+	otpDeliveriesTotal.WithLabelValues(channel, route).Inc()
+}
+
 // RecordOTPVerified records OTP verification
 func RecordOTPVerified(success bool) {
 	status := "failure"
@@ -204,6 +662,115 @@ func RecordOTPVerified(success bool) {
 	otpVerifiedTotal.WithLabelValues(status).Inc()
 }
 
+// RecordOTPDeliveryStatus records a delivery status reported for an OTP SMS
+func RecordOTPDeliveryStatus(status string) {
+	otpDeliveryStatusTotal.WithLabelValues(status).Inc()
+}
+
+// RecordOTPDailyLimitRejection records an OTP request rejected by the daily cap
+func RecordOTPDailyLimitRejection() {
+	otpDailyLimitRejectionsTotal.Inc()
+}
+
+// UpdateOTPState updates the OTP session and rate limit key gauges
+func UpdateOTPState(sessions, rateLimitKeys int) {
+	otpActiveSessions.Set(float64(sessions))
+	otpRateLimitKeys.Set(float64(rateLimitKeys))
+}
+
+// RecordOTPSessionEvicted records an OTP session evicted to stay within the session limit
+func RecordOTPSessionEvicted() {
+	otpSessionsEvictedTotal.Inc()
+}
+
+// SetCircuitBreakerState updates the circuit breaker state gauge for a provider
+func SetCircuitBreakerState(provider string, state int) {
+	circuitBreakerState.WithLabelValues(provider).Set(float64(state))
+}
+
+// SetWorkerPoolQueueDepth updates the background task queue depth gauge
+func SetWorkerPoolQueueDepth(depth int) {
+	workerPoolQueueDepth.Set(float64(depth))
+}
+
+// RecordWorkerPoolTask records the outcome of a background task
+func RecordWorkerPoolTask(status string) {
+	workerPoolTasksTotal.WithLabelValues(status).Inc()
+}
+
+// RecordGraphQLRequest records an executed GraphQL operation
+func RecordGraphQLRequest(operation string) {
+	graphqlRequestsTotal.WithLabelValues(operation).Inc()
+}
+
+// RecordBatchSubrequest records one sub-request of a batch call
+func RecordBatchSubrequest(statusCode int) {
+	batchSubrequestsTotal.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+}
+
+// RecordHubSpotSync records the outcome of a HubSpot contact sync
+func RecordHubSpotSync(status string) {
+	hubspotSyncTotal.WithLabelValues(status).Inc()
+}
+
+// RecordSlackNotification records a Slack notification attempt
+func RecordSlackNotification(success bool) {
+	status := "failure"
+	if success {
+		status = "success"
+	}
+	slackNotificationsTotal.WithLabelValues(status).Inc()
+}
+
+// RecordEmailAttempt records an SMTP send attempt for an email of emailType
+func RecordEmailAttempt(emailType string) {
+	emailSendAttemptsTotal.WithLabelValues(emailType).Inc()
+}
+
+// RecordEmailRetry records a retry of an email of emailType after a temporary failure
+func RecordEmailRetry(emailType string) {
+	emailSendRetriesTotal.WithLabelValues(emailType).Inc()
+}
+
+// RecordEmailThrottled records an email held back by an outbound email limit
+func RecordEmailThrottled(emailType, limit string) {
+	emailThrottledTotal.WithLabelValues(emailType, limit).Inc()
+}
+
+// RecordEmailSent records an email the provider accepted and how long sending it took
+func RecordEmailSent(emailType, provider string, duration time.Duration) {
+	emailsSentTotal.WithLabelValues(emailType, provider).Inc()
+	emailSendDuration.WithLabelValues(emailType, provider, "sent").Observe(duration.Seconds())
+}
+
+// RecordEmailFailure records an email that was given up on, either because the failure was
+// permanent or because every attempt failed, and how long was spent trying
+func RecordEmailFailure(emailType, provider string, permanent bool, duration time.Duration) {
+	reason := "exhausted"
+	if permanent {
+		reason = "permanent"
+	}
+	emailSendFailuresTotal.WithLabelValues(emailType, provider, reason).Inc()
+	emailSendDuration.WithLabelValues(emailType, provider, "failed").Observe(duration.Seconds())
+}
+
+// RecordNotification records the final outcome of a notification delivery
+func RecordNotification(channel string, success bool) {
+	status := "failure"
+	if success {
+		status = "success"
+	}
+	notificationsTotal.WithLabelValues(channel, status).Inc()
+}
+
+// RecordConfigReload records a configuration reload attempt
+func RecordConfigReload(success bool) {
+	configReloadTotal.Inc()
+	if !success {
+		configReloadErrorsTotal.Inc()
+	}
+}
+
 // RecordDBQuery records a database query
 func RecordDBQuery(operation string, duration time.Duration, err error) {
 	status := "success"
@@ -211,7 +778,19 @@ func RecordDBQuery(operation string, duration time.Duration, err error) {
 		status = "error"
 	}
 	dbQueriesTotal.WithLabelValues(operation, status).Inc()
-	dbQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	if dbQueryDuration != nil {
+		dbQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	}
+}
+
+// RecordDBQueryTimeout records a database query that exceeded its deadline
+func RecordDBQueryTimeout(operation string) {
+	dbQueryTimeoutsTotal.WithLabelValues(operation).Inc()
+}
+
+// SetGoroutines records the current number of goroutines
+func SetGoroutines(count int) {
+	processGoroutines.Set(float64(count))
 }
 
 // UpdateDBConnections updates database connection metrics
@@ -220,3 +799,11 @@ func UpdateDBConnections(active, idle int) {
 	dbConnectionsIdle.Set(float64(idle))
 }
 
+// SetReadReplicaAvailable updates the read replica availability gauge
+func SetReadReplicaAvailable(available bool) {
+	if available {
+		dbReadReplicaAvailable.Set(1)
+	} else {
+		dbReadReplicaAvailable.Set(0)
+	}
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestInitCreatesConfiguredHistograms(t *testing.T) {
+	Init([]float64{0.1, 1}, []float64{0.01}, 50)
+	// Later calls can't register the histograms again
+	Init([]float64{5}, []float64{5}, 1)
+
+	handler := PrometheusMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	RecordDBQuery("select", time.Millisecond, nil)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	bounds := make(map[string][]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			if histogram := metric.GetHistogram(); histogram != nil {
+				bounds[family.GetName()] = nil
+				for _, bucket := range histogram.GetBucket() {
+					bounds[family.GetName()] = append(bounds[family.GetName()], bucket.GetUpperBound())
+				}
+			}
+		}
+	}
+	if got := bounds["http_request_duration_seconds"]; !reflect.DeepEqual(got, []float64{0.1, 1}) {
+		t.Errorf("http_request_duration_seconds buckets = %v, want [0.1 1]", got)
+	}
+	if got := bounds["db_query_duration_seconds"]; !reflect.DeepEqual(got, []float64{0.01}) {
+		t.Errorf("db_query_duration_seconds buckets = %v, want [0.01]", got)
+	}
+	if pathLabels.max != 50 {
+		t.Errorf("path label limit = %d, want 50", pathLabels.max)
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/api/v1/investment/123":                                 "/api/v1/investment/{id}",
+		"/api/v1/investment/67890":                               "/api/v1/investment/{id}",
+		"/api/v1/contact/42/notes/7":                             "/api/v1/contact/{id}/notes/{id}",
+		"/api/v1/files/0b5e7a3c-1d2f-4e6a-9b8c-7d6e5f4a3b2c":     "/api/v1/files/{uuid}",
+		"/api/v1/files/0B5E7A3C-1D2F-4E6A-9B8C-7D6E5F4A3B2C/raw": "/api/v1/files/{uuid}/raw",
+		"/api/v1/auth/me":                                        "/api/v1/auth/me",
+		"/api/v1/investment/123abc":                              "/api/v1/investment/123abc",
+		"/api/v1/v2":                                             "/api/v1/v2",
+		"/":                                                      "/",
+	} {
+		if got := NormalizePath(path); got != want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPathLabelSetCapsDistinctLabels(t *testing.T) {
+	s := &pathLabelSet{max: 2, seen: make(map[string]bool)}
+	for _, tc := range []struct{ path, want string }{
+		{"/a", "/a"},
+		{"/b", "/b"},
+		{"/c", otherPathLabel},
+		{"/a", "/a"},
+		{"/d", otherPathLabel},
+	} {
+		if got := s.label(tc.path); got != tc.want {
+			t.Errorf("label(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+	if len(s.seen) != 2 {
+		t.Fatalf("%d labels recorded, want 2", len(s.seen))
+	}
+}
+
+// requestCount returns http_requests_total for the labels, or 0 if the series doesn't exist
+func requestCount(t *testing.T, method, endpoint, status string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	want := map[string]string{"method": method, "endpoint": endpoint, "status_code": status}
+	for _, family := range families {
+		if family.GetName() != "http_requests_total" {
+			continue
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if want[label.GetName()] != label.GetValue() {
+					continue series
+				}
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestPrometheusMiddlewareNormalizesEndpoint(t *testing.T) {
+	handler := PrometheusMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	before := requestCount(t, http.MethodGet, "/api/v1/investment/{id}", "200")
+
+	for _, path := range []string{"/api/v1/investment/123", "/api/v1/investment/67890"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := requestCount(t, http.MethodGet, "/api/v1/investment/{id}", "200"); got != before+2 {
+		t.Fatalf("http_requests_total{endpoint=/api/v1/investment/{id}} = %v, want %v", got, before+2)
+	}
+	if got := requestCount(t, http.MethodGet, "/api/v1/investment/123", "200"); got != 0 {
+		t.Fatal("the raw path was used as a label")
+	}
+}
@@ -0,0 +1,79 @@
+// Package middleware holds HTTP middleware shared by the API's handlers
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"springstreet/internal/metrics"
+)
+
+// MaxBodyMiddleware caps every request body at maxBytes, answering 413 when it is exceeded.
+// A maxBytes of 0 or less disables the limit.
+func MaxBodyMiddleware(next http.Handler, maxBytes int64) http.Handler {
+	return MaxBodyByPathMiddleware(next, func(string) int64 { return maxBytes })
+}
+
+// MaxBodyByPathMiddleware caps request bodies at limit(path) bytes, so routes such as file
+// uploads can allow more than the default. A limit of 0 or less disables it for that path.
+// Requests declaring a larger Content-Length are rejected up front; bodies that turn out to be
+// too large while being decoded turn the decoder's error response into a 413.
+func MaxBodyByPathMiddleware(next http.Handler, limit func(path string) int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxBytes := limit(r.URL.Path)
+		if maxBytes <= 0 || r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.ContentLength > maxBytes {
+			log.Printf("[REQUEST] %s %s rejected: body of %d bytes exceeds limit of %d", r.Method, r.URL.Path, r.ContentLength, maxBytes)
+			metrics.RecordRequestBodySizeExceeded(r.Method, r.URL.Path)
+			http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxBytes)}
+		r.Body = body
+		next.ServeHTTP(&bodyLimitWriter{ResponseWriter: w, body: body, request: r}, r)
+	})
+}
+
+// limitedBody records whether reading a request body hit its size limit
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter replaces the error status with 413 when the request body was too large
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	body    *limitedBody
+	request *http.Request
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.body.exceeded && code >= http.StatusBadRequest {
+		log.Printf("[REQUEST] %s %s rejected: body exceeds limit", w.request.Method, w.request.URL.Path)
+		metrics.RecordRequestBodySizeExceeded(w.request.Method, w.request.URL.Path)
+		code = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readAll stands in for a Goa handler: it reads the body and answers 400 if that fails
+var readAll = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if _, err := io.ReadAll(r.Body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestMaxBodyMiddlewareRejectsOversizedBody(t *testing.T) {
+	handler := MaxBodyMiddleware(readAll, 16)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/contact/submit", strings.NewReader(strings.Repeat("x", 17)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestMaxBodyMiddlewareRejectsOversizedBodyWithoutContentLength(t *testing.T) {
+	handler := MaxBodyMiddleware(readAll, 16)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/contact/submit", strings.NewReader(strings.Repeat("x", 17)))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestMaxBodyMiddlewareAllowsBodyWithinLimit(t *testing.T) {
+	handler := MaxBodyMiddleware(readAll, 16)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/contact/submit", strings.NewReader(strings.Repeat("x", 16)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestMaxBodyByPathMiddlewareUsesPathLimit(t *testing.T) {
+	handler := MaxBodyByPathMiddleware(readAll, func(path string) int64 {
+		if strings.HasPrefix(path, "/api/v1/contact") {
+			return 64
+		}
+		return 16
+	})
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/contact/submit", http.StatusOK},
+		{"/api/v1/investment/", http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(strings.Repeat("x", 32)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.path, rec.Code, tc.want)
+		}
+	}
+}
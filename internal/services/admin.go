@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"springstreet/gen/admin"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"goa.design/goa/v3/security"
+	"gorm.io/gorm"
+)
+
+// AdminService implements the admin service
+type AdminService struct {
+	db              *gorm.DB
+	emailService    *EmailService
+	smsService      *SMSService
+	testSendLimiter *util.RateLimiter // Test emails and SMS per admin
+	queryTimeout    time.Duration
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(db *gorm.DB, emailService *EmailService, smsService *SMSService, queryTimeout time.Duration) *AdminService {
+	return &AdminService{
+		db:              db,
+		emailService:    emailService,
+		smsService:      smsService,
+		testSendLimiter: util.NewRateLimiter(testSendsPerMinute, time.Minute),
+		queryTimeout:    queryTimeout,
+	}
+}
+
+// JWTAuth implements the authorization logic for the JWT security scheme
+func (s *AdminService) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	// Validate JWT token and extract claims
+	claims, err := util.ValidateToken(token)
+	if err != nil {
+		return nil, admin.MakeUnauthorized(fmt.Errorf("invalid or expired token"))
+	}
+
+	// Get user from database
+	var user domain.User
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, admin.MakeUnauthorized(fmt.Errorf("user not found"))
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		return nil, admin.MakeUnauthorized(fmt.Errorf("user account is inactive"))
+	}
+
+	// Check the user belongs to the request's organization
+	if !inRequestOrganization(ctx, &user) {
+		return nil, admin.MakeUnauthorized(fmt.Errorf("user does not belong to this organization"))
+	}
+
+	// Check scopes if required
+	if schema != nil && len(schema.RequiredScopes) > 0 {
+		hasScope := false
+		for _, requiredScope := range schema.RequiredScopes {
+			if requiredScope == "admin" && user.IsAdmin {
+				hasScope = true
+				break
+			}
+			if requiredScope == "staff" && (user.IsStaff || user.IsAdmin) {
+				hasScope = true
+				break
+			}
+		}
+		if !hasScope {
+			return nil, admin.MakeUnauthorized(fmt.Errorf("insufficient permissions"))
+		}
+	}
+
+	// Add user to context
+	ctx = context.WithValue(ctx, "user", &user)
+	return ctx, nil
+}
+
+// Features implements the features method
+func (s *AdminService) Features(ctx context.Context, p *admin.AdminFeaturesPayload) ([]*admin.Featureflagresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[ADMIN] Features request by user=%s", user.Username)
+
+	names := config.FeatureNames()
+	results := make([]*admin.Featureflagresult, len(names))
+	for i, name := range names {
+		results[i] = &admin.Featureflagresult{
+			Name:    name,
+			Enabled: config.FeatureFlag(name),
+		}
+	}
+	return results, nil
+}
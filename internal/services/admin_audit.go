@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"springstreet/gen/admin"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogs implements the audit_logs method. Pages are keyed on the entry ID (newest
+// first) rather than an offset, so entries written while an admin pages through the log
+// don't shift later pages and nothing is repeated or skipped.
+func (s *AdminService) AuditLogs(ctx context.Context, p *admin.AuditLogsPayload) (*admin.Auditlogpageresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[ADMIN] AuditLogs request by user=%s: limit=%d", user.Username, p.Limit)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx).Model(&domain.AuditLog{}))
+	if p.ActorID != nil {
+		query = query.Where("actor_user_id = ?", *p.ActorID)
+	}
+	if p.ResourceType != nil {
+		query = query.Where("resource_type = ?", *p.ResourceType)
+	}
+	if p.ResourceID != nil {
+		query = query.Where("resource_id = ?", *p.ResourceID)
+	}
+	if p.Action != nil && strings.TrimSpace(*p.Action) != "" {
+		query = query.Where("action = ?", strings.TrimSpace(*p.Action))
+	}
+	if p.DateFrom != nil {
+		from, err := parseDateParam(*p.DateFrom)
+		if err != nil {
+			return nil, admin.MakeBadRequest(fmt.Errorf("invalid date_from: expected YYYY-MM-DD or RFC3339"))
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if p.DateTo != nil {
+		to, err := parseDateParam(*p.DateTo)
+		if err != nil {
+			return nil, admin.MakeBadRequest(fmt.Errorf("invalid date_to: expected YYYY-MM-DD or RFC3339"))
+		}
+		query = query.Where("created_at < ?", to)
+	}
+
+	result := &admin.Auditlogpageresult{}
+	if p.Cursor == nil || *p.Cursor == "" {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			log.Printf("[ADMIN] AuditLogs failed: database error: %v", err)
+			return nil, fmt.Errorf("failed to count audit logs: %w", err)
+		}
+		count := int(total)
+		result.TotalCount = &count
+	} else {
+		afterID, err := decodeAuditCursor(*p.Cursor)
+		if err != nil {
+			return nil, admin.MakeBadRequest(fmt.Errorf("invalid cursor"))
+		}
+		query = query.Where("id < ?", afterID)
+	}
+
+	// Fetch one extra entry to learn whether there is a next page
+	var entries []domain.AuditLog
+	if err := query.Order("id DESC").Limit(p.Limit + 1).Find(&entries).Error; err != nil {
+		log.Printf("[ADMIN] AuditLogs failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch audit logs: %w", err)
+	}
+	if len(entries) > p.Limit {
+		entries = entries[:p.Limit]
+		cursor := encodeAuditCursor(entries[len(entries)-1].ID)
+		result.NextCursor = &cursor
+	}
+
+	result.Items = make([]*admin.Auditlogresult, len(entries))
+	for i, entry := range entries {
+		item := &admin.Auditlogresult{
+			ID:           int(entry.ID),
+			Actor:        entry.Actor,
+			Action:       entry.Action,
+			ResourceType: entry.ResourceType,
+			ResourceID:   int(entry.ResourceID),
+			Details:      entry.Details,
+			CreatedAt:    entry.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		}
+		if entry.ActorUserID != nil {
+			actorID := int(*entry.ActorUserID)
+			item.ActorUserID = &actorID
+		}
+		result.Items[i] = item
+	}
+
+	log.Printf("[ADMIN] AuditLogs successful: returned %d entries", len(result.Items))
+	return result, nil
+}
+
+// encodeAuditCursor returns the opaque cursor for the page after the entry with id
+func encodeAuditCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+// decodeAuditCursor returns the entry ID encoded by encodeAuditCursor
+func decodeAuditCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
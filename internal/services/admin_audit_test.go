@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"springstreet/gen/admin"
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// seedAuditLogs stores n audit entries, alternating between two resource types
+func seedAuditLogs(t *testing.T, db *gorm.DB, actor *domain.User, n int) {
+	t.Helper()
+	entries := make([]domain.AuditLog, n)
+	for i := range entries {
+		resourceType := domain.AuditResourceContactInquiry
+		if i%2 == 0 {
+			resourceType = domain.AuditResourceUser
+		}
+		entries[i] = domain.AuditLog{ActorUserID: &actor.ID, Actor: actor.Username, Action: "update", ResourceType: resourceType, ResourceID: uint(i + 1)}
+	}
+	if err := db.CreateInBatches(entries, 100).Error; err != nil {
+		t.Fatalf("seed audit logs: %v", err)
+	}
+}
+
+// newTestAdminService returns an admin service on a fresh database and an admin context
+func newTestAdminService(t *testing.T) (*AdminService, context.Context, *domain.User) {
+	t.Helper()
+	db := newTestDB(t)
+	s := NewAdminService(db, nil, nil, time.Minute)
+	user := createTestUser(t, db, domain.User{Username: "admin", IsAdmin: true}, "correct horse")
+	return s, asUser(context.Background(), user), user
+}
+
+func TestAuditLogsCursorPagination(t *testing.T) {
+	s, ctx, user := newTestAdminService(t)
+	seedAuditLogs(t, s.db, user, 200)
+
+	seen := make(map[int]bool)
+	lastID := 0
+	var cursor *string
+	pages := 0
+	for {
+		page, err := s.AuditLogs(ctx, &admin.AuditLogsPayload{Cursor: cursor, Limit: 30})
+		if err != nil {
+			t.Fatalf("AuditLogs page %d: %v", pages+1, err)
+		}
+		pages++
+		if pages == 1 && (page.TotalCount == nil || *page.TotalCount != 200) {
+			t.Fatalf("first page total_count = %v, want 200", page.TotalCount)
+		}
+		if pages > 1 && page.TotalCount != nil {
+			t.Fatal("total_count returned on a later page")
+		}
+
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Fatalf("entry %d returned twice", item.ID)
+			}
+			if lastID != 0 && item.ID >= lastID {
+				t.Fatalf("entry %d follows %d, want newest first", item.ID, lastID)
+			}
+			seen[item.ID] = true
+			lastID = item.ID
+		}
+
+		if pages == 2 {
+			// Entries written while paging must not shift later pages
+			seedAuditLogs(t, s.db, user, 5)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(seen) != 200 {
+		t.Fatalf("paged through %d entries, want 200", len(seen))
+	}
+	if pages != 7 {
+		t.Fatalf("%d pages, want 7", pages)
+	}
+}
+
+func TestAuditLogsFilters(t *testing.T) {
+	s, ctx, user := newTestAdminService(t)
+	seedAuditLogs(t, s.db, user, 20)
+
+	resourceType := domain.AuditResourceUser
+	page, err := s.AuditLogs(ctx, &admin.AuditLogsPayload{ResourceType: &resourceType, Limit: 100})
+	if err != nil {
+		t.Fatalf("AuditLogs: %v", err)
+	}
+	if *page.TotalCount != 10 || len(page.Items) != 10 {
+		t.Fatalf("total_count = %d with %d items, want 10", *page.TotalCount, len(page.Items))
+	}
+	for _, item := range page.Items {
+		if item.ResourceType != domain.AuditResourceUser {
+			t.Fatalf("item %+v does not match resource_type=%s", item, resourceType)
+		}
+	}
+
+	otherActor := int(user.ID) + 1
+	page, err = s.AuditLogs(ctx, &admin.AuditLogsPayload{ActorID: &otherActor, Limit: 100})
+	if err != nil || *page.TotalCount != 0 {
+		t.Fatalf("AuditLogs for another actor = %+v, %v, want no entries", page, err)
+	}
+}
+
+func TestAuditLogsRejectsInvalidCursor(t *testing.T) {
+	s, ctx, _ := newTestAdminService(t)
+	cursor := "not a cursor"
+
+	_, err := s.AuditLogs(ctx, &admin.AuditLogsPayload{Cursor: &cursor, Limit: 10})
+	assertServiceError(t, err, "bad_request")
+}
+
+func TestAdminLogsScopedToOrganization(t *testing.T) {
+	t.Setenv("MULTI_TENANT", "true")
+	s, ctx, user := newTestAdminService(t)
+	other := domain.Organization{Name: "Other", Slug: "other"}
+	if err := s.db.Create(&other).Error; err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	otherCtx := WithOrganization(ctx, other.ID)
+
+	// Both logs take the organization from the context they are written in
+	if err := recordAudit(s.db.WithContext(ctx), user, "update", domain.AuditResourceUser, user.ID, ""); err != nil {
+		t.Fatalf("recordAudit: %v", err)
+	}
+	if err := recordAudit(s.db.WithContext(otherCtx), nil, "update", domain.AuditResourceUser, user.ID, ""); err != nil {
+		t.Fatalf("recordAudit: %v", err)
+	}
+	emailService := NewEmailService(&config.Get().Email, s.db)
+	emailService.recordEmailLog(ctx, testEmail("investor@example.com"), "smtp", nil)
+	emailService.recordEmailLog(otherCtx, testEmail("investor@example.com"), "smtp", nil)
+
+	for _, tc := range []struct {
+		ctx   context.Context
+		orgID uint
+	}{{ctx, domain.DefaultOrganizationID}, {otherCtx, other.ID}} {
+		page, err := s.AuditLogs(tc.ctx, &admin.AuditLogsPayload{Limit: 100})
+		if err != nil {
+			t.Fatalf("AuditLogs: %v", err)
+		}
+		if len(page.Items) != 1 {
+			t.Fatalf("organization %d sees %d audit entries, want its own 1", tc.orgID, len(page.Items))
+		}
+		var entry domain.AuditLog
+		if err := s.db.First(&entry, page.Items[0].ID).Error; err != nil || entry.OrganizationID != tc.orgID {
+			t.Fatalf("audit entry = %+v, %v, want organization %d", entry, err, tc.orgID)
+		}
+
+		logs, err := s.EmailLogs(tc.ctx, &admin.EmailLogsPayload{Limit: 100})
+		if err != nil {
+			t.Fatalf("EmailLogs: %v", err)
+		}
+		if len(logs) != 1 {
+			t.Fatalf("organization %d sees %d email log entries, want its own 1", tc.orgID, len(logs))
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"springstreet/gen/admin"
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+
+	"goa.design/goa/v3/security"
+)
+
+// testSendsPerMinute limits how many test emails and SMS one admin can send
+const testSendsPerMinute = 3
+
+// GoroutineDumpPath serves a stack dump of every goroutine, for debugging in production
+// without a profiler. It is served outside Goa since the response is plain text.
+const GoroutineDumpPath = "/debug/goroutines"
+
+// HandleGoroutineDump serves GET GoroutineDumpPath to requests made directly from localhost
+// and to callers authorized for diagnostics (see authorizeDiagnostics)
+func (s *AdminService) HandleGoroutineDump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requester := "localhost"
+	if !isDirectLocalRequest(r) {
+		var ok bool
+		if requester, ok = s.authorizeDiagnostics(w, r); !ok {
+			return
+		}
+	}
+	log.Printf("[ADMIN] Goroutine dump requested by '%s'", requester)
+
+	// Grow the buffer until the whole dump fits
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(buf)
+}
+
+// PprofPathPrefix is where the net/http/pprof handlers are served when PPROF_ENABLED is set
+const PprofPathPrefix = "/debug/pprof/"
+
+// PprofHandler serves the net/http/pprof handlers to callers authorized for diagnostics (see
+// authorizeDiagnostics), and answers everyone else with 403
+func (s *AdminService) PprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PprofPathPrefix, pprof.Index)
+	mux.HandleFunc(PprofPathPrefix+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PprofPathPrefix+"profile", pprof.Profile)
+	mux.HandleFunc(PprofPathPrefix+"symbol", pprof.Symbol)
+	mux.HandleFunc(PprofPathPrefix+"trace", pprof.Trace)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requester, ok := s.authorizeDiagnostics(w, r)
+		if !ok {
+			return
+		}
+		log.Printf("[ADMIN] Profile %s requested by '%s'", r.URL.Path, requester)
+
+		// CPU profiles and traces run for as long as the caller asks, past the write timeout
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// MetricsJSONPath serves the Prometheus metrics as JSON, for dashboards and scripts that can't
+// scrape the text format at /metrics
+const MetricsJSONPath = "/metrics/json"
+
+// HandleMetricsJSON serves GET MetricsJSONPath to callers authorized for diagnostics (see
+// authorizeDiagnostics). Values are at most a few seconds old; last_scraped_at says when they
+// were read.
+func (s *AdminService) HandleMetricsJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := s.authorizeDiagnostics(w, r); !ok {
+		return
+	}
+
+	body, err := metrics.JSON()
+	if err != nil {
+		log.Printf("[ADMIN] Failed to encode metrics as JSON: %v", err)
+		http.Error(w, "failed to encode metrics", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(body)
+}
+
+// authorizeDiagnostics checks that r carries "Bearer <PPROF_SECRET>" or the JWT of a super
+// admin. Diagnostics cover the whole process, and so every organization, which an admin of a
+// single organization may not see. It returns who made the request, or false once it has
+// answered r with 403.
+func (s *AdminService) authorizeDiagnostics(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token := bearerToken(r.Header.Get("Authorization"))
+	if pprofSecretMatches(token) {
+		return "PPROF_SECRET", true
+	}
+	if token != "" {
+		ctx, err := s.JWTAuth(r.Context(), token, &security.JWTScheme{Name: "jwt", RequiredScopes: []string{"admin"}})
+		if err == nil {
+			if user := ctx.Value("user").(*domain.User); isSuperAdmin(user) {
+				return user.Username, true
+			}
+		}
+	}
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return "", false
+}
+
+// pprofSecretMatches reports whether token is the configured PPROF_SECRET
+func pprofSecretMatches(token string) bool {
+	secret := config.Get().App.PprofSecret
+	if secret == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// isDirectLocalRequest reports whether r comes from a loopback address without passing
+// through a proxy. A proxy on the same host would otherwise make every request look local.
+func isDirectLocalRequest(r *http.Request) bool {
+	if r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("X-Real-IP") != "" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// TestEmail implements the test_email method. A failure to send is reported in the result
+// rather than as an error, since finding it is the point of the call.
+func (s *AdminService) TestEmail(ctx context.Context, p *admin.TestEmailPayload) (*admin.Testdeliveryresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[ADMIN] TestEmail request by user=%s", user.Username)
+
+	if !s.testSendLimiter.Allow(fmt.Sprintf("%d", user.ID)) {
+		return nil, admin.MakeTooManyRequests(fmt.Errorf("at most %d test messages per minute. Please try again later", testSendsPerMinute))
+	}
+
+	cfg := s.emailService.config()
+	response, err := s.emailService.SendTest(ctx, p.To)
+	result := &admin.Testdeliveryresult{Provider: cfg.Provider}
+	if err != nil {
+		message := redactSecrets(err.Error(), cfg.Password, cfg.SendGridAPIKey)
+		log.Printf("[ADMIN] TestEmail failed: %s", message)
+		result.Error = &message
+		return result, nil
+	}
+	log.Printf("[ADMIN] TestEmail successful: %s", response)
+	result.Sent = true
+	result.Response = &response
+	return result, nil
+}
+
+// TestSms implements the test_sms method. Like TestEmail, it reports a failure to send in
+// the result.
+func (s *AdminService) TestSms(ctx context.Context, p *admin.TestSMSPayload) (*admin.Testdeliveryresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[ADMIN] TestSMS request by user=%s", user.Username)
+
+	if !s.testSendLimiter.Allow(fmt.Sprintf("%d", user.ID)) {
+		return nil, admin.MakeTooManyRequests(fmt.Errorf("at most %d test messages per minute. Please try again later", testSendsPerMinute))
+	}
+
+	cfg := s.smsService.cfg
+	response, err := s.smsService.SendTest(p.PhoneNumber)
+	result := &admin.Testdeliveryresult{Provider: cfg.Provider}
+	if err != nil {
+		message := redactSecrets(err.Error(), cfg.TwilioSID, cfg.TwilioAuth, cfg.MSG91AuthKey)
+		log.Printf("[ADMIN] TestSMS failed: %s", message)
+		result.Error = &message
+		return result, nil
+	}
+	log.Printf("[ADMIN] TestSMS successful: %s", response)
+	result.Sent = true
+	result.Response = &response
+	return result, nil
+}
+
+// redactSecrets replaces every occurrence of the non-empty secrets in text, so provider
+// errors that echo a request can be shown to admins
+func redactSecrets(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, "[redacted]")
+		}
+	}
+	return text
+}
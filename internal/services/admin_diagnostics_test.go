@@ -0,0 +1,56 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+)
+
+func TestDiagnosticsRequireSuperAdminOrSecret(t *testing.T) {
+	t.Setenv("PPROF_SECRET", "pprof-secret")
+	s, _, superAdmin := newTestAdminService(t)
+	other := domain.Organization{Name: "Other", Slug: "other"}
+	if err := s.db.Create(&other).Error; err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	tenantAdmin := createTestUser(t, s.db, domain.User{Username: "tenant-admin", IsAdmin: true, OrganizationID: other.ID}, "correct horse")
+
+	bearer := func(user *domain.User) string {
+		token, err := util.GenerateToken(user)
+		if err != nil {
+			t.Fatalf("GenerateToken: %v", err)
+		}
+		return "Bearer " + token
+	}
+	handlers := map[string]http.Handler{
+		"goroutines":   http.HandlerFunc(s.HandleGoroutineDump),
+		"pprof":        s.PprofHandler(),
+		"metrics/json": http.HandlerFunc(s.HandleMetricsJSON),
+	}
+	for name, handler := range handlers {
+		for _, tc := range []struct {
+			authorization string
+			want          int
+		}{
+			{"", http.StatusForbidden},
+			{"Bearer wrong-secret", http.StatusForbidden},
+			{bearer(tenantAdmin), http.StatusForbidden},
+			{bearer(superAdmin), http.StatusOK},
+			{"Bearer pprof-secret", http.StatusOK},
+		} {
+			req := httptest.NewRequest(http.MethodGet, PprofPathPrefix, nil)
+			req.RemoteAddr = "203.0.113.7:4000"
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("%s with %q: status %d, want %d", name, tc.authorization, rec.Code, tc.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"springstreet/gen/admin"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+)
+
+// EmailLogs implements the email_logs method
+func (s *AdminService) EmailLogs(ctx context.Context, p *admin.EmailLogsPayload) ([]*admin.Emaillogresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[ADMIN] EmailLogs request by user=%s: limit=%d", user.Username, p.Limit)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx).Model(&domain.EmailLog{}))
+	if p.Type != nil {
+		query = query.Where("type = ?", *p.Type)
+	}
+	if p.Status != nil {
+		query = query.Where("status = ?", *p.Status)
+	}
+	if p.Recipient != nil {
+		query = query.Where("recipient_hash = ?", hashEmailAddress(*p.Recipient))
+	}
+
+	var entries []domain.EmailLog
+	if err := query.Order("id DESC").Limit(p.Limit).Find(&entries).Error; err != nil {
+		log.Printf("[ADMIN] EmailLogs failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch email logs: %w", err)
+	}
+
+	results := make([]*admin.Emaillogresult, 0, len(entries))
+	for _, e := range entries {
+		results = append(results, &admin.Emaillogresult{
+			ID:        int(e.ID),
+			Recipient: e.Recipient,
+			Type:      e.Type,
+			Provider:  e.Provider,
+			Status:    e.Status,
+			Error:     e.Error,
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"gorm.io/gorm"
+
+	"springstreet/internal/domain"
+)
+
+// recordAudit writes an audit log entry for a change made by user (nil for system actions).
+// Pass the transaction that made the change so the entry is rolled back with it; the entry
+// belongs to the organization of the transaction's context.
+func recordAudit(tx *gorm.DB, user *domain.User, action, resourceType string, resourceID uint, details string) error {
+	entry := &domain.AuditLog{
+		OrganizationID: OrganizationFromContext(tx.Statement.Context),
+		Actor:          "system",
+		Action:         action,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+	}
+	if user != nil {
+		entry.ActorUserID = &user.ID
+		entry.Actor = user.Username
+	}
+	if details != "" {
+		entry.Details = &details
+	}
+	return tx.Create(entry).Error
+}
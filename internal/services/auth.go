@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"springstreet/gen/auth"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
 	"springstreet/internal/domain"
 	"springstreet/internal/metrics"
 	"springstreet/internal/util"
@@ -24,20 +26,27 @@ func stringPtr(s string) *string {
 
 // AuthService implements the auth service
 type AuthService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	totpLimiter  *util.RateLimiter // Second-factor attempts per user
+	queryTimeout time.Duration
 }
 
 // JWTAuth implements the authorization logic for the JWT security scheme
 func (s *AuthService) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
-	// Validate JWT token and extract claims
+	// Validate JWT token and extract claims. The TOTP enrollment endpoints also take the
+	// enrollment token of a login refused by the 2FA policy.
 	claims, err := util.ValidateToken(token)
+	if err != nil && requiresScope(schema, totpEnrollmentScope) {
+		claims, err = util.ValidatePurposeToken(token, util.TokenPurposeTOTPEnrollment)
+	}
 	if err != nil {
 		return nil, auth.MakeUnauthorized(fmt.Errorf("invalid or expired token"))
 	}
 
 	// Get user from database
 	var user domain.User
-	if err := s.db.Where("username = ?", claims.Username).First(&user).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, auth.MakeUnauthorized(fmt.Errorf("user not found"))
 		}
@@ -49,6 +58,11 @@ func (s *AuthService) JWTAuth(ctx context.Context, token string, schema *securit
 		return nil, auth.MakeUnauthorized(fmt.Errorf("user account is inactive"))
 	}
 
+	// Check the user belongs to the request's organization
+	if !inRequestOrganization(ctx, &user) {
+		return nil, auth.MakeUnauthorized(fmt.Errorf("user does not belong to this organization"))
+	}
+
 	// Check scopes if required
 	if schema != nil && len(schema.RequiredScopes) > 0 {
 		hasScope := false
@@ -61,6 +75,10 @@ func (s *AuthService) JWTAuth(ctx context.Context, token string, schema *securit
 				hasScope = true
 				break
 			}
+			if requiredScope == totpEnrollmentScope {
+				hasScope = true
+				break
+			}
 		}
 		if !hasScope {
 			return nil, auth.MakeUnauthorized(fmt.Errorf("insufficient permissions"))
@@ -73,8 +91,12 @@ func (s *AuthService) JWTAuth(ctx context.Context, token string, schema *securit
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(db *gorm.DB) *AuthService {
-	return &AuthService{db: db}
+func NewAuthService(db *gorm.DB, queryTimeout time.Duration) *AuthService {
+	return &AuthService{
+		db:           db,
+		totpLimiter:  util.NewRateLimiter(totpAttemptsPerChallenge, totpChallengeTTL),
+		queryTimeout: queryTimeout,
+	}
 }
 
 // Login implements the login method
@@ -86,7 +108,8 @@ func (s *AuthService) Login(ctx context.Context, p *auth.LoginPayload) (*auth.Lo
 	log.Printf("[AUTH] Login attempt for user: %s", username)
 
 	var user domain.User
-	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("[AUTH] Login failed: user '%s' not found", username)
 			metrics.RecordAuthAttempt(false)
@@ -109,23 +132,35 @@ func (s *AuthService) Login(ctx context.Context, p *auth.LoginPayload) (*auth.Lo
 		return nil, auth.MakeUnauthorized(fmt.Errorf("user account is inactive"))
 	}
 
+	if user.TOTPEnabled {
+		return totpChallenge(&user)
+	}
+	if role, required := mfaRequiredRole(&user); required {
+		return nil, mfaRequired(&user, role)
+	}
+	return s.completeLogin(ctx, &user)
+}
+
+// completeLogin records user's login and issues their access token
+func (s *AuthService) completeLogin(ctx context.Context, user *domain.User) (*auth.Loginresult, error) {
 	// Update last login
 	now := time.Now()
 	user.LastLogin = &now
-	s.db.Save(&user)
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	s.db.WithContext(qctx).Model(user).Update("last_login", now)
 
 	// Generate token
-	token, err := util.GenerateToken(&user)
+	token, err := util.GenerateToken(user)
 	if err != nil {
-		log.Printf("[AUTH] Login failed: token generation error for user '%s': %v", username, err)
+		log.Printf("[AUTH] Login failed: token generation error for user '%s': %v", user.Username, err)
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	log.Printf("[AUTH] Login successful for user '%s' (id=%d, admin=%v, staff=%v)", username, user.ID, user.IsAdmin, user.IsStaff)
+	log.Printf("[AUTH] Login successful for user '%s' (id=%d, admin=%v, staff=%v)", user.Username, user.ID, user.IsAdmin, user.IsStaff)
 	metrics.RecordAuthAttempt(true)
 
 	return &auth.Loginresult{
-		AccessToken: token,
+		AccessToken: &token,
 		TokenType:   "bearer",
 	}, nil
 }
@@ -157,17 +192,46 @@ func (s *AuthService) CreateUser(ctx context.Context, p *auth.CreateUserPayload)
 
 	// Check if username exists
 	var existingUser domain.User
-	if err := s.db.Where("username = ?", username).First(&existingUser).Error; err == nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", username).First(&existingUser).Error; err == nil {
 		log.Printf("[AUTH] CreateUser failed: username '%s' already exists", username)
 		return nil, auth.MakeBadRequest(fmt.Errorf("username already registered"))
 	}
 
 	// Check if email exists
-	if err := s.db.Where("email = ?", email).First(&existingUser).Error; err == nil {
+	if err := s.db.WithContext(qctx).Where("email = ?", email).First(&existingUser).Error; err == nil {
 		log.Printf("[AUTH] CreateUser failed: email '%s' already exists", email)
 		return nil, auth.MakeBadRequest(fmt.Errorf("email already registered"))
 	}
 
+	if err := checkPasswordPolicy(password); err != nil {
+		log.Printf("[AUTH] CreateUser failed: weak password for '%s'", username)
+		return nil, auth.MakeBadRequest(err)
+	}
+	language := strings.ToLower(strings.TrimSpace(p.Language))
+	if !emailTemplates.Supports(language) {
+		log.Printf("[AUTH] CreateUser failed: unsupported language '%s'", p.Language)
+		return nil, auth.MakeBadRequest(fmt.Errorf("unsupported language: %s", p.Language))
+	}
+
+	orgID := OrganizationFromContext(ctx)
+	if p.OrganizationID != nil && uint(*p.OrganizationID) != orgID {
+		if !isSuperAdmin(ctx.Value("user").(*domain.User)) {
+			log.Printf("[AUTH] CreateUser failed: only superadmins may create users in another organization")
+			return nil, auth.MakeUnauthorized(fmt.Errorf("insufficient permissions"))
+		}
+		var org domain.Organization
+		if err := s.db.WithContext(qctx).First(&org, *p.OrganizationID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("[AUTH] CreateUser failed: organization id=%d not found", *p.OrganizationID)
+				return nil, auth.MakeBadRequest(fmt.Errorf("organization not found"))
+			}
+			log.Printf("[AUTH] CreateUser failed: database error: %v", err)
+			return nil, fmt.Errorf("failed to find organization: %w", err)
+		}
+		orgID = org.ID
+	}
+
 	// Hash password
 	hashedPassword, err := util.HashPassword(password)
 	if err != nil {
@@ -183,13 +247,21 @@ func (s *AuthService) CreateUser(ctx context.Context, p *auth.CreateUserPayload)
 		IsActive:       p.IsActive,
 		IsAdmin:        p.IsAdmin,
 		IsStaff:        p.IsStaff,
+		Language:       language,
+		OrganizationID: orgID,
 	}
 	if p.FullName != nil {
 		fullName := strings.TrimSpace(*p.FullName)
 		user.FullName = &fullName
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
+	err = s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return recordPasswordHistory(tx, user.ID, user.HashedPassword)
+	})
+	if err != nil {
 		log.Printf("[AUTH] CreateUser failed: database error: %v", err)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -203,7 +275,8 @@ func (s *AuthService) ListUsers(ctx context.Context, p *auth.ListUsersPayload) (
 	log.Printf("[AUTH] ListUsers request: skip=%d, limit=%d", p.Skip, p.Limit)
 
 	var users []domain.User
-	query := s.db.Order("created_at DESC")
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)).Order("created_at DESC")
 
 	if p.Skip > 0 {
 		query = query.Offset(p.Skip)
@@ -233,7 +306,8 @@ func (s *AuthService) GetUser(ctx context.Context, p *auth.GetUserPayload) (*aut
 	log.Printf("[AUTH] GetUser request: id=%d", p.ID)
 
 	var user domain.User
-	if err := s.db.First(&user, p.ID).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)).First(&user, p.ID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("[AUTH] GetUser failed: user id=%d not found", p.ID)
 			return nil, auth.MakeNotFound(fmt.Errorf("user not found"))
@@ -251,7 +325,8 @@ func (s *AuthService) UpdateUser(ctx context.Context, p *auth.UpdateUserPayload)
 	log.Printf("[AUTH] UpdateUser request: id=%d", p.ID)
 
 	var user domain.User
-	if err := s.db.First(&user, p.ID).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx)).First(&user, p.ID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("[AUTH] UpdateUser failed: user id=%d not found", p.ID)
 			return nil, auth.MakeNotFound(fmt.Errorf("user not found"))
@@ -265,7 +340,7 @@ func (s *AuthService) UpdateUser(ctx context.Context, p *auth.UpdateUserPayload)
 		username := strings.TrimSpace(*p.Username)
 		// Check if username is taken by another user
 		var existingUser domain.User
-		if err := s.db.Where("username = ? AND id != ?", username, p.ID).First(&existingUser).Error; err == nil {
+		if err := s.db.WithContext(qctx).Where("username = ? AND id != ?", username, p.ID).First(&existingUser).Error; err == nil {
 			log.Printf("[AUTH] UpdateUser failed: username '%s' already taken", username)
 			return nil, auth.MakeBadRequest(fmt.Errorf("username already taken"))
 		}
@@ -275,7 +350,7 @@ func (s *AuthService) UpdateUser(ctx context.Context, p *auth.UpdateUserPayload)
 		email := strings.ToLower(strings.TrimSpace(*p.Email))
 		// Check if email is taken by another user
 		var existingUser domain.User
-		if err := s.db.Where("email = ? AND id != ?", email, p.ID).First(&existingUser).Error; err == nil {
+		if err := s.db.WithContext(qctx).Where("email = ? AND id != ?", email, p.ID).First(&existingUser).Error; err == nil {
 			log.Printf("[AUTH] UpdateUser failed: email '%s' already taken", email)
 			return nil, auth.MakeBadRequest(fmt.Errorf("email already taken"))
 		}
@@ -294,17 +369,49 @@ func (s *AuthService) UpdateUser(ctx context.Context, p *auth.UpdateUserPayload)
 	if p.IsStaff != nil {
 		user.IsStaff = *p.IsStaff
 	}
+	if p.Language != nil {
+		language := strings.ToLower(strings.TrimSpace(*p.Language))
+		if !emailTemplates.Supports(language) {
+			log.Printf("[AUTH] UpdateUser failed: unsupported language '%s'", *p.Language)
+			return nil, auth.MakeBadRequest(fmt.Errorf("unsupported language: %s", *p.Language))
+		}
+		user.Language = language
+	}
+	passwordChanged := false
 	if p.Password != nil {
 		password := strings.TrimSpace(*p.Password)
+		if err := checkPasswordPolicy(password); err != nil {
+			log.Printf("[AUTH] UpdateUser failed: weak password for user id=%d", p.ID)
+			return nil, auth.MakeBadRequest(err)
+		}
+		reused, err := isPasswordReused(s.db.WithContext(qctx), &user, password)
+		if err != nil {
+			log.Printf("[AUTH] UpdateUser failed: database error: %v", err)
+			return nil, err
+		}
+		if reused {
+			log.Printf("[AUTH] UpdateUser failed: reused password for user id=%d", p.ID)
+			return nil, auth.MakeBadRequest(fmt.Errorf("password was used recently. Choose a password you haven't used for your last %d changes", config.Get().Auth.PasswordHistory))
+		}
 		hashedPassword, err := util.HashPassword(password)
 		if err != nil {
 			log.Printf("[AUTH] UpdateUser failed: password hashing error: %v", err)
 			return nil, fmt.Errorf("failed to hash password: %w", err)
 		}
 		user.HashedPassword = hashedPassword
+		passwordChanged = true
 	}
 
-	if err := s.db.Save(&user).Error; err != nil {
+	err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if passwordChanged {
+			return recordPasswordHistory(tx, user.ID, user.HashedPassword)
+		}
+		return nil
+	})
+	if err != nil {
 		log.Printf("[AUTH] UpdateUser failed: database error: %v", err)
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -319,7 +426,8 @@ func (s *AuthService) DeleteUser(ctx context.Context, p *auth.DeleteUserPayload)
 	log.Printf("[AUTH] DeleteUser request: id=%d by user=%s", p.ID, currentUser.Username)
 
 	var user domain.User
-	if err := s.db.First(&user, p.ID).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx)).First(&user, p.ID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("[AUTH] DeleteUser failed: user id=%d not found", p.ID)
 			return auth.MakeNotFound(fmt.Errorf("user not found"))
@@ -334,7 +442,16 @@ func (s *AuthService) DeleteUser(ctx context.Context, p *auth.DeleteUserPayload)
 		return auth.MakeBadRequest(fmt.Errorf("cannot delete your own account"))
 	}
 
-	if err := s.db.Delete(&user).Error; err != nil {
+	err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&domain.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&domain.BackupCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
 		log.Printf("[AUTH] DeleteUser failed: database error: %v", err)
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -343,16 +460,72 @@ func (s *AuthService) DeleteUser(ctx context.Context, p *auth.DeleteUserPayload)
 	return nil
 }
 
+// checkPasswordPolicy validates a new password when the password_policy feature is on
+func checkPasswordPolicy(password string) error {
+	if !config.FeatureFlag(config.FeaturePasswordPolicy) {
+		return nil
+	}
+	return util.ValidatePasswordStrength(password)
+}
+
+// isPasswordReused reports whether password matches the user's current password or one of
+// their last PASSWORD_HISTORY_COUNT passwords
+func isPasswordReused(db *gorm.DB, user *domain.User, password string) (bool, error) {
+	count := config.Get().Auth.PasswordHistory
+	if count <= 0 {
+		return false, nil
+	}
+
+	// Users created before history was kept only have their current password to compare against
+	if util.CheckPasswordHash(password, user.HashedPassword) {
+		return true, nil
+	}
+
+	var history []domain.PasswordHistory
+	if err := db.Where("user_id = ?", user.ID).Order("id DESC").Limit(count).Find(&history).Error; err != nil {
+		return false, fmt.Errorf("failed to load password history: %w", err)
+	}
+	for _, entry := range history {
+		if util.CheckPasswordHash(password, entry.HashedPassword) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPasswordHistory stores a new password hash and drops entries beyond PASSWORD_HISTORY_COUNT
+func recordPasswordHistory(tx *gorm.DB, userID uint, hashedPassword string) error {
+	count := config.Get().Auth.PasswordHistory
+	if count <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&domain.PasswordHistory{UserID: userID, HashedPassword: hashedPassword}).Error; err != nil {
+		return fmt.Errorf("failed to record password history: %w", err)
+	}
+
+	var keep []uint
+	if err := tx.Model(&domain.PasswordHistory{}).Where("user_id = ?", userID).Order("id DESC").Limit(count).Pluck("id", &keep).Error; err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+	if err := tx.Where("user_id = ? AND id NOT IN ?", userID, keep).Delete(&domain.PasswordHistory{}).Error; err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+	return nil
+}
+
 // Helper function to convert User model to UserResult
 func convertUserToResult(user *domain.User) *auth.Userresult {
 	result := &auth.Userresult{
-		ID:        int(user.ID),
-		Username:  user.Username,
-		Email:     user.Email,
-		IsActive:  user.IsActive,
-		IsAdmin:   user.IsAdmin,
-		IsStaff:   user.IsStaff,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		ID:             int(user.ID),
+		Username:       user.Username,
+		Email:          user.Email,
+		IsActive:       user.IsActive,
+		IsAdmin:        user.IsAdmin,
+		IsStaff:        user.IsStaff,
+		Language:       user.Language,
+		CreatedAt:      user.CreatedAt.Format(time.RFC3339),
+		OrganizationID: int(user.OrganizationID),
 	}
 
 	if user.FullName != nil {
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+
+	"gorm.io/gorm"
+)
+
+const (
+	backupCodeCount  = 10
+	backupCodeLength = 8
+
+	// Upper case letters and digits without the look-alikes 0/O and 1/I, since codes are often copied by hand
+	backupCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	auditActionBackupCodesGenerated = "backup_codes_generated"
+	auditActionBackupCodeUsed       = "backup_code_used"
+)
+
+// GenerateBackupCodes implements the generate backup codes method.
+// Codes are only returned here; a user who already has unused codes must regenerate them instead.
+func (s *AuthService) GenerateBackupCodes(ctx context.Context, p *auth.BackupCodesPayload) (*auth.Backupcodesresult, error) {
+	currentUser := ctx.Value("user").(*domain.User)
+	log.Printf("[AUTH] GenerateBackupCodes request: user '%s'", currentUser.Username)
+
+	var codes []string
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		var unused int64
+		if err := tx.Model(&domain.BackupCode{}).Where("user_id = ? AND used = ?", currentUser.ID, false).Count(&unused).Error; err != nil {
+			return err
+		}
+		if unused > 0 {
+			return auth.MakeBadRequest(fmt.Errorf("you still have %d unused backup codes. Regenerate them to get a new set", unused))
+		}
+
+		var err error
+		codes, err = createBackupCodes(tx, currentUser)
+		return err
+	})
+	if err != nil {
+		log.Printf("[AUTH] GenerateBackupCodes failed for '%s': %v", currentUser.Username, err)
+		return nil, err
+	}
+
+	log.Printf("[AUTH] GenerateBackupCodes successful: %d codes for '%s'", len(codes), currentUser.Username)
+	return &auth.Backupcodesresult{
+		Codes:   codes,
+		Message: "Store these backup codes somewhere safe. Each can be used once and they will not be shown again",
+	}, nil
+}
+
+// RegenerateBackupCodes implements the regenerate backup codes method.
+// All of the user's existing codes, used or not, stop working.
+func (s *AuthService) RegenerateBackupCodes(ctx context.Context, p *auth.BackupCodesPayload) (*auth.Backupcodesresult, error) {
+	currentUser := ctx.Value("user").(*domain.User)
+	log.Printf("[AUTH] RegenerateBackupCodes request: user '%s'", currentUser.Username)
+
+	var codes []string
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", currentUser.ID).Delete(&domain.BackupCode{}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = createBackupCodes(tx, currentUser)
+		return err
+	})
+	if err != nil {
+		log.Printf("[AUTH] RegenerateBackupCodes failed for '%s': %v", currentUser.Username, err)
+		return nil, fmt.Errorf("failed to regenerate backup codes: %w", err)
+	}
+
+	log.Printf("[AUTH] RegenerateBackupCodes successful: %d codes for '%s'", len(codes), currentUser.Username)
+	return &auth.Backupcodesresult{
+		Codes:   codes,
+		Message: "Your previous backup codes no longer work. Store these somewhere safe; they will not be shown again",
+	}, nil
+}
+
+// ConsumeBackupCode redeems one of the user's unused backup codes. It reports whether code
+// matched; a matched code is marked used and can't be redeemed again. This is the check a
+// second-factor challenge falls back to when the user has lost their authenticator.
+func (s *AuthService) ConsumeBackupCode(ctx context.Context, user *domain.User, code string) (bool, error) {
+	code = normalizeBackupCode(code)
+	if len(code) != backupCodeLength {
+		return false, nil
+	}
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	var candidates []domain.BackupCode
+	if err := s.db.WithContext(qctx).Where("user_id = ? AND used = ?", user.ID, false).Find(&candidates).Error; err != nil {
+		return false, fmt.Errorf("failed to load backup codes: %w", err)
+	}
+
+	for _, candidate := range candidates {
+		if !util.CheckPasswordHash(code, candidate.CodeHash) {
+			continue
+		}
+
+		consumed := false
+		err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+			// The used = false condition stops two concurrent logins redeeming the same code
+			result := tx.Model(&domain.BackupCode{}).Where("id = ? AND used = ?", candidate.ID, false).Update("used", true)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			consumed = true
+			return recordAudit(tx, user, auditActionBackupCodeUsed, domain.AuditResourceUser, user.ID, "")
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to redeem backup code: %w", err)
+		}
+		if consumed {
+			log.Printf("[AUTH] Backup code redeemed by '%s'", user.Username)
+			metrics.RecordBackupCodeUsed()
+		}
+		return consumed, nil
+	}
+	return false, nil
+}
+
+// createBackupCodes stores a fresh set of hashed backup codes for user and returns them in plain text
+func createBackupCodes(tx *gorm.DB, user *domain.User) ([]string, error) {
+	codes := make([]string, backupCodeCount)
+	for i := range codes {
+		code, err := randomBackupCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := util.HashPassword(code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash backup code: %w", err)
+		}
+		if err := tx.Create(&domain.BackupCode{UserID: user.ID, CodeHash: hash}).Error; err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	if err := recordAudit(tx, user, auditActionBackupCodesGenerated, domain.AuditResourceUser, user.ID, ""); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// randomBackupCode returns a random code drawn from backupCodeAlphabet
+func randomBackupCode() (string, error) {
+	max := big.NewInt(int64(len(backupCodeAlphabet)))
+	code := make([]byte, backupCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate backup code: %w", err)
+		}
+		code[i] = backupCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeBackupCode accepts codes typed in lower case or with spaces and dashes
+func normalizeBackupCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// likeEscaper escapes the LIKE wildcards in a literal, for use with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EraseUserData implements the erase user data method.
+// Personal data is anonymized rather than deleted so related records stay consistent.
+// All changes and the gdpr_erasure_log entry are written in one transaction.
+func (s *AuthService) EraseUserData(ctx context.Context, p *auth.EraseUserDataPayload) (*auth.Erasureresult, error) {
+	currentUser := ctx.Value("user").(*domain.User)
+	log.Printf("[AUTH] EraseUserData request: id=%d by '%s'", p.ID, currentUser.Username)
+
+	if uint(p.ID) == currentUser.ID {
+		log.Printf("[AUTH] EraseUserData failed: user '%s' attempted to erase their own data", currentUser.Username)
+		return nil, auth.MakeBadRequest(fmt.Errorf("cannot erase your own account"))
+	}
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	result := &auth.Erasureresult{UserID: p.ID}
+
+	err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		var user domain.User
+		if err := scopeToOrganization(ctx, tx).First(&user, p.ID).Error; err != nil {
+			return err
+		}
+
+		anonymousName := fmt.Sprintf("deleted-%d", user.ID)
+		anonymousEmail := fmt.Sprintf("deleted-%d@deleted.invalid", user.ID)
+
+		// Inquiries are matched by the user's original email
+		var inquiryIDs []uint
+		if err := scopeToOrganization(ctx, tx.Model(&domain.InvestmentInquiry{})).
+			Where("LOWER(email) = LOWER(?)", user.Email).
+			Pluck("id", &inquiryIDs).Error; err != nil {
+			return err
+		}
+		investments := scopeToOrganization(ctx, tx.Model(&domain.InvestmentInquiry{})).
+			Where("LOWER(email) = LOWER(?)", user.Email).
+			Updates(map[string]interface{}{
+				"email":      nil,
+				"phone":      nil,
+				"first_name": nil,
+				"last_name":  nil,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if investments.Error != nil {
+			return investments.Error
+		}
+		result.InvestmentInquiries = int(investments.RowsAffected)
+
+		// Version history keeps full snapshots, so RestoreVersion could bring the data back.
+		// Delete the history of those inquiries and any snapshot from before an inquiry's email
+		// was changed away from the user's.
+		emailPattern := "%" + likeEscaper.Replace(strings.ToLower(user.Email)) + "%"
+		versions := tx.
+			Where("inquiry_id IN (?)", scopeToOrganization(ctx, tx.Model(&domain.InvestmentInquiry{})).Select("id")).
+			Where(`inquiry_id IN ? OR LOWER(CAST(snapshot AS TEXT)) LIKE ? ESCAPE '\'`, inquiryIDs, emailPattern).
+			Delete(&domain.InvestmentInquiryVersion{})
+		if versions.Error != nil {
+			return versions.Error
+		}
+		result.InvestmentInquiryVersions = int(versions.RowsAffected)
+
+		// Soft-deleted contact inquiries still hold the data, so include them
+		contacts := scopeToOrganization(ctx, tx.Unscoped().Model(&domain.ContactInquiry{})).
+			Where("LOWER(email) = LOWER(?)", user.Email).
+			Updates(map[string]interface{}{
+				"name":         anonymousName,
+				"email":        anonymousEmail,
+				"phone":        nil,
+				"message":      "",
+				"message_hash": "",
+			})
+		if contacts.Error != nil {
+			return contacts.Error
+		}
+		result.ContactInquiries = int(contacts.RowsAffected)
+
+		notes := tx.Model(&domain.ContactNote{}).
+			Where("author = ?", user.Username).
+			Update("author", anonymousName)
+		if notes.Error != nil {
+			return notes.Error
+		}
+		result.ContactNotes = int(notes.RowsAffected)
+
+		audits := tx.Model(&domain.AuditLog{}).
+			Where("actor_user_id = ?", user.ID).
+			Update("actor", anonymousName)
+		if audits.Error != nil {
+			return audits.Error
+		}
+		result.AuditLogs = int(audits.RowsAffected)
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&domain.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&domain.BackupCode{}).Error; err != nil {
+			return err
+		}
+
+		users := tx.Model(&user).Updates(map[string]interface{}{
+			"username":        anonymousName,
+			"email":           anonymousEmail,
+			"full_name":       nil,
+			"hashed_password": "!", // Not a valid bcrypt hash, so no password can match
+			"is_active":       false,
+			"last_login":      nil,
+		})
+		if users.Error != nil {
+			return users.Error
+		}
+		result.Users = int(users.RowsAffected)
+
+		details, err := json.Marshal(map[string]int{
+			"users":                result.Users,
+			"investment_inquiries": result.InvestmentInquiries,
+			"investment_versions":  result.InvestmentInquiryVersions,
+			"contact_inquiries":    result.ContactInquiries,
+			"contact_notes":        result.ContactNotes,
+			"audit_logs":           result.AuditLogs,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Create(&domain.GDPRErasureLog{
+			UserID:         user.ID,
+			ErasedByUserID: currentUser.ID,
+			Details:        string(details),
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[AUTH] EraseUserData failed: user id=%d not found", p.ID)
+			return nil, auth.MakeNotFound(fmt.Errorf("user not found"))
+		}
+		log.Printf("[AUTH] EraseUserData failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to erase user data: %w", err)
+	}
+
+	log.Printf("[AUTH] EraseUserData successful: id=%d, investment_inquiries=%d, investment_versions=%d, contact_inquiries=%d, contact_notes=%d, audit_logs=%d",
+		p.ID, result.InvestmentInquiries, result.InvestmentInquiryVersions, result.ContactInquiries, result.ContactNotes, result.AuditLogs)
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/domain"
+)
+
+func TestEraseUserDataRemovesInquiryVersions(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, domain.User{Username: "admin", IsAdmin: true}, "correct horse")
+	user := createTestUser(t, db, domain.User{Username: "jane", Email: "Jane@example.com"}, "correct horse")
+
+	email := "jane@example.com"
+	other := "other@example.com"
+	name := "Jane"
+	// One inquiry still carries the email, one carried it before it was changed, and one never did
+	current := domain.InvestmentInquiry{Email: &email, FirstName: &name}
+	changed := domain.InvestmentInquiry{Email: &other}
+	unrelated := domain.InvestmentInquiry{Email: &other}
+	for _, inquiry := range []*domain.InvestmentInquiry{&current, &changed, &unrelated} {
+		if err := db.Create(inquiry).Error; err != nil {
+			t.Fatalf("create inquiry: %v", err)
+		}
+	}
+	snapshots := []domain.InvestmentInquiry{current, changed, unrelated}
+	snapshots[1].Email = &email
+	for i := range snapshots {
+		if err := recordInquiryVersion(db, &snapshots[i], admin); err != nil {
+			t.Fatalf("recordInquiryVersion: %v", err)
+		}
+	}
+
+	s := NewAuthService(db, time.Minute)
+	result, err := s.EraseUserData(asUser(context.Background(), admin), &auth.EraseUserDataPayload{ID: int(user.ID)})
+	if err != nil {
+		t.Fatalf("EraseUserData: %v", err)
+	}
+	if result.InvestmentInquiryVersions != 2 {
+		t.Errorf("InvestmentInquiryVersions = %d, want 2", result.InvestmentInquiryVersions)
+	}
+
+	var versions []domain.InvestmentInquiryVersion
+	if err := db.Find(&versions).Error; err != nil {
+		t.Fatalf("load versions: %v", err)
+	}
+	for _, version := range versions {
+		if strings.Contains(strings.ToLower(version.Snapshot), email) {
+			t.Errorf("version %d of inquiry %d still contains the erased email", version.ID, version.InquiryID)
+		}
+	}
+	if len(versions) != 1 || versions[0].InquiryID != unrelated.ID {
+		t.Errorf("remaining versions = %+v, want only inquiry %d's", versions, unrelated.ID)
+	}
+
+	var erasure domain.GDPRErasureLog
+	if err := db.Where("user_id = ?", user.ID).First(&erasure).Error; err != nil {
+		t.Fatalf("load erasure log: %v", err)
+	}
+	if !strings.Contains(erasure.Details, `"investment_versions":2`) {
+		t.Errorf("erasure log details = %s, want investment_versions of 2", erasure.Details)
+	}
+}
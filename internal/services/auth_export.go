@@ -0,0 +1,131 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// dataExportInterval is how often a user may request a data export
+const dataExportInterval = 24 * time.Hour
+
+// auditActionDataExport marks data exports in the audit log, which also drives the rate limit
+const auditActionDataExport = "data_export"
+
+// userDataExport is the JSON document returned by ExportUserData.
+// domain.User never serializes HashedPassword.
+type userDataExport struct {
+	ExportedAt          time.Time                  `json:"exported_at"`
+	User                *domain.User               `json:"user"`
+	InvestmentInquiries []domain.InvestmentInquiry `json:"investment_inquiries"`
+	ContactInquiries    []domain.ContactInquiry    `json:"contact_inquiries"`
+	ContactNotes        []domain.ContactNote       `json:"contact_notes"` // Notes the user wrote
+	AuditLogs           []domain.AuditLog          `json:"audit_logs"`    // Actions the user performed
+}
+
+// ExportUserData implements the export user data method.
+// Users may export their own data; admins may export anyone's.
+func (s *AuthService) ExportUserData(ctx context.Context, p *auth.ExportUserDataPayload) (*auth.ExportUserDataResult, io.ReadCloser, error) {
+	currentUser := ctx.Value("user").(*domain.User)
+
+	targetID := currentUser.ID
+	if p.UserID != nil {
+		targetID = uint(*p.UserID)
+	}
+	log.Printf("[AUTH] ExportUserData request: user id=%d by '%s'", targetID, currentUser.Username)
+
+	if targetID != currentUser.ID && !currentUser.IsAdmin {
+		log.Printf("[AUTH] ExportUserData failed: '%s' is not allowed to export user id=%d", currentUser.Username, targetID)
+		return nil, nil, auth.MakeUnauthorized(fmt.Errorf("you can only export your own data"))
+	}
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+
+	// Rate limit per requesting user, using their previous export in the audit log
+	var lastExport domain.AuditLog
+	err := s.db.WithContext(qctx).
+		Where("actor_user_id = ? AND action = ? AND created_at > ?", currentUser.ID, auditActionDataExport, time.Now().Add(-dataExportInterval)).
+		Order("created_at DESC").
+		First(&lastExport).Error
+	if err == nil {
+		retryAt := lastExport.CreatedAt.Add(dataExportInterval)
+		log.Printf("[AUTH] ExportUserData failed: '%s' already exported at %s", currentUser.Username, lastExport.CreatedAt.Format(time.RFC3339))
+		return nil, nil, auth.MakeTooManyRequests(fmt.Errorf("data export is limited to once per 24 hours. Try again after %s", retryAt.Format(time.RFC3339)))
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[AUTH] ExportUserData failed: database error: %v", err)
+		return nil, nil, err
+	}
+
+	var user domain.User
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx)).First(&user, targetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[AUTH] ExportUserData failed: user id=%d not found", targetID)
+			return nil, nil, auth.MakeNotFound(fmt.Errorf("user not found"))
+		}
+		log.Printf("[AUTH] ExportUserData failed: database error: %v", err)
+		return nil, nil, err
+	}
+
+	export, err := s.collectUserData(qctx, &user)
+	if err != nil {
+		log.Printf("[AUTH] ExportUserData failed: database error: %v", err)
+		return nil, nil, err
+	}
+
+	body, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode data export: %w", err)
+	}
+
+	if err := recordAudit(s.db.WithContext(qctx), currentUser, auditActionDataExport, domain.AuditResourceUser, user.ID, ""); err != nil {
+		log.Printf("[AUTH] ExportUserData failed: could not record audit log: %v", err)
+		return nil, nil, err
+	}
+
+	log.Printf("[AUTH] ExportUserData successful: user id=%d, %d bytes", user.ID, len(body))
+	return &auth.ExportUserDataResult{
+		ContentType:        "application/json",
+		ContentDisposition: fmt.Sprintf(`attachment; filename="user-data-%d.json"`, user.ID),
+	}, io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// collectUserData gathers every record that holds personal data about user.
+// Inquiries are matched by the user's email; soft-deleted contact inquiries are included
+// because they are still stored.
+func (s *AuthService) collectUserData(ctx context.Context, user *domain.User) (*userDataExport, error) {
+	db := s.db.WithContext(ctx)
+	export := &userDataExport{
+		ExportedAt:          time.Now(),
+		User:                user,
+		InvestmentInquiries: []domain.InvestmentInquiry{},
+		ContactInquiries:    []domain.ContactInquiry{},
+		ContactNotes:        []domain.ContactNote{},
+		AuditLogs:           []domain.AuditLog{},
+	}
+
+	if err := scopeToOrganization(ctx, db).Where("LOWER(email) = LOWER(?)", user.Email).Order("created_at").Find(&export.InvestmentInquiries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load investment inquiries: %w", err)
+	}
+	if err := scopeToOrganization(ctx, db.Unscoped()).Where("LOWER(email) = LOWER(?)", user.Email).Order("created_at").Find(&export.ContactInquiries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load contact inquiries: %w", err)
+	}
+	if err := db.Where("author = ?", user.Username).Order("created_at").Find(&export.ContactNotes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load contact notes: %w", err)
+	}
+	if err := db.Where("actor_user_id = ?", user.ID).Order("created_at").Find(&export.AuditLogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load audit logs: %w", err)
+	}
+	return export, nil
+}
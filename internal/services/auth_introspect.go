@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"gorm.io/gorm"
+)
+
+// Introspect implements the introspect method (RFC 7662).
+// Any token the API would reject - invalid, expired, or for a missing or deactivated user -
+// is reported as {"active": false} with no other details.
+func (s *AuthService) Introspect(ctx context.Context, p *auth.IntrospectPayload) (*auth.Introspectresult, error) {
+	ip := clientIP(ctx)
+	if !introspectCallerAllowed(p.Authorization) {
+		log.Printf("[AUTH] Introspect rejected: invalid introspection secret from %s", ip)
+		return nil, auth.MakeUnauthorized(fmt.Errorf("invalid introspection credentials"))
+	}
+
+	inactive := &auth.Introspectresult{Active: false}
+	claims, err := util.ValidateToken(p.Token)
+	if err != nil {
+		log.Printf("[AUTH] Introspect from %s: subject=unknown, active=false (%v)", ip, err)
+		return inactive, nil
+	}
+
+	var user domain.User
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		log.Printf("[AUTH] Introspect from %s: subject=%s, active=false (user not found)", ip, claims.Username)
+		return inactive, nil
+	}
+	if !user.IsActive {
+		log.Printf("[AUTH] Introspect from %s: subject=%s, active=false (user inactive)", ip, claims.Username)
+		return inactive, nil
+	}
+
+	log.Printf("[AUTH] Introspect from %s: subject=%s, active=true", ip, claims.Username)
+	result := &auth.Introspectresult{
+		Active:   true,
+		Username: &user.Username,
+		IsAdmin:  &user.IsAdmin,
+		IsStaff:  &user.IsStaff,
+	}
+	if claims.ExpiresAt != nil {
+		exp := claims.ExpiresAt.Unix()
+		result.Exp = &exp
+	}
+	if claims.IssuedAt != nil {
+		iat := claims.IssuedAt.Unix()
+		result.Iat = &iat
+	}
+	return result, nil
+}
+
+// introspectCallerAllowed reports whether authorization is "Bearer <INTROSPECT_SECRET>".
+// Introspection is disabled while no secret is configured.
+func introspectCallerAllowed(authorization *string) bool {
+	secret := config.Get().Auth.IntrospectSecret
+	if secret == "" || authorization == nil {
+		return false
+	}
+	scheme, token, found := strings.Cut(*authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(secret)) == 1
+}
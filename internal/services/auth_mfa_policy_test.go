@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"springstreet/gen/auth"
+	authsvr "springstreet/gen/http/auth/server"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	goahttp "goa.design/goa/v3/http"
+)
+
+// newAuthServer serves the auth service's HTTP endpoints
+func newAuthServer(t *testing.T, s *AuthService) *httptest.Server {
+	t.Helper()
+	mux := goahttp.NewMuxer()
+	server := authsvr.New(auth.NewEndpoints(s), mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, nil)
+	server.Mount(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// postJSON sends body to path with an optional bearer token
+func postJSON(t *testing.T, ts *httptest.Server, path, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestLoginRefusesAdminWithoutTOTPWhenEnforced(t *testing.T) {
+	t.Setenv("ENFORCE_2FA_FOR_ADMINS", "true")
+	s := NewAuthService(newTestDB(t), time.Minute)
+	createTestUser(t, s.db, domain.User{Username: "admin", IsAdmin: true}, "correct horse")
+	ts := newAuthServer(t, s)
+	blocksBefore := metricValue(t, "mfa_enforcement_blocks_total", map[string]string{"role": "admin"})
+
+	resp := postJSON(t, ts, "/api/v1/auth/login", "", `{"username":"admin","password":"correct horse"}`)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", resp.StatusCode)
+	}
+	var body struct {
+		Code            string  `json:"code"`
+		EnrollmentURL   string  `json:"enrollment_url"`
+		EnrollmentToken string  `json:"enrollment_token"`
+		AccessToken     *string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Code != "MFA_REQUIRED" || body.EnrollmentURL != "/api/v1/auth/me/totp/enroll" {
+		t.Fatalf("body = %+v, want MFA_REQUIRED with the enrollment URL", body)
+	}
+	if body.AccessToken != nil {
+		t.Fatal("a refused login returned an access token")
+	}
+	if got := metricValue(t, "mfa_enforcement_blocks_total", map[string]string{"role": "admin"}); got != blocksBefore+1 {
+		t.Fatalf("mfa_enforcement_blocks_total = %v, want %v", got, blocksBefore+1)
+	}
+
+	// The enrollment token opens the enrollment endpoint and nothing else
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer "+body.EnrollmentToken)
+	me, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/v1/auth/me: %v", err)
+	}
+	me.Body.Close()
+	if me.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("me with the enrollment token: status = %d, want 401", me.StatusCode)
+	}
+	resp = postJSON(t, ts, "/api/v1/auth/me/totp/enroll", body.EnrollmentToken, `{}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("enroll with the enrollment token: status = %d, want 200", resp.StatusCode)
+	}
+	var enrollment struct {
+		Secret string `json:"secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&enrollment); err != nil {
+		t.Fatalf("decode enrollment: %v", err)
+	}
+	code, _ := util.TOTPCode(enrollment.Secret, util.TOTPStep(time.Now()))
+	if resp := postJSON(t, ts, "/api/v1/auth/me/totp/confirm", body.EnrollmentToken, `{"code":"`+code+`"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("confirm with the enrollment token: status = %d, want 200", resp.StatusCode)
+	}
+
+	// Once enrolled, the admin gets the usual TOTP challenge
+	res, err := s.Login(context.Background(), &auth.LoginPayload{Username: "admin", Password: "correct horse"})
+	if err != nil || res.ChallengeToken == nil {
+		t.Fatalf("Login after enrolling = %+v, %v, want a TOTP challenge", res, err)
+	}
+}
+
+func TestLoginPolicyCoversOnlyEnforcedRoles(t *testing.T) {
+	t.Setenv("ENFORCE_2FA_FOR_STAFF", "true")
+	s := NewAuthService(newTestDB(t), time.Minute)
+	createTestUser(t, s.db, domain.User{Username: "admin", IsAdmin: true}, "correct horse")
+	createTestUser(t, s.db, domain.User{Username: "staff", IsStaff: true}, "correct horse")
+
+	if res, err := s.Login(context.Background(), &auth.LoginPayload{Username: "admin", Password: "correct horse"}); err != nil || res.AccessToken == nil {
+		t.Fatalf("admin Login = %+v, %v, want an access token while only staff are enforced", res, err)
+	}
+
+	_, err := s.Login(context.Background(), &auth.LoginPayload{Username: "staff", Password: "correct horse"})
+	mfaErr, ok := err.(*auth.MFARequired)
+	if !ok || mfaErr.Code != "MFA_REQUIRED" {
+		t.Fatalf("staff Login error = %v, want MFA_REQUIRED", err)
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/domain"
+)
+
+func TestUpdateUserRejectsRecentPasswords(t *testing.T) {
+	t.Setenv("PASSWORD_HISTORY_COUNT", "5")
+	s := NewAuthService(newTestDB(t), time.Minute)
+	user := createTestUser(t, s.db, domain.User{Username: "investor"}, "Original0!")
+	ctx := context.Background()
+
+	setPassword := func(password string) error {
+		_, err := s.UpdateUser(ctx, &auth.UpdateUserPayload{ID: int(user.ID), Password: &password})
+		return err
+	}
+
+	// Five changes fill the history
+	for i := 1; i <= 5; i++ {
+		if err := setPassword(fmt.Sprintf("Password%d!", i)); err != nil {
+			t.Fatalf("change #%d: %v", i, err)
+		}
+	}
+
+	err := setPassword("Password2!")
+	assertServiceError(t, err, "bad_request")
+
+	// A new password is accepted as the sixth change and pushes #1 out of the history
+	if err := setPassword("Password6!"); err != nil {
+		t.Fatalf("change #6: %v", err)
+	}
+	var entries int64
+	s.db.Model(&domain.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&entries)
+	if entries != 5 {
+		t.Fatalf("password history has %d entries, want 5", entries)
+	}
+	if err := setPassword("Password1!"); err != nil {
+		t.Fatalf("reusing #1 after five newer passwords: %v", err)
+	}
+}
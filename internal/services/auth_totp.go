@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+
+	"goa.design/goa/v3/security"
+	"gorm.io/gorm"
+)
+
+const (
+	// How long a user has to enter their code after the password was accepted
+	totpChallengeTTL = 5 * time.Minute
+
+	// Wrong codes a user may enter per totpChallengeTTL; enough for typos, too few to guess a code
+	totpAttemptsPerChallenge = 5
+
+	// How long a user refused by the 2FA policy has to enroll with the enrollment token
+	totpEnrollmentTTL = 15 * time.Minute
+
+	// Scope of the TOTP enrollment endpoints, the only ones the enrollment token opens
+	totpEnrollmentScope = "totp_enrollment"
+
+	// Where users refused by the 2FA policy enroll
+	totpEnrollmentURL = "/api/v1/auth/me/totp/enroll"
+
+	auditActionTOTPEnabled = "totp_enabled"
+)
+
+// mfaRequiredRole reports whether the 2FA policy refuses user's login until they enroll TOTP,
+// and the role it applies to
+func mfaRequiredRole(user *domain.User) (string, bool) {
+	cfg := config.Get().Auth
+	switch {
+	case user.IsAdmin && cfg.Enforce2FAForAdmins:
+		return "admin", true
+	case user.IsStaff && cfg.Enforce2FAForStaff:
+		return "staff", true
+	}
+	return "", false
+}
+
+// mfaRequired answers a login refused by the 2FA policy. Its enrollment token lets the user
+// enroll TOTP, but nothing else.
+func mfaRequired(user *domain.User, role string) error {
+	log.Printf("[AUTH] Login refused for user '%s': %s accounts must enroll TOTP", user.Username, role)
+	metrics.RecordAuthAttempt(false)
+	metrics.RecordMFAEnforcementBlock(role)
+
+	res := &auth.MFARequired{Code: "MFA_REQUIRED", EnrollmentURL: totpEnrollmentURL}
+	token, err := util.GeneratePurposeToken(user, util.TokenPurposeTOTPEnrollment, totpEnrollmentTTL)
+	if err != nil {
+		log.Printf("[AUTH] Failed to generate enrollment token for '%s': %v", user.Username, err)
+	} else {
+		res.EnrollmentToken = &token
+	}
+	return res
+}
+
+// requiresScope reports whether schema requires scope
+func requiresScope(schema *security.JWTScheme, scope string) bool {
+	return schema != nil && slices.Contains(schema.RequiredScopes, scope)
+}
+
+// totpChallenge answers a login whose password was right but still needs a second factor
+func totpChallenge(user *domain.User) (*auth.Loginresult, error) {
+	token, err := util.GeneratePurposeToken(user, util.TokenPurposeTOTPChallenge, totpChallengeTTL)
+	if err != nil {
+		log.Printf("[AUTH] Login failed: challenge token error for user '%s': %v", user.Username, err)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	log.Printf("[AUTH] Login for user '%s' needs a TOTP code", user.Username)
+	required := true
+	return &auth.Loginresult{
+		TokenType:      "bearer",
+		TotpRequired:   &required,
+		ChallengeToken: &token,
+	}, nil
+}
+
+// VerifyTotp implements the verify totp method.
+// The code is checked against the user's authenticator first, then against their unused backup codes.
+func (s *AuthService) VerifyTotp(ctx context.Context, p *auth.VerifyTOTPPayload) (*auth.Loginresult, error) {
+	claims, err := util.ValidatePurposeToken(p.ChallengeToken, util.TokenPurposeTOTPChallenge)
+	if err != nil {
+		metrics.RecordAuthAttempt(false)
+		return nil, auth.MakeUnauthorized(fmt.Errorf("invalid or expired challenge. Please log in again"))
+	}
+
+	var user domain.User
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			metrics.RecordAuthAttempt(false)
+			return nil, auth.MakeUnauthorized(fmt.Errorf("invalid or expired challenge. Please log in again"))
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive || !user.TOTPEnabled {
+		metrics.RecordAuthAttempt(false)
+		return nil, auth.MakeUnauthorized(fmt.Errorf("invalid or expired challenge. Please log in again"))
+	}
+	// A challenge token doesn't scope the request, so audit entries take the user's organization
+	ctx = WithOrganization(ctx, user.OrganizationID)
+
+	if !s.totpLimiter.Allow(strconv.FormatUint(uint64(user.ID), 10)) {
+		log.Printf("[AUTH] VerifyTOTP rate limited for user '%s'", user.Username)
+		return nil, auth.MakeTooManyRequests(fmt.Errorf("too many attempts. Please try again in a few minutes"))
+	}
+
+	ok, err := s.checkSecondFactor(ctx, &user, p.Code)
+	if err != nil {
+		log.Printf("[AUTH] VerifyTOTP failed for user '%s': %v", user.Username, err)
+		return nil, err
+	}
+	if !ok {
+		log.Printf("[AUTH] VerifyTOTP failed: wrong code for user '%s'", user.Username)
+		metrics.RecordAuthAttempt(false)
+		return nil, auth.MakeUnauthorized(fmt.Errorf("invalid code"))
+	}
+
+	return s.completeLogin(ctx, &user)
+}
+
+// checkSecondFactor reports whether code is the user's current TOTP code or one of their
+// unused backup codes, using up whichever matched
+func (s *AuthService) checkSecondFactor(ctx context.Context, user *domain.User, code string) (bool, error) {
+	secret, err := util.OpenTOTPSecret(user.TOTPSecret)
+	if err != nil {
+		return false, fmt.Errorf("failed to read TOTP secret: %w", err)
+	}
+	step, ok := util.ValidateTOTP(secret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return s.ConsumeBackupCode(ctx, user, code)
+	}
+
+	// The step condition stops a code being replayed by a concurrent login
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	result := s.db.WithContext(qctx).Model(&domain.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record TOTP code: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// EnrollTotp implements the enroll totp method.
+// Enrolling again before confirming replaces the pending secret.
+func (s *AuthService) EnrollTotp(ctx context.Context, p *auth.TOTPEnrollPayload) (*auth.Totpenrollmentresult, error) {
+	currentUser := ctx.Value("user").(*domain.User)
+	log.Printf("[AUTH] EnrollTOTP request: user '%s'", currentUser.Username)
+
+	if currentUser.TOTPEnabled {
+		return nil, auth.MakeBadRequest(fmt.Errorf("TOTP is already enabled for this account"))
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := util.SealTOTPSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Model(currentUser).Updates(map[string]any{"totp_secret": sealed, "totp_last_step": 0}).Error; err != nil {
+		log.Printf("[AUTH] EnrollTOTP failed for '%s': %v", currentUser.Username, err)
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	return &auth.Totpenrollmentresult{
+		Secret:     secret,
+		OtpauthURL: util.TOTPURI(config.Get().App.Name, currentUser.Username, secret),
+	}, nil
+}
+
+// ConfirmTotp implements the confirm totp method
+func (s *AuthService) ConfirmTotp(ctx context.Context, p *auth.TOTPConfirmPayload) (*auth.Totpconfirmresult, error) {
+	currentUser := ctx.Value("user").(*domain.User)
+	log.Printf("[AUTH] ConfirmTOTP request: user '%s'", currentUser.Username)
+
+	if currentUser.TOTPEnabled {
+		return nil, auth.MakeBadRequest(fmt.Errorf("TOTP is already enabled for this account"))
+	}
+	if currentUser.TOTPSecret == "" {
+		return nil, auth.MakeBadRequest(fmt.Errorf("start TOTP enrollment first"))
+	}
+
+	secret, err := util.OpenTOTPSecret(currentUser.TOTPSecret)
+	if err != nil {
+		log.Printf("[AUTH] ConfirmTOTP failed for '%s': %v", currentUser.Username, err)
+		return nil, fmt.Errorf("failed to read TOTP secret: %w", err)
+	}
+	step, ok := util.ValidateTOTP(secret, p.Code, time.Now(), currentUser.TOTPLastStep)
+	if !ok {
+		log.Printf("[AUTH] ConfirmTOTP failed: wrong code for '%s'", currentUser.Username)
+		return nil, auth.MakeBadRequest(fmt.Errorf("invalid code. Check the time on your device and try again"))
+	}
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	err = s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(currentUser).Updates(map[string]any{"totp_enabled": true, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, currentUser, auditActionTOTPEnabled, domain.AuditResourceUser, currentUser.ID, "")
+	})
+	if err != nil {
+		log.Printf("[AUTH] ConfirmTOTP failed for '%s': %v", currentUser.Username, err)
+		return nil, fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+
+	log.Printf("[AUTH] TOTP enabled for '%s'", currentUser.Username)
+	return &auth.Totpconfirmresult{
+		Message: "Two-factor authentication is on. Generate backup codes in case you lose your authenticator",
+	}, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"springstreet/gen/auth"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+)
+
+// newTOTPUser stores a user with TOTP enabled and returns them with their secret
+func newTOTPUser(t *testing.T, s *AuthService) (*domain.User, string) {
+	t.Helper()
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	sealed, err := util.SealTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("SealTOTPSecret: %v", err)
+	}
+	user := createTestUser(t, s.db, domain.User{Username: "alice", TOTPSecret: sealed, TOTPEnabled: true}, "correct horse")
+	return user, secret
+}
+
+// loginChallenge logs in with the right password and returns the challenge token
+func loginChallenge(t *testing.T, s *AuthService) string {
+	t.Helper()
+	res, err := s.Login(context.Background(), &auth.LoginPayload{Username: "alice", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if res.AccessToken != nil || res.TotpRequired == nil || !*res.TotpRequired || res.ChallengeToken == nil {
+		t.Fatalf("Login = %+v, want a TOTP challenge and no access token", res)
+	}
+	return *res.ChallengeToken
+}
+
+func TestLoginWithTOTPNeedsChallenge(t *testing.T) {
+	s := NewAuthService(newTestDB(t), time.Minute)
+	_, secret := newTOTPUser(t, s)
+	challenge := loginChallenge(t, s)
+
+	// The challenge token isn't an access token
+	if _, err := util.ValidateToken(challenge); err == nil {
+		t.Fatal("challenge token was accepted as an access token")
+	}
+
+	_, err := s.VerifyTotp(context.Background(), &auth.VerifyTOTPPayload{ChallengeToken: challenge, Code: "000000"})
+	assertServiceError(t, err, "unauthorized")
+
+	code, _ := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	res, err := s.VerifyTotp(context.Background(), &auth.VerifyTOTPPayload{ChallengeToken: challenge, Code: code})
+	if err != nil {
+		t.Fatalf("VerifyTotp: %v", err)
+	}
+	if res.AccessToken == nil {
+		t.Fatal("VerifyTotp returned no access token")
+	}
+	if _, err := util.ValidateToken(*res.AccessToken); err != nil {
+		t.Fatalf("access token is invalid: %v", err)
+	}
+
+	// The same code can't be used for a second login
+	_, err = s.VerifyTotp(context.Background(), &auth.VerifyTOTPPayload{ChallengeToken: loginChallenge(t, s), Code: code})
+	assertServiceError(t, err, "unauthorized")
+}
+
+func TestVerifyTOTPAcceptsBackupCodeOnce(t *testing.T) {
+	s := NewAuthService(newTestDB(t), time.Minute)
+	user, _ := newTOTPUser(t, s)
+
+	codes, err := s.GenerateBackupCodes(asUser(context.Background(), user), &auth.BackupCodesPayload{})
+	if err != nil {
+		t.Fatalf("GenerateBackupCodes: %v", err)
+	}
+	backupCode := codes.Codes[0]
+	usedBefore := metricValue(t, "backup_codes_used_total", nil)
+
+	res, err := s.VerifyTotp(context.Background(), &auth.VerifyTOTPPayload{ChallengeToken: loginChallenge(t, s), Code: backupCode})
+	if err != nil {
+		t.Fatalf("VerifyTotp with a backup code: %v", err)
+	}
+	if res.AccessToken == nil {
+		t.Fatal("VerifyTotp returned no access token")
+	}
+
+	var used int64
+	s.db.Model(&domain.BackupCode{}).Where("user_id = ? AND used = ?", user.ID, true).Count(&used)
+	if used != 1 {
+		t.Fatalf("used backup codes = %d, want 1", used)
+	}
+	if got := metricValue(t, "backup_codes_used_total", nil); got != usedBefore+1 {
+		t.Fatalf("backup_codes_used_total = %v, want %v", got, usedBefore+1)
+	}
+
+	_, err = s.VerifyTotp(context.Background(), &auth.VerifyTOTPPayload{ChallengeToken: loginChallenge(t, s), Code: backupCode})
+	assertServiceError(t, err, "unauthorized")
+}
+
+func TestEnrollAndConfirmTOTP(t *testing.T) {
+	s := NewAuthService(newTestDB(t), time.Minute)
+	user := createTestUser(t, s.db, domain.User{Username: "bob"}, "correct horse")
+	ctx := asUser(context.Background(), user)
+
+	enrollment, err := s.EnrollTotp(ctx, &auth.TOTPEnrollPayload{})
+	if err != nil {
+		t.Fatalf("EnrollTotp: %v", err)
+	}
+
+	// Login doesn't ask for a code until enrollment is confirmed
+	res, err := s.Login(context.Background(), &auth.LoginPayload{Username: "bob", Password: "correct horse"})
+	if err != nil || res.AccessToken == nil {
+		t.Fatalf("Login before confirming = %+v, %v, want an access token", res, err)
+	}
+
+	_, err = s.ConfirmTotp(ctx, &auth.TOTPConfirmPayload{Code: "000000"})
+	assertServiceError(t, err, "bad_request")
+
+	code, _ := util.TOTPCode(enrollment.Secret, util.TOTPStep(time.Now()))
+	if _, err := s.ConfirmTotp(ctx, &auth.TOTPConfirmPayload{Code: code}); err != nil {
+		t.Fatalf("ConfirmTotp: %v", err)
+	}
+
+	res, err = s.Login(context.Background(), &auth.LoginPayload{Username: "bob", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if res.AccessToken != nil || res.ChallengeToken == nil {
+		t.Fatalf("Login after confirming = %+v, want a TOTP challenge", res)
+	}
+}
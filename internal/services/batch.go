@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+)
+
+const (
+	// BatchPath runs several API calls in one HTTP round trip
+	BatchPath = "/api/v1/batch"
+
+	maxBatchRequests   = 10
+	batchRequestsPerIP = 10 // Batch calls allowed per IP per minute
+)
+
+// batchForwardedHeaders are copied from the batch request to each sub-request so
+// authentication and per-IP limits apply as if the calls were made separately
+var batchForwardedHeaders = []string{"Authorization", "X-Forwarded-For", "X-Real-IP", "User-Agent"}
+
+var batchMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// batchSubRequest is one entry of a batch request
+type batchSubRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"` // May include a query string
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// batchSubResponse is the result of one sub-request. JSON bodies are embedded as is;
+// anything else is returned as a string.
+type batchSubResponse struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+}
+
+// BatchMiddleware serves POST requests to BatchPath by running each sub-request against next,
+// in order, and returning their responses as a JSON array. Sub-requests for BatchPath or any
+// of the excluded paths are rejected before anything runs.
+func BatchMiddleware(next http.Handler, excluded ...string) http.Handler {
+	blocked := map[string]bool{BatchPath: true}
+	for _, p := range excluded {
+		blocked[p] = true
+	}
+	limiter := util.NewRateLimiter(batchRequestsPerIP, time.Minute)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != BatchPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if ip := requestIP(r); ip != "" && !limiter.Allow(ip) {
+			log.Printf("[BATCH] Rejected: rate limit exceeded for %s", ip)
+			http.Error(w, "rate limit exceeded: maximum 10 batch requests per minute", http.StatusTooManyRequests)
+			return
+		}
+
+		var subRequests []batchSubRequest
+		if err := json.NewDecoder(r.Body).Decode(&subRequests); err != nil {
+			http.Error(w, "request body must be a JSON array of {method, path, body} objects", http.StatusBadRequest)
+			return
+		}
+		if len(subRequests) == 0 || len(subRequests) > maxBatchRequests {
+			http.Error(w, "a batch must contain between 1 and 10 requests", http.StatusBadRequest)
+			return
+		}
+
+		targets := make([]*url.URL, len(subRequests))
+		for i, sub := range subRequests {
+			target, err := url.Parse(sub.Path)
+			if err != nil || !strings.HasPrefix(target.Path, "/") || target.Host != "" {
+				http.Error(w, "request "+strconv.Itoa(i)+": path must be an absolute API path", http.StatusBadRequest)
+				return
+			}
+			// Run the cleaned path so the handler serves exactly the path that was checked
+			target.Path, target.RawPath = path.Clean(target.Path), ""
+			if blocked[target.Path] {
+				http.Error(w, "request "+strconv.Itoa(i)+": "+target.Path+" cannot be called from a batch", http.StatusBadRequest)
+				return
+			}
+			if !batchMethods[strings.ToUpper(sub.Method)] {
+				http.Error(w, "request "+strconv.Itoa(i)+": unsupported method "+sub.Method, http.StatusBadRequest)
+				return
+			}
+			targets[i] = target
+		}
+
+		log.Printf("[BATCH] Running %d requests from %s", len(subRequests), r.RemoteAddr)
+		responses := make([]batchSubResponse, len(subRequests))
+		for i, sub := range subRequests {
+			responses[i] = runBatchSubRequest(next, r, strings.ToUpper(sub.Method), targets[i], sub.Body)
+			metrics.RecordBatchSubrequest(responses[i].StatusCode)
+		}
+
+		body, err := json.Marshal(responses)
+		if err != nil {
+			log.Printf("[BATCH] Failed to encode responses: %v", err)
+			http.Error(w, "failed to encode batch response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
+// runBatchSubRequest serves a single sub-request of parent and records its response
+func runBatchSubRequest(handler http.Handler, parent *http.Request, method string, target *url.URL, body json.RawMessage) batchSubResponse {
+	req := httptest.NewRequest(method, target.RequestURI(), bytes.NewReader(body)).WithContext(parent.Context())
+	req.RemoteAddr = parent.RemoteAddr
+	for _, name := range batchForwardedHeaders {
+		if value := parent.Header.Get(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	headers := make(map[string]string, len(rec.Header()))
+	for name, values := range rec.Header() {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	respBody := rec.Body.Bytes()
+	if !json.Valid(respBody) {
+		respBody, _ = json.Marshal(string(respBody))
+	}
+	return batchSubResponse{
+		StatusCode: rec.Code,
+		Headers:    headers,
+		Body:       respBody,
+	}
+}
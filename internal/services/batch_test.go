@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newBatchHandler wraps a handler that echoes each request's method, path and forwarded
+// headers in BatchMiddleware with /metrics excluded
+func newBatchHandler() http.Handler {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method":        r.Method,
+			"path":          r.URL.Path,
+			"authorization": r.Header.Get("Authorization"),
+			"forwarded_for": r.Header.Get("X-Forwarded-For"),
+		})
+	})
+	return BatchMiddleware(echo, "/metrics")
+}
+
+// postBatch posts body to BatchPath from a client connecting from ip, which also names itself
+// in X-Forwarded-For
+func postBatch(handler http.Handler, ip, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, BatchPath, strings.NewReader(body))
+	req.RemoteAddr = ip + ":4000"
+	req.Header.Set("X-Forwarded-For", ip)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBatchRunsSubRequestsWithForwardedHeaders(t *testing.T) {
+	body := `[{"method": "get", "path": "/api/v1/auth/me"}, {"method": "POST", "path": "/api/v1/contact/", "body": {"name": "x"}}]`
+	rec := postBatch(newBatchHandler(), "203.0.113.1", body, map[string]string{"Authorization": "Bearer token"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s), want 200", rec.Code, rec.Body)
+	}
+
+	var responses []struct {
+		StatusCode int               `json:"status_code"`
+		Body       map[string]string `json:"body"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &responses); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []map[string]string{
+		{"method": "GET", "path": "/api/v1/auth/me"},
+		{"method": "POST", "path": "/api/v1/contact"},
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("got %d responses, want %d", len(responses), len(want))
+	}
+	for i, resp := range responses {
+		if resp.StatusCode != http.StatusOK || resp.Body["method"] != want[i]["method"] || resp.Body["path"] != want[i]["path"] {
+			t.Errorf("response %d = %d %v, want 200 %v", i, resp.StatusCode, resp.Body, want[i])
+		}
+		if resp.Body["authorization"] != "Bearer token" || resp.Body["forwarded_for"] != "203.0.113.1" {
+			t.Errorf("response %d: sub-request headers = %v, want Authorization and X-Forwarded-For forwarded", i, resp.Body)
+		}
+	}
+}
+
+func TestBatchRejectsBlockedPaths(t *testing.T) {
+	handler := newBatchHandler()
+	paths := []string{
+		BatchPath,
+		BatchPath + "/",
+		"/api/v1//batch",
+		"/api/v1/./batch",
+		"/api/v1/../v1/batch",
+		"/api/v1/auth/../batch",
+		"/api/v1/%62atch",
+		"/metrics",
+		"/metrics?format=json",
+		"/api/../metrics",
+		"http://example.com/api/v1/auth/me",
+		"api/v1/auth/me",
+	}
+	for i, p := range paths {
+		body := fmt.Sprintf(`[{"method": "GET", "path": "/api/v1/auth/me"}, {"method": "GET", "path": %q}]`, p)
+		if rec := postBatch(handler, fmt.Sprintf("203.0.113.%d", 10+i), body, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("sub-request for %s: status = %d, want 400", p, rec.Code)
+		}
+	}
+}
+
+func TestBatchLimitsRequestCount(t *testing.T) {
+	handler := newBatchHandler()
+	batch := func(n int) string {
+		subRequests := make([]string, n)
+		for i := range subRequests {
+			subRequests[i] = `{"method": "GET", "path": "/api/v1/auth/me"}`
+		}
+		return "[" + strings.Join(subRequests, ",") + "]"
+	}
+
+	if rec := postBatch(handler, "203.0.113.2", batch(10), nil); rec.Code != http.StatusOK {
+		t.Errorf("10 sub-requests: status = %d, want 200", rec.Code)
+	}
+	if rec := postBatch(handler, "203.0.113.2", batch(11), nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("11 sub-requests: status = %d, want 400", rec.Code)
+	}
+	if rec := postBatch(handler, "203.0.113.2", batch(0), nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("no sub-requests: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestBatchRateLimitsPerIP(t *testing.T) {
+	handler := newBatchHandler()
+	body := `[{"method": "GET", "path": "/api/v1/auth/me"}]`
+	for i := 0; i < batchRequestsPerIP; i++ {
+		if rec := postBatch(handler, "203.0.113.3", body, nil); rec.Code != http.StatusOK {
+			t.Fatalf("batch %d: status = %d, want 200", i+1, rec.Code)
+		}
+	}
+	if rec := postBatch(handler, "203.0.113.3", body, nil); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("batch %d: status = %d, want 429", batchRequestsPerIP+1, rec.Code)
+	}
+	// Other clients keep their own allowance
+	if rec := postBatch(handler, "203.0.113.4", body, nil); rec.Code != http.StatusOK {
+		t.Errorf("batch from another IP: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestBatchPassesOtherRequestsThrough(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", bytes.NewReader(nil))
+	rec := httptest.NewRecorder()
+	newBatchHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"path":"/api/v1/auth/me"`) {
+		t.Errorf("GET /api/v1/auth/me = %d %s, want it served by the wrapped handler", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+)
+
+// External providers with their own circuit breaker
+const (
+	providerTwilioSMS      = "twilio_sms"
+	providerTwilioVerify   = "twilio_verify"
+	providerMSG91          = "msg91"
+	providerTwilioWhatsApp = "twilio_whatsapp"
+	providerMetaWhatsApp   = "meta_whatsapp"
+	providerSlack          = "slack"
+	providerWebhook        = "webhook"
+	providerHubSpot        = "hubspot"
+	providerHCaptcha       = "hcaptcha"
+	providerTurnstile      = "turnstile"
+	providerSendGrid       = "sendgrid"
+	providerSES            = "ses"
+)
+
+var (
+	providerBreakers   = make(map[string]*util.CircuitBreaker)
+	providerBreakersMu sync.Mutex
+)
+
+// providerBreaker returns the circuit breaker for an external provider, creating it on first use
+func providerBreaker(provider string) *util.CircuitBreaker {
+	providerBreakersMu.Lock()
+	defer providerBreakersMu.Unlock()
+
+	if cb, ok := providerBreakers[provider]; ok {
+		return cb
+	}
+
+	cfg := config.Get().Breaker
+	cb := util.NewCircuitBreaker(provider, cfg.FailureThreshold, time.Duration(cfg.ResetTimeoutSec)*time.Second, cfg.HalfOpenProbes)
+	cb.OnStateChange(func(name string, state util.CircuitState) {
+		log.Printf("[BREAKER] %s circuit is now %s", name, state)
+		metrics.SetCircuitBreakerState(name, int(state))
+	})
+	metrics.SetCircuitBreakerState(provider, int(util.CircuitClosed))
+	providerBreakers[provider] = cb
+	return cb
+}
+
+// doWithBreaker sends req through the provider's circuit breaker.
+// Transport errors and 5xx responses count as failures; other responses are returned as-is
+// for the caller to interpret. While the circuit is open the request is not sent.
+func doWithBreaker(provider string, client *http.Client, req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := providerBreaker(provider).Execute(func() error {
+		var err error
+		resp, err = client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s returned status %d", provider, resp.StatusCode)
+		}
+		return nil
+	})
+	if resp != nil {
+		return resp, nil
+	}
+	if errors.Is(err, util.ErrCircuitOpen) {
+		log.Printf("[BREAKER] Skipping %s request: circuit is open", provider)
+	}
+	return nil, err
+}
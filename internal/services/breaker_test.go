@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"springstreet/internal/util"
+)
+
+func TestDoWithBreakerOpensOnServerErrors(t *testing.T) {
+	t.Setenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD", "2")
+	t.Setenv("CIRCUIT_BREAKER_RESET_SECONDS", "60")
+	loadTestConfig(t)
+	resetProviderBreakers(t)
+
+	var requests, status atomic.Int32
+	status.Store(http.StatusBadRequest)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(ts.Close)
+
+	send := func() (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, nil)
+		resp, err := doWithBreaker(providerTwilioSMS, http.DefaultClient, req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+	state := func() float64 {
+		return metricValue(t, "circuit_breaker_state", map[string]string{"provider": providerTwilioSMS})
+	}
+
+	// Client errors are the caller's problem, not an outage
+	for range 3 {
+		if resp, err := send(); err != nil || resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("send = %v, %v, want the 400 response", resp, err)
+		}
+	}
+	if state() != float64(util.CircuitClosed) {
+		t.Fatal("circuit opened on 4xx responses")
+	}
+
+	status.Store(http.StatusServiceUnavailable)
+	send()
+	send()
+	if state() != float64(util.CircuitOpen) {
+		t.Fatalf("circuit_breaker_state = %v after 2 server errors, want open", state())
+	}
+
+	before := requests.Load()
+	if _, err := send(); !errors.Is(err, util.ErrCircuitOpen) {
+		t.Fatalf("send while open = %v, want ErrCircuitOpen", err)
+	}
+	if requests.Load() != before {
+		t.Fatal("the request was sent while the circuit was open")
+	}
+}
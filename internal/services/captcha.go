@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/metrics"
+)
+
+// Siteverify endpoints of the supported CAPTCHA providers
+var (
+	hcaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+const captchaRequestTimeout = 5 * time.Second
+
+// errCaptchaRequired is returned when CAPTCHA is enabled and the request carries no token
+var errCaptchaRequired = errors.New("captcha_token is required")
+
+// CaptchaValidator verifies the token a CAPTCHA widget issued to the browser
+type CaptchaValidator interface {
+	// Validate returns an error unless token is a valid, unused token for clientIP
+	Validate(token, clientIP string) error
+}
+
+// NewCaptchaValidator returns the validator for cfg.Provider
+func NewCaptchaValidator(cfg *config.CaptchaConfig) CaptchaValidator {
+	if cfg.Provider == "turnstile" {
+		return NewTurnstileValidator(cfg.SecretKey)
+	}
+	return NewHCaptchaValidator(cfg.SecretKey)
+}
+
+// HCaptchaValidator verifies hCaptcha tokens
+type HCaptchaValidator struct {
+	verifier siteverifyClient
+}
+
+// NewHCaptchaValidator creates an hCaptcha validator for the site secret
+func NewHCaptchaValidator(secret string) *HCaptchaValidator {
+	return &HCaptchaValidator{verifier: newSiteverifyClient(providerHCaptcha, hcaptchaVerifyURL, secret)}
+}
+
+// Validate implements CaptchaValidator
+func (v *HCaptchaValidator) Validate(token, clientIP string) error {
+	return v.verifier.verify(token, clientIP)
+}
+
+// TurnstileValidator verifies Cloudflare Turnstile tokens
+type TurnstileValidator struct {
+	verifier siteverifyClient
+}
+
+// NewTurnstileValidator creates a Turnstile validator for the site secret
+func NewTurnstileValidator(secret string) *TurnstileValidator {
+	return &TurnstileValidator{verifier: newSiteverifyClient(providerTurnstile, turnstileVerifyURL, secret)}
+}
+
+// Validate implements CaptchaValidator
+func (v *TurnstileValidator) Validate(token, clientIP string) error {
+	return v.verifier.verify(token, clientIP)
+}
+
+// siteverifyClient calls a siteverify endpoint. hCaptcha and Turnstile share the same
+// form-encoded request and JSON response, so only the URL differs.
+type siteverifyClient struct {
+	provider string
+	url      string
+	secret   string
+	client   *http.Client
+}
+
+func newSiteverifyClient(provider, verifyURL, secret string) siteverifyClient {
+	return siteverifyClient{
+		provider: provider,
+		url:      verifyURL,
+		secret:   secret,
+		client:   &http.Client{Timeout: captchaRequestTimeout},
+	}
+}
+
+// siteverifyResponse is the part of a siteverify response we use
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (c siteverifyClient) verify(token, clientIP string) error {
+	form := url.Values{}
+	form.Set("secret", c.secret)
+	form.Set("response", token)
+	if clientIP != "" {
+		form.Set("remoteip", clientIP)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", c.provider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := doWithBreaker(c.provider, c.client, req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", c.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s siteverify returned status %d", c.provider, resp.StatusCode)
+	}
+	var result siteverifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", c.provider, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("captcha verification failed (%s)", strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
+
+// checkCaptcha validates token when the captcha feature is on and does nothing otherwise,
+// so clients that don't send a token keep working until CAPTCHA_ENABLED is set. Provider
+// outages fail closed: a token that can't be checked is rejected.
+func checkCaptcha(validator CaptchaValidator, token *string, clientIP string) error {
+	if !config.FeatureFlag(config.FeatureCaptcha) {
+		return nil
+	}
+	provider := config.Get().Captcha.Provider
+
+	if token == nil || strings.TrimSpace(*token) == "" {
+		metrics.RecordCaptchaValidation(provider, "missing")
+		return errCaptchaRequired
+	}
+	if err := validator.Validate(strings.TrimSpace(*token), clientIP); err != nil {
+		log.Printf("[CAPTCHA] Validation failed for %s: %v", clientIP, err)
+		metrics.RecordCaptchaValidation(provider, "failed")
+		return errors.New("captcha verification failed")
+	}
+	metrics.RecordCaptchaValidation(provider, "success")
+	return nil
+}
@@ -2,16 +2,22 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"regexp"
 	"strings"
+	"time"
 
 	"goa.design/goa/v3/security"
 	"gorm.io/gorm"
 
 	"springstreet/gen/contact"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
 	"springstreet/internal/domain"
 	"springstreet/internal/metrics"
 	"springstreet/internal/util"
@@ -21,13 +27,25 @@ import (
 type ContactService struct {
 	db           *gorm.DB
 	emailService *EmailService
+	notifier     *Notifier
+	cfg          *config.ContactConfig
+	ipLimiter    *util.RateLimiter
+	emailLimiter *util.RateLimiter
+	captcha      CaptchaValidator
+	queryTimeout time.Duration
 }
 
 // NewContactService creates a new contact service
-func NewContactService(db *gorm.DB, emailService *EmailService) *ContactService {
+func NewContactService(db *gorm.DB, emailService *EmailService, notifier *Notifier, cfg *config.ContactConfig, captcha CaptchaValidator, queryTimeout time.Duration) *ContactService {
 	return &ContactService{
 		db:           db,
 		emailService: emailService,
+		notifier:     notifier,
+		cfg:          cfg,
+		ipLimiter:    util.NewRateLimiter(cfg.RateLimitPerIP, time.Hour),
+		emailLimiter: util.NewRateLimiter(cfg.RateLimitPerEmail, time.Hour),
+		captcha:      captcha,
+		queryTimeout: queryTimeout,
 	}
 }
 
@@ -41,7 +59,8 @@ func (s *ContactService) JWTAuth(ctx context.Context, token string, schema *secu
 
 	// Get user from database
 	var user domain.User
-	if err := s.db.Where("username = ?", claims.Username).First(&user).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, contact.MakeUnauthorized(fmt.Errorf("user not found"))
 		}
@@ -53,6 +72,11 @@ func (s *ContactService) JWTAuth(ctx context.Context, token string, schema *secu
 		return nil, contact.MakeUnauthorized(fmt.Errorf("user account is inactive"))
 	}
 
+	// Check the user belongs to the request's organization
+	if !inRequestOrganization(ctx, &user) {
+		return nil, contact.MakeUnauthorized(fmt.Errorf("user does not belong to this organization"))
+	}
+
 	// Check scopes if required
 	if schema != nil && len(schema.RequiredScopes) > 0 {
 		hasScope := false
@@ -85,14 +109,62 @@ func (s *ContactService) Submit(ctx context.Context, p *contact.ContactSubmitPay
 		log.Printf("[CONTACT] Submit failed: validation error: %v", err)
 		return nil, contact.MakeBadRequest(err)
 	}
+	if err := checkCaptcha(s.captcha, p.CaptchaToken, clientIP(ctx)); err != nil {
+		log.Printf("[CONTACT] Submit failed: %v", err)
+		return nil, contact.MakeBadRequest(err)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(p.Email))
+	message := strings.TrimSpace(p.Message)
+	messageHash := contactMessageHash(message)
+
+	// A repeated submission (e.g. a double-click) returns the original inquiry without re-notifying
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if existing, err := s.findDuplicate(qctx, email, messageHash); err != nil {
+		log.Printf("[CONTACT] Submit failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to check for duplicate contact inquiry: %w", err)
+	} else if existing != nil {
+		log.Printf("[CONTACT] Submit duplicate of inquiry id=%d from email=%s, not storing again", existing.ID, email)
+		metrics.RecordContactDuplicate()
+		return &contact.Contactsubmitresult{
+			ID:      int(existing.ID),
+			Message: "Thank you for contacting us! We'll get back to you soon.",
+		}, nil
+	}
+
+	// Rate limit per IP and per email
+	if ip := clientIP(ctx); ip != "" && !s.ipLimiter.Allow(ip) {
+		log.Printf("[CONTACT] Submit failed: rate limit exceeded for ip=%s", ip)
+		return nil, submissionLimited(s.ipLimiter.RetryAfter(ip))
+	}
+	if !s.emailLimiter.Allow(email) {
+		log.Printf("[CONTACT] Submit failed: rate limit exceeded for email=%s", email)
+		return nil, submissionLimited(s.emailLimiter.RetryAfter(email))
+	}
 
 	// Create contact inquiry
 	inquiry := &domain.ContactInquiry{
-		Name:    strings.TrimSpace(p.Name),
-		Email:   strings.ToLower(strings.TrimSpace(p.Email)),
-		Message: strings.TrimSpace(p.Message),
-		Status:  "new",
+		Name:           strings.TrimSpace(p.Name),
+		Email:          email,
+		Category:       contactCategory(p.Category),
+		Message:        message,
+		Status:         "new",
+		OrganizationID: OrganizationFromContext(ctx),
+	}
+	inquiry.MessageHash = messageHash
+	if p.Subject != nil && strings.TrimSpace(*p.Subject) != "" {
+		subject := strings.TrimSpace(*p.Subject)
+		inquiry.Subject = &subject
+	}
+
+	// Flag spam: a filled honeypot is always spam, otherwise use the heuristic score
+	honeypot := p.Website != nil && strings.TrimSpace(*p.Website) != ""
+	if honeypot {
+		inquiry.SpamScore = maxSpamScore
+	} else {
+		inquiry.SpamScore = contactSpamScore(inquiry.Message, s.cfg)
 	}
+	inquiry.IsSpam = inquiry.SpamScore >= s.cfg.SpamScoreThreshold
 
 	// Add phone if provided
 	if p.Phone != nil && strings.TrimSpace(*p.Phone) != "" {
@@ -101,22 +173,31 @@ func (s *ContactService) Submit(ctx context.Context, p *contact.ContactSubmitPay
 	}
 
 	// Save to database
-	if err := s.db.Create(inquiry).Error; err != nil {
+	if err := s.db.WithContext(qctx).Create(inquiry).Error; err != nil {
 		log.Printf("[CONTACT] Submit failed: database error: %v", err)
 		return nil, fmt.Errorf("failed to save contact inquiry: %w", err)
 	}
 
-	log.Printf("[CONTACT] Submit successful: id=%d, name=%s, email=%s", inquiry.ID, inquiry.Name, inquiry.Email)
+	log.Printf("[CONTACT] Submit successful: id=%d, name=%s, email=%s, spam_score=%d", inquiry.ID, inquiry.Name, inquiry.Email, inquiry.SpamScore)
 	metrics.RecordContactSubmission()
 
-	// Send email notification to admin (async, don't fail if email fails)
-	go func() {
-		if err := s.sendContactNotification(inquiry); err != nil {
-			log.Printf("[CONTACT] Warning: failed to send notification email: %v", err)
-		} else {
-			log.Printf("[CONTACT] Notification email sent for inquiry id=%d", inquiry.ID)
-		}
-	}()
+	// Spam is stored for review but never notifies anyone; the caller sees a normal response
+	if inquiry.IsSpam {
+		log.Printf("[CONTACT] Inquiry id=%d flagged as spam, skipping notification", inquiry.ID)
+		metrics.RecordContactSpamBlocked(contactSpamReason(honeypot))
+		return &contact.Contactsubmitresult{
+			ID:      int(inquiry.ID),
+			Message: "Thank you for contacting us! We'll get back to you soon.",
+		}, nil
+	}
+
+	// Notify admin over the configured channels (async with retry, don't fail the request)
+	if s.notifier.ChannelEnabled(EventContactSubmitted, ChannelEmail) {
+		s.notifier.Deliver(ChannelEmail, fmt.Sprintf("contact inquiry id=%d", inquiry.ID), func() error {
+			return s.sendContactNotification(inquiry)
+		})
+	}
+	s.notifier.NotifyContactSubmitted(inquiry)
 
 	return &contact.Contactsubmitresult{
 		ID:      int(inquiry.ID),
@@ -126,7 +207,7 @@ func (s *ContactService) Submit(ctx context.Context, p *contact.ContactSubmitPay
 
 // List returns all contact inquiries (Staff/Admin only)
 func (s *ContactService) List(ctx context.Context, p *contact.ListContactInquiriesPayload) ([]*contact.Contactinquiryresult, error) {
-	log.Printf("[CONTACT] List request: skip=%d, limit=%d", p.Skip, p.Limit)
+	log.Printf("[CONTACT] List request: skip=%d, limit=%d, include_spam=%v", p.Skip, p.Limit, p.IncludeSpam)
 
 	var inquiries []domain.ContactInquiry
 
@@ -134,38 +215,568 @@ func (s *ContactService) List(ctx context.Context, p *contact.ListContactInquiri
 	skip := p.Skip
 	limit := p.Limit
 
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)).Order("created_at DESC")
+	if !p.IncludeSpam {
+		query = query.Where("is_spam = ?", false)
+	}
+	if p.Q != nil && strings.TrimSpace(*p.Q) != "" {
+		like := likeOperator(s.db)
+		pattern := "%" + strings.TrimSpace(*p.Q) + "%"
+		query = query.Where(
+			fmt.Sprintf("name %[1]s ? OR email %[1]s ? OR message %[1]s ?", like),
+			pattern, pattern, pattern,
+		)
+	}
+	if p.Status != nil {
+		query = query.Where("status = ?", *p.Status)
+	}
+	if p.Category != nil {
+		query = query.Where("category = ?", *p.Category)
+	}
+	if p.CreatedAfter != nil {
+		after, err := parseDateParam(*p.CreatedAfter)
+		if err != nil {
+			log.Printf("[CONTACT] List failed: invalid created_after: %v", err)
+			return nil, contact.MakeBadRequest(fmt.Errorf("invalid created_after: expected YYYY-MM-DD or RFC3339"))
+		}
+		query = query.Where("created_at >= ?", after)
+	}
+	if p.CreatedBefore != nil {
+		before, err := parseDateParam(*p.CreatedBefore)
+		if err != nil {
+			log.Printf("[CONTACT] List failed: invalid created_before: %v", err)
+			return nil, contact.MakeBadRequest(fmt.Errorf("invalid created_before: expected YYYY-MM-DD or RFC3339"))
+		}
+		query = query.Where("created_at < ?", before)
+	}
+	if p.UnreadOnly {
+		query = query.Where("first_read_at IS NULL")
+	}
+
 	// Query database
-	if err := s.db.Order("created_at DESC").Offset(skip).Limit(limit).Find(&inquiries).Error; err != nil {
+	if err := query.Offset(skip).Limit(limit).Find(&inquiries).Error; err != nil {
 		log.Printf("[CONTACT] List failed: database error: %v", err)
 		return nil, fmt.Errorf("failed to fetch contact inquiries: %w", err)
 	}
 
+	// Count notes for the returned page
+	ids := make([]uint, len(inquiries))
+	for i, inq := range inquiries {
+		ids[i] = inq.ID
+	}
+	noteCounts, err := s.noteCounts(ctx, ids)
+	if err != nil {
+		log.Printf("[CONTACT] List failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count contact notes: %w", err)
+	}
+
+	readers, err := s.readerUsernames(ctx, inquiries)
+	if err != nil {
+		log.Printf("[CONTACT] List failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch contact inquiry readers: %w", err)
+	}
+
 	// Convert to result type
 	results := make([]*contact.Contactinquiryresult, len(inquiries))
-	for i, inq := range inquiries {
-		createdAt := inq.CreatedAt.Format("2006-01-02T15:04:05Z")
-		var updatedAt *string
-		if inq.UpdatedAt != nil {
-			ua := inq.UpdatedAt.Format("2006-01-02T15:04:05Z")
-			updatedAt = &ua
+	for i := range inquiries {
+		results[i] = convertContactInquiryToResult(&inquiries[i], noteCounts[inquiries[i].ID], readers)
+	}
+
+	log.Printf("[CONTACT] List successful: returned %d inquiries", len(results))
+	return results, nil
+}
+
+// Get returns a single contact inquiry (Staff/Admin only). The first staff member to open it is
+// recorded as its reader; later reads leave that untouched.
+func (s *ContactService) Get(ctx context.Context, p *contact.GetContactInquiryPayload) (*contact.Contactinquiryresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] Get request: inquiry id=%d by user=%s", p.ID, user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	var inquiry domain.ContactInquiry
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx)).First(&inquiry, p.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[CONTACT] Get failed: inquiry id=%d not found", p.ID)
+			return nil, contact.MakeNotFound(fmt.Errorf("contact inquiry not found"))
 		}
+		log.Printf("[CONTACT] Get failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch contact inquiry: %w", err)
+	}
 
-		results[i] = &contact.Contactinquiryresult{
-			ID:        int(inq.ID),
-			Name:      inq.Name,
-			Email:     inq.Email,
-			Phone:     inq.Phone,
-			Message:   inq.Message,
-			Status:    inq.Status,
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
+	if inquiry.FirstReadAt == nil {
+		// The first_read_at IS NULL condition keeps the earliest reader when two staff open it at once
+		now := time.Now()
+		result := s.db.WithContext(qctx).Model(&domain.ContactInquiry{}).
+			Where("id = ? AND first_read_at IS NULL", inquiry.ID).
+			UpdateColumns(map[string]interface{}{"first_read_at": now, "first_read_by": user.ID})
+		if result.Error != nil {
+			log.Printf("[CONTACT] Get failed: database error: %v", result.Error)
+			return nil, fmt.Errorf("failed to mark contact inquiry as read: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			inquiry.FirstReadAt = &now
+			inquiry.FirstReadBy = &user.ID
+			metrics.RecordContactFirstRead()
+			log.Printf("[CONTACT] Inquiry id=%d first read by user=%s", inquiry.ID, user.Username)
+		} else if err := s.db.WithContext(qctx).First(&inquiry, inquiry.ID).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch contact inquiry: %w", err)
 		}
 	}
 
-	log.Printf("[CONTACT] List successful: returned %d inquiries", len(results))
+	counts, err := s.noteCounts(ctx, []uint{inquiry.ID})
+	if err != nil {
+		log.Printf("[CONTACT] Get failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count contact notes: %w", err)
+	}
+	readers, err := s.readerUsernames(ctx, []domain.ContactInquiry{inquiry})
+	if err != nil {
+		log.Printf("[CONTACT] Get failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch contact inquiry reader: %w", err)
+	}
+
+	log.Printf("[CONTACT] Get successful: inquiry id=%d", inquiry.ID)
+	return convertContactInquiryToResult(&inquiry, counts[inquiry.ID], readers), nil
+}
+
+// AddNote appends an internal note to a contact inquiry (Staff/Admin only)
+func (s *ContactService) AddNote(ctx context.Context, p *contact.AddContactNotePayload) (*contact.Contactnoteresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] AddNote request: inquiry id=%d by user=%s", p.ID, user.Username)
+
+	if err := s.ensureInquiryExists(ctx, p.ID); err != nil {
+		log.Printf("[CONTACT] AddNote failed: %v", err)
+		return nil, err
+	}
+
+	text := strings.TrimSpace(p.Text)
+	if text == "" {
+		return nil, contact.MakeBadRequest(fmt.Errorf("note text is required"))
+	}
+
+	note := &domain.ContactNote{
+		ContactInquiryID: uint(p.ID),
+		Author:           user.Username,
+		Text:             text,
+	}
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Create(note).Error; err != nil {
+		log.Printf("[CONTACT] AddNote failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to save contact note: %w", err)
+	}
+
+	log.Printf("[CONTACT] AddNote successful: note id=%d, inquiry id=%d", note.ID, note.ContactInquiryID)
+	return convertContactNoteToResult(note), nil
+}
+
+// ListNotes returns the notes on a contact inquiry, oldest first (Staff/Admin only)
+func (s *ContactService) ListNotes(ctx context.Context, p *contact.ListContactNotesPayload) ([]*contact.Contactnoteresult, error) {
+	log.Printf("[CONTACT] ListNotes request: inquiry id=%d", p.ID)
+
+	if err := s.ensureInquiryExists(ctx, p.ID); err != nil {
+		log.Printf("[CONTACT] ListNotes failed: %v", err)
+		return nil, err
+	}
+
+	var notes []domain.ContactNote
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := database.GetReadDB().WithContext(qctx).Where("contact_inquiry_id = ?", p.ID).Order("created_at ASC").Find(&notes).Error; err != nil {
+		log.Printf("[CONTACT] ListNotes failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch contact notes: %w", err)
+	}
+
+	results := make([]*contact.Contactnoteresult, len(notes))
+	for i := range notes {
+		results[i] = convertContactNoteToResult(&notes[i])
+	}
+
+	log.Printf("[CONTACT] ListNotes successful: returned %d notes", len(results))
 	return results, nil
 }
 
+// DeleteNote removes a note from a contact inquiry (Admin only)
+func (s *ContactService) DeleteNote(ctx context.Context, p *contact.DeleteContactNotePayload) error {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] DeleteNote request: note id=%d, inquiry id=%d by user=%s", p.NoteID, p.ID, user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.ensureInquiryExists(ctx, p.ID); err != nil {
+		log.Printf("[CONTACT] DeleteNote failed: %v", err)
+		return err
+	}
+
+	result := s.db.WithContext(qctx).Where("id = ? AND contact_inquiry_id = ?", p.NoteID, p.ID).Delete(&domain.ContactNote{})
+	if result.Error != nil {
+		log.Printf("[CONTACT] DeleteNote failed: database error: %v", result.Error)
+		return fmt.Errorf("failed to delete contact note: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("[CONTACT] DeleteNote failed: note id=%d not found", p.NoteID)
+		return contact.MakeNotFound(fmt.Errorf("contact note not found"))
+	}
+
+	log.Printf("[CONTACT] DeleteNote successful: note id=%d", p.NoteID)
+	return nil
+}
+
+// Delete soft-deletes a contact inquiry so it no longer appears in listings (Staff/Admin only).
+// The row is kept until Purge removes it.
+func (s *ContactService) Delete(ctx context.Context, p *contact.DeleteContactInquiryPayload) error {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] Delete request: inquiry id=%d by user=%s", p.ID, user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	result := scopeToOrganization(ctx, s.db.WithContext(qctx)).Delete(&domain.ContactInquiry{}, p.ID)
+	if result.Error != nil {
+		log.Printf("[CONTACT] Delete failed: database error: %v", result.Error)
+		return fmt.Errorf("failed to delete contact inquiry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("[CONTACT] Delete failed: inquiry id=%d not found", p.ID)
+		return contact.MakeNotFound(fmt.Errorf("contact inquiry not found"))
+	}
+
+	log.Printf("[CONTACT] Delete successful: inquiry id=%d", p.ID)
+	return nil
+}
+
+// BulkUpdateStatus moves up to 100 contact inquiries to a new status (Staff/Admin only).
+// Invalid transitions and unknown IDs are skipped; the rest are updated in one transaction.
+func (s *ContactService) BulkUpdateStatus(ctx context.Context, p *contact.BulkUpdateContactStatusPayload) (*contact.Bulkoperationresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] BulkUpdateStatus request: %d ids to status=%s by user=%s", len(p.Ids), p.Status, user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	inquiries, result, err := s.loadForBulk(ctx, p.Ids)
+	if err != nil {
+		log.Printf("[CONTACT] BulkUpdateStatus failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to load contact inquiries: %w", err)
+	}
+
+	var valid []*domain.ContactInquiry
+	for _, inquiry := range inquiries {
+		if !domain.CanTransitionContactStatus(inquiry.Status, p.Status) {
+			addBulkFailure(result, int(inquiry.ID), fmt.Sprintf("cannot change status from %s to %s", inquiry.Status, p.Status))
+			continue
+		}
+		valid = append(valid, inquiry)
+	}
+
+	err = s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if len(valid) == 0 {
+			return nil
+		}
+		if err := tx.Model(&domain.ContactInquiry{}).Where("id IN ?", inquiryIDs(valid)).Updates(map[string]any{
+			"status":     p.Status,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		for _, inquiry := range valid {
+			details := fmt.Sprintf("status %s -> %s (bulk)", inquiry.Status, p.Status)
+			if err := recordAudit(tx, user, "status_change", domain.AuditResourceContactInquiry, inquiry.ID, details); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[CONTACT] BulkUpdateStatus failed: database error, nothing changed: %v", err)
+		return nil, fmt.Errorf("failed to update contact inquiries: %w", err)
+	}
+
+	result.SuccessCount = len(valid)
+	log.Printf("[CONTACT] BulkUpdateStatus successful: updated=%d, failed=%d", result.SuccessCount, result.FailureCount)
+	return result, nil
+}
+
+// BulkDelete soft-deletes up to 100 contact inquiries in one transaction (Admin only)
+func (s *ContactService) BulkDelete(ctx context.Context, p *contact.BulkDeleteContactsPayload) (*contact.Bulkoperationresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] BulkDelete request: %d ids by user=%s", len(p.Ids), user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	inquiries, result, err := s.loadForBulk(ctx, p.Ids)
+	if err != nil {
+		log.Printf("[CONTACT] BulkDelete failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to load contact inquiries: %w", err)
+	}
+
+	err = s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if len(inquiries) == 0 {
+			return nil
+		}
+		if err := tx.Where("id IN ?", inquiryIDs(inquiries)).Delete(&domain.ContactInquiry{}).Error; err != nil {
+			return err
+		}
+		for _, inquiry := range inquiries {
+			if err := recordAudit(tx, user, "delete", domain.AuditResourceContactInquiry, inquiry.ID, "soft delete (bulk)"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[CONTACT] BulkDelete failed: database error, nothing changed: %v", err)
+		return nil, fmt.Errorf("failed to delete contact inquiries: %w", err)
+	}
+
+	result.SuccessCount = len(inquiries)
+	log.Printf("[CONTACT] BulkDelete successful: deleted=%d, failed=%d", result.SuccessCount, result.FailureCount)
+	return result, nil
+}
+
+// Purge permanently deletes contact inquiries soft-deleted more than older_than_days ago (Admin only)
+func (s *ContactService) Purge(ctx context.Context, p *contact.PurgeContactInquiriesPayload) (*contact.Purgecontactinquiriesresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[CONTACT] Purge request: older_than_days=%d by user=%s", p.OlderThanDays, user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	purged, err := purgeDeletedContactInquiries(s.db.WithContext(qctx), time.Duration(p.OlderThanDays)*24*time.Hour, func(query *gorm.DB) *gorm.DB {
+		return scopeToOrganization(ctx, query)
+	})
+	if err != nil {
+		log.Printf("[CONTACT] Purge failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to purge contact inquiries: %w", err)
+	}
+
+	log.Printf("[CONTACT] Purge successful: %d inquiries permanently deleted", purged)
+	return &contact.Purgecontactinquiriesresult{
+		Purged:  int(purged),
+		Message: fmt.Sprintf("Permanently deleted %d contact inquiries", purged),
+	}, nil
+}
+
+// PurgeDeletedContactInquiries permanently deletes contact inquiries (and their notes) that were
+// soft-deleted more than olderThan ago, in every organization. It is used by cmd/purge_contacts.
+func PurgeDeletedContactInquiries(db *gorm.DB, olderThan time.Duration) (int64, error) {
+	return purgeDeletedContactInquiries(db, olderThan, func(query *gorm.DB) *gorm.DB { return query })
+}
+
+// purgeDeletedContactInquiries is PurgeDeletedContactInquiries limited to the inquiries scope
+// selects, so the purge endpoint only touches the caller's organization
+func purgeDeletedContactInquiries(db *gorm.DB, olderThan time.Duration, scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	cutoff := time.Now().Add(-olderThan)
+	var purged int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := scope(tx.Unscoped().Model(&domain.ContactInquiry{})).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("contact_inquiry_id IN ?", ids).Delete(&domain.ContactNote{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&domain.ContactInquiry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+// findDuplicate returns the most recent inquiry from email with the same message hash
+// inside the duplicate window, or nil if there is none
+func (s *ContactService) findDuplicate(ctx context.Context, email, messageHash string) (*domain.ContactInquiry, error) {
+	if s.cfg.DuplicateWindowMin <= 0 {
+		return nil, nil
+	}
+
+	since := time.Now().Add(-time.Duration(s.cfg.DuplicateWindowMin) * time.Minute)
+	var existing domain.ContactInquiry
+	err := scopeToOrganization(ctx, s.db.WithContext(ctx)).
+		Where("email = ? AND message_hash = ? AND created_at >= ?", email, messageHash, since).
+		Order("created_at DESC").
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// submissionLimited is the too_many_requests error for a submission that may be retried after
+// retryAfter, rounded up to whole seconds for the Retry-After header
+func submissionLimited(retryAfter time.Duration) error {
+	return &contact.SubmissionLimited{
+		Message:    "too many submissions. Please try again later",
+		RetryAfter: max(1, int(math.Ceil(retryAfter.Seconds()))),
+	}
+}
+
+// contactMessageHash returns the hex SHA-256 of a message, ignoring surrounding whitespace
+func contactMessageHash(message string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(message)))
+	return hex.EncodeToString(sum[:])
+}
+
+// addBulkFailure records a skipped ID and the reason in a bulk operation result
+func addBulkFailure(result *contact.Bulkoperationresult, id int, reason string) {
+	result.FailureCount++
+	result.FailedIds = append(result.FailedIds, id)
+	result.Errors = append(result.Errors, reason)
+}
+
+// loadForBulk loads the (de-duplicated) inquiries for a bulk operation in request order.
+// IDs that do not exist, are already deleted or belong to another organization are recorded
+// as failures.
+func (s *ContactService) loadForBulk(ctx context.Context, ids []int) ([]*domain.ContactInquiry, *contact.Bulkoperationresult, error) {
+	result := &contact.Bulkoperationresult{FailedIds: []int{}, Errors: []string{}}
+
+	var found []domain.ContactInquiry
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx)).Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, result, err
+	}
+	byID := make(map[int]*domain.ContactInquiry, len(found))
+	for i := range found {
+		byID[int(found[i].ID)] = &found[i]
+	}
+
+	var inquiries []*domain.ContactInquiry
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		inquiry, ok := byID[id]
+		if !ok {
+			addBulkFailure(result, id, "contact inquiry not found")
+			continue
+		}
+		inquiries = append(inquiries, inquiry)
+	}
+	return inquiries, result, nil
+}
+
+// inquiryIDs returns the IDs of inquiries
+func inquiryIDs(inquiries []*domain.ContactInquiry) []uint {
+	ids := make([]uint, len(inquiries))
+	for i, inquiry := range inquiries {
+		ids[i] = inquiry.ID
+	}
+	return ids
+}
+
+// ensureInquiryExists returns not_found if the contact inquiry does not exist
+func (s *ContactService) ensureInquiryExists(ctx context.Context, id int) error {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	var count int64
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx).Model(&domain.ContactInquiry{})).Where("id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to fetch contact inquiry: %w", err)
+	}
+	if count == 0 {
+		return contact.MakeNotFound(fmt.Errorf("contact inquiry not found"))
+	}
+	return nil
+}
+
+// noteCounts returns the number of notes per contact inquiry ID
+func (s *ContactService) noteCounts(ctx context.Context, ids []uint) (map[uint]int, error) {
+	counts := make(map[uint]int, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ContactInquiryID uint
+		Count            int
+	}
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	err := database.GetReadDB().WithContext(qctx).Model(&domain.ContactNote{}).
+		Select("contact_inquiry_id, COUNT(*) AS count").
+		Where("contact_inquiry_id IN ?", ids).
+		Group("contact_inquiry_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ContactInquiryID] = row.Count
+	}
+	return counts, nil
+}
+
+// readerUsernames returns the usernames of the staff members who first read inquiries, by user ID
+func (s *ContactService) readerUsernames(ctx context.Context, inquiries []domain.ContactInquiry) (map[uint]string, error) {
+	var ids []uint
+	for _, inq := range inquiries {
+		if inq.FirstReadBy != nil {
+			ids = append(ids, *inq.FirstReadBy)
+		}
+	}
+	usernames := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return usernames, nil
+	}
+
+	var users []domain.User
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := database.GetReadDB().WithContext(qctx).Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+	}
+	return usernames, nil
+}
+
+// convertContactInquiryToResult converts a ContactInquiry model to ContactInquiryResult
+func convertContactInquiryToResult(inq *domain.ContactInquiry, notesCount int, readers map[uint]string) *contact.Contactinquiryresult {
+	result := &contact.Contactinquiryresult{
+		ID:         int(inq.ID),
+		Name:       inq.Name,
+		Email:      inq.Email,
+		Phone:      inq.Phone,
+		Subject:    inq.Subject,
+		Category:   inq.Category,
+		Message:    inq.Message,
+		Status:     inq.Status,
+		IsSpam:     inq.IsSpam,
+		SpamScore:  inq.SpamScore,
+		NotesCount: notesCount,
+		CreatedAt:  inq.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if inq.UpdatedAt != nil {
+		ua := inq.UpdatedAt.Format("2006-01-02T15:04:05Z")
+		result.UpdatedAt = &ua
+	}
+	if inq.FirstReadAt != nil {
+		ra := inq.FirstReadAt.Format("2006-01-02T15:04:05Z")
+		result.FirstReadAt = &ra
+	}
+	if inq.FirstReadBy != nil {
+		if username, ok := readers[*inq.FirstReadBy]; ok {
+			result.FirstReadByUsername = &username
+		}
+	}
+	return result
+}
+
+// convertContactNoteToResult converts a ContactNote model to ContactNoteResult
+func convertContactNoteToResult(note *domain.ContactNote) *contact.Contactnoteresult {
+	return &contact.Contactnoteresult{
+		ID:               int(note.ID),
+		ContactInquiryID: int(note.ContactInquiryID),
+		Author:           note.Author,
+		Text:             note.Text,
+		CreatedAt:        note.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
 // validateContactForm validates the contact form input
 func (s *ContactService) validateContactForm(p *contact.ContactSubmitPayload) error {
 	// Validate name
@@ -180,6 +791,17 @@ func (s *ContactService) validateContactForm(p *contact.ContactSubmitPayload) er
 	if !emailRegex.MatchString(email) {
 		return fmt.Errorf("invalid email address")
 	}
+	if err := checkDisposableEmail(email); err != nil {
+		return err
+	}
+
+	// Validate subject and category
+	if p.Subject != nil && len(strings.TrimSpace(*p.Subject)) > 200 {
+		return fmt.Errorf("subject must not exceed 200 characters")
+	}
+	if !isContactCategory(contactCategory(p.Category)) {
+		return fmt.Errorf("invalid category: must be one of %s", strings.Join(domain.ContactCategories, ", "))
+	}
 
 	// Validate message
 	message := strings.TrimSpace(p.Message)
@@ -189,6 +811,14 @@ func (s *ContactService) validateContactForm(p *contact.ContactSubmitPayload) er
 	if len(message) > 5000 {
 		return fmt.Errorf("message must not exceed 5000 characters")
 	}
+	if contactSpamKeywordMatches(message, s.cfg) > spamRejectKeywords {
+		metrics.RecordContactSpamBlocked("keywords")
+		return fmt.Errorf("Message flagged as spam")
+	}
+	if len(urlRegex.FindAllString(message, -1)) > s.cfg.SpamMaxURLs {
+		metrics.RecordContactSpamBlocked("urls")
+		return fmt.Errorf("Message flagged as spam")
+	}
 
 	// Validate phone if provided
 	if p.Phone != nil && strings.TrimSpace(*p.Phone) != "" {
@@ -210,59 +840,72 @@ func (s *ContactService) sendContactNotification(inquiry *domain.ContactInquiry)
 		return nil
 	}
 
-	// Admin email (should be configured in environment)
-	adminEmail := "nishant@springstreet.in" // TODO: Move to config
-
-	subject := fmt.Sprintf("New Contact Form Submission from %s", inquiry.Name)
+	recipients := s.emailService.AdminRecipients()
+	if len(recipients.To) == 0 {
+		return fmt.Errorf("ADMIN_EMAIL is not configured")
+	}
+	// Staff can answer the customer by replying to the notification
+	recipients.ReplyTo = inquiry.Email
 
-	// Build email body
 	phoneInfo := "Not provided"
 	if inquiry.Phone != nil && *inquiry.Phone != "" {
 		phoneInfo = *inquiry.Phone
 	}
+	subject := ""
+	if inquiry.Subject != nil {
+		subject = *inquiry.Subject
+	}
+
+	// The notification is sent after the request has been answered, so the email log takes the
+	// inquiry's organization instead of the request's
+	ctx := WithOrganization(context.Background(), inquiry.OrganizationID)
+
+	// Name, subject and message come straight from the form; the HTML template escapes them
+	return s.emailService.sendTemplate(ctx, emailTypeContactNotification, recipients, DefaultEmailLanguage, "contact_notification", map[string]any{
+		"ID":        inquiry.ID,
+		"Name":      inquiry.Name,
+		"Email":     inquiry.Email,
+		"Phone":     phoneInfo,
+		"Category":  inquiry.Category,
+		"Subject":   subject,
+		"Message":   inquiry.Message,
+		"Submitted": inquiry.CreatedAt.Format("January 2, 2006 at 3:04 PM"),
+	})
+}
 
-	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>New Contact Form Submission</title>
-</head>
-<body style="font-family: 'Barlow', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #334155;">
-    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
-        <h2 style="color: #1C5D99;">New Contact Form Submission</h2>
-        
-        <div style="background: #F8FAFC; padding: 20px; border-radius: 8px; margin: 20px 0;">
-            <p><strong>Name:</strong> %s</p>
-            <p><strong>Email:</strong> <a href="mailto:%s">%s</a></p>
-            <p><strong>Phone:</strong> %s</p>
-            <p><strong>Submitted:</strong> %s</p>
-        </div>
-        
-        <div style="background: #FFFFFF; padding: 20px; border-left: 4px solid #1C5D99; border-radius: 4px; margin: 20px 0;">
-            <h3 style="color: #0D1A2D; margin-top: 0;">Message:</h3>
-            <p style="white-space: pre-wrap;">%s</p>
-        </div>
-        
-        <p style="color: #64748B; font-size: 14px;">
-            Contact Inquiry ID: #%d
-        </p>
-    </div>
-</body>
-</html>`, inquiry.Name, inquiry.Email, inquiry.Email, phoneInfo, inquiry.CreatedAt.Format("January 2, 2006 at 3:04 PM"), inquiry.Message, inquiry.ID)
-
-	textBody := fmt.Sprintf(`New Contact Form Submission
-
-Name: %s
-Email: %s
-Phone: %s
-Submitted: %s
-
-Message:
-%s
-
-Contact Inquiry ID: #%d`, inquiry.Name, inquiry.Email, phoneInfo, inquiry.CreatedAt.Format("January 2, 2006 at 3:04 PM"), inquiry.Message, inquiry.ID)
-
-	return s.emailService.SendHTMLEmail(adminEmail, subject, htmlBody, textBody)
+// contactCategory normalizes a submitted category, defaulting to general
+func contactCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return domain.ContactCategoryGeneral
+	}
+	return category
 }
 
+// isContactCategory reports whether category is an accepted contact category
+func isContactCategory(category string) bool {
+	for _, c := range domain.ContactCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDateParam parses a date filter given as YYYY-MM-DD or RFC3339
+func parseDateParam(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// likeOperator returns the case-insensitive LIKE operator for the database dialect.
+// SQLite's LIKE is already case-insensitive for ASCII and has no ILIKE.
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
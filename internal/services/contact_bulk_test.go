@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"springstreet/gen/contact"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// failAuditWrites makes every audit log insert on db fail, for the rest of the test
+func failAuditWrites(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_audit", func(tx *gorm.DB) {
+		if tx.Statement.Table == "audit_logs" {
+			tx.AddError(errors.New("audit log unavailable"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	t.Cleanup(func() { db.Callback().Create().Remove("test:fail_audit") })
+}
+
+func TestContactBulkUpdateStatusReportsFailures(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	fresh := createTestContact(t, s.db, domain.ContactStatusNew)
+	replied := createTestContact(t, s.db, domain.ContactStatusReplied)
+
+	res, err := s.BulkUpdateStatus(ctx, &contact.BulkUpdateContactStatusPayload{
+		Ids:    []int{int(fresh.ID), int(replied.ID), 9999},
+		Status: domain.ContactStatusNew,
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdateStatus: %v", err)
+	}
+	if res.SuccessCount != 0 || res.FailureCount != 3 {
+		t.Fatalf("result = %+v, want every id to fail", res)
+	}
+
+	res, err = s.BulkUpdateStatus(ctx, &contact.BulkUpdateContactStatusPayload{
+		Ids:    []int{int(fresh.ID), int(replied.ID), 9999},
+		Status: domain.ContactStatusRead,
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdateStatus: %v", err)
+	}
+	if res.SuccessCount != 2 || res.FailureCount != 1 || res.FailedIds[0] != 9999 {
+		t.Fatalf("result = %+v, want 2 updated and 9999 failed", res)
+	}
+}
+
+func TestContactBulkUpdateStatusRollsBackOnError(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	first := createTestContact(t, s.db, domain.ContactStatusNew)
+	second := createTestContact(t, s.db, domain.ContactStatusNew)
+	failAuditWrites(t, s.db)
+
+	_, err := s.BulkUpdateStatus(ctx, &contact.BulkUpdateContactStatusPayload{
+		Ids:    []int{int(first.ID), int(second.ID)},
+		Status: domain.ContactStatusRead,
+	})
+	if err == nil {
+		t.Fatal("BulkUpdateStatus succeeded although the audit log could not be written")
+	}
+
+	var statuses []string
+	s.db.Model(&domain.ContactInquiry{}).Order("id").Pluck("status", &statuses)
+	for _, status := range statuses {
+		if status != domain.ContactStatusNew {
+			t.Fatalf("statuses = %v, want the update rolled back", statuses)
+		}
+	}
+}
+
+func TestContactBulkDeleteRollsBackOnError(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	first := createTestContact(t, s.db, domain.ContactStatusNew)
+	second := createTestContact(t, s.db, domain.ContactStatusNew)
+	failAuditWrites(t, s.db)
+
+	_, err := s.BulkDelete(ctx, &contact.BulkDeleteContactsPayload{Ids: []int{int(first.ID), int(second.ID)}})
+	if err == nil {
+		t.Fatal("BulkDelete succeeded although the audit log could not be written")
+	}
+
+	var count int64
+	s.db.Model(&domain.ContactInquiry{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("%d inquiries left, want the delete rolled back", count)
+	}
+}
+
+func TestContactBulkDeleteSoftDeletes(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	first := createTestContact(t, s.db, domain.ContactStatusNew)
+	second := createTestContact(t, s.db, domain.ContactStatusNew)
+
+	res, err := s.BulkDelete(ctx, &contact.BulkDeleteContactsPayload{Ids: []int{int(first.ID), int(second.ID), int(first.ID)}})
+	if err != nil {
+		t.Fatalf("BulkDelete: %v", err)
+	}
+	if res.SuccessCount != 2 || res.FailureCount != 0 {
+		t.Fatalf("result = %+v, want 2 deleted", res)
+	}
+
+	var live, all int64
+	s.db.Model(&domain.ContactInquiry{}).Count(&live)
+	s.db.Unscoped().Model(&domain.ContactInquiry{}).Count(&all)
+	if live != 0 || all != 2 {
+		t.Fatalf("live = %d, stored = %d, want 0 and 2", live, all)
+	}
+}
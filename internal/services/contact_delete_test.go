@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"springstreet/gen/contact"
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// newTestContactService returns a contact service on a fresh database and a staff context
+func newTestContactService(t *testing.T) (*ContactService, context.Context) {
+	t.Helper()
+	db := newTestDB(t)
+	s := NewContactService(db, nil, nil, &config.Get().Contact, nil, time.Minute)
+	staff := createTestUser(t, db, domain.User{Username: "staff", IsStaff: true, IsAdmin: true}, "correct horse")
+	return s, asUser(context.Background(), staff)
+}
+
+// createTestContact stores a contact inquiry with the given status
+func createTestContact(t *testing.T, db *gorm.DB, status string) *domain.ContactInquiry {
+	t.Helper()
+	inquiry := &domain.ContactInquiry{Name: "Asha", Email: "asha@example.com", Message: "Please call me", Status: status, Category: domain.ContactCategoryGeneral}
+	if err := db.Create(inquiry).Error; err != nil {
+		t.Fatalf("create contact inquiry: %v", err)
+	}
+	return inquiry
+}
+
+func TestContactDeleteHidesInquiry(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	deleted := createTestContact(t, s.db, domain.ContactStatusNew)
+	kept := createTestContact(t, s.db, domain.ContactStatusNew)
+
+	if err := s.Delete(ctx, &contact.DeleteContactInquiryPayload{ID: int(deleted.ID)}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	results, err := s.List(ctx, &contact.ListContactInquiriesPayload{Limit: 100})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != int(kept.ID) {
+		t.Fatalf("List returned %d inquiries, want only id=%d", len(results), kept.ID)
+	}
+
+	_, err = s.Get(ctx, &contact.GetContactInquiryPayload{ID: int(deleted.ID)})
+	assertServiceError(t, err, "not_found")
+
+	// The row is only soft-deleted
+	var count int64
+	s.db.Unscoped().Model(&domain.ContactInquiry{}).Where("id = ?", deleted.ID).Count(&count)
+	if count != 1 {
+		t.Fatal("Delete removed the row instead of soft-deleting it")
+	}
+}
+
+func TestContactDeleteNotFound(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	inquiry := createTestContact(t, s.db, domain.ContactStatusNew)
+
+	err := s.Delete(ctx, &contact.DeleteContactInquiryPayload{ID: 9999})
+	assertServiceError(t, err, "not_found")
+
+	if err := s.Delete(ctx, &contact.DeleteContactInquiryPayload{ID: int(inquiry.ID)}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = s.Delete(ctx, &contact.DeleteContactInquiryPayload{ID: int(inquiry.ID)})
+	assertServiceError(t, err, "not_found")
+}
+
+func TestContactPurgeRemovesOnlyOldDeletedInquiries(t *testing.T) {
+	s, ctx := newTestContactService(t)
+	old := createTestContact(t, s.db, domain.ContactStatusNew)
+	recent := createTestContact(t, s.db, domain.ContactStatusNew)
+	live := createTestContact(t, s.db, domain.ContactStatusNew)
+
+	s.db.Model(&domain.ContactInquiry{}).Unscoped().Where("id = ?", old.ID).Update("deleted_at", time.Now().Add(-10*24*time.Hour))
+	s.db.Delete(recent)
+
+	res, err := s.Purge(ctx, &contact.PurgeContactInquiriesPayload{OlderThanDays: 7})
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if res.Purged != 1 {
+		t.Fatalf("Purged = %d, want 1", res.Purged)
+	}
+
+	var remaining []uint
+	s.db.Unscoped().Model(&domain.ContactInquiry{}).Order("id").Pluck("id", &remaining)
+	if len(remaining) != 2 || remaining[0] != recent.ID || remaining[1] != live.ID {
+		t.Fatalf("remaining rows = %v, want [%d %d]", remaining, recent.ID, live.ID)
+	}
+}
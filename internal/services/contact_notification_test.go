@@ -0,0 +1,62 @@
+package services
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+)
+
+// recordingEmailSender is an EmailSender that keeps the messages instead of sending them
+type recordingEmailSender struct {
+	mu       sync.Mutex
+	messages []*EmailMessage
+}
+
+func (r *recordingEmailSender) Send(msg *EmailMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// newRecordingEmailService returns an enabled email service whose messages end up in the returned sender
+func newRecordingEmailService(t *testing.T) (*EmailService, *recordingEmailSender) {
+	t.Helper()
+	sender := &recordingEmailSender{}
+	s := NewEmailService(&config.Get().Email, nil)
+	s.sender = sender
+	return s, sender
+}
+
+func TestContactNotificationGoesToEveryAdmin(t *testing.T) {
+	t.Setenv("EMAIL_ENABLED", "true")
+	t.Setenv("ADMIN_EMAIL", "ops@springstreet.in, sales@springstreet.in,founders@springstreet.in")
+	t.Setenv("ADMIN_EMAIL_CC", "cto@springstreet.in")
+	db := newTestDB(t)
+	emailService, sender := newRecordingEmailService(t)
+	s := NewContactService(db, emailService, nil, &config.Get().Contact, nil, time.Minute)
+	inquiry := createTestContact(t, db, domain.ContactStatusNew)
+
+	if err := s.sendContactNotification(inquiry); err != nil {
+		t.Fatalf("sendContactNotification: %v", err)
+	}
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("%d emails sent, want one to all admins", len(sender.messages))
+	}
+	msg := sender.messages[0]
+	want := []string{"ops@springstreet.in", "sales@springstreet.in", "founders@springstreet.in"}
+	if !slices.Equal(msg.To, want) {
+		t.Errorf("To = %v, want %v", msg.To, want)
+	}
+	if !slices.Equal(msg.Cc, []string{"cto@springstreet.in"}) {
+		t.Errorf("Cc = %v, want the ADMIN_EMAIL_CC address", msg.Cc)
+	}
+	if msg.ReplyTo != inquiry.Email {
+		t.Errorf("Reply-To = %q, want the customer's address %q", msg.ReplyTo, inquiry.Email)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	goamiddleware "goa.design/goa/v3/http/middleware"
+
+	"springstreet/gen/contact"
+	"springstreet/internal/config"
+)
+
+func TestContactSubmitLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	t.Setenv("CONTACT_RATE_LIMIT_PER_IP", "2")
+	db := newTestDB(t)
+	s := NewContactService(db, nil, nil, &config.Get().Contact, nil, time.Minute)
+
+	var err error
+	for i := range 3 {
+		// Each request claims a different client IP; the peer isn't a trusted proxy, so the
+		// claim is ignored
+		ctx := context.WithValue(context.Background(), goamiddleware.RequestRemoteAddrKey, "203.0.113.9:5000")
+		ctx = context.WithValue(ctx, goamiddleware.RequestXForwardedForKey, fmt.Sprintf("198.51.100.%d", i))
+		// The honeypot marks the submission as spam, so nothing is notified
+		website := "https://example.com"
+		_, err = s.Submit(ctx, &contact.ContactSubmitPayload{
+			Name:     "Visitor",
+			Email:    fmt.Sprintf("visitor%d@example.com", i),
+			Category: "general",
+			Message:  fmt.Sprintf("Question number %d about investing", i),
+			Website:  &website,
+		})
+		if i < 2 && err != nil {
+			t.Fatalf("submission %d: %v", i, err)
+		}
+	}
+
+	var limited *contact.SubmissionLimited
+	if !errors.As(err, &limited) {
+		t.Fatalf("third submission: error = %v, want SubmissionLimited", err)
+	}
+	if limited.RetryAfter < 1 || limited.RetryAfter > int(time.Hour/time.Second) {
+		t.Errorf("RetryAfter = %d, want between 1 and 3600 seconds", limited.RetryAfter)
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"springstreet/internal/config"
+)
+
+const (
+	spamKeywordScore = 25
+	spamURLScore     = 10
+	maxSpamScore     = 100
+
+	// Messages matching more keywords than this are rejected outright instead of being stored
+	spamRejectKeywords = 3
+)
+
+var urlRegex = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// contactSpamScore rates how likely a contact message is spam on a 0-100 scale.
+// Each configured keyword found adds to the score, as does each link. Messages with
+// more keywords or links than allowed are rejected by validateContactForm before scoring.
+func contactSpamScore(message string, cfg *config.ContactConfig) int {
+	score := contactSpamKeywordMatches(message, cfg) * spamKeywordScore
+	score += len(urlRegex.FindAllString(message, -1)) * spamURLScore
+
+	if score > maxSpamScore {
+		score = maxSpamScore
+	}
+	return score
+}
+
+// contactSpamKeywordMatches counts the configured spam keywords found in message
+func contactSpamKeywordMatches(message string, cfg *config.ContactConfig) int {
+	lower := strings.ToLower(message)
+	matches := 0
+	for _, keyword := range cfg.SpamKeywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && strings.Contains(lower, keyword) {
+			matches++
+		}
+	}
+	return matches
+}
+
+// contactSpamReason names what got a stored submission flagged, for the spam metric
+func contactSpamReason(honeypot bool) string {
+	if honeypot {
+		return "honeypot"
+	}
+	return "score"
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"springstreet/gen/contact"
+	"springstreet/internal/config"
+)
+
+// testContactConfig mirrors the default spam settings
+var testContactConfig = &config.ContactConfig{
+	SpamKeywords:       []string{"seo services", "backlinks", "guest post", "casino", "buy followers"},
+	SpamMaxURLs:        3,
+	SpamScoreThreshold: 50,
+}
+
+func TestContactSpamScoreFlagsSpam(t *testing.T) {
+	for _, message := range []string{
+		"We offer SEO services and high quality backlinks to boost your ranking",
+		"Great site! Order backlinks at http://a.example http://b.example http://c.example",
+		"Guest post opportunity: buy followers and backlinks at www.cheap.example",
+		"CASINO bonus! SEO Services available, see https://spam.example",
+	} {
+		if score := contactSpamScore(message, testContactConfig); score < testContactConfig.SpamScoreThreshold {
+			t.Errorf("contactSpamScore(%q) = %d, want at least %d", message, score, testContactConfig.SpamScoreThreshold)
+		}
+	}
+}
+
+func TestContactSpamScorePassesHam(t *testing.T) {
+	for _, message := range []string{
+		"Hi, I'd like to know more about investing in US stocks from India.",
+		"Could someone call me about the minimum investment? My details are on https://linkedin.com/in/me",
+		"Is there a fee for the LRS remittance? See www.rbi.org.in and https://example.com/faq for context.",
+		"",
+	} {
+		if score := contactSpamScore(message, testContactConfig); score >= testContactConfig.SpamScoreThreshold {
+			t.Errorf("contactSpamScore(%q) = %d, want below %d", message, score, testContactConfig.SpamScoreThreshold)
+		}
+	}
+}
+
+func TestContactSpamScoreIsCapped(t *testing.T) {
+	message := "seo services backlinks guest post casino buy followers http://a.example http://b.example http://c.example"
+	if score := contactSpamScore(message, testContactConfig); score != maxSpamScore {
+		t.Fatalf("contactSpamScore = %d, want %d", score, maxSpamScore)
+	}
+}
+
+func TestValidateContactFormRejectsSpam(t *testing.T) {
+	s := &ContactService{cfg: testContactConfig}
+	for _, tc := range []struct {
+		message string
+		reason  string
+	}{
+		{"SEO services, backlinks, a guest post and casino ads for your site", "keywords"},
+		{"See http://a.example http://b.example https://c.example and www.d.example", "urls"},
+	} {
+		before := metricValue(t, "contact_spam_blocked_total", map[string]string{"reason": tc.reason})
+		payload := &contact.ContactSubmitPayload{Name: "Spammer", Email: "spam@example.com", Category: "general", Message: tc.message}
+		if err := s.validateContactForm(payload); err == nil || err.Error() != "Message flagged as spam" {
+			t.Errorf("validateContactForm(%q) = %v, want Message flagged as spam", tc.message, err)
+		}
+		if got := metricValue(t, "contact_spam_blocked_total", map[string]string{"reason": tc.reason}) - before; got != 1 {
+			t.Errorf("contact_spam_blocked_total{reason=%s} increased by %v, want 1", tc.reason, got)
+		}
+	}
+
+	// Three keywords and three links are still allowed; the score decides whether they're flagged
+	payload := &contact.ContactSubmitPayload{
+		Name: "Someone", Email: "someone@example.com", Category: "general",
+		Message: "Not casino backlinks or a guest post: http://a.example http://b.example http://c.example",
+	}
+	if err := s.validateContactForm(payload); err != nil {
+		t.Errorf("validateContactForm = %v, want the message accepted", err)
+	}
+}
+
+func TestContactSpamReason(t *testing.T) {
+	if got := contactSpamReason(true); got != "honeypot" {
+		t.Errorf("contactSpamReason(true) = %s, want honeypot", got)
+	}
+	if got := contactSpamReason(false); got != "score" {
+		t.Errorf("contactSpamReason(false) = %s, want score", got)
+	}
+}
@@ -1,249 +1,442 @@
 package services
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"log"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"springstreet/internal/config"
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+
+	"gorm.io/gorm"
+)
+
+// SMTP connection modes (EmailConfig.SMTPMode)
+const (
+	SMTPModeStartTLS = "starttls" // Plain connection upgraded with STARTTLS; fails if the server doesn't offer it
+	SMTPModeTLS      = "tls"      // TLS from the first byte (implicit TLS, usually port 465)
+	SMTPModePlain    = "plain"    // No encryption, for local relays such as MailHog
 )
 
-// EmailService handles sending emails
+// Errors returned by SendHTMLEmail, wrapping the underlying error, so callers can tell
+// an unreachable server from rejected credentials or a rejected message. API providers
+// map their failures onto the same errors (see SendGridError and SESError).
+var (
+	ErrSMTPDial = errors.New("smtp dial failed")
+	ErrSMTPTLS  = errors.New("smtp tls negotiation failed")
+	ErrSMTPAuth = errors.New("smtp authentication failed")
+	ErrSMTPData = errors.New("smtp message rejected")
+)
+
+// EmailService handles sending emails. Delivery is delegated to the EmailSender of the
+// configured provider, and the outcome of each email is recorded in the email log.
 type EmailService struct {
-	cfg *config.EmailConfig
+	mu       sync.RWMutex
+	cfg      *config.EmailConfig
+	sender   EmailSender
+	throttle *emailThrottle
+	db       *gorm.DB // Email log; nil disables it
 }
 
 // NewEmailService creates a new email service
-func NewEmailService(cfg *config.EmailConfig) *EmailService {
-	return &EmailService{cfg: cfg}
+func NewEmailService(cfg *config.EmailConfig, db *gorm.DB) *EmailService {
+	return &EmailService{cfg: cfg, sender: NewEmailSender(cfg), throttle: newEmailThrottle(), db: db}
+}
+
+// UpdateConfig replaces the email configuration (used on config reload)
+func (s *EmailService) UpdateConfig(cfg *config.EmailConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	if old, ok := s.sender.(interface{ Close() }); ok {
+		old.Close()
+	}
+	s.sender = NewEmailSender(cfg)
+}
+
+// Close ends the connection kept open for the next email, after any email being sent on it
+func (s *EmailService) Close() {
+	if sender, ok := s.emailSender().(interface{ Close() }); ok {
+		sender.Close()
+	}
+}
+
+// emailSender returns the sender for the current configuration
+func (s *EmailService) emailSender() EmailSender {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sender
 }
 
-// SendOTP sends an OTP code via email
-func (s *EmailService) SendOTP(to, otpCode string) error {
-	if !s.cfg.Enabled {
+// config returns the current email configuration
+func (s *EmailService) config() *config.EmailConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// SendOTP sends an OTP code via email, in locale where there are templates for it
+func (s *EmailService) SendOTP(ctx context.Context, to, otpCode, locale string) error {
+	if !s.IsEnabled() {
 		// In development mode, just log
-		fmt.Printf("[EMAIL] OTP would be sent to %s: %s\n", to, otpCode)
+		fmt.Printf("[EMAIL] OTP would be sent to %s: %s\n", to, util.LoggableOTP(otpCode))
 		return nil
 	}
 
-	subject := "Your Spring Street Verification Code"
-	htmlBody := s.generateOTPEmailHTML(otpCode)
-	textBody := fmt.Sprintf(`
-Hello,
+	subject, err := renderOTPMessage(otpMessageEmailSubject, locale, otpCode)
+	if err != nil {
+		return err
+	}
+	textBody, err := renderOTPMessage(otpMessageEmail, locale, otpCode)
+	if err != nil {
+		return err
+	}
+	htmlBody, err := s.generateOTPEmailHTML(otpCode, locale)
+	if err != nil {
+		return err
+	}
 
-Your verification code for Spring Street is: %s
+	return s.sendHTML(ctx, emailTypeOTP, EmailTo(to), subject, htmlBody, textBody)
+}
 
-This code will expire in 10 minutes.
+// generateOTPEmailHTML renders the OTP email template in lang
+func (s *EmailService) generateOTPEmailHTML(otpCode, lang string) (string, error) {
+	// Split OTP into individual digits for display
+	digits := make([]string, 0, len(otpCode))
+	for _, digit := range otpCode {
+		digits = append(digits, string(digit))
+	}
 
-If you did not request this code, please ignore this email.
+	return emailTemplates.Render(lang, "otp", map[string]any{
+		"LogoURL":       "https://springstreet.in/logo-new.png",
+		"Digits":        digits,
+		"ExpiryMinutes": otpExpiryMinutes(),
+		"Year":          time.Now().Format("2006"),
+	})
+}
 
-Best regards,
-Spring Street Team
-`, otpCode)
+// SendTemplate renders the email templateName (see EmailTemplateRenderer) in English and sends it to to
+func (s *EmailService) SendTemplate(ctx context.Context, to, templateName string, data map[string]any) error {
+	return s.sendTemplate(ctx, emailTypeGeneric, EmailTo(to), DefaultEmailLanguage, templateName, data)
+}
 
-	return s.SendHTMLEmail(to, subject, htmlBody, textBody)
+// sendTemplate renders templateName in lang and sends it to recipients
+func (s *EmailService) sendTemplate(ctx context.Context, emailType string, recipients EmailRecipients, lang, templateName string, data map[string]any) error {
+	htmlBody, err := emailTemplates.Render(lang, templateName, data)
+	if err != nil {
+		return err
+	}
+	textBody, err := emailTemplates.RenderText(lang, templateName+".txt", data)
+	if err != nil {
+		return err
+	}
+	subject, err := emailTemplates.RenderText(lang, templateName+".subject.txt", data)
+	if err != nil {
+		return err
+	}
+	if subject = strings.TrimSpace(subject); subject == "" {
+		return fmt.Errorf("email template %q has no subject", templateName)
+	}
+	return s.sendHTML(ctx, emailType, recipients, subject, htmlBody, textBody)
 }
 
-// generateOTPEmailHTML generates a professional HTML email template for OTP
-func (s *EmailService) generateOTPEmailHTML(otpCode string) string {
-	// Split OTP into individual digits for display
-	otpDigits := ""
-	spacer := `<span style="display: inline-block; width: 10px;"></span>`
-	digitStyle := `style="display: inline-block; width: 52px; height: 64px; line-height: 64px; background: linear-gradient(135deg, #F8FAFC 0%%, #FFFFFF 100%%); border: 2px solid #1C5D99; border-radius: 10px; text-align: center; font-size: 32px; font-weight: 700; color: #1C5D99; font-family: 'Barlow', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; box-shadow: 0 2px 4px rgba(28, 93, 153, 0.1);"`
-	for i, digit := range otpCode {
-		if i > 0 {
-			otpDigits += spacer
-		}
-		otpDigits += fmt.Sprintf(`<span %s>%c</span>`, digitStyle, digit)
-	}
-
-	logoURL := "https://springstreet.in/logo-new.png"
-	currentYear := time.Now().Format("2006")
-
-	return fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <meta http-equiv="X-UA-Compatible" content="IE=edge">
-    <title>Spring Street Verification Code</title>
-</head>
-<body style="margin: 0; padding: 0; background: linear-gradient(135deg, #F8FAFC 0%%, #EEF2F7 100%%); font-family: 'Barlow', -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;">
-    <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%" style="background: linear-gradient(135deg, #F8FAFC 0%%, #EEF2F7 100%%);">
-        <tr>
-            <td style="padding: 48px 20px;">
-                <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="600" style="margin: 0 auto; background-color: #FFFFFF; border-radius: 16px; box-shadow: 0 8px 24px rgba(0, 0, 0, 0.08); overflow: hidden;">
-                    <!-- Header with Logo -->
-                    <tr>
-                        <td style="padding: 0; background: linear-gradient(135deg, #1C5D99 0%%, #0D4A7A 100%%);">
-                            <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%">
-                                <tr>
-                                    <td style="padding: 40px 40px 32px; text-align: center;">
-                                        <img src="%s" alt="Spring Street" width="180" height="auto" style="max-width: 180px; height: auto; display: block; margin: 0 auto;" />
-                                    </td>
-                                </tr>
-                            </table>
-                        </td>
-                    </tr>
-                    
-                    <!-- Content -->
-                    <tr>
-                        <td style="padding: 48px 40px 40px;">
-                            <h2 style="margin: 0 0 12px; font-size: 28px; font-weight: 700; color: #0D1A2D; line-height: 1.3; letter-spacing: -0.5px;">Verify Your Account</h2>
-                            <p style="margin: 0 0 40px; font-size: 16px; line-height: 1.6; color: #64748B;">We've sent you a verification code to complete your registration. Enter this code in the verification form:</p>
-                            
-                            <!-- OTP Code Display -->
-                            <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%" style="margin: 0 0 40px;">
-                                <tr>
-                                    <td style="text-align: center; padding: 24px; background: linear-gradient(135deg, #F8FAFC 0%%, #FFFFFF 100%%); border-radius: 12px; border: 1px solid #E2E8F0;">
-                                        %s
-                                    </td>
-                                </tr>
-                            </table>
-                            
-                            <!-- Info Box -->
-                            <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%" style="margin: 0 0 32px;">
-                                <tr>
-                                    <td style="padding: 20px; background: linear-gradient(135deg, #F1F5F9 0%%, #FFFFFF 100%%); border-left: 4px solid #1C5D99; border-radius: 8px; box-shadow: 0 2px 8px rgba(28, 93, 153, 0.08);">
-                                        <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%">
-                                            <tr>
-                                                <td style="padding-right: 12px; vertical-align: top;">
-                                                    <div style="width: 24px; height: 24px; background-color: #1C5D99; border-radius: 50%%; display: inline-block; text-align: center; line-height: 24px;">
-                                                        <span style="color: #FFFFFF; font-size: 14px; font-weight: 700;">!</span>
-                                                    </div>
-                                                </td>
-                                                <td>
-                                                    <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #334155;">
-                                                        <strong style="color: #1C5D99;">Important:</strong> This code will expire in <strong style="color: #0D1A2D;">10 minutes</strong>. If you didn't request this code, please ignore this email.
-                                                    </p>
-                                                </td>
-                                            </tr>
-                                        </table>
-                                    </td>
-                                </tr>
-                            </table>
-                            
-                            <p style="margin: 0; font-size: 15px; line-height: 1.6; color: #64748B;">If you have any questions, feel free to contact our support team.</p>
-                        </td>
-                    </tr>
-                    
-                    <!-- Divider -->
-                    <tr>
-                        <td style="padding: 0 40px;">
-                            <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%">
-                                <tr>
-                                    <td style="height: 1px; background: linear-gradient(90deg, transparent 0%%, #E2E8F0 50%%, transparent 100%%);"></td>
-                                </tr>
-                            </table>
-                        </td>
-                    </tr>
-                    
-                    <!-- Footer -->
-                    <tr>
-                        <td style="padding: 32px 40px; background-color: #F8FAFC;">
-                            <table role="presentation" cellspacing="0" cellpadding="0" border="0" width="100%%">
-                                <tr>
-                                    <td>
-                                        <p style="margin: 0 0 8px; font-size: 15px; font-weight: 600; color: #334155;">Best regards,</p>
-                                        <p style="margin: 0 0 24px; font-size: 15px; color: #64748B;">The Spring Street Team</p>
-                                        
-                                        <table role="presentation" cellspacing="0" cellpadding="0" border="0">
-                                            <tr>
-                                                <td style="padding-right: 16px;">
-                                                    <a href="https://springstreet.in" style="color: #1C5D99; text-decoration: none; font-size: 14px; font-weight: 500;">Visit Website</a>
-                                                </td>
-                                                <td style="padding-right: 16px;">
-                                                    <span style="color: #CBD5E1;">|</span>
-                                                </td>
-                                                <td>
-                                                    <a href="https://springstreet.in/contact" style="color: #1C5D99; text-decoration: none; font-size: 14px; font-weight: 500;">Contact Support</a>
-                                                </td>
-                                            </tr>
-                                        </table>
-                                        
-                                        <p style="margin: 24px 0 0; font-size: 12px; color: #94A3B8; line-height: 1.6;">
-                                            This is an automated message. Please do not reply to this email.<br>
-                                            © %s Spring Street. All rights reserved.
-                                        </p>
-                                    </td>
-                                </tr>
-                            </table>
-                        </td>
-                    </tr>
-                </table>
-            </td>
-        </tr>
-    </table>
-</body>
-</html>`, logoURL, otpDigits, currentYear)
-}
-
-// SendEmail sends a generic email (plain text)
-func (s *EmailService) SendEmail(to, subject, body string) error {
-	return s.SendHTMLEmail(to, subject, "", body)
+// SendEmail sends a generic email (plain text) to a single address
+func (s *EmailService) SendEmail(ctx context.Context, to, subject, body string) error {
+	return s.SendHTMLEmail(ctx, EmailTo(to), subject, "", body)
 }
 
 // SendHTMLEmail sends an HTML email with plain text fallback
-func (s *EmailService) SendHTMLEmail(to, subject, htmlBody, textBody string) error {
-	if !s.cfg.Enabled {
-		fmt.Printf("[EMAIL] Would send to %s: %s\n", to, subject)
+func (s *EmailService) SendHTMLEmail(ctx context.Context, recipients EmailRecipients, subject, htmlBody, textBody string) error {
+	return s.sendHTML(ctx, emailTypeGeneric, recipients, subject, htmlBody, textBody)
+}
+
+// SendHTMLEmailWithAttachments sends an HTML email with plain text fallback and the given
+// attachments. Their combined size may not exceed EmailConfig.MaxAttachmentBytes.
+func (s *EmailService) SendHTMLEmailWithAttachments(ctx context.Context, recipients EmailRecipients, subject, htmlBody, textBody string, attachments []Attachment) error {
+	return s.send(ctx, emailTypeGeneric, recipients, subject, htmlBody, textBody, attachments, nil)
+}
+
+// SendTest sends a canned email to to through the configured provider, to check the email
+// settings. It returns a description of the provider's answer.
+func (s *EmailService) SendTest(ctx context.Context, to string) (string, error) {
+	if !s.IsEnabled() {
+		return "", fmt.Errorf("email is disabled (EMAIL_ENABLED=false)")
+	}
+	cfg := s.config()
+	subject := "Spring Street test email"
+	text := fmt.Sprintf("This is a test email from Spring Street, sent through %s at %s. Your email settings are working.", cfg.Provider, time.Now().UTC().Format(time.RFC1123))
+	if err := s.send(ctx, emailTypeTest, EmailTo(to), subject, "", text, nil, nil); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("accepted by %s for %s", cfg.Provider, to), nil
+}
+
+// sendHTML sends the email through the configured provider, retrying temporary failures.
+// emailType labels the email in metrics; the email log entries belong to ctx's organization.
+func (s *EmailService) sendHTML(ctx context.Context, emailType string, recipients EmailRecipients, subject, htmlBody, textBody string) error {
+	return s.send(ctx, emailType, recipients, subject, htmlBody, textBody, nil, nil)
+}
+
+// send is sendHTML with attachments and extra headers. Every address is checked before
+// anything is sent; without a Reply-To of its own the email gets EmailConfig.ReplyTo, and it
+// comes from the sender EmailConfig.FromByType gives its type, if any. An email over the
+// outbound limits is not sent and an *EmailThrottledError is returned.
+func (s *EmailService) send(ctx context.Context, emailType string, recipients EmailRecipients, subject, htmlBody, textBody string, attachments []Attachment, headers map[string]string) error {
+	cfg := s.config()
+	recipients, err := recipients.normalize()
+	if err != nil {
+		return err
+	}
+	if size := attachmentsSize(attachments); cfg.MaxAttachmentBytes > 0 && size > cfg.MaxAttachmentBytes {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrAttachmentsTooLarge, size, cfg.MaxAttachmentBytes)
+	}
+	if !s.IsEnabled() {
+		fmt.Printf("[EMAIL] Would send to %s: %s (%d attachments)\n", strings.Join(recipients.To, ", "), subject, len(attachments))
 		return nil
 	}
 
-	// Validate configuration
-	if s.cfg.SMTPHost == "" || s.cfg.Username == "" || s.cfg.Password == "" {
+	replyTo := recipients.ReplyTo
+	if replyTo == "" {
+		replyTo = cfg.ReplyTo
+	}
+	from, fromName := emailSenderFor(cfg, emailType)
+	msg := &EmailMessage{
+		Type:        emailType,
+		From:        from,
+		FromName:    fromName,
+		To:          recipients.To,
+		Cc:          recipients.Cc,
+		Bcc:         recipients.Bcc,
+		ReplyTo:     replyTo,
+		Subject:     subject,
+		HTML:        htmlBody,
+		Text:        textBody,
+		Attachments: attachments,
+		Headers:     headers,
+	}
+	if throttled := s.throttle.reserve(cfg, emailType, msg.envelopeRecipients()); throttled != nil {
+		log.Printf("[EMAIL] %s email throttled: %s limit reached, retry in %s", emailType, throttled.Limit, throttled.RetryAfter.Round(time.Second))
+		metrics.RecordEmailThrottled(emailType, throttled.Limit)
+		return throttled
+	}
+
+	sender := s.emailSender()
+	err = sendWithRetry(cfg, emailType, func() error {
+		return sender.Send(msg)
+	})
+	s.recordEmailLog(ctx, msg, cfg.Provider, err)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// emailSenderFor returns the sender address and name of emails of emailType: the override in
+// cfg.FromByType, already validated, or else the default sender. An override without a name
+// keeps the default name.
+func emailSenderFor(cfg *config.EmailConfig, emailType string) (string, string) {
+	if override, ok := cfg.FromByType[emailType]; ok {
+		if addr, err := mail.ParseAddress(override); err == nil {
+			if addr.Name == "" {
+				return addr.Address, cfg.FromName
+			}
+			return addr.Address, addr.Name
+		}
+	}
+	return cfg.FromEmail, cfg.FromName
+}
+
+// smtpSender delivers email over SMTP. Unless EmailConfig.SMTPIdleTimeoutSec is 0, the
+// authenticated connection is kept open and reused for the next email until it has been idle
+// that long, so a burst of emails needs one handshake and one AUTH. Sends take turns on the
+// connection.
+type smtpSender struct {
+	cfg *config.EmailConfig
+
+	mu     sync.Mutex
+	client *smtp.Client // Open connection; nil when there is none
+	conn   net.Conn
+	idle   *time.Timer // Closes the connection once it has been idle long enough
+}
+
+// Send implements EmailSender
+func (s *smtpSender) Send(msg *EmailMessage) error {
+	cfg := s.cfg
+
+	// Validate configuration. A plain relay may accept mail without credentials.
+	if cfg.SMTPHost == "" || (cfg.SMTPMode != SMTPModePlain && (cfg.Username == "" || cfg.Password == "")) {
 		return fmt.Errorf("email service not properly configured")
 	}
 
-	// Set up authentication
-	auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.SMTPHost)
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+
+	client, err := s.connection()
+	if err != nil {
+		return err
+	}
+	if err := sendSMTPMessage(client, msg.From, msg.envelopeRecipients(), data); err != nil {
+		// Where the conversation stopped is unknown, so the next email starts afresh
+		s.closeConnection()
+		return err
+	}
 
-	// Create email message
-	from := s.cfg.FromEmail
-	if s.cfg.FromName != "" {
-		from = fmt.Sprintf("%s <%s>", s.cfg.FromName, s.cfg.FromEmail)
+	if cfg.SMTPIdleTimeoutSec > 0 {
+		s.idle = time.AfterFunc(time.Duration(cfg.SMTPIdleTimeoutSec)*time.Second, s.Close)
+	} else {
+		s.closeConnection()
 	}
+	return nil
+}
 
-	// Build multipart message
-	boundary := "----=_NextPart_1234567890"
+// Close ends the open connection, if there is one
+func (s *smtpSender) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	s.closeConnection()
+}
 
-	headers := fmt.Sprintf("From: %s\r\n", from) +
-		fmt.Sprintf("To: %s\r\n", to) +
-		fmt.Sprintf("Subject: %s\r\n", subject) +
-		"MIME-Version: 1.0\r\n" +
-		fmt.Sprintf("Content-Type: multipart/alternative; boundary=\"%s\"\r\n", boundary) +
-		"\r\n"
+// connection returns the open connection if the server still answers on it, and a new one
+// otherwise. Callers must hold mu.
+func (s *smtpSender) connection() (*smtp.Client, error) {
+	deadline := time.Now().Add(time.Duration(s.cfg.SendTimeoutSec) * time.Second)
+	if s.client != nil {
+		if err := s.conn.SetDeadline(deadline); err == nil {
+			if err := s.client.Reset(); err == nil {
+				return s.client, nil
+			}
+		}
+		log.Printf("[EMAIL] SMTP connection to %s was dropped, reconnecting", s.cfg.SMTPHost)
+		s.closeConnection()
+	}
 
-	// Plain text part
-	message := headers +
-		fmt.Sprintf("--%s\r\n", boundary) +
-		"Content-Type: text/plain; charset=UTF-8\r\n" +
-		"Content-Transfer-Encoding: quoted-printable\r\n" +
-		"\r\n" +
-		textBody + "\r\n"
+	client, conn, err := dialSMTP(s.cfg, deadline)
+	if err != nil {
+		return nil, err
+	}
+	s.client, s.conn = client, conn
+	return client, nil
+}
 
-	// HTML part (if provided)
-	if htmlBody != "" {
-		message += fmt.Sprintf("--%s\r\n", boundary) +
-			"Content-Type: text/html; charset=UTF-8\r\n" +
-			"Content-Transfer-Encoding: quoted-printable\r\n" +
-			"\r\n" +
-			htmlBody + "\r\n"
+// closeConnection says goodbye to the server, if it is still listening, and closes the
+// connection. Callers must hold mu.
+func (s *smtpSender) closeConnection() {
+	if s.client == nil {
+		return
 	}
+	// The message has been accepted or abandoned at this point, so a failed QUIT isn't reported
+	_ = s.conn.SetDeadline(time.Now().Add(time.Duration(s.cfg.DialTimeoutSec) * time.Second))
+	_ = s.client.Quit()
+	s.client.Close()
+	s.client, s.conn = nil, nil
+}
 
-	message += fmt.Sprintf("--%s--\r\n", boundary)
+// dialSMTP opens a connection set up according to cfg.SMTPMode and authenticates when a
+// username is configured. Unlike smtp.SendMail it never waits on the server indefinitely:
+// dialing is bounded by the dial timeout and the rest of the conversation by deadline.
+func dialSMTP(cfg *config.EmailConfig, deadline time.Time) (*smtp.Client, net.Conn, error) {
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))
+	dialer := &net.Dialer{Timeout: time.Duration(cfg.DialTimeoutSec) * time.Second}
+	tlsConfig := &tls.Config{ServerName: cfg.SMTPHost, InsecureSkipVerify: cfg.InsecureSkipVerify}
 
-	// Send email
-	addr := fmt.Sprintf("%s:%d", s.cfg.SMTPHost, s.cfg.SMTPPort)
-	err := smtp.SendMail(addr, auth, s.cfg.FromEmail, []string{to}, []byte(message))
+	var conn net.Conn
+	var err error
+	if cfg.SMTPMode == SMTPModeTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return nil, nil, fmt.Errorf("%w: %w", ErrSMTPDial, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%w: %w", ErrSMTPDial, err)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%w: %w", ErrSMTPDial, err)
+	}
+
+	if cfg.SMTPMode == SMTPModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, nil, fmt.Errorf("%w: server does not offer STARTTLS", ErrSMTPTLS)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, nil, fmt.Errorf("%w: %w", ErrSMTPTLS, err)
+		}
+	}
+
+	if cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			client.Close()
+			return nil, nil, fmt.Errorf("%w: server does not offer AUTH", ErrSMTPAuth)
+		}
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)); err != nil {
+			client.Close()
+			return nil, nil, fmt.Errorf("%w: %w", ErrSMTPAuth, err)
+		}
 	}
 
+	return client, conn, nil
+}
+
+// sendSMTPMessage sends msg from from to the to addresses over an open connection
+func sendSMTPMessage(client *smtp.Client, from string, to []string, msg []byte) error {
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("%w: %w", ErrSMTPData, err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("%w: %w", ErrSMTPData, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSMTPData, err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("%w: %w", ErrSMTPData, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrSMTPData, err)
+	}
 	return nil
 }
 
+// AdminRecipients returns the recipients of admin notifications: ADMIN_EMAIL, copying ADMIN_EMAIL_CC
+func (s *EmailService) AdminRecipients() EmailRecipients {
+	cfg := s.config()
+	return EmailRecipients{To: cfg.AdminEmail, Cc: cfg.AdminEmailCc}
+}
+
 // IsEnabled returns whether email service is enabled
 func (s *EmailService) IsEnabled() bool {
-	return s.cfg.Enabled
+	return config.FeatureFlag(config.FeatureEmail)
 }
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	return string(out)
+}
+
+func TestConsoleSenderPrintsBodyOnlyWithPlaintextOTPLogging(t *testing.T) {
+	msg := testEmail("investor@example.com")
+	msg.Text = "Your code is 482913"
+
+	for _, tc := range []struct {
+		debug, plaintext string
+		wantBody         bool
+	}{
+		{"false", "false", false},
+		{"true", "false", false},
+		{"false", "true", false},
+		{"true", "true", true},
+	} {
+		t.Run("debug="+tc.debug+",plaintext="+tc.plaintext, func(t *testing.T) {
+			t.Setenv("DEBUG", tc.debug)
+			t.Setenv("OTP_LOG_PLAINTEXT", tc.plaintext)
+			loadTestConfig(t)
+
+			out := captureStdout(t, func() {
+				if err := (consoleSender{}).Send(msg); err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+			})
+			if !strings.Contains(out, "test email to investor@example.com: Hello") {
+				t.Errorf("output %q is missing the envelope", out)
+			}
+			if got := strings.Contains(out, "482913"); got != tc.wantBody {
+				t.Errorf("output %q contains the body = %v, want %v", out, got, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+)
+
+// maxEmailLogErrorLength caps the error stored with a failed email
+const maxEmailLogErrorLength = 500
+
+// recordEmailLog stores the outcome of msg, one entry per recipient, under ctx's organization.
+// sendErr is nil when the provider accepted the email. A failure to store the log is only
+// logged: the email has already been sent or given up on.
+func (s *EmailService) recordEmailLog(ctx context.Context, msg *EmailMessage, provider string, sendErr error) {
+	if s.db == nil {
+		return
+	}
+
+	status := domain.EmailLogSent
+	var errText *string
+	if sendErr != nil {
+		status = domain.EmailLogFailed
+		text := sendErr.Error()
+		if len(text) > maxEmailLogErrorLength {
+			text = text[:maxEmailLogErrorLength]
+		}
+		errText = &text
+	}
+
+	orgID := OrganizationFromContext(ctx)
+	recipients := msg.envelopeRecipients()
+	entries := make([]domain.EmailLog, 0, len(recipients))
+	for _, address := range recipients {
+		entries = append(entries, domain.EmailLog{
+			OrganizationID: orgID,
+			RecipientHash:  hashEmailAddress(address),
+			Recipient:      maskEmail(address),
+			Type:           msg.Type,
+			Provider:       provider,
+			Status:         status,
+			Error:          errText,
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if err := s.db.Create(&entries).Error; err != nil {
+		log.Printf("[EMAIL] Failed to record %s email log: %v", msg.Type, err)
+	}
+}
+
+// hashEmailAddress returns the HMAC-SHA256 of the lowercased address keyed with the
+// application secret, so the email log can be searched by address without storing it
+func hashEmailAddress(address string) string {
+	mac := hmac.New(sha256.New, []byte(config.Get().Auth.SecretKey))
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(address))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// maskEmail keeps the first two characters of the local part and the domain,
+// e.g. ravi@example.com becomes ra***@example.com
+func maskEmail(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return "***"
+	}
+	local, domain := address[:at], address[at+1:]
+	if len(local) > 2 {
+		local = local[:2]
+	}
+	return local + "***@" + domain
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrAttachmentsTooLarge is returned when an email's attachments exceed EmailConfig.MaxAttachmentBytes
+var ErrAttachmentsTooLarge = errors.New("email attachments too large")
+
+const (
+	// base64LineLength is the longest encoded line RFC 2045 allows
+	base64LineLength = 76
+
+	// maxHeaderLineLength is the line length RFC 5322 recommends staying within
+	maxHeaderLineLength = 78
+)
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string // e.g. application/pdf; guessed from Filename when empty
+	Content     []byte
+}
+
+// contentType returns the attachment's content type, guessed from its file name if unset
+func (a Attachment) contentType() string {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.filename()))
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+// filename returns the attachment's file name without any directory or control characters
+func (a Attachment) filename() string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, a.Filename)
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// attachmentsSize returns the total size of the attachments' content, before encoding
+func attachmentsSize(attachments []Attachment) int {
+	total := 0
+	for _, a := range attachments {
+		total += len(a.Content)
+	}
+	return total
+}
+
+// buildMIMEMessage renders msg with its headers, as sent over SMTP or handed to SES as raw
+// content. The text and HTML bodies form a multipart/alternative; with attachments, that is
+// the first part of a multipart/mixed followed by one base64 part per attachment.
+func buildMIMEMessage(msg *EmailMessage) ([]byte, error) {
+	// net/mail quotes or encodes the display name, so it can't break the From header
+	from := (&mail.Address{Name: msg.FromName, Address: msg.From}).String()
+
+	var body bytes.Buffer
+	alternative := multipart.NewWriter(&body)
+	if err := writeAlternativeParts(alternative, msg.Text, msg.HTML); err != nil {
+		return nil, err
+	}
+	contentType := "multipart/alternative; boundary=" + alternative.Boundary()
+
+	if len(msg.Attachments) > 0 {
+		var mixedBody bytes.Buffer
+		mixed := multipart.NewWriter(&mixedBody)
+		part, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(body.Bytes()); err != nil {
+			return nil, err
+		}
+		for _, attachment := range msg.Attachments {
+			if err := writeAttachmentPart(mixed, attachment); err != nil {
+				return nil, err
+			}
+		}
+		if err := mixed.Close(); err != nil {
+			return nil, err
+		}
+		body = mixedBody
+		contentType = "multipart/mixed; boundary=" + mixed.Boundary()
+	}
+
+	var message bytes.Buffer
+	writeHeader(&message, "From", from)
+	// Bcc recipients are only in the envelope
+	if len(msg.To) > 0 {
+		writeHeader(&message, "To", strings.Join(msg.To, ", "))
+	} else {
+		writeHeader(&message, "To", "undisclosed-recipients:;")
+	}
+	if len(msg.Cc) > 0 {
+		writeHeader(&message, "Cc", strings.Join(msg.Cc, ", "))
+	}
+	if msg.ReplyTo != "" {
+		writeHeader(&message, "Reply-To", msg.ReplyTo)
+	}
+	for _, name := range sortedKeys(msg.Headers) {
+		writeHeader(&message, name, msg.Headers[name])
+	}
+	writeHeader(&message, "Subject", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	writeHeader(&message, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&message, "Message-ID", newMessageID(msg.From))
+	writeHeader(&message, "MIME-Version", "1.0")
+	writeHeader(&message, "Content-Type", contentType)
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// writeHeader writes a header field, folding it at spaces so its lines stay within
+// maxHeaderLineLength where the value allows. Folding only inserts CRLF before existing
+// whitespace, so the unfolded value is unchanged (RFC 5322 section 2.2.3).
+func writeHeader(w *bytes.Buffer, name, value string) {
+	w.WriteString(name + ":")
+	lineLen := len(name) + 1
+	for _, word := range strings.Split(value, " ") {
+		if word != "" && lineLen > len(name)+1 && lineLen+1+len(word) > maxHeaderLineLength {
+			w.WriteString("\r\n")
+			lineLen = 0
+		}
+		w.WriteString(" " + word)
+		lineLen += 1 + len(word)
+	}
+	w.WriteString("\r\n")
+}
+
+// sortedKeys returns the keys of headers in alphabetical order, so messages are built the
+// same way every time
+func sortedKeys(headers map[string]string) []string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// Unique enough without the random part
+		return fmt.Sprintf("<%d@%s>", time.Now().UnixNano(), domain)
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().Unix(), hex.EncodeToString(id), domain)
+}
+
+// writeAlternativeParts writes the plain text part and, if there is one, the HTML part,
+// both quoted-printable, and closes w
+func writeAlternativeParts(w *multipart.Writer, text, html string) error {
+	parts := []struct{ contentType, body string }{{"text/plain; charset=UTF-8", text}}
+	if html != "" {
+		parts = append(parts, struct{ contentType, body string }{"text/html; charset=UTF-8", html})
+	}
+
+	for _, p := range parts {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// writeAttachmentPart writes attachment as a base64 part wrapped at base64LineLength
+func writeAttachmentPart(w *multipart.Writer, attachment Attachment) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(attachment.contentType(), map[string]string{"name": attachment.filename()})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.filename()})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+	for len(encoded) > 0 {
+		n := min(base64LineLength, len(encoded))
+		if _, err := part.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// mimePart is a decoded leaf part of a parsed message
+type mimePart struct {
+	contentType string
+	params      map[string]string
+	header      map[string][]string
+	raw         []byte // As transmitted
+	body        []byte // With the transfer encoding undone
+}
+
+// parseMIMEMessage parses a message built by buildMIMEMessage and returns its headers and
+// its leaf parts in order
+func parseMIMEMessage(t *testing.T, data []byte) (*mail.Message, []mimePart) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return msg, collectParts(t, msg.Header.Get("Content-Type"), body)
+}
+
+func collectParts(t *testing.T, contentType string, body []byte) []mimePart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType(%q): %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("Content-Type = %s, want a multipart message", mediaType)
+	}
+
+	var parts []mimePart
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("NextRawPart: %v", err)
+		}
+		raw, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+
+		partType := part.Header.Get("Content-Type")
+		if strings.HasPrefix(partType, "multipart/") {
+			parts = append(parts, mimePart{contentType: partType})
+			parts = append(parts, collectParts(t, partType, raw)...)
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(partType)
+		if err != nil {
+			t.Fatalf("ParseMediaType(%q): %v", partType, err)
+		}
+		decoded := decodePart(t, part.Header.Get("Content-Transfer-Encoding"), raw)
+		parts = append(parts, mimePart{contentType: mediaType, params: params, header: part.Header, raw: raw, body: decoded})
+	}
+}
+
+// decodePart undoes a part's transfer encoding
+func decodePart(t *testing.T, encoding string, raw []byte) []byte {
+	t.Helper()
+	switch strings.ToLower(encoding) {
+	case "base64":
+		decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(raw)))
+		if err != nil {
+			t.Fatalf("decode base64: %v", err)
+		}
+		return decoded
+	case "quoted-printable":
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(raw)))
+		if err != nil {
+			t.Fatalf("decode quoted-printable: %v", err)
+		}
+		return decoded
+	}
+	return raw
+}
+
+func TestBuildMIMEMessageWithAttachments(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF-1.7 statement\x00\xff"), 40)
+	msg := testEmail("investor@example.com")
+	msg.HTML = "<p>Your statement is attached</p>"
+	msg.Attachments = []Attachment{
+		{Filename: "statement.pdf", Content: pdf},
+		{Filename: "../../etc/notes.txt", ContentType: "text/plain; charset=UTF-8", Content: []byte("read me")},
+		{Filename: "data", Content: []byte{0, 1, 2}},
+	}
+
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	header, parts := parseMIMEMessage(t, data)
+
+	if mediaType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type")); mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %s, want multipart/mixed", mediaType)
+	}
+	var types []string
+	for _, part := range parts {
+		mediaType, _, _ := mime.ParseMediaType(part.contentType)
+		types = append(types, mediaType)
+	}
+	want := []string{"multipart/alternative", "text/plain", "text/html", "application/pdf", "text/plain", "application/octet-stream"}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("parts = %v, want %v", types, want)
+	}
+
+	if string(parts[1].body) != msg.Text || string(parts[2].body) != msg.HTML {
+		t.Errorf("bodies = %q, %q, want the text and HTML", parts[1].body, parts[2].body)
+	}
+	for i, attachment := range []struct {
+		filename string
+		content  []byte
+	}{{"statement.pdf", pdf}, {"notes.txt", []byte("read me")}, {"data", []byte{0, 1, 2}}} {
+		part := parts[3+i]
+		if !bytes.Equal(part.body, attachment.content) {
+			t.Errorf("attachment %d content differs after decoding", i)
+		}
+		_, disposition, err := mime.ParseMediaType(strings.Join(part.header["Content-Disposition"], ""))
+		if err != nil || disposition["filename"] != attachment.filename || part.params["name"] != attachment.filename {
+			t.Errorf("attachment %d filename = %q, name = %q, want %q", i, disposition["filename"], part.params["name"], attachment.filename)
+		}
+	}
+}
+
+func TestBuildMIMEMessageWrapsBase64(t *testing.T) {
+	msg := testEmail("investor@example.com")
+	msg.Attachments = []Attachment{{Filename: "statement.pdf", Content: bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 1000)}}
+
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	_, parts := parseMIMEMessage(t, data)
+	attachment := parts[len(parts)-1]
+
+	lines := strings.Split(strings.TrimRight(string(attachment.raw), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("the base64 content is on %d line, want it wrapped", len(lines))
+	}
+	for i, line := range lines {
+		if len(line) > base64LineLength {
+			t.Fatalf("line %d is %d characters, want at most %d", i, len(line), base64LineLength)
+		}
+		if i < len(lines)-1 && len(line) != base64LineLength {
+			t.Fatalf("line %d is %d characters, want full lines of %d", i, len(line), base64LineLength)
+		}
+	}
+}
+
+func TestBuildMIMEMessageWithoutAttachments(t *testing.T) {
+	msg := testEmail("investor@example.com")
+	msg.HTML = "<p>Hello</p>"
+
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	header, parts := parseMIMEMessage(t, data)
+	if mediaType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type")); mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %s, want multipart/alternative", mediaType)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("%d parts, want the text and HTML", len(parts))
+	}
+}
+
+func TestSendRejectsOversizedAttachments(t *testing.T) {
+	t.Setenv("EMAIL_ENABLED", "true")
+	t.Setenv("ADMIN_EMAIL", "ops@springstreet.in")
+	t.Setenv("EMAIL_MAX_ATTACHMENT_BYTES", "1024")
+	loadTestConfig(t)
+	s, sender := newRecordingEmailService(t)
+
+	attachments := []Attachment{{Filename: "a.pdf", Content: make([]byte, 600)}, {Filename: "b.pdf", Content: make([]byte, 600)}}
+	err := s.SendHTMLEmailWithAttachments(context.Background(), EmailTo("investor@example.com"), "Statements", "", "Attached", attachments)
+	if !errors.Is(err, ErrAttachmentsTooLarge) {
+		t.Fatalf("SendHTMLEmailWithAttachments = %v, want ErrAttachmentsTooLarge for 1200 bytes over a 1024 byte limit", err)
+	}
+	if len(sender.messages) != 0 {
+		t.Fatal("the oversized email was sent")
+	}
+
+	if err := s.SendHTMLEmailWithAttachments(context.Background(), EmailTo("investor@example.com"), "Statements", "", "Attached", attachments[:1]); err != nil {
+		t.Fatalf("SendHTMLEmailWithAttachments within the limit: %v", err)
+	}
+	if len(sender.messages) != 1 {
+		t.Fatal("the email within the limit was not sent")
+	}
+}
+
+func TestBuildMIMEMessageEncodesBodies(t *testing.T) {
+	longLine := strings.Repeat("Invest in US stocks from India with Spring Street 📈 ", 12)
+	msg := testEmail("investor@example.com")
+	msg.Text = "Namaste Señor 🙏\nनमस्ते\n" + longLine + "\nA line ending in spaces   \n=3D is not an escape\n.\n"
+	msg.HTML = "<p style=\"color: #333\">नमस्ते 🙏</p>" + strings.Repeat("<span>long html line</span>", 20)
+
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	_, parts := parseMIMEMessage(t, data)
+	if len(parts) != 2 {
+		t.Fatalf("%d parts, want the text and HTML", len(parts))
+	}
+
+	for i, want := range []string{msg.Text, msg.HTML} {
+		part := parts[i]
+		if got := part.header["Content-Transfer-Encoding"]; len(got) != 1 || got[0] != "quoted-printable" {
+			t.Errorf("part %d Content-Transfer-Encoding = %v, want quoted-printable", i, got)
+		}
+		if part.params["charset"] != "UTF-8" {
+			t.Errorf("part %d charset = %q, want UTF-8", i, part.params["charset"])
+		}
+		// Quoted-printable text is sent with CRLF line breaks
+		if got := strings.ReplaceAll(string(part.body), "\r\n", "\n"); got != want {
+			t.Errorf("part %d decodes to %q, want %q", i, got, want)
+		}
+		for n, line := range strings.Split(string(part.raw), "\r\n") {
+			if len(line) > 76 {
+				t.Errorf("part %d line %d is %d characters, want at most 76", i, n, len(line))
+			}
+			for _, c := range []byte(line) {
+				if c > 127 {
+					t.Fatalf("part %d line %d contains 8-bit data: %q", i, n, line)
+				}
+			}
+		}
+	}
+}
+
+func TestBuildMIMEMessageEncodesHeaders(t *testing.T) {
+	msg := testEmail("investor@example.com")
+	msg.FromName = "Señor Jiménez, Spring Street"
+	msg.Subject = "Your statement for नवंबर is ready 📄 — " + strings.Repeat("a very long subject ", 6)
+
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+
+	headerEnd := bytes.Index(data, []byte("\r\n\r\n"))
+	for n, line := range strings.Split(string(data[:headerEnd]), "\r\n") {
+		if len(line) > maxHeaderLineLength {
+			t.Errorf("header line %d is %d characters, want at most %d: %q", n, len(line), maxHeaderLineLength, line)
+		}
+		for _, c := range []byte(line) {
+			if c > 127 {
+				t.Fatalf("header line %d contains 8-bit data: %q", n, line)
+			}
+		}
+	}
+
+	parsed, _ := parseMIMEMessage(t, data)
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("DecodeHeader(Subject): %v", err)
+	}
+	if subject != msg.Subject {
+		t.Errorf("Subject decodes to %q, want %q", subject, msg.Subject)
+	}
+	from, err := parsed.Header.AddressList("From")
+	if err != nil || len(from) != 1 {
+		t.Fatalf("From = %v, %v, want one address", from, err)
+	}
+	if from[0].Name != msg.FromName || from[0].Address != msg.From {
+		t.Errorf("From = %q <%s>, want %q <%s>", from[0].Name, from[0].Address, msg.FromName, msg.From)
+	}
+}
+
+func TestBuildMIMEMessageKeepsASCIISubject(t *testing.T) {
+	data, err := buildMIMEMessage(testEmail("investor@example.com"))
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	if !bytes.Contains(data, []byte("\r\nSubject: Hello\r\n")) {
+		t.Fatal("a plain ASCII Subject was encoded")
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/textproto"
+	"syscall"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/metrics"
+)
+
+// Email types, used to label email metrics
+const (
+	emailTypeOTP                 = "otp"
+	emailTypeContactNotification = "contact_notification"
+	emailTypeFollowUpReminder    = "follow_up_reminder"
+	emailTypeGeneric             = "generic"
+	emailTypeTest                = "test"
+	emailTypeMarketing           = "marketing" // The only type that isn't transactional; see SendMarketingEmail
+)
+
+// ErrEmailSendFailed wraps the last error of an email EmailService has given up on, whether
+// the failure was permanent or every attempt failed. Callers should not retry it themselves.
+var ErrEmailSendFailed = errors.New("email not sent")
+
+// sendWithRetry calls send up to cfg.RetryMaxAttempts times, waiting with exponential backoff
+// and jitter between attempts. Only temporary failures are retried; a permanent one, such as
+// a rejected recipient, fails at once.
+func sendWithRetry(cfg *config.EmailConfig, emailType string, send func() error) error {
+	start := time.Now()
+	var err error
+	for attempt := 1; attempt <= cfg.RetryMaxAttempts; attempt++ {
+		metrics.RecordEmailAttempt(emailType)
+		if err = send(); err == nil {
+			metrics.RecordEmailSent(emailType, cfg.Provider, time.Since(start))
+			return nil
+		}
+		if !isTransientEmailError(err) {
+			log.Printf("[EMAIL] %s email failed permanently: %v", emailType, err)
+			metrics.RecordEmailFailure(emailType, cfg.Provider, true, time.Since(start))
+			return fmt.Errorf("%w: %w", ErrEmailSendFailed, err)
+		}
+		if attempt < cfg.RetryMaxAttempts {
+			delay := emailRetryDelay(cfg, attempt, err)
+			log.Printf("[EMAIL] %s email failed (attempt %d/%d), retrying in %v: %v", emailType, attempt, cfg.RetryMaxAttempts, delay, err)
+			metrics.RecordEmailRetry(emailType)
+			time.Sleep(delay)
+		}
+	}
+
+	log.Printf("[EMAIL] %s email failed after %d attempts: %v", emailType, cfg.RetryMaxAttempts, err)
+	metrics.RecordEmailFailure(emailType, cfg.Provider, false, time.Since(start))
+	return fmt.Errorf("%w after %d attempts: %w", ErrEmailSendFailed, cfg.RetryMaxAttempts, err)
+}
+
+// maxEmailRetryAfter caps how long a provider's Retry-After can hold up a retry
+const maxEmailRetryAfter = time.Minute
+
+// emailRetryDelay returns the wait after the given failed attempt: the base delay doubled
+// for each earlier retry, plus up to RetryJitterMS of random delay. A longer Retry-After
+// from the provider (up to maxEmailRetryAfter) takes precedence.
+func emailRetryDelay(cfg *config.EmailConfig, attempt int, err error) time.Duration {
+	delay := time.Duration(cfg.RetryBaseDelayMS) * time.Millisecond << (attempt - 1)
+	if cfg.RetryJitterMS > 0 {
+		delay += time.Duration(rand.Int64N(int64(cfg.RetryJitterMS))) * time.Millisecond
+	}
+
+	var sendGridErr *SendGridError
+	if errors.As(err, &sendGridErr) && sendGridErr.RetryAfter > delay {
+		delay = min(sendGridErr.RetryAfter, maxEmailRetryAfter)
+	}
+	return delay
+}
+
+// isTransientEmailError reports whether err may go away on retry: 4xx SMTP replies, timeouts,
+// dropped connections and servers that can't be reached. 5xx replies, TLS and configuration
+// problems are permanent. API provider errors decide for themselves.
+func isTransientEmailError(err error) bool {
+	var sendGridErr *SendGridError
+	if errors.As(err, &sendGridErr) {
+		return sendGridErr.Temporary()
+	}
+	var sesErr *SESError
+	if errors.As(err, &sesErr) {
+		return sesErr.Temporary()
+	}
+
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	return errors.Is(err, ErrSMTPDial)
+}
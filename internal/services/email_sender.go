@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"springstreet/internal/config"
+	"springstreet/internal/util"
+)
+
+// Email providers (EmailConfig.Provider)
+const (
+	EmailProviderSMTP     = "smtp"
+	EmailProviderSendGrid = "sendgrid"
+	EmailProviderSES      = "ses"
+	EmailProviderConsole  = "console" // Logs emails instead of sending them
+)
+
+// ErrInvalidRecipient is returned when an email address to send to is malformed
+var ErrInvalidRecipient = errors.New("invalid email recipient")
+
+// EmailRecipients are the addresses an email is sent to. Bcc recipients receive the email
+// but aren't listed in its headers. ReplyTo is optional; replies go to the sender without it.
+type EmailRecipients struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+}
+
+// EmailTo returns the recipients for an email to the given addresses only
+func EmailTo(to ...string) EmailRecipients {
+	return EmailRecipients{To: to}
+}
+
+// normalize checks every address and returns the recipients with surrounding spaces
+// trimmed and each address kept only in the first list it appears in (To, then Cc, then
+// Bcc), since providers reject duplicates. It fails if there are no recipients at all.
+func (r EmailRecipients) normalize() (EmailRecipients, error) {
+	seen := make(map[string]bool)
+	clean := func(list []string) ([]string, error) {
+		var out []string
+		for _, addr := range list {
+			addr = strings.TrimSpace(addr)
+			if err := validateEmailAddress(addr); err != nil {
+				return nil, err
+			}
+			if key := strings.ToLower(addr); !seen[key] {
+				seen[key] = true
+				out = append(out, addr)
+			}
+		}
+		return out, nil
+	}
+
+	var out EmailRecipients
+	var err error
+	if out.To, err = clean(r.To); err != nil {
+		return out, err
+	}
+	if out.Cc, err = clean(r.Cc); err != nil {
+		return out, err
+	}
+	if out.Bcc, err = clean(r.Bcc); err != nil {
+		return out, err
+	}
+	if len(seen) == 0 {
+		return out, fmt.Errorf("email has no recipients")
+	}
+	if out.ReplyTo = strings.TrimSpace(r.ReplyTo); out.ReplyTo != "" {
+		if err := validateEmailAddress(out.ReplyTo); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// validateEmailAddress accepts a bare address such as ops@springstreet.in
+func validateEmailAddress(addr string) error {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil || parsed.Address != addr {
+		return fmt.Errorf("%w: %q", ErrInvalidRecipient, addr)
+	}
+	return nil
+}
+
+// EmailMessage is one outbound email, whichever provider delivers it
+type EmailMessage struct {
+	Type        string // emailType*, used for metrics and provider analytics
+	From        string
+	FromName    string
+	To          []string
+	Cc          []string // Optional
+	Bcc         []string // Optional; never written to the headers
+	ReplyTo     string   // Optional
+	Subject     string
+	HTML        string // Optional
+	Text        string
+	Attachments []Attachment      // Optional
+	Headers     map[string]string // Optional extra headers, e.g. List-Unsubscribe
+}
+
+// envelopeRecipients returns every address the email is delivered to, including Bcc
+func (m *EmailMessage) envelopeRecipients() []string {
+	all := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	all = append(all, m.To...)
+	all = append(all, m.Cc...)
+	return append(all, m.Bcc...)
+}
+
+// EmailSender delivers an email through one provider. EmailService handles retries, so Send
+// makes a single attempt.
+type EmailSender interface {
+	Send(msg *EmailMessage) error
+}
+
+// NewEmailSender returns the sender for cfg.Provider
+func NewEmailSender(cfg *config.EmailConfig) EmailSender {
+	switch cfg.Provider {
+	case EmailProviderSendGrid:
+		return NewSendGridSender(cfg)
+	case EmailProviderSES:
+		return NewSESSender(cfg)
+	case EmailProviderConsole:
+		return consoleSender{}
+	default:
+		return &smtpSender{cfg: cfg}
+	}
+}
+
+// consoleSender prints emails to stdout, for development. Bodies carry OTP codes and reset
+// links, so only the envelope is printed unless plaintext OTP logging is enabled.
+type consoleSender struct{}
+
+// Send implements EmailSender
+func (consoleSender) Send(msg *EmailMessage) error {
+	fmt.Printf("[EMAIL] %s email to %s: %s\n", msg.Type, strings.Join(msg.To, ", "), msg.Subject)
+	if len(msg.Cc) > 0 || len(msg.Bcc) > 0 {
+		fmt.Printf("[EMAIL] Cc: %s; Bcc: %s\n", strings.Join(msg.Cc, ", "), strings.Join(msg.Bcc, ", "))
+	}
+	if msg.ReplyTo != "" {
+		fmt.Printf("[EMAIL] Reply-To: %s\n", msg.ReplyTo)
+	}
+	for _, name := range sortedKeys(msg.Headers) {
+		fmt.Printf("[EMAIL] %s: %s\n", name, msg.Headers[name])
+	}
+	if util.PlaintextOTPLogging() {
+		fmt.Printf("%s\n", msg.Text)
+	} else {
+		fmt.Printf("[EMAIL] Body: %d bytes [redacted]\n", len(msg.Text))
+	}
+	for _, a := range msg.Attachments {
+		fmt.Printf("[EMAIL] Attachment: %s (%s, %d bytes)\n", a.filename(), a.contentType(), len(a.Content))
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"springstreet/internal/config"
+)
+
+// sendGridAPIURL is SendGrid's v3 mail send endpoint
+var sendGridAPIURL = "https://api.sendgrid.com/v3/mail/send"
+
+const sendGridRequestTimeout = 30 * time.Second
+
+// SendGridSender delivers email through SendGrid's HTTPS API, for networks where outbound
+// SMTP is blocked
+type SendGridSender struct {
+	apiKey     string
+	sandbox    bool
+	categories []string
+	client     *http.Client
+}
+
+// NewSendGridSender creates a SendGrid sender from the email configuration
+func NewSendGridSender(cfg *config.EmailConfig) *SendGridSender {
+	return &SendGridSender{
+		apiKey:     cfg.SendGridAPIKey,
+		sandbox:    cfg.SendGridSandbox,
+		categories: cfg.SendGridCategories,
+		client:     &http.Client{Timeout: sendGridRequestTimeout},
+	}
+}
+
+// SendGridError is a failed SendGrid request. It unwraps to ErrSMTPAuth for a rejected API
+// key, ErrSMTPData for a rejected message and ErrSMTPDial when SendGrid couldn't be reached.
+// Rate limiting (429) and server errors are temporary; RetryAfter is SendGrid's requested wait.
+type SendGridError struct {
+	StatusCode int // 0 when no response was received
+	Message    string
+	RetryAfter time.Duration
+	kind       error
+}
+
+func (e *SendGridError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("sendgrid request failed: %s", e.Message)
+	}
+	return fmt.Sprintf("sendgrid returned status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *SendGridError) Unwrap() error {
+	return e.kind
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *SendGridError) Temporary() bool {
+	return e.StatusCode == 0 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"` // Base64
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+}
+
+type sendGridPersonalization struct {
+	To  []sendGridAddress `json:"to,omitempty"`
+	Cc  []sendGridAddress `json:"cc,omitempty"`
+	Bcc []sendGridAddress `json:"bcc,omitempty"`
+}
+
+type sendGridMailSettings struct {
+	SandboxMode sendGridSetting `json:"sandbox_mode"`
+}
+
+type sendGridSetting struct {
+	Enable bool `json:"enable"`
+}
+
+// sendGridRequest is the body of a mail send request
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+	Categories       []string                  `json:"categories,omitempty"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	MailSettings     *sendGridMailSettings     `json:"mail_settings,omitempty"`
+}
+
+// sendGridErrorResponse is the body SendGrid returns with an error status
+type sendGridErrorResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+		Field   string `json:"field"`
+	} `json:"errors"`
+}
+
+// Send implements EmailSender. All recipients share one personalization, so each sees the
+// others in To and Cc, as with SMTP.
+func (s *SendGridSender) Send(msg *EmailMessage) error {
+	if s.apiKey == "" {
+		return fmt.Errorf("email service not properly configured")
+	}
+
+	body, err := json.Marshal(s.buildRequest(msg))
+	if err != nil {
+		return fmt.Errorf("failed to encode sendgrid request: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, sendGridAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build sendgrid request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := doWithBreaker(providerSendGrid, s.client, req)
+	if err != nil {
+		return &SendGridError{Message: err.Error(), kind: ErrSMTPDial}
+	}
+	defer resp.Body.Close()
+
+	// 202 when queued for delivery, 200 in sandbox mode
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
+		return nil
+	}
+	return parseSendGridError(resp)
+}
+
+func (s *SendGridSender) buildRequest(msg *EmailMessage) *sendGridRequest {
+	req := &sendGridRequest{
+		From:    sendGridAddress{Email: msg.From, Name: msg.FromName},
+		Subject: msg.Subject,
+	}
+
+	// SendGrid requires a To, so an email only to Bcc recipients is addressed to the sender
+	to := msg.To
+	if len(to) == 0 {
+		to = []string{msg.From}
+	}
+	req.Personalizations = []sendGridPersonalization{{
+		To:  sendGridAddresses(to),
+		Cc:  sendGridAddresses(msg.Cc),
+		Bcc: sendGridAddresses(msg.Bcc),
+	}}
+	if msg.ReplyTo != "" {
+		req.ReplyTo = &sendGridAddress{Email: msg.ReplyTo}
+	}
+	req.Headers = msg.Headers
+
+	// SendGrid requires text/plain before text/html and rejects empty values
+	if msg.Text != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	if msg.HTML != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+
+	for _, a := range msg.Attachments {
+		req.Attachments = append(req.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(a.Content),
+			Type:        a.contentType(),
+			Filename:    a.filename(),
+			Disposition: "attachment",
+		})
+	}
+
+	// Tag each email with its type so SendGrid's statistics can be broken down by it
+	req.Categories = append([]string{msg.Type}, s.categories...)
+
+	if s.sandbox {
+		req.MailSettings = &sendGridMailSettings{SandboxMode: sendGridSetting{Enable: true}}
+	}
+	return req
+}
+
+// sendGridAddresses converts a list of bare addresses
+func sendGridAddresses(addrs []string) []sendGridAddress {
+	if len(addrs) == 0 {
+		return nil
+	}
+	out := make([]sendGridAddress, len(addrs))
+	for i, addr := range addrs {
+		out[i] = sendGridAddress{Email: addr}
+	}
+	return out
+}
+
+// parseSendGridError maps an error response onto a SendGridError
+func parseSendGridError(resp *http.Response) error {
+	e := &SendGridError{StatusCode: resp.StatusCode}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		e.kind = ErrSMTPAuth
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if seconds, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && seconds > 0 {
+			e.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	case resp.StatusCode < http.StatusInternalServerError:
+		e.kind = ErrSMTPData
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body sendGridErrorResponse
+	if err := json.Unmarshal(raw, &body); err == nil && len(body.Errors) > 0 {
+		messages := make([]string, len(body.Errors))
+		for i, item := range body.Errors {
+			messages[i] = item.Message
+			if item.Field != "" {
+				messages[i] = item.Field + ": " + item.Message
+			}
+		}
+		e.Message = strings.Join(messages, "; ")
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"springstreet/internal/config"
+)
+
+// newSendGridSender returns a SendGrid sender whose requests go to handler
+func newSendGridSender(t *testing.T, cfg *config.EmailConfig, handler http.HandlerFunc) *SendGridSender {
+	t.Helper()
+	resetProviderBreakers(t)
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	original := sendGridAPIURL
+	sendGridAPIURL = ts.URL + "/v3/mail/send"
+	t.Cleanup(func() { sendGridAPIURL = original })
+
+	if cfg.SendGridAPIKey == "" {
+		cfg.SendGridAPIKey = "SG.test-key"
+	}
+	return NewSendGridSender(cfg)
+}
+
+func TestSendGridSend(t *testing.T) {
+	var got sendGridRequest
+	var auth string
+	sender := newSendGridSender(t, &config.EmailConfig{SendGridSandbox: true, SendGridCategories: []string{"springstreet"}}, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	msg := testEmail("investor@example.com")
+	msg.Cc = []string{"ops@springstreet.in"}
+	msg.ReplyTo = "asha@example.com"
+	msg.HTML = "<p>Hello</p>"
+	msg.Attachments = []Attachment{{Filename: "statement.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}}
+	if err := sender.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if auth != "Bearer SG.test-key" {
+		t.Errorf("Authorization = %q, want the API key as a bearer token", auth)
+	}
+	if len(got.Personalizations) != 1 || len(got.Personalizations[0].To) != 1 || got.Personalizations[0].To[0].Email != "investor@example.com" ||
+		len(got.Personalizations[0].Cc) != 1 || got.Personalizations[0].Cc[0].Email != "ops@springstreet.in" {
+		t.Errorf("personalizations = %+v, want the To and Cc recipients", got.Personalizations)
+	}
+	if got.ReplyTo == nil || got.ReplyTo.Email != "asha@example.com" {
+		t.Errorf("reply_to = %+v, want asha@example.com", got.ReplyTo)
+	}
+	if len(got.Content) != 2 || got.Content[0].Type != "text/plain" || got.Content[1].Type != "text/html" {
+		t.Errorf("content = %+v, want text/plain then text/html", got.Content)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].Content != "JVBERg==" || got.Attachments[0].Filename != "statement.pdf" {
+		t.Errorf("attachments = %+v, want the base64 encoded statement", got.Attachments)
+	}
+	if len(got.Categories) != 2 || got.Categories[0] != "test" || got.Categories[1] != "springstreet" {
+		t.Errorf("categories = %v, want the email type then the configured categories", got.Categories)
+	}
+	if got.MailSettings == nil || !got.MailSettings.SandboxMode.Enable {
+		t.Error("sandbox mode was not enabled")
+	}
+}
+
+func TestSendGridSendRejectedKey(t *testing.T) {
+	sender := newSendGridSender(t, &config.EmailConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"message":"The provided authorization grant is invalid, expired, or revoked","field":null}]}`))
+	})
+
+	err := sender.Send(testEmail("investor@example.com"))
+	var sendGridErr *SendGridError
+	if !errors.As(err, &sendGridErr) || sendGridErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Send = %v, want a 401 SendGridError", err)
+	}
+	if !errors.Is(err, ErrSMTPAuth) {
+		t.Error("a rejected API key does not unwrap to ErrSMTPAuth")
+	}
+	if sendGridErr.Temporary() || isTransientEmailError(err) {
+		t.Error("a rejected API key is treated as temporary")
+	}
+	if sendGridErr.Message != "The provided authorization grant is invalid, expired, or revoked" {
+		t.Errorf("Message = %q, want SendGrid's error message", sendGridErr.Message)
+	}
+}
+
+func TestSendGridSendRateLimited(t *testing.T) {
+	sender := newSendGridSender(t, &config.EmailConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"errors":[{"message":"too many requests"}]}`))
+	})
+
+	err := sender.Send(testEmail("investor@example.com"))
+	var sendGridErr *SendGridError
+	if !errors.As(err, &sendGridErr) || sendGridErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Send = %v, want a 429 SendGridError", err)
+	}
+	if sendGridErr.RetryAfter != 7*time.Second {
+		t.Errorf("RetryAfter = %v, want 7s", sendGridErr.RetryAfter)
+	}
+	if !isTransientEmailError(err) {
+		t.Error("rate limiting is not treated as temporary")
+	}
+
+	// Retry-After outweighs a shorter backoff and is capped
+	cfg := &config.EmailConfig{RetryBaseDelayMS: 100}
+	if delay := emailRetryDelay(cfg, 1, err); delay != 7*time.Second {
+		t.Errorf("emailRetryDelay = %v, want SendGrid's 7s", delay)
+	}
+	sendGridErr.RetryAfter = time.Hour
+	if delay := emailRetryDelay(cfg, 1, err); delay != maxEmailRetryAfter {
+		t.Errorf("emailRetryDelay = %v, want the %v cap", delay, maxEmailRetryAfter)
+	}
+}
+
+func TestSendGridRetriesAfterRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	sender := newSendGridSender(t, &config.EmailConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	cfg := &config.EmailConfig{Provider: "sendgrid", RetryMaxAttempts: 2, RetryBaseDelayMS: 10}
+	start := time.Now()
+	if err := sendWithRetry(cfg, "test", func() error { return sender.Send(testEmail("investor@example.com")) }); err != nil {
+		t.Fatalf("sendWithRetry: %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("SendGrid received %d requests, want 2", requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %v, want SendGrid's Retry-After of 1s honoured", elapsed)
+	}
+}
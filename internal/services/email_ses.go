@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/aws/smithy-go"
+
+	"springstreet/internal/config"
+)
+
+const sesRequestTimeout = 30 * time.Second
+
+// sesAPI is the part of the SES v2 client SESSender uses
+type sesAPI interface {
+	SendEmail(ctx context.Context, params *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+	GetAccount(ctx context.Context, params *sesv2.GetAccountInput, optFns ...func(*sesv2.Options)) (*sesv2.GetAccountOutput, error)
+}
+
+// SESSender delivers email through Amazon SES with the default AWS credential chain (IAM role,
+// environment or shared config). Messages are sent as raw MIME, the SES v2 equivalent of
+// SendRawEmail, so the multipart/alternative body is the same as over SMTP.
+type SESSender struct {
+	cfg *config.EmailConfig
+
+	once    sync.Once
+	client  sesAPI
+	limiter *sendRateLimiter
+	initErr error
+}
+
+// NewSESSender creates an SES sender. The client is set up on the first send.
+func NewSESSender(cfg *config.EmailConfig) *SESSender {
+	return &SESSender{cfg: cfg}
+}
+
+// SESError is a failed SES request. It unwraps to ErrSMTPAuth for rejected credentials,
+// ErrSMTPData for a rejected message and ErrSMTPDial when SES couldn't be reached.
+// Throttling and server errors are temporary.
+type SESError struct {
+	Code    string // SES error code; empty when no response was received
+	Message string
+	kind    error
+	temp    bool
+}
+
+func (e *SESError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("ses request failed: %s", e.Message)
+	}
+	return fmt.Sprintf("ses returned %s: %s", e.Code, e.Message)
+}
+
+func (e *SESError) Unwrap() error {
+	return e.kind
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *SESError) Temporary() bool {
+	return e.temp
+}
+
+// init creates the client and the send rate limiter. Unless EmailConfig.SESMaxSendRate is
+// set, the rate is the account's own maximum send rate.
+func (s *SESSender) init() error {
+	s.once.Do(func() {
+		if s.client == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), sesRequestTimeout)
+			defer cancel()
+			awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(s.cfg.SESRegion))
+			if err != nil {
+				s.initErr = fmt.Errorf("failed to load AWS configuration: %w", err)
+				return
+			}
+			// sendWithRetry handles retries, so the SDK makes a single attempt
+			s.client = sesv2.NewFromConfig(awsCfg, func(o *sesv2.Options) {
+				o.Retryer = aws.NopRetryer{}
+			})
+		}
+
+		rate := float64(s.cfg.SESMaxSendRate)
+		if rate <= 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), sesRequestTimeout)
+			defer cancel()
+			account, err := s.client.GetAccount(ctx, &sesv2.GetAccountInput{})
+			if err != nil {
+				// Sending still works; SES throttles us and those sends are retried
+				log.Printf("[EMAIL] Could not read the SES send quota, sending without a rate limit: %v", err)
+			} else if account.SendQuota != nil {
+				rate = account.SendQuota.MaxSendRate
+			}
+		}
+		if rate > 0 {
+			log.Printf("[EMAIL] SES sends limited to %.1f per second", rate)
+			s.limiter = newSendRateLimiter(rate)
+		}
+	})
+	return s.initErr
+}
+
+// Send implements EmailSender
+func (s *SESSender) Send(msg *EmailMessage) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+	if s.limiter != nil {
+		s.limiter.Wait()
+	}
+
+	data, err := buildMIMEMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	input := &sesv2.SendEmailInput{
+		// The envelope sender is the bare address; the display name only goes in the From header
+		FromEmailAddress: aws.String(msg.From),
+		Destination:      &types.Destination{ToAddresses: msg.To, CcAddresses: msg.Cc, BccAddresses: msg.Bcc},
+		Content:          &types.EmailContent{Raw: &types.RawMessage{Data: data}},
+		EmailTags:        []types.MessageTag{{Name: aws.String("type"), Value: aws.String(msg.Type)}},
+	}
+	if s.cfg.SESConfigurationSet != "" {
+		input.ConfigurationSetName = aws.String(s.cfg.SESConfigurationSet)
+	}
+	// Without this, bounces and complaints are forwarded to the From address, usually a no-reply mailbox
+	if s.cfg.SESFeedbackAddress != "" {
+		input.FeedbackForwardingEmailAddress = aws.String(s.cfg.SESFeedbackAddress)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sesRequestTimeout)
+	defer cancel()
+
+	var sendErr error
+	err = providerBreaker(providerSES).Execute(func() error {
+		_, sendErr = s.client.SendEmail(ctx, input)
+		if sendErr == nil {
+			return nil
+		}
+		// Only outages count against the circuit; a rejected message says nothing about SES's health
+		if mapped := mapSESError(sendErr); mapped.Code == "" || mapped.temp {
+			return sendErr
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return mapSESError(sendErr)
+	}
+	if err != nil {
+		// The circuit is open
+		return &SESError{Message: err.Error(), kind: ErrSMTPDial, temp: true}
+	}
+	return nil
+}
+
+// mapSESError maps an error from the SES client onto an SESError
+func mapSESError(err error) *SESError {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return &SESError{Message: err.Error(), kind: ErrSMTPDial, temp: true}
+	}
+
+	e := &SESError{Code: apiErr.ErrorCode(), Message: apiErr.ErrorMessage()}
+	switch e.Code {
+	case "TooManyRequestsException", "Throttling", "ThrottlingException":
+		e.temp = true
+	case "AccessDeniedException", "UnrecognizedClientException", "InvalidClientTokenId",
+		"SignatureDoesNotMatch", "ExpiredTokenException":
+		e.kind = ErrSMTPAuth
+	default:
+		if apiErr.ErrorFault() == smithy.FaultServer {
+			e.temp = true
+		} else {
+			// MessageRejected, MailFromDomainNotVerifiedException, SendingPausedException, ...
+			e.kind = ErrSMTPData
+		}
+	}
+	return e
+}
+
+// sendRateLimiter spaces calls evenly so no more than rate happen per second
+type sendRateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newSendRateLimiter(rate float64) *sendRateLimiter {
+	return &sendRateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// Wait blocks until the caller may send
+func (l *sendRateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/aws/smithy-go"
+
+	"springstreet/internal/config"
+)
+
+// fakeSES records sent emails and fails sends with the queued errors, in order
+type fakeSES struct {
+	mu      sync.Mutex
+	sent    []*sesv2.SendEmailInput
+	errs    []error
+	maxRate float64
+}
+
+func (f *fakeSES) SendEmail(ctx context.Context, params *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	f.sent = append(f.sent, params)
+	return &sesv2.SendEmailOutput{}, nil
+}
+
+func (f *fakeSES) GetAccount(ctx context.Context, params *sesv2.GetAccountInput, optFns ...func(*sesv2.Options)) (*sesv2.GetAccountOutput, error) {
+	return &sesv2.GetAccountOutput{SendQuota: &types.SendQuota{MaxSendRate: f.maxRate}}, nil
+}
+
+// newFakeSESSender returns an SES sender using client
+func newFakeSESSender(t *testing.T, cfg *config.EmailConfig, client *fakeSES) *SESSender {
+	t.Helper()
+	resetProviderBreakers(t)
+	s := NewSESSender(cfg)
+	s.client = client
+	return s
+}
+
+// sesAPIError is an error response from SES
+func sesAPIError(code string, fault smithy.ErrorFault) error {
+	return &smithy.GenericAPIError{Code: code, Message: code + " from the fake", Fault: fault}
+}
+
+func TestSESSend(t *testing.T) {
+	client := &fakeSES{}
+	s := newFakeSESSender(t, &config.EmailConfig{SESMaxSendRate: 100, SESConfigurationSet: "transactional", SESFeedbackAddress: "bounces@springstreet.in"}, client)
+
+	msg := testEmail("investor@example.com")
+	msg.FromName = "Spring Street"
+	msg.Bcc = []string{"archive@springstreet.in"}
+	if err := s.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(client.sent) != 1 {
+		t.Fatalf("SES received %d emails, want 1", len(client.sent))
+	}
+	input := client.sent[0]
+	if *input.FromEmailAddress != "noreply@springstreet.in" {
+		t.Errorf("FromEmailAddress = %q, want the bare address", *input.FromEmailAddress)
+	}
+	if len(input.Destination.ToAddresses) != 1 || len(input.Destination.BccAddresses) != 1 {
+		t.Errorf("Destination = %+v, want the To and Bcc recipients", input.Destination)
+	}
+	raw := string(input.Content.Raw.Data)
+	if !strings.Contains(raw, "Subject: Hello") || !strings.Contains(raw, `From: "Spring Street" <noreply@springstreet.in>`) {
+		t.Errorf("raw message is missing its headers:\n%s", raw)
+	}
+	if strings.Contains(raw, "archive@springstreet.in") {
+		t.Error("the raw message reveals the Bcc recipient")
+	}
+	if *input.ConfigurationSetName != "transactional" || *input.FeedbackForwardingEmailAddress != "bounces@springstreet.in" {
+		t.Errorf("configuration set %q, feedback address %q, want the configured values", *input.ConfigurationSetName, *input.FeedbackForwardingEmailAddress)
+	}
+	if len(input.EmailTags) != 1 || *input.EmailTags[0].Value != "test" {
+		t.Errorf("EmailTags = %+v, want the email type", input.EmailTags)
+	}
+}
+
+func TestSESSendThrottled(t *testing.T) {
+	for _, code := range []string{"TooManyRequestsException", "Throttling", "ThrottlingException"} {
+		t.Run(code, func(t *testing.T) {
+			client := &fakeSES{errs: []error{sesAPIError(code, smithy.FaultClient)}}
+			s := newFakeSESSender(t, &config.EmailConfig{SESMaxSendRate: 100}, client)
+
+			err := s.Send(testEmail("investor@example.com"))
+			var sesErr *SESError
+			if !errors.As(err, &sesErr) || sesErr.Code != code {
+				t.Fatalf("Send = %v, want an SESError with code %s", err, code)
+			}
+			if !sesErr.Temporary() || !isTransientEmailError(err) {
+				t.Fatal("throttling is not treated as temporary")
+			}
+
+			// The retry goes through
+			cfg := &config.EmailConfig{Provider: "ses", RetryMaxAttempts: 3, RetryBaseDelayMS: 1}
+			client.errs = []error{sesAPIError(code, smithy.FaultClient)}
+			if err := sendWithRetry(cfg, "test", func() error { return s.Send(testEmail("investor@example.com")) }); err != nil {
+				t.Fatalf("sendWithRetry: %v", err)
+			}
+			if len(client.sent) != 1 {
+				t.Fatalf("SES accepted %d emails, want 1 after the retry", len(client.sent))
+			}
+		})
+	}
+}
+
+func TestSESSendPermanentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		kind error
+	}{
+		{sesAPIError("AccessDeniedException", smithy.FaultClient), ErrSMTPAuth},
+		{sesAPIError("SignatureDoesNotMatch", smithy.FaultClient), ErrSMTPAuth},
+		{sesAPIError("MessageRejected", smithy.FaultClient), ErrSMTPData},
+		{sesAPIError("MailFromDomainNotVerifiedException", smithy.FaultClient), ErrSMTPData},
+	} {
+		client := &fakeSES{errs: []error{tc.err}}
+		s := newFakeSESSender(t, &config.EmailConfig{SESMaxSendRate: 100}, client)
+
+		err := s.Send(testEmail("investor@example.com"))
+		if !errors.Is(err, tc.kind) {
+			t.Errorf("%v: Send = %v, want %v", tc.err, err, tc.kind)
+		}
+		if isTransientEmailError(err) {
+			t.Errorf("%v is treated as temporary", tc.err)
+		}
+	}
+}
+
+func TestSESSendUnreachable(t *testing.T) {
+	for _, sendErr := range []error{
+		errors.New("dial tcp: lookup email.ap-south-1.amazonaws.com: no such host"),
+		sesAPIError("InternalFailure", smithy.FaultServer),
+	} {
+		s := newFakeSESSender(t, &config.EmailConfig{SESMaxSendRate: 100}, &fakeSES{errs: []error{sendErr}})
+		err := s.Send(testEmail("investor@example.com"))
+		if !isTransientEmailError(err) {
+			t.Errorf("%v: Send = %v, want a temporary error", sendErr, err)
+		}
+	}
+}
+
+func TestSESSendRateLimit(t *testing.T) {
+	// Without SESMaxSendRate the account's quota applies
+	client := &fakeSES{maxRate: 20}
+	s := newFakeSESSender(t, &config.EmailConfig{}, client)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := s.Send(testEmail("investor@example.com")); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	// At 20 per second, sends are 50ms apart
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("5 sends took %v, want at least 200ms at the account's rate of 20/s", elapsed)
+	}
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"springstreet/internal/config"
+)
+
+// fakeSMTPServer is just enough of an SMTP server to exercise smtpSender
+type fakeSMTPServer struct {
+	listener net.Listener
+	cert     tls.Certificate
+
+	startTLS   bool // Offer STARTTLS
+	silent     bool // Accept connections but never send the greeting
+	rejectAuth bool // Answer AUTH with 535
+
+	mu          sync.Mutex
+	messages    []string // DATA received, one entry per message
+	connections int
+	auths       int
+	open        []net.Conn
+	closed      chan struct{}
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1, which clients don't trust
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	ts := httptest.NewTLSServer(nil)
+	defer ts.Close()
+	return ts.TLS.Certificates[0]
+}
+
+// newFakeSMTPServer starts a server; with implicitTLS the connection is TLS from the first byte
+func newFakeSMTPServer(t *testing.T, implicitTLS bool, configure func(*fakeSMTPServer)) *fakeSMTPServer {
+	t.Helper()
+	s := &fakeSMTPServer{cert: testCertificate(t), closed: make(chan struct{})}
+	if configure != nil {
+		configure(s)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	if implicitTLS {
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+	}
+	s.listener = listener
+	t.Cleanup(func() {
+		close(s.closed)
+		listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// config returns an email config pointing at the server
+func (s *fakeSMTPServer) config(mode string) *config.EmailConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return &config.EmailConfig{
+		SMTPHost:       host,
+		SMTPPort:       portNumber,
+		Username:       "mailer",
+		Password:       "secret",
+		SMTPMode:       mode,
+		DialTimeoutSec: 1,
+		SendTimeoutSec: 1,
+	}
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.connections++
+	s.open = append(s.open, conn)
+	s.mu.Unlock()
+	if s.silent {
+		<-s.closed
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			conn.Write([]byte(line + "\r\n"))
+		}
+	}
+	reply("220 fake.smtp ESMTP ready")
+
+	var data strings.Builder
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if inData {
+			if line == "." {
+				inData = false
+				s.mu.Lock()
+				s.messages = append(s.messages, data.String())
+				s.mu.Unlock()
+				reply("250 OK: queued")
+				continue
+			}
+			data.WriteString(line + "\n")
+			continue
+		}
+
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			_, isTLS := conn.(*tls.Conn)
+			if s.startTLS && !isTLS {
+				reply("250-fake.smtp", "250-STARTTLS", "250 AUTH PLAIN")
+			} else {
+				reply("250-fake.smtp", "250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, reader = tlsConn, bufio.NewReader(tlsConn)
+		case "AUTH":
+			s.mu.Lock()
+			s.auths++
+			s.mu.Unlock()
+			if s.rejectAuth {
+				reply("535 Authentication credentials invalid")
+			} else {
+				reply("235 Authentication successful")
+			}
+		case "MAIL", "RSET", "NOOP":
+			reply("250 OK")
+		case "RCPT":
+			if strings.Contains(line, "unknown@") {
+				reply("550 No such user")
+			} else {
+				reply("250 OK")
+			}
+		case "DATA":
+			data.Reset()
+			inData = true
+			reply("354 End data with <CR><LF>.<CR><LF>")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// received returns the messages the server accepted
+func (s *fakeSMTPServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// counts returns the number of connections accepted and AUTH commands received
+func (s *fakeSMTPServer) counts() (connections, auths int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, s.auths
+}
+
+// dropConnections closes every connection accepted so far, as a server restart would
+func (s *fakeSMTPServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.open {
+		conn.Close()
+	}
+	s.open = nil
+}
+
+// testEmail is a message for the fake server
+func testEmail(to string) *EmailMessage {
+	return &EmailMessage{
+		Type:    "test",
+		From:    "noreply@springstreet.in",
+		To:      []string{to},
+		Subject: "Hello",
+		Text:    "Hello from the test",
+	}
+}
+
+func TestSMTPSendStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t, false, func(s *fakeSMTPServer) { s.startTLS = true })
+	cfg := server.config(SMTPModeStartTLS)
+	cfg.InsecureSkipVerify = true
+
+	if err := (&smtpSender{cfg: cfg}).Send(testEmail("investor@example.com")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if messages := server.received(); len(messages) != 1 || !strings.Contains(messages[0], "Subject: Hello") {
+		t.Fatalf("server received %q, want the message", messages)
+	}
+}
+
+func TestSMTPSendStartTLSRequired(t *testing.T) {
+	server := newFakeSMTPServer(t, false, nil)
+	cfg := server.config(SMTPModeStartTLS)
+	cfg.InsecureSkipVerify = true
+
+	err := (&smtpSender{cfg: cfg}).Send(testEmail("investor@example.com"))
+	if !errors.Is(err, ErrSMTPTLS) {
+		t.Fatalf("Send = %v, want ErrSMTPTLS from a server without STARTTLS", err)
+	}
+	if len(server.received()) != 0 {
+		t.Fatal("the message was sent without encryption")
+	}
+}
+
+func TestSMTPSendVerifiesCertificate(t *testing.T) {
+	server := newFakeSMTPServer(t, false, func(s *fakeSMTPServer) { s.startTLS = true })
+
+	err := (&smtpSender{cfg: server.config(SMTPModeStartTLS)}).Send(testEmail("investor@example.com"))
+	if !errors.Is(err, ErrSMTPTLS) {
+		t.Fatalf("Send = %v, want ErrSMTPTLS for an untrusted certificate", err)
+	}
+}
+
+func TestSMTPSendImplicitTLS(t *testing.T) {
+	server := newFakeSMTPServer(t, true, nil)
+
+	err := (&smtpSender{cfg: server.config(SMTPModeTLS)}).Send(testEmail("investor@example.com"))
+	if !errors.Is(err, ErrSMTPDial) {
+		t.Fatalf("Send = %v, want ErrSMTPDial for an untrusted certificate", err)
+	}
+
+	cfg := server.config(SMTPModeTLS)
+	cfg.InsecureSkipVerify = true
+	if err := (&smtpSender{cfg: cfg}).Send(testEmail("investor@example.com")); err != nil {
+		t.Fatalf("Send with InsecureSkipVerify: %v", err)
+	}
+	if len(server.received()) != 1 {
+		t.Fatal("the server did not receive the message")
+	}
+}
+
+func TestSMTPSendTimesOut(t *testing.T) {
+	server := newFakeSMTPServer(t, false, func(s *fakeSMTPServer) { s.silent = true })
+
+	start := time.Now()
+	err := (&smtpSender{cfg: server.config(SMTPModePlain)}).Send(testEmail("investor@example.com"))
+	if !errors.Is(err, ErrSMTPDial) {
+		t.Fatalf("Send = %v, want ErrSMTPDial from a server that never greets", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("Send took %v, want it cut off by the 1s send timeout", elapsed)
+	}
+}
+
+func TestSMTPSendDistinctErrors(t *testing.T) {
+	rejecting := newFakeSMTPServer(t, false, func(s *fakeSMTPServer) { s.rejectAuth = true })
+	if err := (&smtpSender{cfg: rejecting.config(SMTPModePlain)}).Send(testEmail("investor@example.com")); !errors.Is(err, ErrSMTPAuth) {
+		t.Errorf("rejected AUTH: Send = %v, want ErrSMTPAuth", err)
+	}
+
+	server := newFakeSMTPServer(t, false, nil)
+	if err := (&smtpSender{cfg: server.config(SMTPModePlain)}).Send(testEmail("unknown@example.com")); !errors.Is(err, ErrSMTPData) {
+		t.Errorf("rejected recipient: Send = %v, want ErrSMTPData", err)
+	}
+
+	// Nothing listens on the port once the listener is closed
+	cfg := server.config(SMTPModePlain)
+	server.listener.Close()
+	if err := (&smtpSender{cfg: cfg}).Send(testEmail("investor@example.com")); !errors.Is(err, ErrSMTPDial) {
+		t.Errorf("closed port: Send = %v, want ErrSMTPDial", err)
+	}
+}
+
+func TestSMTPSendReusesConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, false, nil)
+	cfg := server.config(SMTPModePlain)
+	cfg.SMTPIdleTimeoutSec = 60
+	sender := &smtpSender{cfg: cfg}
+	t.Cleanup(sender.Close)
+
+	for i := 0; i < 5; i++ {
+		if err := sender.Send(testEmail("investor@example.com")); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+	if connections, auths := server.counts(); connections != 1 || auths != 1 {
+		t.Fatalf("%d connections and %d AUTHs for 5 emails, want 1 of each", connections, auths)
+	}
+	if len(server.received()) != 5 {
+		t.Fatalf("server received %d messages, want 5", len(server.received()))
+	}
+}
+
+func TestSMTPSendConcurrentlyOnOneConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, false, nil)
+	cfg := server.config(SMTPModePlain)
+	cfg.SMTPIdleTimeoutSec = 60
+	sender := &smtpSender{cfg: cfg}
+	t.Cleanup(sender.Close)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- sender.Send(testEmail("investor@example.com"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if connections, auths := server.counts(); connections != 1 || auths != 1 {
+		t.Fatalf("%d connections and %d AUTHs for 10 concurrent emails, want 1 of each", connections, auths)
+	}
+	if len(server.received()) != 10 {
+		t.Fatalf("server received %d messages, want 10", len(server.received()))
+	}
+}
+
+func TestSMTPSendReconnectsAfterDrop(t *testing.T) {
+	server := newFakeSMTPServer(t, false, nil)
+	cfg := server.config(SMTPModePlain)
+	cfg.SMTPIdleTimeoutSec = 60
+	sender := &smtpSender{cfg: cfg}
+	t.Cleanup(sender.Close)
+
+	if err := sender.Send(testEmail("investor@example.com")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	server.dropConnections()
+	if err := sender.Send(testEmail("investor@example.com")); err != nil {
+		t.Fatalf("Send after the connection was dropped: %v", err)
+	}
+	if connections, auths := server.counts(); connections != 2 || auths != 2 {
+		t.Fatalf("%d connections and %d AUTHs, want a second connection after the drop", connections, auths)
+	}
+	if len(server.received()) != 2 {
+		t.Fatalf("server received %d messages, want 2", len(server.received()))
+	}
+}
+
+func TestSMTPSendWithoutReuse(t *testing.T) {
+	server := newFakeSMTPServer(t, false, nil)
+	sender := &smtpSender{cfg: server.config(SMTPModePlain)}
+
+	for i := 0; i < 3; i++ {
+		if err := sender.Send(testEmail("investor@example.com")); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+	if connections, auths := server.counts(); connections != 3 || auths != 3 {
+		t.Fatalf("%d connections and %d AUTHs, want one per email with SMTPIdleTimeoutSec=0", connections, auths)
+	}
+}
+
+func TestSMTPSendClosesIdleConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, false, nil)
+	cfg := server.config(SMTPModePlain)
+	cfg.SMTPIdleTimeoutSec = 1
+	sender := &smtpSender{cfg: cfg}
+	t.Cleanup(sender.Close)
+
+	if err := sender.Send(testEmail("investor@example.com")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	sender.mu.Lock()
+	open := sender.client != nil
+	sender.mu.Unlock()
+	if open {
+		t.Fatal("the connection is still open after the idle timeout")
+	}
+
+	if err := sender.Send(testEmail("investor@example.com")); err != nil {
+		t.Fatalf("Send after the idle timeout: %v", err)
+	}
+	if connections, _ := server.counts(); connections != 2 {
+		t.Fatalf("%d connections, want a new one after the idle timeout", connections)
+	}
+}
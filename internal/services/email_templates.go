@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultEmailLanguage is used when a recipient has no language or one without templates
+const DefaultEmailLanguage = "en"
+
+//go:embed templates/email
+var emailTemplateFS embed.FS
+
+// emailTemplates holds the email templates. It starts with the embedded templates, which ship
+// with the binary, so one that doesn't parse is a build mistake and stops startup. It is
+// replaced by LoadEmailTemplates at startup.
+var emailTemplates = mustLoadEmailTemplates()
+
+// EmailTemplateRenderer renders the email templates in templates/email/{lang}/. Each email
+// has an HTML part, {name}.html, rendered with html/template so data is escaped, and
+// optionally a plain text part, {name}.txt, and a subject, {name}.subject.txt.
+type EmailTemplateRenderer struct {
+	html map[string]*template.Template     // Keyed by language; each holds one template per file
+	text map[string]*texttemplate.Template // Keyed by language; plain text parts and subjects
+}
+
+// NewEmailTemplateRenderer parses the embedded templates of every language, then the files in
+// dir (if set), laid out the same way, which replace or add to them
+func NewEmailTemplateRenderer(dir string) (*EmailTemplateRenderer, error) {
+	sources := []fs.FS{mustSub(emailTemplateFS, "templates/email")}
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("EMAIL_TEMPLATE_DIR %s is not a directory", dir)
+		}
+		sources = append(sources, os.DirFS(filepath.Clean(dir)))
+	}
+
+	// Later sources win, file by file
+	files := make(map[string]map[string]string) // language -> file name -> content
+	for _, fsys := range sources {
+		matches, err := fs.Glob(fsys, "*/*")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range matches {
+			name := path.Base(file)
+			if !strings.HasSuffix(name, ".html") && !strings.HasSuffix(name, ".txt") {
+				continue
+			}
+			content, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read email template %s: %w", file, err)
+			}
+			lang := path.Dir(file)
+			if files[lang] == nil {
+				files[lang] = make(map[string]string)
+			}
+			files[lang][name] = string(content)
+		}
+	}
+
+	r := &EmailTemplateRenderer{
+		html: make(map[string]*template.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+	for lang, set := range files {
+		htmlSet := template.New(lang)
+		textSet := texttemplate.New(lang)
+		for name, content := range set {
+			var err error
+			if strings.HasSuffix(name, ".html") {
+				_, err = htmlSet.New(name).Parse(content)
+			} else {
+				_, err = textSet.New(name).Parse(content)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s/%s: %w", lang, name, err)
+			}
+		}
+		r.html[lang] = htmlSet
+		r.text[lang] = textSet
+	}
+	if _, ok := r.html[DefaultEmailLanguage]; !ok {
+		return nil, fmt.Errorf("no %s email templates found", DefaultEmailLanguage)
+	}
+	return r, nil
+}
+
+// LoadEmailTemplates replaces the email templates with the embedded ones plus the overrides
+// in dir. Every template is parsed here, so a syntax error stops startup.
+func LoadEmailTemplates(dir string) error {
+	r, err := NewEmailTemplateRenderer(dir)
+	if err != nil {
+		return err
+	}
+	emailTemplates = r
+	return nil
+}
+
+func mustLoadEmailTemplates() *EmailTemplateRenderer {
+	r, err := NewEmailTemplateRenderer("")
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Supports reports whether lang has its own templates
+func (r *EmailTemplateRenderer) Supports(lang string) bool {
+	_, ok := r.html[lang]
+	return ok
+}
+
+// Render executes the HTML template templateName in lang, falling back to English when lang
+// or the template isn't available in it
+func (r *EmailTemplateRenderer) Render(lang, templateName string, data map[string]any) (string, error) {
+	name := templateName + ".html"
+	tmpl := r.html[strings.ToLower(lang)]
+	if tmpl == nil || tmpl.Lookup(name) == nil {
+		tmpl = r.html[DefaultEmailLanguage]
+	}
+	if tmpl.Lookup(name) == nil {
+		return "", fmt.Errorf("email template %q not found", templateName)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render email template %q: %w", templateName, err)
+	}
+	return buf.String(), nil
+}
+
+// RenderText executes the text template file (e.g. "contact_notification.txt") in lang with
+// the same fallback as Render. It returns "" when there is no such template in either language.
+func (r *EmailTemplateRenderer) RenderText(lang, file string, data map[string]any) (string, error) {
+	tmpl := r.text[strings.ToLower(lang)]
+	if tmpl == nil || tmpl.Lookup(file) == nil {
+		tmpl = r.text[DefaultEmailLanguage]
+	}
+	if tmpl == nil || tmpl.Lookup(file) == nil {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, file, data); err != nil {
+		return "", fmt.Errorf("failed to render email template %q: %w", file, err)
+	}
+	return buf.String(), nil
+}
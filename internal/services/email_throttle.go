@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"springstreet/internal/config"
+)
+
+// Limits an email can be throttled by (EmailThrottledError.Limit)
+const (
+	emailLimitPerMinute    = "per_minute"
+	emailLimitPerHour      = "per_hour"
+	emailLimitPerRecipient = "per_recipient"
+)
+
+// ErrEmailThrottled is wrapped by EmailThrottledError
+var ErrEmailThrottled = errors.New("email throttled")
+
+// EmailThrottledError is returned, before anything is sent, when an email would exceed the
+// outbound limits in EmailConfig. Queued notifications are sent again after RetryAfter.
+type EmailThrottledError struct {
+	Limit      string // per_minute, per_hour or per_recipient
+	RetryAfter time.Duration
+}
+
+func (e *EmailThrottledError) Error() string {
+	return fmt.Sprintf("email throttled: %s limit reached, retry in %s", e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+func (e *EmailThrottledError) Unwrap() error {
+	return ErrEmailThrottled
+}
+
+// emailThrottle tracks the emails sent in the last hour, in total and per recipient address
+type emailThrottle struct {
+	mu          sync.Mutex
+	sent        []time.Time
+	recipients  map[string][]time.Time
+	lastCleanup time.Time
+}
+
+// newEmailThrottle creates an empty email throttle
+func newEmailThrottle() *emailThrottle {
+	return &emailThrottle{recipients: make(map[string][]time.Time), lastCleanup: time.Now()}
+}
+
+// reserve records an email of emailType to addresses if cfg's limits allow it, and otherwise
+// returns the limit it would exceed. OTP emails are only held to the total limits; their
+// recipients are limited by the OTP rules instead.
+func (t *emailThrottle) reserve(cfg *config.EmailConfig, emailType string, addresses []string) *EmailThrottledError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastCleanup) > time.Hour {
+		t.cleanup(now)
+	}
+
+	t.sent = eventsSince(t.sent, now.Add(-time.Hour))
+	if cfg.MaxPerMinute > 0 {
+		lastMinute := eventsSince(t.sent, now.Add(-time.Minute))
+		if len(lastMinute) >= cfg.MaxPerMinute {
+			return &EmailThrottledError{Limit: emailLimitPerMinute, RetryAfter: lastMinute[len(lastMinute)-cfg.MaxPerMinute].Add(time.Minute).Sub(now)}
+		}
+	}
+	if cfg.MaxPerHour > 0 && len(t.sent) >= cfg.MaxPerHour {
+		return &EmailThrottledError{Limit: emailLimitPerHour, RetryAfter: t.sent[len(t.sent)-cfg.MaxPerHour].Add(time.Hour).Sub(now)}
+	}
+
+	limitRecipients := cfg.RecipientMaxPerHour > 0 && emailType != emailTypeOTP
+	if limitRecipients {
+		for _, address := range addresses {
+			key := strings.ToLower(address)
+			recent := eventsSince(t.recipients[key], now.Add(-time.Hour))
+			t.recipients[key] = recent
+			if len(recent) >= cfg.RecipientMaxPerHour {
+				return &EmailThrottledError{Limit: emailLimitPerRecipient, RetryAfter: recent[len(recent)-cfg.RecipientMaxPerHour].Add(time.Hour).Sub(now)}
+			}
+		}
+	}
+
+	t.sent = append(t.sent, now)
+	if limitRecipients {
+		for _, address := range addresses {
+			key := strings.ToLower(address)
+			t.recipients[key] = append(t.recipients[key], now)
+		}
+	}
+	return nil
+}
+
+// cleanup removes recipients with no emails in the last hour; callers must hold mu
+func (t *emailThrottle) cleanup(now time.Time) {
+	t.lastCleanup = now
+	for key, events := range t.recipients {
+		if recent := eventsSince(events, now.Add(-time.Hour)); len(recent) == 0 {
+			delete(t.recipients, key)
+		} else {
+			t.recipients[key] = recent
+		}
+	}
+}
+
+// eventsSince returns the events, oldest first, that happened after cutoff
+func eventsSince(events []time.Time, cutoff time.Time) []time.Time {
+	for i, t := range events {
+		if t.After(cutoff) {
+			return events[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm/clause"
+)
+
+// EmailUnsubscribePath records marketing email opt-outs. It is served outside Goa because
+// mail clients POST List-Unsubscribe=One-Click form bodies to it (RFC 8058).
+const EmailUnsubscribePath = "/api/v1/email/unsubscribe"
+
+// ErrEmailOptedOut is returned by SendMarketingEmail when the address has unsubscribed
+var ErrEmailOptedOut = errors.New("recipient unsubscribed from marketing email")
+
+// unsubscribeConfirmPage asks for confirmation, since link scanners follow GET links in emails
+var unsubscribeConfirmPage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body><form method="post" action="?token={{.}}"><p>Stop receiving marketing email from Spring Street?</p>
+<button type="submit">Unsubscribe</button></form></body></html>
+`))
+
+// SendMarketingEmail sends a marketing email to a single address, unless the address has
+// unsubscribed. Unlike the transactional emails it carries List-Unsubscribe headers, so it
+// requires EmailConfig.UnsubscribeURL.
+func (s *EmailService) SendMarketingEmail(ctx context.Context, to, subject, htmlBody, textBody string) error {
+	cfg := s.config()
+	if cfg.UnsubscribeURL == "" {
+		return fmt.Errorf("EMAIL_UNSUBSCRIBE_URL must be set to send marketing email")
+	}
+	to = strings.TrimSpace(to)
+	optedOut, err := s.isOptedOut(to)
+	if err != nil {
+		return fmt.Errorf("failed to check email opt-out: %w", err)
+	}
+	if optedOut {
+		return ErrEmailOptedOut
+	}
+
+	link := cfg.UnsubscribeURL + "?token=" + url.QueryEscape(unsubscribeToken(to))
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + link + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	return s.send(ctx, emailTypeMarketing, EmailTo(to), subject, htmlBody, textBody, nil, headers)
+}
+
+// isOptedOut reports whether address has unsubscribed from marketing email
+func (s *EmailService) isOptedOut(address string) (bool, error) {
+	if s.db == nil {
+		return false, nil
+	}
+	var count int64
+	err := s.db.Model(&domain.EmailOptOut{}).Where("address_hash = ?", hashEmailAddress(address)).Count(&count).Error
+	return count > 0, err
+}
+
+// HandleUnsubscribe serves EmailUnsubscribePath?token=... A GET shows a confirmation form;
+// a POST, from that form or a mail client's one-click unsubscribe, records the opt-out.
+func (s *EmailService) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	address, ok := parseUnsubscribeToken(token)
+	if !ok {
+		http.Error(w, "invalid unsubscribe link", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = unsubscribeConfirmPage.Execute(w, token)
+	case http.MethodPost:
+		optOut := domain.EmailOptOut{AddressHash: hashEmailAddress(address)}
+		if err := s.db.WithContext(r.Context()).Clauses(clause.OnConflict{DoNothing: true}).Create(&optOut).Error; err != nil {
+			log.Printf("[EMAIL] Failed to record opt-out: %v", err)
+			http.Error(w, "failed to unsubscribe, please try again", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[EMAIL] %s unsubscribed from marketing email", maskEmail(address))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("You have been unsubscribed from Spring Street marketing email.\n"))
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// unsubscribeToken returns the token of address for unsubscribe links: the lowercased
+// address and its HMAC keyed with the application secret, both base64url encoded
+func unsubscribeToken(address string) string {
+	address = strings.ToLower(address)
+	return base64.RawURLEncoding.EncodeToString([]byte(address)) + "." +
+		base64.RawURLEncoding.EncodeToString(unsubscribeMAC(address))
+}
+
+// parseUnsubscribeToken returns the address of a token made by unsubscribeToken
+func parseUnsubscribeToken(token string) (string, bool) {
+	encodedAddress, encodedMAC, found := strings.Cut(token, ".")
+	if !found {
+		return "", false
+	}
+	address, err := base64.RawURLEncoding.DecodeString(encodedAddress)
+	if err != nil {
+		return "", false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, unsubscribeMAC(string(address))) {
+		return "", false
+	}
+	return string(address), true
+}
+
+// unsubscribeMAC signs address for unsubscribe tokens
+func unsubscribeMAC(address string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.Get().Auth.SecretKey))
+	mac.Write([]byte("unsubscribe:" + address))
+	return mac.Sum(nil)
+}
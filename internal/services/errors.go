@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	goa "goa.design/goa/v3/pkg"
+	"springstreet/gen/admin"
 	"springstreet/gen/auth"
 	"springstreet/gen/contact"
 	"springstreet/gen/investment"
@@ -68,6 +69,15 @@ func NewInternalError(message string, err error) *ServiceError {
 	}
 }
 
+// ============================================================
+// Admin Service Error Helpers
+// ============================================================
+
+// AdminUnauthorized creates a properly formatted unauthorized error for admin service
+func AdminUnauthorized(message string) *goa.ServiceError {
+	return admin.MakeUnauthorized(errors.New(message))
+}
+
 // ============================================================
 // Auth Service Error Helpers
 // ============================================================
@@ -101,6 +111,11 @@ func ContactUnauthorized(message string) *goa.ServiceError {
 	return contact.MakeUnauthorized(errors.New(message))
 }
 
+// ContactNotFound creates a properly formatted not found error for contact service
+func ContactNotFound(message string) *goa.ServiceError {
+	return contact.MakeNotFound(errors.New(message))
+}
+
 // ============================================================
 // Investment Service Error Helpers
 // ============================================================
@@ -115,6 +130,11 @@ func InvestmentNotFound(message string) *goa.ServiceError {
 	return investment.MakeNotFound(errors.New(message))
 }
 
+// InvestmentConflict creates a properly formatted conflict error for investment service
+func InvestmentConflict(message string) *goa.ServiceError {
+	return investment.MakeConflict(errors.New(message))
+}
+
 // ============================================================
 // OTP Service Error Helpers
 // ============================================================
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"springstreet/internal/domain"
+)
+
+// subscriberBufferSize is how many events a slow subscriber can fall behind before events are dropped
+const subscriberBufferSize = 16
+
+// InquiryEventBus fans out newly created investment inquiries to stream subscribers
+type InquiryEventBus struct {
+	subscribers sync.Map // map[uint64]chan *domain.InvestmentInquiry
+	nextID      atomic.Uint64
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewInquiryEventBus creates a new event bus
+func NewInquiryEventBus() *InquiryEventBus {
+	return &InquiryEventBus{done: make(chan struct{})}
+}
+
+// Done is closed when the bus shuts down so open streams can end
+func (b *InquiryEventBus) Done() <-chan struct{} {
+	return b.done
+}
+
+// Close signals all subscribers to stop (called on server shutdown)
+func (b *InquiryEventBus) Close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// Subscribe registers a new subscriber and returns its ID and event channel
+func (b *InquiryEventBus) Subscribe() (uint64, <-chan *domain.InvestmentInquiry) {
+	id := b.nextID.Add(1)
+	ch := make(chan *domain.InvestmentInquiry, subscriberBufferSize)
+	b.subscribers.Store(id, ch)
+	return id, ch
+}
+
+// Unsubscribe removes a subscriber
+func (b *InquiryEventBus) Unsubscribe(id uint64) {
+	b.subscribers.Delete(id)
+}
+
+// Publish sends a copy of the inquiry to every subscriber without blocking.
+// Subscribers whose buffer is full miss the event.
+func (b *InquiryEventBus) Publish(inquiry *domain.InvestmentInquiry) {
+	b.subscribers.Range(func(key, value any) bool {
+		event := *inquiry
+		select {
+		case value.(chan *domain.InvestmentInquiry) <- &event:
+		default:
+			log.Printf("[EVENTS] Dropped inquiry event id=%d for slow subscriber %d", inquiry.ID, key.(uint64))
+		}
+		return true
+	})
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"springstreet/gen/auth"
+	"springstreet/gen/investment"
+	"springstreet/internal/metrics"
+
+	"github.com/graph-gophers/graphql-go"
+	goa "goa.design/goa/v3/pkg"
+	"goa.design/goa/v3/security"
+)
+
+const (
+	// GraphQLPath serves GraphQL queries. It is served outside Goa because a single request
+	// resolves fields from several services.
+	GraphQLPath = "/api/v1/graphql"
+
+	// GraphQLPlaygroundPath serves an in-browser query editor in debug mode
+	GraphQLPlaygroundPath = "/api/v1/graphql/playground"
+)
+
+//go:embed graphql_schema.graphql
+var graphqlSchema string
+
+// graphqlTokenKey holds the bearer token of a GraphQL request in its context
+type graphqlTokenKey struct{}
+
+// graphqlFieldsKey holds the *graphqlFields of a GraphQL request in its context
+type graphqlFieldsKey struct{}
+
+// graphqlFields collects the top-level fields an operation resolves. Fields resolve concurrently.
+type graphqlFields struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// resolving records that the request in ctx resolves the top-level field name
+func resolving(ctx context.Context, name string) {
+	if fields, ok := ctx.Value(graphqlFieldsKey{}).(*graphqlFields); ok {
+		fields.mu.Lock()
+		fields.names = append(fields.names, name)
+		fields.mu.Unlock()
+	}
+}
+
+// operation names an executed operation by its distinct top-level fields, so the
+// graphql_requests_total label set stays bounded by the schema
+func (f *graphqlFields) operation(response *graphql.Response) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.names) == 0 {
+		if len(response.Errors) > 0 {
+			return "invalid"
+		}
+		return "introspection"
+	}
+	names := append([]string(nil), f.names...)
+	sort.Strings(names)
+	return strings.Join(slices.Compact(names), ",")
+}
+
+// GraphQLService resolves GraphQL queries against the existing services.
+// Each field is authorized with the owning service's JWTAuth using the scopes of the matching REST method.
+type GraphQLService struct {
+	schema *graphql.Schema
+	debug  bool
+}
+
+// NewGraphQLService creates a new GraphQL service. Introspection and the playground are only
+// available in debug mode.
+func NewGraphQLService(authSvc *AuthService, investmentSvc *InvestmentService, debug bool) *GraphQLService {
+	opts := []graphql.SchemaOpt{graphql.MaxDepth(5)}
+	if !debug {
+		opts = append(opts, graphql.DisableIntrospection())
+	}
+	resolver := &graphqlResolver{auth: authSvc, investment: investmentSvc}
+	return &GraphQLService{
+		schema: graphql.MustParseSchema(graphqlSchema, resolver, opts...),
+		debug:  debug,
+	}
+}
+
+// graphqlRequest is the body of a GraphQL POST request
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP executes a GraphQL query. The JWT is read from the Authorization header as for REST requests.
+func (s *GraphQLService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid GraphQL request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if token := bearerToken(r.Header.Get("Authorization")); token != "" {
+		ctx = context.WithValue(ctx, graphqlTokenKey{}, token)
+	}
+
+	fields := &graphqlFields{}
+	ctx = context.WithValue(ctx, graphqlFieldsKey{}, fields)
+
+	response := s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	metrics.RecordGraphQLRequest(fields.operation(response))
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("[GRAPHQL] Failed to encode response: %v", err)
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// ServePlayground serves a GraphiQL page for trying queries (debug mode only)
+func (s *GraphQLService) ServePlayground(w http.ResponseWriter, r *http.Request) {
+	if !s.debug {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(graphqlPlaygroundHTML))
+}
+
+// bearerToken extracts the token from an Authorization header value
+func bearerToken(header string) string {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// graphqlResolver is the root Query resolver
+type graphqlResolver struct {
+	auth       *AuthService
+	investment *InvestmentService
+}
+
+// authorize runs a service's JWTAuth for the request token and returns the context it populates
+func authorize(ctx context.Context, jwtAuth func(context.Context, string, *security.JWTScheme) (context.Context, error), scopes ...string) (context.Context, error) {
+	token, _ := ctx.Value(graphqlTokenKey{}).(string)
+	if token == "" {
+		return nil, goa.NewServiceError(errors.New("missing or invalid Authorization header"), "unauthorized", false, false, false)
+	}
+	return jwtAuth(ctx, token, &security.JWTScheme{Name: "jwt", RequiredScopes: scopes})
+}
+
+// graphqlError hides internal errors from clients; service errors are returned as is
+func graphqlError(field string, err error) error {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+	log.Printf("[GRAPHQL] %s failed: %v", field, err)
+	return errors.New("internal server error")
+}
+
+func (r *graphqlResolver) Me(ctx context.Context) (*userResolver, error) {
+	resolving(ctx, "me")
+	ctx, err := authorize(ctx, r.auth.JWTAuth)
+	if err != nil {
+		return nil, graphqlError("me", err)
+	}
+	user, err := r.auth.Me(ctx, &auth.MePayload{})
+	if err != nil {
+		return nil, graphqlError("me", err)
+	}
+	return &userResolver{user}, nil
+}
+
+func (r *graphqlResolver) InvestmentInquiry(ctx context.Context, args struct{ ID int32 }) (*inquiryResolver, error) {
+	resolving(ctx, "investmentInquiry")
+	ctx, err := authorize(ctx, r.investment.JWTAuth, "staff")
+	if err != nil {
+		return nil, graphqlError("investmentInquiry", err)
+	}
+	inquiry, err := r.investment.Get(ctx, &investment.GetInquiryPayload{ID: int(args.ID)})
+	if err != nil {
+		return nil, graphqlError("investmentInquiry", err)
+	}
+	return &inquiryResolver{inquiry}, nil
+}
+
+// inquiryFilter is the InquiryFilter input
+type inquiryFilter struct {
+	Skip            *int32
+	Limit           *int32
+	MinScore        *int32
+	IncludeArchived *bool
+}
+
+func (r *graphqlResolver) InvestmentInquiries(ctx context.Context, args struct{ Filter *inquiryFilter }) ([]*inquiryResolver, error) {
+	resolving(ctx, "investmentInquiries")
+	ctx, err := authorize(ctx, r.investment.JWTAuth, "staff")
+	if err != nil {
+		return nil, graphqlError("investmentInquiries", err)
+	}
+
+	payload := &investment.ListInquiriesPayload{Limit: 100}
+	if f := args.Filter; f != nil {
+		if f.Skip != nil {
+			payload.Skip = int(*f.Skip)
+		}
+		if f.Limit != nil {
+			payload.Limit = int(*f.Limit)
+		}
+		if f.MinScore != nil {
+			minScore := int(*f.MinScore)
+			payload.MinScore = &minScore
+		}
+		if f.IncludeArchived != nil {
+			payload.IncludeArchived = *f.IncludeArchived
+		}
+	}
+	// Apply the same bounds as the REST endpoint
+	if err := validateListInquiriesPayload(payload); err != nil {
+		return nil, err
+	}
+
+	inquiries, err := r.investment.List(ctx, payload)
+	if err != nil {
+		return nil, graphqlError("investmentInquiries", err)
+	}
+	resolvers := make([]*inquiryResolver, len(inquiries))
+	for i, inquiry := range inquiries {
+		resolvers[i] = &inquiryResolver{inquiry}
+	}
+	return resolvers, nil
+}
+
+// validateListInquiriesPayload checks the bounds Goa enforces on the list endpoint's query params
+func validateListInquiriesPayload(p *investment.ListInquiriesPayload) error {
+	if p.Skip < 0 {
+		return errors.New("skip must be at least 0")
+	}
+	if p.Limit < 1 || p.Limit > 500 {
+		return errors.New("limit must be between 1 and 500")
+	}
+	if p.MinScore != nil && (*p.MinScore < 0 || *p.MinScore > 100) {
+		return errors.New("minScore must be between 0 and 100")
+	}
+	return nil
+}
+
+func (r *graphqlResolver) InvestmentStats(ctx context.Context) (*statsResolver, error) {
+	resolving(ctx, "investmentStats")
+	ctx, err := authorize(ctx, r.investment.JWTAuth, "staff")
+	if err != nil {
+		return nil, graphqlError("investmentStats", err)
+	}
+	stats, err := r.investment.Stats(ctx)
+	if err != nil {
+		return nil, graphqlError("investmentStats", err)
+	}
+	return &statsResolver{stats}, nil
+}
+
+// userResolver resolves the User type
+type userResolver struct{ u *auth.Userresult }
+
+func (r *userResolver) ID() int32          { return int32(r.u.ID) }
+func (r *userResolver) Username() string   { return r.u.Username }
+func (r *userResolver) Email() string      { return r.u.Email }
+func (r *userResolver) FullName() *string  { return r.u.FullName }
+func (r *userResolver) IsActive() bool     { return r.u.IsActive }
+func (r *userResolver) IsAdmin() bool      { return r.u.IsAdmin }
+func (r *userResolver) IsStaff() bool      { return r.u.IsStaff }
+func (r *userResolver) Language() string   { return r.u.Language }
+func (r *userResolver) CreatedAt() string  { return r.u.CreatedAt }
+func (r *userResolver) UpdatedAt() *string { return r.u.UpdatedAt }
+func (r *userResolver) LastLogin() *string { return r.u.LastLogin }
+
+// inquiryResolver resolves the InvestmentInquiry type
+type inquiryResolver struct {
+	i *investment.Investmentinquiryresult
+}
+
+func (r *inquiryResolver) ID() int32                { return int32(r.i.ID) }
+func (r *inquiryResolver) FirstName() *string       { return r.i.FirstName }
+func (r *inquiryResolver) LastName() *string        { return r.i.LastName }
+func (r *inquiryResolver) Phone() *string           { return r.i.Phone }
+func (r *inquiryResolver) Email() *string           { return r.i.Email }
+func (r *inquiryResolver) InvestmentSize() *string  { return r.i.InvestmentSize }
+func (r *inquiryResolver) CurrentExposure() *string { return r.i.CurrentExposure }
+func (r *inquiryResolver) Verified() bool           { return r.i.Verified }
+func (r *inquiryResolver) ExitType() *string        { return r.i.ExitType }
+func (r *inquiryResolver) Status() string           { return r.i.Status }
+func (r *inquiryResolver) LeadScore() int32         { return int32(r.i.LeadScore) }
+func (r *inquiryResolver) CreatedAt() string        { return r.i.CreatedAt }
+func (r *inquiryResolver) UpdatedAt() *string       { return r.i.UpdatedAt }
+func (r *inquiryResolver) Version() int32           { return int32(r.i.Version) }
+func (r *inquiryResolver) Archived() bool           { return r.i.Archived }
+func (r *inquiryResolver) ArchivedAt() *string      { return r.i.ArchivedAt }
+
+// statsResolver resolves the InvestmentStats type
+type statsResolver struct{ s *InquiryStats }
+
+func (r *statsResolver) Total() int32              { return int32(r.s.Total) }
+func (r *statsResolver) Verified() int32           { return int32(r.s.Verified) }
+func (r *statsResolver) AverageLeadScore() float64 { return r.s.AverageLeadScore }
+func (r *statsResolver) ByStatus() []*statCount    { return sortedStatCounts(r.s.ByStatus) }
+func (r *statsResolver) BySize() []*statCount      { return sortedStatCounts(r.s.BySize) }
+
+// statCount resolves the StatCount type
+type statCount struct {
+	key   string
+	count int64
+}
+
+func (c *statCount) Key() string  { return c.key }
+func (c *statCount) Count() int32 { return int32(c.count) }
+
+// sortedStatCounts returns counts ordered by key so responses are stable
+func sortedStatCounts(counts map[string]int64) []*statCount {
+	result := make([]*statCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, &statCount{key: key, count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].key < result[j].key })
+	return result
+}
+
+// graphqlPlaygroundHTML loads GraphiQL from a CDN and points it at GraphQLPath
+const graphqlPlaygroundHTML = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Spring Street GraphQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css" />
+</head>
+<body style="margin: 0">
+  <div id="graphiql" style="height: 100vh"></div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: '` + GraphQLPath + `' });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(
+      React.createElement(GraphiQL, { fetcher: fetcher })
+    );
+  </script>
+</body>
+</html>
+`
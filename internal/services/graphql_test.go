@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+)
+
+// graphqlResult is the decoded body of a GraphQL response
+type graphqlResult struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// newGraphQLServer serves the GraphQL endpoint and playground and returns a staff and a
+// regular user token for it
+func newGraphQLServer(t *testing.T, debug bool) (ts *httptest.Server, staffToken, userToken string) {
+	t.Helper()
+	db := newTestDB(t)
+	s := NewGraphQLService(NewAuthService(db, time.Minute), NewInvestmentService(db, nil, nil, nil, nil, time.Minute), debug)
+	mux := http.NewServeMux()
+	mux.HandleFunc(GraphQLPath, s.ServeHTTP)
+	mux.HandleFunc(GraphQLPlaygroundPath, s.ServePlayground)
+	ts = httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	staff := createTestUser(t, db, domain.User{Username: "staff", IsStaff: true}, "correct horse")
+	user := createTestUser(t, db, domain.User{Username: "user"}, "correct horse")
+	staffToken, _ = util.GenerateToken(staff)
+	userToken, _ = util.GenerateToken(user)
+	return ts, staffToken, userToken
+}
+
+// graphqlQuery posts query with an optional bearer token and decodes the response
+func graphqlQuery(t *testing.T, ts *httptest.Server, query, token string) graphqlResult {
+	t.Helper()
+	body, _ := json.Marshal(graphqlRequest{Query: query})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+GraphQLPath, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST graphql: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var result graphqlResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return result
+}
+
+// assertGraphQLError fails the test unless result has an error mentioning message
+func assertGraphQLError(t *testing.T, result graphqlResult, message string) {
+	t.Helper()
+	for _, err := range result.Errors {
+		if strings.Contains(err.Message, message) {
+			return
+		}
+	}
+	t.Fatalf("errors = %+v, want one containing %q", result.Errors, message)
+}
+
+func TestGraphQLRequiresAuthentication(t *testing.T) {
+	ts, _, userToken := newGraphQLServer(t, false)
+
+	result := graphqlQuery(t, ts, "{ me { username } }", "")
+	assertGraphQLError(t, result, "missing or invalid Authorization header")
+	if result.Data != nil {
+		t.Errorf("data = %v without a token, want null", result.Data)
+	}
+
+	result = graphqlQuery(t, ts, "{ me { username } }", "not-a-jwt")
+	assertGraphQLError(t, result, "invalid or expired token")
+
+	result = graphqlQuery(t, ts, "{ me { username } }", userToken)
+	if len(result.Errors) > 0 || string(result.Data["me"]) != `{"username":"user"}` {
+		t.Errorf("me = %s (errors %+v), want the token's user", result.Data["me"], result.Errors)
+	}
+}
+
+func TestGraphQLChecksScopesPerField(t *testing.T) {
+	ts, staffToken, userToken := newGraphQLServer(t, false)
+	query := "{ me { username } investmentStats { total } }"
+
+	// The user may resolve me but not investmentStats, which needs the staff scope
+	result := graphqlQuery(t, ts, query, userToken)
+	assertGraphQLError(t, result, "insufficient permissions")
+	if result.Data != nil {
+		t.Errorf("data = %v for a user without the staff scope, want null", result.Data)
+	}
+
+	result = graphqlQuery(t, ts, query, staffToken)
+	if len(result.Errors) > 0 || string(result.Data["investmentStats"]) != `{"total":0}` {
+		t.Errorf("investmentStats = %s (errors %+v), want empty stats", result.Data["investmentStats"], result.Errors)
+	}
+}
+
+func TestGraphQLCountsOperations(t *testing.T) {
+	ts, staffToken, _ := newGraphQLServer(t, false)
+	labels := map[string]string{"operation": "investmentStats,me"}
+	before := metricValue(t, "graphql_requests_total", labels)
+
+	graphqlQuery(t, ts, "{ a: me { username } b: me { email } investmentStats { total } }", staffToken)
+	if got := metricValue(t, "graphql_requests_total", labels) - before; got != 1 {
+		t.Errorf("graphql_requests_total{operation=investmentStats,me} increased by %v, want 1", got)
+	}
+}
+
+func TestGraphQLLimitsQueryDepth(t *testing.T) {
+	ts, staffToken, _ := newGraphQLServer(t, false)
+	invalid := metricValue(t, "graphql_requests_total", map[string]string{"operation": "invalid"})
+
+	result := graphqlQuery(t, ts, "{ a { b { c { d { e { f } } } } } }", staffToken)
+	assertGraphQLError(t, result, "exceeds max depth 5")
+	if got := metricValue(t, "graphql_requests_total", map[string]string{"operation": "invalid"}) - invalid; got != 1 {
+		t.Errorf("graphql_requests_total{operation=invalid} increased by %v, want 1", got)
+	}
+}
+
+func TestGraphQLIntrospectionAndPlaygroundOnlyInDebugMode(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		ts, _, _ := newGraphQLServer(t, debug)
+
+		result := graphqlQuery(t, ts, "{ __schema { queryType { name } } }", "")
+		if introspected := string(result.Data["__schema"]) == `{"queryType":{"name":"Query"}}`; introspected != debug {
+			t.Errorf("debug=%v: __schema = %s, introspection enabled = %v", debug, result.Data["__schema"], introspected)
+		}
+
+		resp, err := http.Get(ts.URL + GraphQLPlaygroundPath)
+		if err != nil {
+			t.Fatalf("GET playground: %v", err)
+		}
+		resp.Body.Close()
+		want := http.StatusNotFound
+		if debug {
+			want = http.StatusOK
+		}
+		if resp.StatusCode != want {
+			t.Errorf("debug=%v: playground status = %d, want %d", debug, resp.StatusCode, want)
+		}
+	}
+}
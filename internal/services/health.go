@@ -2,25 +2,40 @@ package services
 
 import (
 	"context"
+	"runtime"
 	health "springstreet/gen/health"
+	"time"
 )
 
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string // From App.Version
+	BuildTime string // Set at build time with -ldflags "-X main.buildTime=..."
+}
+
 // HealthService implements the health service
-type HealthService struct{}
+type HealthService struct {
+	startTime time.Time
+	build     BuildInfo
+}
 
-// NewHealthService creates a new health service
-func NewHealthService() *HealthService {
-	return &HealthService{}
+// NewHealthService creates a new health service for a process started at startTime
+func NewHealthService(startTime time.Time, build BuildInfo) *HealthService {
+	return &HealthService{startTime: startTime, build: build}
 }
 
 // Check implements the health check method
 func (s *HealthService) Check(ctx context.Context) (*health.Healthresult, error) {
 	status := "healthy"
 	service := "Spring Street API"
+	uptime := time.Since(s.startTime).Seconds()
+	goVersion := runtime.Version()
 	return &health.Healthresult{
-		Status:  &status,
-		Service: &service,
+		Status:        &status,
+		Service:       &service,
+		UptimeSeconds: &uptime,
+		Version:       &s.build.Version,
+		BuildTime:     &s.build.BuildTime,
+		GoVersion:     &goVersion,
 	}, nil
 }
-
-
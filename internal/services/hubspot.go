@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+// hubspotBaseURL is the HubSpot API root
+var hubspotBaseURL = "https://api.hubapi.com"
+
+const hubspotRequestTimeout = 10 * time.Second
+
+// HubSpot answers a duplicate create with "Contact already exists. Existing ID: 12345"
+var hubspotExistingIDPattern = regexp.MustCompile(`Existing ID: (\d+)`)
+
+// HubSpotClient pushes investment inquiries to HubSpot's CRM as contacts
+type HubSpotClient struct {
+	mu     sync.RWMutex
+	cfg    *config.HubSpotConfig
+	client *http.Client
+	pool   *WorkerPool
+}
+
+// NewHubSpotClient creates a HubSpot client that syncs in the background on pool
+func NewHubSpotClient(cfg *config.HubSpotConfig, pool *WorkerPool) *HubSpotClient {
+	if cfg.APIKey == "" && config.FeatureFlag(config.FeatureHubSpot) {
+		log.Println("[HUBSPOT] Warning: HUBSPOT_ENABLED is set but HUBSPOT_API_KEY is empty, inquiries will not be synced")
+	}
+	return &HubSpotClient{
+		cfg:    cfg,
+		client: &http.Client{Timeout: hubspotRequestTimeout},
+		pool:   pool,
+	}
+}
+
+// UpdateConfig replaces the HubSpot configuration (used when secrets are rotated)
+func (c *HubSpotClient) UpdateConfig(cfg *config.HubSpotConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = cfg
+}
+
+// config returns the current HubSpot configuration
+func (c *HubSpotClient) config() *config.HubSpotConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// IsEnabled returns whether HubSpot sync is switched on and has an API key
+func (c *HubSpotClient) IsEnabled() bool {
+	return c.config().APIKey != "" && config.FeatureFlag(config.FeatureHubSpot)
+}
+
+// hubspotContact is the body of a contact create or update
+type hubspotContact struct {
+	Properties map[string]string `json:"properties"`
+}
+
+// hubspotContactResponse is the part of a contact resource we use
+type hubspotContactResponse struct {
+	ID string `json:"id"`
+}
+
+// hubspotErrorResponse is the body of a failed HubSpot API call
+type hubspotErrorResponse struct {
+	Message  string `json:"message"`
+	Category string `json:"category"`
+}
+
+// CreateContact creates a HubSpot contact for inquiry, or updates the existing contact when
+// the inquiry was synced before or HubSpot reports a duplicate. The contact ID is stored in
+// inquiry.HubSpotContactID.
+func (c *HubSpotClient) CreateContact(inquiry *domain.InvestmentInquiry) error {
+	contact := hubspotContact{Properties: hubspotProperties(inquiry)}
+
+	if inquiry.HubSpotContactID != nil {
+		if err := c.updateContact(*inquiry.HubSpotContactID, contact); err != nil {
+			metrics.RecordHubSpotSync("failed")
+			return err
+		}
+		metrics.RecordHubSpotSync("updated")
+		return nil
+	}
+
+	resp, err := c.do(http.MethodPost, "/crm/v3/objects/contacts", contact)
+	if err != nil {
+		metrics.RecordHubSpotSync("failed")
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		var created hubspotContactResponse
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || created.ID == "" {
+			metrics.RecordHubSpotSync("failed")
+			return fmt.Errorf("HubSpot returned an unreadable contact (status %d): %v", resp.StatusCode, err)
+		}
+		inquiry.HubSpotContactID = &created.ID
+		metrics.RecordHubSpotSync("created")
+		return nil
+	case http.StatusConflict:
+		var conflict hubspotErrorResponse
+		json.NewDecoder(resp.Body).Decode(&conflict)
+		match := hubspotExistingIDPattern.FindStringSubmatch(conflict.Message)
+		if match == nil {
+			metrics.RecordHubSpotSync("failed")
+			return fmt.Errorf("HubSpot reported a duplicate contact without its ID: %s", conflict.Message)
+		}
+		if err := c.updateContact(match[1], contact); err != nil {
+			metrics.RecordHubSpotSync("failed")
+			return err
+		}
+		inquiry.HubSpotContactID = &match[1]
+		metrics.RecordHubSpotSync("updated")
+		return nil
+	default:
+		var failure hubspotErrorResponse
+		json.NewDecoder(resp.Body).Decode(&failure)
+		metrics.RecordHubSpotSync("failed")
+		return fmt.Errorf("HubSpot API error (status %d, %s): %s", resp.StatusCode, failure.Category, failure.Message)
+	}
+}
+
+// SyncInquiry creates or updates the HubSpot contact for inquiry on the worker pool and saves
+// the contact ID. It never blocks the caller; failures are logged.
+func (c *HubSpotClient) SyncInquiry(db *gorm.DB, inquiry domain.InvestmentInquiry, queryTimeout time.Duration) {
+	queued := c.pool.Submit(func() {
+		if err := c.CreateContact(&inquiry); err != nil {
+			log.Printf("[HUBSPOT] Warning: failed to sync investment inquiry id=%d: %v", inquiry.ID, err)
+			return
+		}
+		// UpdateColumn leaves updated_at and version alone; this is bookkeeping, not an edit
+		qctx := database.WithTimeout(context.Background(), queryTimeout)
+		if err := db.WithContext(qctx).Model(&domain.InvestmentInquiry{}).Where("id = ?", inquiry.ID).
+			UpdateColumn("hubspot_contact_id", *inquiry.HubSpotContactID).Error; err != nil {
+			log.Printf("[HUBSPOT] Warning: failed to save contact ID %s for investment inquiry id=%d: %v", *inquiry.HubSpotContactID, inquiry.ID, err)
+			return
+		}
+		log.Printf("[HUBSPOT] Synced investment inquiry id=%d to contact %s", inquiry.ID, *inquiry.HubSpotContactID)
+	})
+	if !queued {
+		log.Printf("[HUBSPOT] Warning: sync of investment inquiry id=%d dropped", inquiry.ID)
+		metrics.RecordHubSpotSync("dropped")
+	}
+}
+
+// updateContact overwrites the mapped properties of an existing HubSpot contact
+func (c *HubSpotClient) updateContact(id string, contact hubspotContact) error {
+	resp, err := c.do(http.MethodPatch, "/crm/v3/objects/contacts/"+id, contact)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure hubspotErrorResponse
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("HubSpot API error updating contact %s (status %d, %s): %s", id, resp.StatusCode, failure.Category, failure.Message)
+	}
+	return nil
+}
+
+// do sends an authenticated JSON request to the HubSpot API
+func (c *HubSpotClient) do(method, path string, payload any) (*http.Response, error) {
+	cfg := c.config()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode HubSpot payload: %w", err)
+	}
+
+	req, err := http.NewRequest(method, hubspotBaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build HubSpot request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := doWithBreaker(providerHubSpot, c.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("HubSpot request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// hubspotProperties maps an inquiry to HubSpot contact properties. investment_size_hs is a
+// custom property that must exist in the HubSpot account.
+func hubspotProperties(inquiry *domain.InvestmentInquiry) map[string]string {
+	properties := make(map[string]string)
+	fields := map[string]*string{
+		"firstname":          inquiry.FirstName,
+		"lastname":           inquiry.LastName,
+		"email":              inquiry.Email,
+		"phone":              inquiry.Phone,
+		"investment_size_hs": inquiry.InvestmentSize,
+	}
+	for name, value := range fields {
+		if value != nil && *value != "" {
+			properties[name] = *value
+		}
+	}
+	return properties
+}
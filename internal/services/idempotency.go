@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header clients use to make a POST safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// idempotencyReplayedHeader marks responses served from a stored key
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// idempotencyKeyTTL is how long a stored response is replayed
+	idempotencyKeyTTL = 24 * time.Hour
+
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	errIdempotencyInProgress = errors.New("a request with this Idempotency-Key is still being processed")
+	errIdempotencyMismatch   = errors.New("Idempotency-Key was already used with a different request body")
+)
+
+// IdempotencyMiddleware makes POST requests to paths safe to retry.
+// The first request with a given Idempotency-Key runs normally and its response is stored;
+// repeats by the same caller in the same organization within 24 hours get the stored response without running the
+// handler again.
+// Server errors are not stored so the client can retry them.
+func IdempotencyMiddleware(next http.Handler, db *gorm.DB, paths []string) http.Handler {
+	idempotent := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		idempotent[path] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+		if r.Method != http.MethodPost || key == "" || !idempotent[r.URL.Path] || !config.FeatureFlag(config.FeatureIdempotency) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		tx := db.WithContext(r.Context())
+		stored, reserved, err := reserveIdempotencyKey(tx, OrganizationFromContext(r.Context()), key, r.URL.Path, idempotencyCaller(r), requestHash)
+		switch {
+		case errors.Is(err, errIdempotencyInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, errIdempotencyMismatch):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			log.Printf("[IDEMPOTENCY] Failed to look up key for %s: %v", r.URL.Path, err)
+			http.Error(w, "failed to process Idempotency-Key", http.StatusInternalServerError)
+			return
+		case stored != nil:
+			log.Printf("[IDEMPOTENCY] Replaying %d response for %s", stored.StatusCode, r.URL.Path)
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			_, _ = io.WriteString(w, stored.ResponseBody)
+			return
+		}
+
+		// Release the key if the handler fails or panics so the client can retry
+		completed := false
+		defer func() {
+			if !completed {
+				// The request context may already be cancelled
+				if err := db.WithContext(context.Background()).Delete(reserved).Error; err != nil {
+					log.Printf("[IDEMPOTENCY] Failed to release key for %s: %v", r.URL.Path, err)
+				}
+			}
+		}()
+
+		rec := &idempotencyRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		err = db.WithContext(context.Background()).Model(reserved).Updates(map[string]interface{}{
+			"status_code":   rec.statusCode,
+			"content_type":  rec.Header().Get("Content-Type"),
+			"response_body": rec.body.String(),
+		}).Error
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] Failed to store response for %s: %v", r.URL.Path, err)
+			return
+		}
+		completed = true
+	})
+}
+
+// idempotencyCaller identifies who sent r, so that clients picking the same key never get each
+// other's responses: the user of a valid bearer token, or else the client IP
+func idempotencyCaller(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if claims, err := util.ValidateToken(token); err == nil {
+			return "user:" + claims.Username
+		}
+	}
+	return "ip:" + requestIP(r)
+}
+
+// reserveIdempotencyKey returns the stored record if caller already used key for path in
+// organization orgID, or reserves the key for a new request and returns the reserved record.
+func reserveIdempotencyKey(db *gorm.DB, orgID uint, key, path, caller, requestHash string) (stored, reserved *domain.IdempotencyKey, err error) {
+	scope := domain.IdempotencyKey{OrganizationID: orgID, Key: key, Path: path, Caller: caller}
+	var existing domain.IdempotencyKey
+	err = db.Where(&scope).First(&existing).Error
+	switch {
+	case err == nil && time.Since(existing.CreatedAt) >= idempotencyKeyTTL:
+		// Expired but not yet cleaned up; the key can be reused
+		if err := db.Delete(&existing).Error; err != nil {
+			return nil, nil, err
+		}
+	case err == nil:
+		if existing.RequestHash != requestHash {
+			return nil, nil, errIdempotencyMismatch
+		}
+		if existing.StatusCode == 0 {
+			return nil, nil, errIdempotencyInProgress
+		}
+		return &existing, nil, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil, err
+	}
+
+	record := &domain.IdempotencyKey{OrganizationID: orgID, Key: key, Path: path, Caller: caller, RequestHash: requestHash}
+	if err := db.Create(record).Error; err != nil {
+		// A concurrent request with the same key won the unique index
+		var count int64
+		if db.Model(&domain.IdempotencyKey{}).Where(&scope).Count(&count).Error == nil && count > 0 {
+			return nil, nil, errIdempotencyInProgress
+		}
+		return nil, nil, err
+	}
+	return nil, record, nil
+}
+
+// CleanupIdempotencyKeys deletes stored responses older than the replay window
+func CleanupIdempotencyKeys(db *gorm.DB) (int64, error) {
+	result := db.Where("created_at < ?", time.Now().Add(-idempotencyKeyTTL)).Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// RunIdempotencyKeyCleanup removes expired idempotency keys every interval until ctx is cancelled
+func RunIdempotencyKeyCleanup(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := CleanupIdempotencyKeys(db.WithContext(ctx))
+			if err != nil {
+				log.Printf("[IDEMPOTENCY] Failed to clean up expired keys: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[IDEMPOTENCY] Removed %d expired keys", deleted)
+			}
+		}
+	}
+}
+
+// idempotencyRecorder passes a response through while keeping a copy of it
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	rec.statusCode = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(p []byte) (int, error) {
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// newIdempotentServer serves a POST /api/v1/investment/ handler that stores an inquiry per
+// request it actually runs
+func newIdempotentServer(t *testing.T, db *gorm.DB) *httptest.Server {
+	t.Helper()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Phone string `json:"phone"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		inquiry := &domain.InvestmentInquiry{Phone: &body.Phone}
+		if err := db.Create(inquiry).Error; err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]uint{"id": inquiry.ID})
+	})
+	ts := httptest.NewServer(TenantMiddleware(IdempotencyMiddleware(handler, db, []string{"/api/v1/investment/"}), db))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// postWithKey posts body to the investment endpoint with an optional Idempotency-Key and
+// returns the response and its body
+func postWithKey(t *testing.T, ts *httptest.Server, key, body string) (*http.Response, string) {
+	t.Helper()
+	return postWithKeyAndHeaders(t, ts, nil, key, body)
+}
+
+// postWithKeyAndHeaders is postWithKey with extra request headers
+func postWithKeyAndHeaders(t *testing.T, ts *httptest.Server, headers map[string]string, key, body string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/investment/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+// countInquiries returns the number of stored investment inquiries
+func countInquiries(db *gorm.DB) int64 {
+	var count int64
+	db.Model(&domain.InvestmentInquiry{}).Count(&count)
+	return count
+}
+
+func TestIdempotencyKeyReplaysResponse(t *testing.T) {
+	db := newTestDB(t)
+	ts := newIdempotentServer(t, db)
+	body := `{"phone":"+919876543210"}`
+
+	first, firstBody := postWithKey(t, ts, "retry-1", body)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("first request: status = %d, want 201", first.StatusCode)
+	}
+	second, secondBody := postWithKey(t, ts, "retry-1", body)
+	if second.StatusCode != http.StatusCreated || secondBody != firstBody {
+		t.Fatalf("retry = %d %s, want the stored 201 %s", second.StatusCode, secondBody, firstBody)
+	}
+	if second.Header.Get(idempotencyReplayedHeader) != "true" {
+		t.Error("replayed response is not marked Idempotent-Replayed")
+	}
+	if second.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("replayed Content-Type = %q, want application/json", second.Header.Get("Content-Type"))
+	}
+
+	if n := countInquiries(db); n != 1 {
+		t.Fatalf("%d inquiries stored, want 1", n)
+	}
+}
+
+func TestIdempotencyKeyRejectsDifferentBody(t *testing.T) {
+	db := newTestDB(t)
+	ts := newIdempotentServer(t, db)
+
+	postWithKey(t, ts, "retry-1", `{"phone":"+919876543210"}`)
+	resp, _ := postWithKey(t, ts, "retry-1", `{"phone":"+919812345678"}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", resp.StatusCode)
+	}
+	if n := countInquiries(db); n != 1 {
+		t.Fatalf("%d inquiries stored, want 1", n)
+	}
+}
+
+func TestIdempotencyKeyIsScopedToCaller(t *testing.T) {
+	// The test server's peer is the loopback address, standing in for a reverse proxy
+	t.Setenv("TRUSTED_PROXIES", "127.0.0.1")
+	db := newTestDB(t)
+	ts := newIdempotentServer(t, db)
+	body := `{"phone":"+919876543210"}`
+
+	client := map[string]string{"X-Forwarded-For": "203.0.113.7"}
+	_, first := postWithKeyAndHeaders(t, ts, client, "retry-1", body)
+	other, otherBody := postWithKeyAndHeaders(t, ts, map[string]string{"X-Forwarded-For": "198.51.100.4"}, "retry-1", body)
+	if other.StatusCode != http.StatusCreated || other.Header.Get(idempotencyReplayedHeader) != "" || otherBody == first {
+		t.Fatalf("another caller with the same key got %d %s, want its own 201", other.StatusCode, otherBody)
+	}
+	if retry, retryBody := postWithKeyAndHeaders(t, ts, client, "retry-1", body); retryBody != first {
+		t.Fatalf("retry by the first caller = %d %s, want the stored %s", retry.StatusCode, retryBody, first)
+	}
+
+	if n := countInquiries(db); n != 2 {
+		t.Fatalf("%d inquiries stored, want 2", n)
+	}
+}
+
+func TestIdempotencyKeyIsScopedToOrganization(t *testing.T) {
+	t.Setenv("MULTI_TENANT", "true")
+	db := newTestDB(t)
+	if err := db.Create(&domain.Organization{Name: "Other", Slug: "other"}).Error; err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	ts := newIdempotentServer(t, db)
+	body := `{"phone":"+919876543210"}`
+
+	_, first := postWithKeyAndHeaders(t, ts, map[string]string{OrganizationHeader: "default"}, "retry-1", body)
+	other, otherBody := postWithKeyAndHeaders(t, ts, map[string]string{OrganizationHeader: "other"}, "retry-1", body)
+	if other.StatusCode != http.StatusCreated || other.Header.Get(idempotencyReplayedHeader) != "" || otherBody == first {
+		t.Fatalf("the same caller in another organization got %d %s, want its own 201", other.StatusCode, otherBody)
+	}
+	if n := countInquiries(db); n != 2 {
+		t.Fatalf("%d inquiries stored, want 2", n)
+	}
+}
+
+func TestIdempotencyKeyIsOptional(t *testing.T) {
+	db := newTestDB(t)
+	ts := newIdempotentServer(t, db)
+	body := `{"phone":"+919876543210"}`
+
+	postWithKey(t, ts, "", body)
+	postWithKey(t, ts, "", body)
+	postWithKey(t, ts, "retry-1", body)
+	postWithKey(t, ts, "retry-2", body)
+
+	if n := countInquiries(db); n != 4 {
+		t.Fatalf("%d inquiries stored, want 4", n)
+	}
+}
+
+func TestIdempotencyKeyDisabled(t *testing.T) {
+	t.Setenv("IDEMPOTENCY_KEY_ENABLED", "false")
+	db := newTestDB(t)
+	ts := newIdempotentServer(t, db)
+	body := `{"phone":"+919876543210"}`
+
+	postWithKey(t, ts, "retry-1", body)
+	postWithKey(t, ts, "retry-1", body)
+
+	if n := countInquiries(db); n != 2 {
+		t.Fatalf("%d inquiries stored with IDEMPOTENCY_KEY_ENABLED=false, want 2", n)
+	}
+}
@@ -6,20 +6,30 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"springstreet/gen/investment"
+	"springstreet/internal/config"
+	"springstreet/internal/database"
 	"springstreet/internal/domain"
 	"springstreet/internal/metrics"
 	"springstreet/internal/util"
 
 	"goa.design/goa/v3/security"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // InvestmentService implements the investment service
 type InvestmentService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	bus          *InquiryEventBus
+	notifier     *Notifier
+	hubspot      *HubSpotClient
+	captcha      CaptchaValidator
+	queryTimeout time.Duration
 }
 
 // JWTAuth implements the authorization logic for the JWT security scheme
@@ -32,7 +42,8 @@ func (s *InvestmentService) JWTAuth(ctx context.Context, token string, schema *s
 
 	// Get user from database
 	var user domain.User
-	if err := s.db.Where("username = ?", claims.Username).First(&user).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, investment.MakeUnauthorized(fmt.Errorf("user not found"))
 		}
@@ -44,6 +55,11 @@ func (s *InvestmentService) JWTAuth(ctx context.Context, token string, schema *s
 		return nil, investment.MakeUnauthorized(fmt.Errorf("user account is inactive"))
 	}
 
+	// Check the user belongs to the request's organization
+	if !inRequestOrganization(ctx, &user) {
+		return nil, investment.MakeUnauthorized(fmt.Errorf("user does not belong to this organization"))
+	}
+
 	// Check scopes if required
 	if schema != nil && len(schema.RequiredScopes) > 0 {
 		hasScope := false
@@ -68,8 +84,8 @@ func (s *InvestmentService) JWTAuth(ctx context.Context, token string, schema *s
 }
 
 // NewInvestmentService creates a new investment service
-func NewInvestmentService(db *gorm.DB) *InvestmentService {
-	return &InvestmentService{db: db}
+func NewInvestmentService(db *gorm.DB, bus *InquiryEventBus, notifier *Notifier, hubspot *HubSpotClient, captcha CaptchaValidator, queryTimeout time.Duration) *InvestmentService {
+	return &InvestmentService{db: db, bus: bus, notifier: notifier, hubspot: hubspot, captcha: captcha, queryTimeout: queryTimeout}
 }
 
 // Create implements the create investment inquiry method
@@ -84,6 +100,22 @@ func (s *InvestmentService) Create(ctx context.Context, p *investment.Investment
 	}
 	log.Printf("[INVESTMENT] Create request: email=%s, phone=%s", email, phone)
 
+	if err := checkCaptcha(s.captcha, p.CaptchaToken, clientIP(ctx)); err != nil {
+		log.Printf("[INVESTMENT] Create failed: %v", err)
+		return nil, investment.MakeBadRequest(err)
+	}
+
+	if err := checkDisposableEmail(email); err != nil {
+		log.Printf("[INVESTMENT] Create failed: %v", err)
+		return nil, investment.MakeBadRequest(err)
+	}
+
+	customFields, err := parseCustomFields(p.CustomFields)
+	if err != nil {
+		log.Printf("[INVESTMENT] Create failed: %v", err)
+		return nil, investment.MakeBadRequest(err)
+	}
+
 	// Normalize phone - convert empty string to nil
 	var phoneValue *string
 	if p.Phone != nil && strings.TrimSpace(*p.Phone) != "" {
@@ -98,6 +130,12 @@ func (s *InvestmentService) Create(ctx context.Context, p *investment.Investment
 		currentExposureValue = &normalized
 	}
 
+	country, err := inquiryCountry(p.Country, phoneValue)
+	if err != nil {
+		log.Printf("[INVESTMENT] Create failed: %v", err)
+		return nil, investment.MakeBadRequest(err)
+	}
+
 	// Create inquiry
 	inquiry := domain.InvestmentInquiry{
 		Phone:           phoneValue,
@@ -105,7 +143,18 @@ func (s *InvestmentService) Create(ctx context.Context, p *investment.Investment
 		InvestmentSize:  p.InvestmentSize,
 		CurrentExposure: currentExposureValue,
 		Verified:        false,
+		Country:         country,
+		OrganizationID:  OrganizationFromContext(ctx),
 	}
+	if p.State != nil && strings.TrimSpace(*p.State) != "" {
+		state := strings.TrimSpace(*p.State)
+		inquiry.State = &state
+	}
+	if p.ReferrerURL != nil && strings.TrimSpace(*p.ReferrerURL) != "" {
+		referrer := strings.TrimSpace(*p.ReferrerURL)
+		inquiry.ReferrerURL = &referrer
+	}
+	inquiry.LeadSource = inquiryLeadSource(p.LeadSource, inquiry.ReferrerURL)
 
 	if p.FirstName != nil {
 		inquiry.FirstName = p.FirstName
@@ -119,14 +168,22 @@ func (s *InvestmentService) Create(ctx context.Context, p *investment.Investment
 		defaultExitType := "abandoned"
 		inquiry.ExitType = &defaultExitType
 	}
+	applyCustomFields(&inquiry, customFields)
+	scoreInquiry(&inquiry)
 
-	if err := s.db.Create(&inquiry).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Create(&inquiry).Error; err != nil {
 		log.Printf("[INVESTMENT] Create failed: database error: %v", err)
 		return nil, fmt.Errorf("failed to create inquiry: %w", err)
 	}
 
-	log.Printf("[INVESTMENT] Create successful: id=%d, email=%s, phone=%s", inquiry.ID, email, phone)
-	metrics.RecordInvestmentInquiry()
+	log.Printf("[INVESTMENT] Create successful: id=%d, email=%s, phone=%s, lead_source=%s", inquiry.ID, email, phone, inquiry.LeadSource)
+	metrics.RecordInvestmentInquiry(inquiry.LeadSource)
+	s.bus.Publish(&inquiry)
+
+	// Notify staff (async, don't fail if a channel is unavailable)
+	notified := inquiry
+	s.notifier.NotifyInvestmentCreated(&notified)
 	return convertInquiryToResult(&inquiry), nil
 }
 
@@ -134,12 +191,19 @@ func (s *InvestmentService) Create(ctx context.Context, p *investment.Investment
 func (s *InvestmentService) UpdateByPhone(ctx context.Context, p *investment.UpdateInquiryByPhonePayload) (*investment.Investmentinquiryresult, error) {
 	log.Printf("[INVESTMENT] UpdateByPhone request: phone=%s", p.Phone)
 
+	customFields, err := parseCustomFields(p.CustomFields)
+	if err != nil {
+		log.Printf("[INVESTMENT] UpdateByPhone failed: %v", err)
+		return nil, investment.MakeBadRequest(err)
+	}
+
 	// Normalize phone number
 	normalizedPhone := normalizePhone(p.Phone)
 
 	// Find most recent inquiry by phone
 	var inquiry domain.InvestmentInquiry
-	query := s.db.Where("phone LIKE ?", "%"+normalizedPhone[len(normalizedPhone)-10:]+"%").
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, s.db.WithContext(qctx)).Where("phone LIKE ?", "%"+normalizedPhone[len(normalizedPhone)-10:]+"%").
 		Order("created_at DESC").
 		First(&inquiry)
 
@@ -151,6 +215,13 @@ func (s *InvestmentService) UpdateByPhone(ctx context.Context, p *investment.Upd
 		log.Printf("[INVESTMENT] UpdateByPhone failed: database error: %v", query.Error)
 		return nil, fmt.Errorf("failed to find inquiry: %w", query.Error)
 	}
+	if inquiry.Version != p.Version {
+		log.Printf("[INVESTMENT] UpdateByPhone failed: version conflict for id=%d (have %d, got %d)", inquiry.ID, inquiry.Version, p.Version)
+		return nil, InvestmentConflict("investment inquiry was modified by another request; reload and try again")
+	}
+
+	// Keep the state being replaced so it can be restored from the history
+	before := inquiry
 
 	// Update fields
 	if p.FirstName != nil {
@@ -169,8 +240,27 @@ func (s *InvestmentService) UpdateByPhone(ctx context.Context, p *investment.Upd
 		normalized := normalizeCurrentExposure(*p.CurrentExposure)
 		inquiry.CurrentExposure = &normalized
 	}
+	if len(customFields) > 0 {
+		applyCustomFields(&inquiry, customFields)
+	}
+	scoreInquiry(&inquiry)
 
-	if err := s.db.Save(&inquiry).Error; err != nil {
+	// Only write if nobody else has updated the row since it was read
+	err = s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&inquiry).Where("version = ?", p.Version).Select("*").Updates(&inquiry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInquiryModified
+		}
+		return recordInquiryVersion(tx, &before, nil)
+	})
+	if errors.Is(err, errInquiryModified) {
+		log.Printf("[INVESTMENT] UpdateByPhone failed: concurrent update of id=%d", inquiry.ID)
+		return nil, InvestmentConflict("investment inquiry was modified by another request; reload and try again")
+	}
+	if err != nil {
 		log.Printf("[INVESTMENT] UpdateByPhone failed: save error: %v", err)
 		return nil, fmt.Errorf("failed to update inquiry: %w", err)
 	}
@@ -188,13 +278,14 @@ func (s *InvestmentService) Verify(ctx context.Context, p *investment.VerifyInqu
 	var inquiry domain.InvestmentInquiry
 	var query *gorm.DB
 
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
 	if isEmail {
-		query = s.db.Where("email = ?", strings.ToLower(strings.TrimSpace(identifier))).
+		query = scopeToOrganization(ctx, s.db.WithContext(qctx)).Where("email = ?", strings.ToLower(strings.TrimSpace(identifier))).
 			Order("created_at DESC").
 			First(&inquiry)
 	} else {
 		normalizedPhone := normalizePhone(identifier)
-		query = s.db.Where("phone LIKE ?", "%"+normalizedPhone[len(normalizedPhone)-10:]+"%").
+		query = scopeToOrganization(ctx, s.db.WithContext(qctx)).Where("phone LIKE ?", "%"+normalizedPhone[len(normalizedPhone)-10:]+"%").
 			Order("created_at DESC").
 			First(&inquiry)
 	}
@@ -208,16 +299,39 @@ func (s *InvestmentService) Verify(ctx context.Context, p *investment.VerifyInqu
 		return nil, fmt.Errorf("failed to find inquiry: %w", query.Error)
 	}
 
+	// Use up the OTP verification so it can't verify another inquiry
+	consumed, err := util.ConsumeVerification(identifier)
+	if err != nil {
+		log.Printf("[INVESTMENT] Verify failed: could not check OTP verification: %v", err)
+		return nil, fmt.Errorf("failed to check verification: %w", err)
+	}
+	if !consumed {
+		log.Printf("[INVESTMENT] Verify failed: no unused OTP verification for identifier=%s", identifier)
+		return nil, investment.MakeBadRequest(fmt.Errorf("contact has not been verified. Please verify the OTP sent to it first"))
+	}
+
 	// Mark as verified
+	before := inquiry
 	inquiry.Verified = true
 	exitType := "verified"
 	inquiry.ExitType = &exitType
+	scoreInquiry(&inquiry)
 
-	if err := s.db.Save(&inquiry).Error; err != nil {
+	if err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&inquiry).Error; err != nil {
+			return err
+		}
+		return recordInquiryVersion(tx, &before, nil)
+	}); err != nil {
 		log.Printf("[INVESTMENT] Verify failed: save error: %v", err)
 		return nil, fmt.Errorf("failed to verify inquiry: %w", err)
 	}
 
+	// Hand verified leads to sales in HubSpot (async, doesn't affect the response)
+	if s.hubspot.IsEnabled() {
+		s.hubspot.SyncInquiry(s.db, inquiry, s.queryTimeout)
+	}
+
 	log.Printf("[INVESTMENT] Verify successful: id=%d, identifier=%s", inquiry.ID, identifier)
 	return convertInquiryToResult(&inquiry), nil
 }
@@ -228,7 +342,8 @@ func (s *InvestmentService) GetByPhone(ctx context.Context, p *investment.GetInq
 	normalizedPhone := normalizePhone(p.Phone)
 
 	var inquiry domain.InvestmentInquiry
-	query := s.db.Where("phone LIKE ?", "%"+normalizedPhone[len(normalizedPhone)-10:]+"%").
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)).Where("phone LIKE ?", "%"+normalizedPhone[len(normalizedPhone)-10:]+"%").
 		Order("created_at DESC").
 		First(&inquiry)
 
@@ -247,10 +362,29 @@ func (s *InvestmentService) GetByPhone(ctx context.Context, p *investment.GetInq
 
 // List implements the list inquiries method
 func (s *InvestmentService) List(ctx context.Context, p *investment.ListInquiriesPayload) ([]*investment.Investmentinquiryresult, error) {
-	log.Printf("[INVESTMENT] List request: skip=%d, limit=%d", p.Skip, p.Limit)
+	minScore := "none"
+	if p.MinScore != nil {
+		minScore = strconv.Itoa(*p.MinScore)
+	}
+	log.Printf("[INVESTMENT] List request: skip=%d, limit=%d, min_score=%s, include_archived=%v, search=%v", p.Skip, p.Limit, minScore, p.IncludeArchived, p.Q != nil)
 
 	var inquiries []domain.InvestmentInquiry
-	query := s.db.Order("created_at DESC")
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query, err := inquiryQueryFilter{
+		MinScore:        p.MinScore,
+		IncludeArchived: p.IncludeArchived,
+		Q:               p.Q,
+		Country:         p.Country,
+		State:           p.State,
+		LeadSource:      p.LeadSource,
+		Status:          p.Status,
+		CreatedAfter:    p.CreatedAfter,
+		CreatedBefore:   p.CreatedBefore,
+	}.apply(scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)))
+	if err != nil {
+		log.Printf("[INVESTMENT] List failed: %v", err)
+		return nil, investment.MakeBadRequest(err)
+	}
 
 	if p.Skip > 0 {
 		query = query.Offset(p.Skip)
@@ -279,12 +413,108 @@ func (s *InvestmentService) List(ctx context.Context, p *investment.ListInquirie
 	return results, nil
 }
 
+// inquiryQueryFilter holds the filters shared by the inquiry list and export
+type inquiryQueryFilter struct {
+	MinScore        *int
+	IncludeArchived bool
+	Q               *string
+	Country         *string
+	State           *string
+	LeadSource      *string
+	Status          *string
+	CreatedAfter    *string
+	CreatedBefore   *string
+}
+
+// apply narrows query to the inquiries matching f and orders them newest first, or by
+// relevance when searching. It fails only on an unparseable date.
+func (f inquiryQueryFilter) apply(query *gorm.DB) (*gorm.DB, error) {
+	if f.Q != nil && strings.TrimSpace(*f.Q) != "" {
+		query = searchInquiries(query, strings.TrimSpace(*f.Q))
+	} else {
+		query = query.Order("created_at DESC")
+	}
+
+	if !f.IncludeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	if f.MinScore != nil {
+		query = query.Where("lead_score >= ?", *f.MinScore)
+	}
+	if f.Country != nil {
+		query = query.Where("country = ?", strings.ToUpper(*f.Country))
+	}
+	if f.State != nil && strings.TrimSpace(*f.State) != "" {
+		query = query.Where("LOWER(state) = ?", strings.ToLower(strings.TrimSpace(*f.State)))
+	}
+	if f.LeadSource != nil {
+		query = query.Where("lead_source = ?", *f.LeadSource)
+	}
+	if f.Status != nil {
+		query = query.Where("status = ?", *f.Status)
+	}
+	if f.CreatedAfter != nil {
+		after, err := parseDateParam(*f.CreatedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_after: expected YYYY-MM-DD or RFC3339")
+		}
+		query = query.Where("created_at >= ?", after)
+	}
+	if f.CreatedBefore != nil {
+		before, err := parseDateParam(*f.CreatedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_before: expected YYYY-MM-DD or RFC3339")
+		}
+		query = query.Where("created_at < ?", before)
+	}
+	return query, nil
+}
+
+// inquiryCountry returns the country of a new inquiry: the one given, else the phone
+// number's, else domain.DefaultInvestmentCountry
+func inquiryCountry(country, phone *string) (string, error) {
+	if country != nil && *country != "" {
+		code := strings.ToUpper(*country)
+		if !util.IsRegionCode(code) {
+			return "", fmt.Errorf("unknown country code %q", *country)
+		}
+		return code, nil
+	}
+	if phone != nil {
+		if region := util.PhoneRegion(*phone, config.Get().SMS.DefaultRegion); region != "" {
+			return region, nil
+		}
+	}
+	return domain.DefaultInvestmentCountry, nil
+}
+
+// searchInquiries filters query to the inquiries matching term by name, email or phone. On
+// PostgreSQL it uses the search_vector full-text column and orders by relevance, newest first
+// among equals; on SQLite it falls back to LIKE and orders newest first.
+func searchInquiries(query *gorm.DB, term string) *gorm.DB {
+	if config.Get().Database.IsPostgres() {
+		return query.Where("search_vector @@ plainto_tsquery('english', ?)", term).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "ts_rank(search_vector, plainto_tsquery('english', ?)) DESC, created_at DESC",
+				Vars: []any{term},
+			}})
+	}
+
+	like := likeOperator(query)
+	pattern := "%" + term + "%"
+	return query.Where(
+		fmt.Sprintf("(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) %[1]s ? OR email %[1]s ? OR phone %[1]s ?", like),
+		pattern, pattern, pattern,
+	).Order("created_at DESC")
+}
+
 // Get implements the get inquiry method
 func (s *InvestmentService) Get(ctx context.Context, p *investment.GetInquiryPayload) (*investment.Investmentinquiryresult, error) {
 	log.Printf("[INVESTMENT] Get request: id=%d", p.ID)
 
 	var inquiry domain.InvestmentInquiry
-	if err := s.db.First(&inquiry, p.ID).Error; err != nil {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)).First(&inquiry, p.ID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("[INVESTMENT] Get failed: inquiry id=%d not found", p.ID)
 			return nil, investment.MakeNotFound(fmt.Errorf("investment inquiry not found"))
@@ -297,7 +527,246 @@ func (s *InvestmentService) Get(ctx context.Context, p *investment.GetInquiryPay
 	return convertInquiryToResult(&inquiry), nil
 }
 
+// InquiryStats summarizes all investment inquiries
+type InquiryStats struct {
+	Total            int64
+	Verified         int64
+	AverageLeadScore float64
+	ByStatus         map[string]int64
+	BySize           map[string]int64 // Keyed by investment size bucket; "" for inquiries without one
+}
+
+// Stats returns counts of investment inquiries by status and investment size
+func (s *InvestmentService) Stats(ctx context.Context) (*InquiryStats, error) {
+	log.Printf("[INVESTMENT] Stats request")
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	// Session lets each aggregate below start from the organization scope without
+	// inheriting the previous one's Select and Group
+	db := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx).Model(&domain.InvestmentInquiry{})).Session(&gorm.Session{})
+
+	var totals struct {
+		Total            int64
+		Verified         int64
+		AverageLeadScore float64
+	}
+	if err := db.Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN verified THEN 1 ELSE 0 END), 0) AS verified, COALESCE(AVG(lead_score), 0) AS average_lead_score").
+		Scan(&totals).Error; err != nil {
+		log.Printf("[INVESTMENT] Stats failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count inquiries: %w", err)
+	}
+
+	stats := &InquiryStats{
+		Total:            totals.Total,
+		Verified:         totals.Verified,
+		AverageLeadScore: totals.AverageLeadScore,
+		ByStatus:         map[string]int64{},
+		BySize:           map[string]int64{},
+	}
+
+	var groups []struct {
+		Key   string
+		Count int64
+	}
+	if err := db.Select("status AS key, COUNT(*) AS count").Group("status").Scan(&groups).Error; err != nil {
+		log.Printf("[INVESTMENT] Stats failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count inquiries by status: %w", err)
+	}
+	for _, g := range groups {
+		stats.ByStatus[g.Key] = g.Count
+	}
+
+	groups = nil
+	if err := db.Select("COALESCE(investment_size, '') AS key, COUNT(*) AS count").Group("investment_size").Scan(&groups).Error; err != nil {
+		log.Printf("[INVESTMENT] Stats failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count inquiries by size: %w", err)
+	}
+	for _, g := range groups {
+		stats.BySize[g.Key] += g.Count
+	}
+
+	log.Printf("[INVESTMENT] Stats successful: total=%d", stats.Total)
+	return stats, nil
+}
+
+// StatsByCountry implements the stats by country method
+func (s *InvestmentService) StatsByCountry(ctx context.Context, p *investment.InquiryStatsByCountryPayload) ([]*investment.CountryCountResult, error) {
+	log.Printf("[INVESTMENT] StatsByCountry request: include_archived=%v", p.IncludeArchived)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx).Model(&domain.InvestmentInquiry{})).
+		Select("country, COUNT(*) AS count").
+		Group("country").
+		Order("count DESC, country ASC")
+	if !p.IncludeArchived {
+		query = query.Where("archived = ?", false)
+	}
+
+	var counts []*investment.CountryCountResult
+	if err := query.Scan(&counts).Error; err != nil {
+		log.Printf("[INVESTMENT] StatsByCountry failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count inquiries by country: %w", err)
+	}
+
+	log.Printf("[INVESTMENT] StatsByCountry successful: %d countries", len(counts))
+	return counts, nil
+}
+
+// StatsBySource implements the stats by source method
+func (s *InvestmentService) StatsBySource(ctx context.Context, p *investment.InquiryStatsBySourcePayload) ([]*investment.LeadSourceStatsResult, error) {
+	log.Printf("[INVESTMENT] StatsBySource request: include_archived=%v", p.IncludeArchived)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx).Model(&domain.InvestmentInquiry{})).
+		Select("lead_source, COUNT(*) AS total, SUM(CASE WHEN verified THEN 1 ELSE 0 END) AS verified").
+		Group("lead_source").
+		Order("total DESC, lead_source ASC")
+	if !p.IncludeArchived {
+		query = query.Where("archived = ?", false)
+	}
+
+	var stats []*investment.LeadSourceStatsResult
+	if err := query.Scan(&stats).Error; err != nil {
+		log.Printf("[INVESTMENT] StatsBySource failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to count inquiries by lead source: %w", err)
+	}
+	for _, stat := range stats {
+		if stat.Total > 0 {
+			stat.ConversionRate = float64(stat.Verified) / float64(stat.Total)
+		}
+	}
+
+	log.Printf("[INVESTMENT] StatsBySource successful: %d lead sources", len(stats))
+	return stats, nil
+}
+
+// BulkUpdateStatus moves up to 100 inquiries to a new status (Admin only).
+// Each inquiry's transition is validated individually; valid ones are updated and audited in
+// a single transaction, with their previous state kept in the version history like any other
+// update, and the rest are reported in failed_ids.
+func (s *InvestmentService) BulkUpdateStatus(ctx context.Context, p *investment.BulkUpdateInquiryStatusPayload) (*investment.Bulkupdateresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[INVESTMENT] BulkUpdateStatus request: %d ids to status=%s by user=%s", len(p.Ids), p.Status, user.Username)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	var inquiries []domain.InvestmentInquiry
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx)).Where("id IN ?", p.Ids).Find(&inquiries).Error; err != nil {
+		log.Printf("[INVESTMENT] BulkUpdateStatus failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to load inquiries: %w", err)
+	}
+
+	byID := make(map[int]*domain.InvestmentInquiry, len(inquiries))
+	for i := range inquiries {
+		byID[int(inquiries[i].ID)] = &inquiries[i]
+	}
+
+	// Validate each transition from the inquiry's current status
+	var valid []*domain.InvestmentInquiry
+	failedIDs := []int{}
+	seen := make(map[int]bool, len(p.Ids))
+	for _, id := range p.Ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		inquiry, ok := byID[id]
+		if !ok || !domain.CanTransitionInvestmentStatus(inquiry.Status, p.Status) {
+			failedIDs = append(failedIDs, id)
+			continue
+		}
+		valid = append(valid, inquiry)
+	}
+
+	updated := 0
+	if len(valid) > 0 {
+		sources := domain.InvestmentStatusesMovingTo(p.Status)
+		var modifiedIDs []int
+		err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+			modifiedIDs = nil
+			for _, inquiry := range valid {
+				// Only write if the inquiry is still in the state validated above and recorded
+				// below; one changed since it was read is reported as failed
+				result := tx.Model(&domain.InvestmentInquiry{}).
+					Where("id = ? AND version = ? AND status IN ?", inquiry.ID, inquiry.Version, sources).
+					Updates(map[string]any{
+						"status":     p.Status,
+						"updated_at": time.Now(),
+						"version":    gorm.Expr("version + 1"),
+					})
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					modifiedIDs = append(modifiedIDs, int(inquiry.ID))
+					continue
+				}
+				if err := recordInquiryVersion(tx, inquiry, user); err != nil {
+					return err
+				}
+				details := fmt.Sprintf("status %s -> %s (bulk)", inquiry.Status, p.Status)
+				if err := recordAudit(tx, user, "status_change", domain.AuditResourceInvestmentInquiry, inquiry.ID, details); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("[INVESTMENT] BulkUpdateStatus failed: database error: %v", err)
+			return nil, fmt.Errorf("failed to update inquiries: %w", err)
+		}
+		if len(modifiedIDs) > 0 {
+			log.Printf("[INVESTMENT] BulkUpdateStatus: %d inquiries were modified concurrently: %v", len(modifiedIDs), modifiedIDs)
+		}
+		updated = len(valid) - len(modifiedIDs)
+		failedIDs = append(failedIDs, modifiedIDs...)
+	}
+
+	metrics.RecordInvestmentBulkUpdate(updated, len(failedIDs))
+	log.Printf("[INVESTMENT] BulkUpdateStatus successful: updated=%d, failed=%d", updated, len(failedIDs))
+	return &investment.Bulkupdateresult{
+		UpdatedCount: updated,
+		FailedIds:    failedIDs,
+	}, nil
+}
+
+// Stream pushes newly created inquiries to the client until it disconnects (Staff/Admin only)
+func (s *InvestmentService) Stream(ctx context.Context, p *investment.StreamInquiriesPayload, stream investment.StreamServerStream) error {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[INVESTMENT] Stream opened by user=%s", user.Username)
+
+	id, events := s.bus.Subscribe()
+	defer func() {
+		s.bus.Unsubscribe(id)
+		log.Printf("[INVESTMENT] Stream closed for user=%s", user.Username)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.bus.Done():
+			return nil
+		case inquiry := <-events:
+			// The bus carries every organization's inquiries
+			if !inOrganization(ctx, inquiry.OrganizationID) {
+				continue
+			}
+			if err := stream.SendWithContext(ctx, convertInquiryToEvent(inquiry)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // Helper functions
+
+// scoreInquiry updates the inquiry's lead score from its current fields
+func scoreInquiry(inquiry *domain.InvestmentInquiry) {
+	inquiry.LeadScore = ComputeLeadScore(inquiry)
+	metrics.ObserveInvestmentLeadScore(inquiry.LeadScore)
+}
+
 func normalizePhone(phone string) string {
 	re := regexp.MustCompile(`\d+`)
 	digits := re.FindAllString(phone, -1)
@@ -342,7 +811,18 @@ func convertInquiryToResult(inquiry *domain.InvestmentInquiry) *investment.Inves
 	result := &investment.Investmentinquiryresult{
 		ID:        int(inquiry.ID),
 		Verified:  inquiry.Verified,
+		Status:    inquiry.Status,
+		LeadScore: inquiry.LeadScore,
 		CreatedAt: inquiry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Version:   inquiry.Version,
+		Archived:  inquiry.Archived,
+
+		FollowUpNotified: inquiry.FollowUpNotified,
+		CustomFields:     inquiry.CustomFields,
+		Country:          inquiry.Country,
+		State:            inquiry.State,
+		LeadSource:       inquiry.LeadSource,
+		ReferrerURL:      inquiry.ReferrerURL,
 	}
 
 	if inquiry.FirstName != nil {
@@ -370,6 +850,22 @@ func convertInquiryToResult(inquiry *domain.InvestmentInquiry) *investment.Inves
 		updatedAt := inquiry.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
 		result.UpdatedAt = &updatedAt
 	}
+	if inquiry.ArchivedAt != nil {
+		archivedAt := inquiry.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
+		result.ArchivedAt = &archivedAt
+	}
+	if inquiry.FollowUpAt != nil {
+		followUpAt := inquiry.FollowUpAt.Format("2006-01-02T15:04:05Z07:00")
+		result.FollowUpAt = &followUpAt
+	}
 
 	return result
 }
+
+// convertInquiryToEvent converts an InvestmentInquiry model to a stream event. The event
+// extends the result type, so converting the result keeps the two from drifting apart: a
+// field added to only one of them fails to compile here.
+func convertInquiryToEvent(inquiry *domain.InvestmentInquiry) *investment.InvestmentInquiryEvent {
+	event := investment.InvestmentInquiryEvent(*convertInquiryToResult(inquiry))
+	return &event
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditActionArchive   = "archive"
+	auditActionUnarchive = "unarchive"
+)
+
+// ArchiveInquiry archives inquiries so they drop out of the default list. Inquiries that
+// don't exist or are already archived are returned in failed_ids.
+func (s *InvestmentService) ArchiveInquiry(ctx context.Context, p *investment.ArchiveInquiriesPayload) (*investment.Bulkupdateresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[INVESTMENT] ArchiveInquiry request: %d ids by user=%s", len(p.Ids), user.Username)
+
+	result, err := s.setArchived(ctx, user, p.Ids, true)
+	if err != nil {
+		log.Printf("[INVESTMENT] ArchiveInquiry failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to archive inquiries: %w", err)
+	}
+	metrics.RecordInquiriesArchived("manual", result.UpdatedCount)
+	log.Printf("[INVESTMENT] ArchiveInquiry successful: archived=%d, failed=%d", result.UpdatedCount, len(result.FailedIds))
+	return result, nil
+}
+
+// UnarchiveInquiry returns archived inquiries to the default list. Inquiries that don't
+// exist or aren't archived are returned in failed_ids.
+func (s *InvestmentService) UnarchiveInquiry(ctx context.Context, p *investment.ArchiveInquiriesPayload) (*investment.Bulkupdateresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[INVESTMENT] UnarchiveInquiry request: %d ids by user=%s", len(p.Ids), user.Username)
+
+	result, err := s.setArchived(ctx, user, p.Ids, false)
+	if err != nil {
+		log.Printf("[INVESTMENT] UnarchiveInquiry failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to unarchive inquiries: %w", err)
+	}
+	log.Printf("[INVESTMENT] UnarchiveInquiry successful: unarchived=%d, failed=%d", result.UpdatedCount, len(result.FailedIds))
+	return result, nil
+}
+
+// ListArchived returns archived inquiries, newest first
+func (s *InvestmentService) ListArchived(ctx context.Context, p *investment.ListArchivedInquiriesPayload) ([]*investment.Investmentinquiryresult, error) {
+	log.Printf("[INVESTMENT] ListArchived request: skip=%d, limit=%d", p.Skip, p.Limit)
+
+	var inquiries []domain.InvestmentInquiry
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)).
+		Where("archived = ?", true).
+		Order("created_at DESC").
+		Offset(p.Skip).Limit(p.Limit).
+		Find(&inquiries).Error; err != nil {
+		log.Printf("[INVESTMENT] ListArchived failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to list archived inquiries: %w", err)
+	}
+
+	results := make([]*investment.Investmentinquiryresult, len(inquiries))
+	for i, inquiry := range inquiries {
+		results[i] = convertInquiryToResult(&inquiry)
+	}
+
+	log.Printf("[INVESTMENT] ListArchived successful: returned %d inquiries", len(results))
+	return results, nil
+}
+
+// setArchived moves the inquiries in ids that aren't already in the requested state
+func (s *InvestmentService) setArchived(ctx context.Context, user *domain.User, ids []int, archived bool) (*investment.Bulkupdateresult, error) {
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	var matched []uint
+	if err := scopeToOrganization(ctx, s.db.WithContext(qctx).Model(&domain.InvestmentInquiry{})).
+		Where("id IN ? AND archived = ?", ids, !archived).
+		Pluck("id", &matched).Error; err != nil {
+		return nil, err
+	}
+
+	eligible := make(map[int]bool, len(matched))
+	for _, id := range matched {
+		eligible[int(id)] = true
+	}
+	failedIDs := []int{}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] && !eligible[id] {
+			failedIDs = append(failedIDs, id)
+		}
+		seen[id] = true
+	}
+
+	if len(matched) > 0 {
+		action := auditActionArchive
+		if !archived {
+			action = auditActionUnarchive
+		}
+		err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&domain.InvestmentInquiry{}).Where("id IN ?", matched).Updates(archiveUpdates(archived)).Error; err != nil {
+				return err
+			}
+			for _, id := range matched {
+				if err := recordAudit(tx, user, action, domain.AuditResourceInvestmentInquiry, id, ""); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &investment.Bulkupdateresult{
+		UpdatedCount: len(matched),
+		FailedIds:    failedIDs,
+	}, nil
+}
+
+// archiveUpdates returns the column updates that archive or unarchive an inquiry
+func archiveUpdates(archived bool) map[string]any {
+	now := time.Now()
+	updates := map[string]any{
+		"archived":    archived,
+		"archived_at": nil,
+		"updated_at":  now,
+		"version":     gorm.Expr("version + 1"),
+	}
+	if archived {
+		updates["archived_at"] = now
+	}
+	return updates
+}
+
+// ArchiveInquiriesOlderThan archives every inquiry created more than olderThan ago that isn't
+// archived yet, and returns how many it archived. It is used by cmd/archive.
+func ArchiveInquiriesOlderThan(db *gorm.DB, olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-olderThan)
+	var archived int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			ID             uint
+			OrganizationID uint
+		}
+		if err := tx.Model(&domain.InvestmentInquiry{}).
+			Where("archived = ? AND created_at < ?", false, cutoff).
+			Select("id", "organization_id").Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+
+		result := tx.Model(&domain.InvestmentInquiry{}).Where("id IN ?", ids).Updates(archiveUpdates(true))
+		if result.Error != nil {
+			return result.Error
+		}
+		details := fmt.Sprintf("created before %s", cutoff.Format("2006-01-02"))
+		for _, row := range rows {
+			// The job runs across organizations, so each entry goes to its inquiry's
+			orgTx := tx.WithContext(WithOrganization(tx.Statement.Context, row.OrganizationID))
+			if err := recordAudit(orgTx, nil, auditActionArchive, domain.AuditResourceInvestmentInquiry, row.ID, details); err != nil {
+				return err
+			}
+		}
+		archived = result.RowsAffected
+		return nil
+	})
+	if err == nil {
+		metrics.RecordInquiriesArchived("auto", int(archived))
+	}
+	return archived, err
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+func TestInvestmentBulkUpdateStatusRecordsVersions(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, domain.User{Username: "admin", IsAdmin: true}, "correct horse")
+	s := NewInvestmentService(db, nil, nil, nil, nil, time.Minute)
+
+	fresh := &domain.InvestmentInquiry{Status: domain.InvestmentStatusNew}
+	converted := &domain.InvestmentInquiry{Status: domain.InvestmentStatusConverted}
+	raced := &domain.InvestmentInquiry{Status: domain.InvestmentStatusNew}
+	for _, inquiry := range []*domain.InvestmentInquiry{fresh, converted, raced} {
+		if err := db.Create(inquiry).Error; err != nil {
+			t.Fatalf("create inquiry: %v", err)
+		}
+	}
+
+	// Another request disqualifies raced after the bulk update has read it
+	err := db.Callback().Query().After("gorm:query").Register("test:concurrent_update", func(tx *gorm.DB) {
+		if tx.Statement.Table == "investment_inquiries" {
+			tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE investment_inquiries SET status = ?, version = version + 1 WHERE id = ?",
+				domain.InvestmentStatusDisqualified, raced.ID)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	res, err := s.BulkUpdateStatus(asUser(context.Background(), admin), &investment.BulkUpdateInquiryStatusPayload{
+		Ids:    []int{int(fresh.ID), int(converted.ID), int(raced.ID)},
+		Status: domain.InvestmentStatusContacted,
+	})
+	db.Callback().Query().Remove("test:concurrent_update")
+	if err != nil {
+		t.Fatalf("BulkUpdateStatus: %v", err)
+	}
+	if res.UpdatedCount != 1 || !slices.Equal(res.FailedIds, []int{int(converted.ID), int(raced.ID)}) {
+		t.Fatalf("result = %+v, want only inquiry %d updated", res, fresh.ID)
+	}
+
+	var reloaded domain.InvestmentInquiry
+	if err := db.First(&reloaded, raced.ID).Error; err != nil {
+		t.Fatalf("load inquiry: %v", err)
+	}
+	if reloaded.Status != domain.InvestmentStatusDisqualified {
+		t.Errorf("concurrently changed inquiry has status %q, want the other request's %q", reloaded.Status, domain.InvestmentStatusDisqualified)
+	}
+	var updated domain.InvestmentInquiry
+	if err := db.First(&updated, fresh.ID).Error; err != nil {
+		t.Fatalf("load inquiry: %v", err)
+	}
+	if updated.Status != domain.InvestmentStatusContacted || updated.Version != fresh.Version+1 {
+		t.Errorf("updated inquiry has status %q and version %d, want %q and %d", updated.Status, updated.Version, domain.InvestmentStatusContacted, fresh.Version+1)
+	}
+
+	// The replaced state is in the history, so the bulk change can be undone
+	var versions []domain.InvestmentInquiryVersion
+	if err := db.Find(&versions).Error; err != nil {
+		t.Fatalf("load versions: %v", err)
+	}
+	if len(versions) != 1 || versions[0].InquiryID != fresh.ID || versions[0].ChangedBy == nil || *versions[0].ChangedBy != admin.ID {
+		t.Fatalf("versions = %+v, want one for inquiry %d changed by the admin", versions, fresh.ID)
+	}
+	restored, err := s.RestoreVersion(asUser(context.Background(), admin), &investment.RestoreInquiryVersionPayload{ID: int(fresh.ID), VersionID: int(versions[0].ID)})
+	if err != nil {
+		t.Fatalf("RestoreVersion: %v", err)
+	}
+	if restored.Status != domain.InvestmentStatusNew {
+		t.Errorf("restored status = %q, want %q", restored.Status, domain.InvestmentStatusNew)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+)
+
+// parseCustomFields checks fields against the custom field schema and converts each value
+// to the type the schema gives it. Empty values are returned as nil.
+func parseCustomFields(fields map[string]string) (map[string]interface{}, error) {
+	schema := config.Get().Investment.CustomFieldSchema
+	parsed := make(map[string]interface{}, len(fields))
+	for key, raw := range fields {
+		fieldType, ok := schema[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom field %q", key)
+		}
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			parsed[key] = nil
+			continue
+		}
+
+		switch fieldType {
+		case config.CustomFieldNumber:
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("custom field %q must be a number", key)
+			}
+			parsed[key] = value
+		case config.CustomFieldInteger:
+			value, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("custom field %q must be an integer", key)
+			}
+			parsed[key] = value
+		case config.CustomFieldBoolean:
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("custom field %q must be true or false", key)
+			}
+			parsed[key] = value
+		default:
+			parsed[key] = raw
+		}
+	}
+	return parsed, nil
+}
+
+// applyCustomFields sets the parsed values on inquiry, removing the fields whose value is nil
+func applyCustomFields(inquiry *domain.InvestmentInquiry, parsed map[string]interface{}) {
+	// Copy so the inquiry's previous state, kept for its version history, isn't changed
+	fields := make(domain.CustomFields, len(inquiry.CustomFields)+len(parsed))
+	for key, value := range inquiry.CustomFields {
+		fields[key] = value
+	}
+	for key, value := range parsed {
+		if value == nil {
+			delete(fields, key)
+		} else {
+			fields[key] = value
+		}
+	}
+	if len(fields) == 0 {
+		fields = nil
+	}
+	inquiry.CustomFields = fields
+}
+
+// customFieldKeys returns the keys of the custom field schema in alphabetical order
+func customFieldKeys() []string {
+	schema := config.Get().Investment.CustomFieldSchema
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// xlsxContentType is the media type of Excel workbooks
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// maxExportRows caps a single export; narrow it with the list filters if more are needed
+	maxExportRows = 10000
+
+	// auditActionInquiryExport marks inquiry exports in the audit log
+	auditActionInquiryExport = "export"
+
+	exportSheetName  = "Inquiries"
+	exportDateFormat = "yyyy-mm-dd hh:mm"
+	minColumnWidth   = 10
+	maxColumnWidth   = 50
+)
+
+// exportColumns are the workbook headers, in column order
+var exportColumns = []string{
+	"ID", "First Name", "Last Name", "Phone", "Email", "Investment Size", "Current Exposure",
+	"Verified", "Exit Type", "Status", "Lead Score", "Created At (UTC)", "Updated At (UTC)",
+	"Country", "State", "Lead Source", "Referrer URL",
+}
+
+// ExportXlsx implements the export xlsx method
+func (s *InvestmentService) ExportXlsx(ctx context.Context, p *investment.ExportInquiriesPayload) (*investment.ExportInquiriesResult, io.ReadCloser, error) {
+	user := ctx.Value("user").(*domain.User)
+	minScore := "none"
+	if p.MinScore != nil {
+		minScore = strconv.Itoa(*p.MinScore)
+	}
+	log.Printf("[INVESTMENT] ExportXlsx request: min_score=%s, include_archived=%v, search=%v by '%s'", minScore, p.IncludeArchived, p.Q != nil, user.Username)
+
+	var inquiries []domain.InvestmentInquiry
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	query, err := inquiryQueryFilter{
+		MinScore:        p.MinScore,
+		IncludeArchived: p.IncludeArchived,
+		Q:               p.Q,
+		Country:         p.Country,
+		State:           p.State,
+		LeadSource:      p.LeadSource,
+		Status:          p.Status,
+		CreatedAfter:    p.CreatedAfter,
+		CreatedBefore:   p.CreatedBefore,
+	}.apply(scopeToOrganization(ctx, database.GetReadDB().WithContext(qctx)))
+	if err != nil {
+		log.Printf("[INVESTMENT] ExportXlsx failed: %v", err)
+		return nil, nil, investment.MakeBadRequest(err)
+	}
+	if err := query.Limit(maxExportRows).Find(&inquiries).Error; err != nil {
+		log.Printf("[INVESTMENT] ExportXlsx failed: database error: %v", err)
+		return nil, nil, fmt.Errorf("failed to list inquiries: %w", err)
+	}
+
+	body, err := ExportInvestmentXLSX(inquiries, customFieldKeys())
+	if err != nil {
+		log.Printf("[INVESTMENT] ExportXlsx failed: %v", err)
+		return nil, nil, err
+	}
+
+	details := fmt.Sprintf("xlsx export of %d inquiries (min_score=%s, include_archived=%v)", len(inquiries), minScore, p.IncludeArchived)
+	if err := recordAudit(s.db.WithContext(qctx), user, auditActionInquiryExport, domain.AuditResourceInvestmentInquiry, 0, details); err != nil {
+		log.Printf("[INVESTMENT] ExportXlsx failed: could not record audit log: %v", err)
+		return nil, nil, err
+	}
+
+	log.Printf("[INVESTMENT] ExportXlsx successful: %d inquiries, %d bytes", len(inquiries), len(body))
+	return &investment.ExportInquiriesResult{
+		ContentType:        xlsxContentType,
+		ContentDisposition: "attachment; filename=inquiries.xlsx",
+	}, io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// ExportInvestmentXLSX renders inquiries as a single-sheet Excel workbook with a styled
+// header row, date-formatted timestamp cells and columns sized to their content. Each of
+// customFields gets a column after the standard ones.
+func ExportInvestmentXLSX(inquiries []domain.InvestmentInquiry, customFields []string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", exportSheetName); err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %w", err)
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"1C5D99"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+	dateFormat := exportDateFormat
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create date style: %w", err)
+	}
+
+	columns := append(append([]string{}, exportColumns...), customFields...)
+	widths := make([]int, len(columns))
+	for i, header := range columns {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+
+	rows := make([][]interface{}, len(inquiries))
+	for i, inquiry := range inquiries {
+		row := []interface{}{
+			int(inquiry.ID),
+			stringValue(inquiry.FirstName),
+			stringValue(inquiry.LastName),
+			stringValue(inquiry.Phone),
+			stringValue(inquiry.Email),
+			stringValue(inquiry.InvestmentSize),
+			stringValue(inquiry.CurrentExposure),
+			inquiry.Verified,
+			stringValue(inquiry.ExitType),
+			inquiry.Status,
+			inquiry.LeadScore,
+			excelize.Cell{StyleID: dateStyle, Value: inquiry.CreatedAt.UTC()},
+			nil,
+			inquiry.Country,
+			stringValue(inquiry.State),
+			inquiry.LeadSource,
+			stringValue(inquiry.ReferrerURL),
+		}
+		if inquiry.UpdatedAt != nil {
+			row[12] = excelize.Cell{StyleID: dateStyle, Value: inquiry.UpdatedAt.UTC()}
+		}
+		for _, key := range customFields {
+			row = append(row, inquiry.CustomFields[key])
+		}
+		for col, value := range row {
+			if width := exportCellWidth(value); width > widths[col] {
+				widths[col] = width
+			}
+		}
+		rows[i] = row
+	}
+
+	sw, err := f.NewStreamWriter(exportSheetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheet writer: %w", err)
+	}
+
+	// The stream writer only accepts column widths before the first row
+	for col, width := range widths {
+		width += 2
+		if width < minColumnWidth {
+			width = minColumnWidth
+		}
+		if width > maxColumnWidth {
+			width = maxColumnWidth
+		}
+		if err := sw.SetColWidth(col+1, col+1, float64(width)); err != nil {
+			return nil, fmt.Errorf("failed to size column %d: %w", col+1, err)
+		}
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, name := range columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: name}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return nil, err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return nil, fmt.Errorf("failed to write row %d: %w", i+2, err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write sheet: %w", err)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode workbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// stringValue returns the value of an optional column, or an empty cell
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// exportCellWidth estimates the display width of a cell value in characters
+func exportCellWidth(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return utf8.RuneCountInString(v)
+	case excelize.Cell:
+		if _, ok := v.Value.(time.Time); ok {
+			return len("2006-01-02 15:04")
+		}
+		return exportCellWidth(v.Value)
+	case nil:
+		return 0
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
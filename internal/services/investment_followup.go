@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditActionSetFollowUp = "set_follow_up"
+
+	// followUpBatchSize caps the reminders sent per check; the rest wait for the next one
+	followUpBatchSize = 100
+)
+
+// SetFollowUp implements the set follow up method. The staff member who sets the reminder
+// is the one it is emailed to. Setting a new time replaces the earlier reminder, even one
+// that has already been sent.
+func (s *InvestmentService) SetFollowUp(ctx context.Context, p *investment.SetFollowUpPayload) (*investment.Investmentinquiryresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[INVESTMENT] SetFollowUp request: id=%d, follow_up_at=%s by user=%s", p.ID, p.FollowUpAt, user.Username)
+
+	followUpAt, err := time.Parse(time.RFC3339, p.FollowUpAt)
+	if err != nil {
+		return nil, investment.MakeBadRequest(fmt.Errorf("invalid follow_up_at: expected RFC3339"))
+	}
+	if !followUpAt.After(time.Now()) {
+		return nil, investment.MakeBadRequest(fmt.Errorf("follow_up_at must be in the future"))
+	}
+
+	var inquiry domain.InvestmentInquiry
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	err = s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := scopeToOrganization(ctx, tx).First(&inquiry, p.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return investment.MakeNotFound(fmt.Errorf("investment inquiry not found"))
+			}
+			return err
+		}
+
+		updates := map[string]any{
+			"follow_up_at":       followUpAt,
+			"follow_up_by":       user.ID,
+			"follow_up_notified": false,
+			"updated_at":         time.Now(),
+			"version":            gorm.Expr("version + 1"),
+		}
+		if err := tx.Model(&inquiry).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&inquiry, p.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, user, auditActionSetFollowUp, domain.AuditResourceInvestmentInquiry, inquiry.ID, followUpAt.Format(time.RFC3339))
+	})
+	if err != nil {
+		log.Printf("[INVESTMENT] SetFollowUp failed: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[INVESTMENT] SetFollowUp successful: id=%d reminder at %s", inquiry.ID, followUpAt.Format(time.RFC3339))
+	return convertInquiryToResult(&inquiry), nil
+}
+
+// RunFollowUpReminders emails the reminders that have come due every interval until ctx is done
+func RunFollowUpReminders(ctx context.Context, db *gorm.DB, emailService *EmailService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := SendDueFollowUpReminders(db.WithContext(ctx), emailService)
+			if err != nil {
+				log.Printf("[REMINDER] Failed to send follow-up reminders: %v", err)
+			} else if sent > 0 {
+				log.Printf("[REMINDER] Sent %d follow-up reminders", sent)
+			}
+		}
+	}
+}
+
+// SendDueFollowUpReminders emails each due, unsent reminder on an inquiry that isn't archived
+// to the staff member who set it, and returns how many it sent. A reminder is claimed before
+// it is sent so two replicas don't both send it; if sending fails the claim is released and
+// the next run tries again.
+func SendDueFollowUpReminders(db *gorm.DB, emailService *EmailService) (int, error) {
+	var due []domain.InvestmentInquiry
+	if err := db.Where("follow_up_at <= ? AND follow_up_notified = ? AND archived = ? AND follow_up_by IS NOT NULL", time.Now(), false, false).
+		Order("follow_up_at ASC").
+		Limit(followUpBatchSize).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	userIDs := make([]uint, 0, len(due))
+	for _, inquiry := range due {
+		userIDs = append(userIDs, *inquiry.FollowUpBy)
+	}
+	var users []domain.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return 0, err
+	}
+	staff := make(map[uint]*domain.User, len(users))
+	for i := range users {
+		staff[users[i].ID] = &users[i]
+	}
+
+	sent := 0
+	for i := range due {
+		inquiry := &due[i]
+		claim := db.Model(&domain.InvestmentInquiry{}).
+			Where("id = ? AND follow_up_notified = ?", inquiry.ID, false).
+			UpdateColumn("follow_up_notified", true)
+		if claim.Error != nil {
+			return sent, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		recipient, ok := staff[*inquiry.FollowUpBy]
+		if !ok || !recipient.IsActive {
+			// Nobody to remind; the claim stays so it isn't retried every run
+			log.Printf("[REMINDER] Skipping reminder for inquiry id=%d: user id=%d not found or inactive", inquiry.ID, *inquiry.FollowUpBy)
+			continue
+		}
+
+		if err := sendFollowUpReminder(emailService, recipient, inquiry); err != nil {
+			log.Printf("[REMINDER] Failed to send reminder for inquiry id=%d to '%s': %v", inquiry.ID, recipient.Username, err)
+			if err := db.Model(&domain.InvestmentInquiry{}).Where("id = ?", inquiry.ID).UpdateColumn("follow_up_notified", false).Error; err != nil {
+				log.Printf("[REMINDER] Failed to release reminder for inquiry id=%d: %v", inquiry.ID, err)
+			}
+			continue
+		}
+		metrics.RecordFollowUpReminderSent()
+		sent++
+	}
+	return sent, nil
+}
+
+// sendFollowUpReminder emails recipient the reminder for inquiry, in the recipient's language
+func sendFollowUpReminder(emailService *EmailService, recipient *domain.User, inquiry *domain.InvestmentInquiry) error {
+	staffName := recipient.Username
+	if recipient.FullName != nil && *recipient.FullName != "" {
+		staffName = *recipient.FullName
+	}
+	name := strings.TrimSpace(valueOr(inquiry.FirstName, "") + " " + valueOr(inquiry.LastName, ""))
+	if name == "" {
+		name = "Unnamed lead"
+	}
+
+	ctx := WithOrganization(context.Background(), inquiry.OrganizationID)
+	return emailService.sendTemplate(ctx, emailTypeFollowUpReminder, EmailTo(recipient.Email), recipient.Language, "follow_up_reminder", map[string]any{
+		"ID":             inquiry.ID,
+		"StaffName":      staffName,
+		"Name":           name,
+		"Phone":          valueOr(inquiry.Phone, "Not provided"),
+		"Email":          valueOr(inquiry.Email, "Not provided"),
+		"InvestmentSize": valueOr(inquiry.InvestmentSize, "Not provided"),
+		"Status":         inquiry.Status,
+		"FollowUpAt":     inquiry.FollowUpAt.Format("January 2, 2006 at 3:04 PM MST"),
+	})
+}
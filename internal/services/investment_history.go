@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+const auditActionRestoreVersion = "restore_version"
+
+// errInquiryModified aborts an update transaction when the row changed since it was read
+var errInquiryModified = errors.New("investment inquiry was modified concurrently")
+
+// History implements the history method: the versions an inquiry went through, newest first
+func (s *InvestmentService) History(ctx context.Context, p *investment.GetInquiryPayload) ([]*investment.Inquiryversionresult, error) {
+	log.Printf("[INVESTMENT] History request: id=%d", p.ID)
+
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	db := database.GetReadDB().WithContext(qctx)
+	var count int64
+	if err := scopeToOrganization(ctx, db.Model(&domain.InvestmentInquiry{})).Where("id = ?", p.ID).Count(&count).Error; err != nil {
+		log.Printf("[INVESTMENT] History failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to get inquiry: %w", err)
+	}
+	if count == 0 {
+		return nil, investment.MakeNotFound(fmt.Errorf("investment inquiry not found"))
+	}
+
+	var versions []domain.InvestmentInquiryVersion
+	if err := db.Where("inquiry_id = ?", p.ID).Order("id DESC").Find(&versions).Error; err != nil {
+		log.Printf("[INVESTMENT] History failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to fetch inquiry history: %w", err)
+	}
+
+	// Resolve the usernames of everyone who made a change
+	var userIDs []uint
+	for _, v := range versions {
+		if v.ChangedBy != nil {
+			userIDs = append(userIDs, *v.ChangedBy)
+		}
+	}
+	usernames := make(map[uint]string)
+	if len(userIDs) > 0 {
+		var users []domain.User
+		if err := db.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			log.Printf("[INVESTMENT] History failed: database error: %v", err)
+			return nil, fmt.Errorf("failed to fetch users: %w", err)
+		}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+
+	results := make([]*investment.Inquiryversionresult, len(versions))
+	for i, v := range versions {
+		var snapshot map[string]any
+		if err := json.Unmarshal([]byte(v.Snapshot), &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to decode inquiry version %d: %w", v.ID, err)
+		}
+		results[i] = &investment.Inquiryversionresult{
+			VersionID: int(v.ID),
+			ChangedAt: v.ChangedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Snapshot:  snapshot,
+		}
+		if v.ChangedBy != nil {
+			if username, ok := usernames[*v.ChangedBy]; ok {
+				results[i].ChangedByUsername = &username
+			}
+		}
+	}
+
+	log.Printf("[INVESTMENT] History successful: id=%d, %d versions", p.ID, len(results))
+	return results, nil
+}
+
+// RestoreVersion implements the restore version method. The state being replaced is recorded
+// as a new version in the same transaction, so a restore can itself be undone.
+func (s *InvestmentService) RestoreVersion(ctx context.Context, p *investment.RestoreInquiryVersionPayload) (*investment.Investmentinquiryresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[INVESTMENT] RestoreVersion request: id=%d, version=%d by user=%s", p.ID, p.VersionID, user.Username)
+
+	var inquiry domain.InvestmentInquiry
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	err := s.db.WithContext(qctx).Transaction(func(tx *gorm.DB) error {
+		if err := scopeToOrganization(ctx, tx).First(&inquiry, p.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return investment.MakeNotFound(fmt.Errorf("investment inquiry not found"))
+			}
+			return err
+		}
+		var version domain.InvestmentInquiryVersion
+		if err := tx.Where("id = ? AND inquiry_id = ?", p.VersionID, p.ID).First(&version).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return investment.MakeNotFound(fmt.Errorf("version not found for this inquiry"))
+			}
+			return err
+		}
+
+		var restored domain.InvestmentInquiry
+		if err := json.Unmarshal([]byte(version.Snapshot), &restored); err != nil {
+			return fmt.Errorf("failed to decode inquiry version %d: %w", version.ID, err)
+		}
+		// Only the inquiry's data is restored; identity, organization, locking, sync/archive
+		// state and reminders stay current
+		restored.ID = inquiry.ID
+		restored.OrganizationID = inquiry.OrganizationID
+		restored.CreatedAt = inquiry.CreatedAt
+		restored.Version = inquiry.Version
+		restored.HubSpotContactID = inquiry.HubSpotContactID
+		restored.Archived = inquiry.Archived
+		restored.ArchivedAt = inquiry.ArchivedAt
+		restored.FollowUpAt = inquiry.FollowUpAt
+		restored.FollowUpBy = inquiry.FollowUpBy
+		restored.FollowUpNotified = inquiry.FollowUpNotified
+
+		before := inquiry
+		result := tx.Model(&restored).Where("version = ?", inquiry.Version).Select("*").Updates(&restored)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInquiryModified
+		}
+		if err := recordInquiryVersion(tx, &before, user); err != nil {
+			return err
+		}
+		inquiry = restored
+		return recordAudit(tx, user, auditActionRestoreVersion, domain.AuditResourceInvestmentInquiry, inquiry.ID, fmt.Sprintf("version %d", version.ID))
+	})
+	if errors.Is(err, errInquiryModified) {
+		log.Printf("[INVESTMENT] RestoreVersion failed: concurrent update of id=%d", p.ID)
+		return nil, InvestmentConflict("investment inquiry was modified by another request; reload and try again")
+	}
+	if err != nil {
+		log.Printf("[INVESTMENT] RestoreVersion failed: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[INVESTMENT] RestoreVersion successful: id=%d restored to version %d", p.ID, p.VersionID)
+	return convertInquiryToResult(&inquiry), nil
+}
+
+// recordInquiryVersion stores before, the state of an inquiry an update is replacing, as a
+// version. changedBy is nil for changes through the public form.
+func recordInquiryVersion(tx *gorm.DB, before *domain.InvestmentInquiry, changedBy *domain.User) error {
+	snapshot, err := json.Marshal(before)
+	if err != nil {
+		return fmt.Errorf("failed to encode inquiry version: %w", err)
+	}
+	version := &domain.InvestmentInquiryVersion{
+		InquiryID: before.ID,
+		Snapshot:  string(snapshot),
+	}
+	if changedBy != nil {
+		version.ChangedBy = &changedBy.ID
+	}
+	return tx.Create(version).Error
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/domain"
+)
+
+func TestRestoreVersionKeepsOrganization(t *testing.T) {
+	t.Setenv("MULTI_TENANT", "true")
+	db := newTestDB(t)
+	other := domain.Organization{Name: "Other", Slug: "other"}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	user := createTestUser(t, db, domain.User{Username: "staff", IsStaff: true, OrganizationID: other.ID}, "correct horse")
+	ctx := WithOrganization(asUser(context.Background(), user), other.ID)
+
+	name := "Current"
+	inquiry := &domain.InvestmentInquiry{FirstName: &name, OrganizationID: other.ID}
+	if err := db.Create(inquiry).Error; err != nil {
+		t.Fatalf("create inquiry: %v", err)
+	}
+	// Snapshots taken before inquiries had an organization carry none
+	version := &domain.InvestmentInquiryVersion{InquiryID: inquiry.ID, Snapshot: `{"first_name":"Old"}`}
+	if err := db.Create(version).Error; err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+
+	s := NewInvestmentService(db, nil, nil, nil, nil, time.Minute)
+	if _, err := s.RestoreVersion(ctx, &investment.RestoreInquiryVersionPayload{ID: int(inquiry.ID), VersionID: int(version.ID)}); err != nil {
+		t.Fatalf("RestoreVersion: %v", err)
+	}
+
+	var restored domain.InvestmentInquiry
+	if err := db.First(&restored, inquiry.ID).Error; err != nil {
+		t.Fatalf("load inquiry: %v", err)
+	}
+	if restored.OrganizationID != other.ID {
+		t.Errorf("OrganizationID = %d, want %d", restored.OrganizationID, other.ID)
+	}
+	if restored.FirstName == nil || *restored.FirstName != "Old" {
+		t.Errorf("FirstName = %v, want Old", restored.FirstName)
+	}
+}
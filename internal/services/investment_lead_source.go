@@ -0,0 +1,84 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+
+	"springstreet/internal/domain"
+)
+
+// webmailHosts are webmail sites, whose links come from email campaigns. They are checked
+// before searchEngines and socialNetworks since some share a domain with them.
+var webmailHosts = []string{"mail.google.com", "mail.yahoo.com", "outlook.live.com", "outlook.office.com", "mail.zoho.com"}
+
+// searchEngines are domain labels of search engines, so that google.co.in matches as well
+// as google.com. Paid search is the channel marketing spends on, so search traffic is
+// attributed to it.
+var searchEngines = []string{"google", "bing", "yahoo", "duckduckgo", "baidu", "yandex"}
+
+// socialNetworks are domain labels of social networks
+var socialNetworks = []string{"facebook", "instagram", "linkedin", "twitter", "youtube", "reddit", "pinterest", "quora", "whatsapp"}
+
+// socialHosts are social network hosts that socialNetworks doesn't cover, such as link shorteners
+var socialHosts = []string{"x.com", "t.co", "fb.me", "lnkd.in", "wa.me"}
+
+// inquiryLeadSource returns the lead source of a new inquiry: the one given, else the one
+// detected from referrerURL, else domain.LeadSourceUnknown
+func inquiryLeadSource(leadSource, referrerURL *string) string {
+	if leadSource != nil && *leadSource != "" {
+		return *leadSource
+	}
+	if referrerURL == nil {
+		return domain.LeadSourceUnknown
+	}
+	return leadSourceFromReferrer(*referrerURL)
+}
+
+// leadSourceFromReferrer classifies a referring page by its domain. Sites that match none of
+// the known channels are referrals.
+func leadSourceFromReferrer(referrer string) string {
+	referrer = strings.TrimSpace(referrer)
+	if referrer == "" {
+		return domain.LeadSourceDirect
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return domain.LeadSourceUnknown
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	switch {
+	case hostIn(host, webmailHosts):
+		return domain.LeadSourceEmailCampaign
+	case hostIn(host, socialHosts), hostHasLabel(host, socialNetworks):
+		return domain.LeadSourceSocial
+	case hostHasLabel(host, searchEngines):
+		return domain.LeadSourcePaidSearch
+	default:
+		return domain.LeadSourceReferral
+	}
+}
+
+// hostIn reports whether host is one of hosts or a subdomain of one
+func hostIn(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostHasLabel reports whether one of host's dot-separated labels, other than the top-level
+// domain, is one of labels
+func hostHasLabel(host string, labels []string) bool {
+	parts := strings.Split(host, ".")
+	for _, part := range parts[:len(parts)-1] {
+		for _, label := range labels {
+			if part == label {
+				return true
+			}
+		}
+	}
+	return false
+}
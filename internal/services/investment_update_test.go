@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"springstreet/gen/investment"
+	"springstreet/internal/domain"
+)
+
+func TestUpdateByPhoneConcurrentUpdatesConflict(t *testing.T) {
+	db := newTestDB(t)
+	s := NewInvestmentService(db, nil, nil, nil, nil, time.Minute)
+	phone := "+919876543210"
+	inquiry := &domain.InvestmentInquiry{Phone: &phone}
+	if err := db.Create(inquiry).Error; err != nil {
+		t.Fatalf("create inquiry: %v", err)
+	}
+
+	// SQLite allows one writer; a single connection turns "database is locked" into waiting
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	names := []string{"Asha", "Ravi"}
+	errs := make([]error, len(names))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = s.UpdateByPhone(context.Background(), &investment.UpdateInquiryByPhonePayload{
+				Phone:     phone,
+				FirstName: &name,
+				Version:   1,
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var succeeded, conflicted int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assertServiceError(t, err, "conflict")
+		conflicted++
+	}
+	if succeeded != 1 || conflicted != 1 {
+		t.Fatalf("%d updates succeeded and %d conflicted, want one of each", succeeded, conflicted)
+	}
+
+	var stored domain.InvestmentInquiry
+	db.First(&stored, inquiry.ID)
+	if stored.Version != 2 {
+		t.Fatalf("version = %d, want 2", stored.Version)
+	}
+}
+
+func TestUpdateByPhoneRejectsStaleVersion(t *testing.T) {
+	db := newTestDB(t)
+	s := NewInvestmentService(db, nil, nil, nil, nil, time.Minute)
+	phone := "+919876543210"
+	if err := db.Create(&domain.InvestmentInquiry{Phone: &phone}).Error; err != nil {
+		t.Fatalf("create inquiry: %v", err)
+	}
+
+	name := "Asha"
+	res, err := s.UpdateByPhone(context.Background(), &investment.UpdateInquiryByPhonePayload{Phone: phone, FirstName: &name, Version: 1})
+	if err != nil {
+		t.Fatalf("UpdateByPhone: %v", err)
+	}
+	if res.Version != 2 {
+		t.Fatalf("result version = %d, want 2", res.Version)
+	}
+
+	_, err = s.UpdateByPhone(context.Background(), &investment.UpdateInquiryByPhonePayload{Phone: phone, FirstName: &name, Version: 1})
+	assertServiceError(t, err, "conflict")
+}
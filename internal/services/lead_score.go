@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"springstreet/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// leadScoreBackfillBatch is how many unscored inquiries BackfillLeadScores loads at a time
+const leadScoreBackfillBatch = 500
+
+// investmentSizeScores rates each investment size bucket; larger portfolios score higher
+var investmentSizeScores = map[string]int{
+	"under-5l":  5,
+	"5l-25l":    15,
+	"25l-1cr":   25,
+	"1cr-5cr":   35,
+	"above-5cr": 45,
+}
+
+// ComputeLeadScore rates how promising an investment inquiry is, from 0 to 100.
+// Points come from the investment size, OTP verification (+20), having both email and
+// phone (+10), and existing PMS/AIF (+15) or direct stock (+10) exposure.
+func ComputeLeadScore(inquiry *domain.InvestmentInquiry) int {
+	score := 0
+	if inquiry.InvestmentSize != nil {
+		score += investmentSizeScores[strings.ToLower(strings.TrimSpace(*inquiry.InvestmentSize))]
+	}
+	if inquiry.Verified {
+		score += 20
+	}
+	if hasValue(inquiry.Email) && hasValue(inquiry.Phone) {
+		score += 10
+	}
+	if inquiry.CurrentExposure != nil {
+		for _, exposure := range strings.Split(*inquiry.CurrentExposure, ",") {
+			switch strings.TrimSpace(exposure) {
+			case "pms-aif":
+				score += 15
+			case "direct-stocks":
+				score += 10
+			}
+		}
+	}
+
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
+
+// BackfillLeadScores scores inquiries stored before lead scoring existed.
+// Scores are written directly so the inquiries' version and updated_at are left alone.
+func (s *InvestmentService) BackfillLeadScores(ctx context.Context) {
+	var inquiries []domain.InvestmentInquiry
+	updated := 0
+	err := s.db.WithContext(ctx).Where("lead_score = 0").
+		FindInBatches(&inquiries, leadScoreBackfillBatch, func(tx *gorm.DB, batch int) error {
+			for i := range inquiries {
+				score := ComputeLeadScore(&inquiries[i])
+				if score == 0 {
+					continue
+				}
+				if err := s.db.WithContext(ctx).Model(&inquiries[i]).UpdateColumn("lead_score", score).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Printf("[INVESTMENT] Lead score backfill failed after %d inquiries: %v", updated, err)
+		return
+	}
+	if updated > 0 {
+		log.Printf("[INVESTMENT] Lead score backfill scored %d inquiries", updated)
+	}
+}
+
+func hasValue(s *string) bool {
+	return s != nil && strings.TrimSpace(*s) != ""
+}
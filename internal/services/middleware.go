@@ -2,9 +2,13 @@ package services
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 
+	goamiddleware "goa.design/goa/v3/http/middleware"
+
+	"springstreet/internal/config"
 	"springstreet/internal/util"
 	"springstreet/internal/database"
 )
@@ -113,4 +117,45 @@ func checkScope(path string, user interface{}) bool {
 	return true
 }
 
+// clientIP returns the caller's IP address from the request context populated by
+// Goa's PopulateRequestContext middleware. Proxy headers are only used when the connection
+// comes from a trusted proxy (TRUSTED_PROXIES).
+func clientIP(ctx context.Context) string {
+	forwarded, _ := ctx.Value(goamiddleware.RequestXForwardedForKey).(string)
+	realIP, _ := ctx.Value(goamiddleware.RequestXRealIPKey).(string)
+	remoteAddr, _ := ctx.Value(goamiddleware.RequestRemoteAddrKey).(string)
+	return pickClientIP(forwarded, realIP, remoteAddr)
+}
+
+// requestIP is clientIP for handlers served outside Goa, reading the request directly
+func requestIP(r *http.Request) string {
+	return pickClientIP(r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Real-IP"), r.RemoteAddr)
+}
+
+// pickClientIP returns the peer address unless it is a trusted proxy. Behind trusted proxies it
+// walks X-Forwarded-For from the right and returns the first hop that isn't one of them: entries
+// further left were written by the client and can be anything.
+func pickClientIP(forwarded, realIP, remoteAddr string) string {
+	peer := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		peer = host
+	}
+	httpCfg := &config.Get().HTTP
+	if peer == "" || !httpCfg.IsTrustedProxy(peer) {
+		return peer
+	}
 
+	if forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop != "" && (i == 0 || !httpCfg.IsTrustedProxy(hop)) {
+				return hop
+			}
+		}
+	}
+	if realIP = strings.TrimSpace(realIP); realIP != "" {
+		return realIP
+	}
+	return peer
+}
@@ -0,0 +1,27 @@
+package services
+
+import "testing"
+
+func TestPickClientIPTrustsOnlyConfiguredProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1")
+	loadTestConfig(t)
+
+	for _, tc := range []struct {
+		name                          string
+		forwarded, realIP, remoteAddr string
+		want                          string
+	}{
+		{"direct client", "", "", "203.0.113.7:4000", "203.0.113.7"},
+		{"headers from an untrusted peer", "198.51.100.1", "198.51.100.2", "203.0.113.7:4000", "203.0.113.7"},
+		{"one trusted proxy", "203.0.113.7", "", "10.1.2.3:4000", "203.0.113.7"},
+		{"spoofed entries left of the real client", "1.2.3.4, 5.6.7.8, 203.0.113.7", "", "10.1.2.3:4000", "203.0.113.7"},
+		{"chain of trusted proxies", "1.2.3.4, 203.0.113.7, 10.0.0.5, 192.0.2.1", "", "10.1.2.3:4000", "203.0.113.7"},
+		{"only trusted hops", "10.0.0.9, 10.0.0.5", "", "192.0.2.1:4000", "10.0.0.9"},
+		{"X-Real-IP from a trusted proxy", "", "203.0.113.7", "192.0.2.1:4000", "203.0.113.7"},
+		{"trusted proxy without headers", "", "", "10.1.2.3:4000", "10.1.2.3"},
+	} {
+		if got := pickClientIP(tc.forwarded, tc.realIP, tc.remoteAddr); got != tc.want {
+			t.Errorf("%s: pickClientIP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+)
+
+const (
+	// Block Kit limits, in characters, on a section's text and on each of its fields
+	slackSectionTextLimit = 3000
+	slackFieldTextLimit   = 2000
+
+	notifyRequestTimeout = 10 * time.Second
+	notifyMaxAttempts    = 3
+	notifyRetryBackoff   = 2 * time.Second
+)
+
+// Notification event types
+const (
+	EventInvestmentCreated = "investment.created"
+	EventContactSubmitted  = "contact.submitted"
+)
+
+// Notification channels
+const (
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+)
+
+// Notifier routes staff notifications to the channels configured for each event
+type Notifier struct {
+	cfg    *config.NotifyConfig
+	slack  *SlackNotifier
+	client *http.Client
+	pool   *WorkerPool
+
+	mu       sync.Mutex
+	deferred map[*deferredDelivery]struct{} // Throttled emails waiting to be sent again
+	flushed  bool
+}
+
+// deferredDelivery is a notification waiting for the email throttle
+type deferredDelivery struct {
+	channel, description string
+	send                 func() error
+	timer                *time.Timer
+}
+
+// NewNotifier creates a new notifier that delivers on pool
+func NewNotifier(cfg *config.NotifyConfig, pool *WorkerPool) *Notifier {
+	return &Notifier{
+		cfg:      cfg,
+		slack:    NewSlackNotifier(cfg),
+		client:   &http.Client{Timeout: notifyRequestTimeout},
+		pool:     pool,
+		deferred: make(map[*deferredDelivery]struct{}),
+	}
+}
+
+// ChannelEnabled reports whether channel is configured for event and has the settings it needs
+func (n *Notifier) ChannelEnabled(event, channel string) bool {
+	var channels []string
+	switch event {
+	case EventContactSubmitted:
+		channels = n.cfg.ContactChannels
+	case EventInvestmentCreated:
+		channels = n.cfg.InvestmentChannels
+	}
+
+	configured := false
+	for _, c := range channels {
+		if strings.EqualFold(strings.TrimSpace(c), channel) {
+			configured = true
+			break
+		}
+	}
+	if !configured {
+		return false
+	}
+
+	switch channel {
+	case ChannelSlack:
+		return n.slack.IsEnabled()
+	case ChannelWebhook:
+		return n.cfg.WebhookURL != "" && config.FeatureFlag(config.FeatureWebhooks)
+	default:
+		return true
+	}
+}
+
+// Deliver runs send on the worker pool, retrying failures with exponential backoff.
+// It never blocks the caller; if the pool's queue is full the notification is dropped.
+// A throttled email is queued again once the limit allows it, without using up an attempt.
+func (n *Notifier) Deliver(channel, description string, send func() error) {
+	queued := n.pool.Submit(func() {
+		var err error
+		backoff := notifyRetryBackoff
+		for attempt := 1; attempt <= notifyMaxAttempts; attempt++ {
+			if err = send(); err == nil {
+				log.Printf("[NOTIFY] %s notification sent for %s", channel, description)
+				metrics.RecordNotification(channel, true)
+				return
+			}
+			var throttled *EmailThrottledError
+			if errors.As(err, &throttled) {
+				n.deliverLater(throttled.RetryAfter, channel, description, send)
+				return
+			}
+			// EmailService retries on its own, so another round would only multiply attempts
+			if errors.Is(err, ErrEmailSendFailed) {
+				break
+			}
+			if attempt < notifyMaxAttempts {
+				log.Printf("[NOTIFY] %s notification for %s failed (attempt %d/%d), retrying in %v: %v", channel, description, attempt, notifyMaxAttempts, backoff, err)
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+		}
+		log.Printf("[NOTIFY] Warning: %s notification for %s failed, giving up: %v", channel, description, err)
+		metrics.RecordNotification(channel, false)
+	})
+	if !queued {
+		log.Printf("[NOTIFY] Warning: %s notification for %s dropped", channel, description)
+		metrics.RecordNotification(channel, false)
+	}
+}
+
+// deliverLater runs Deliver again after delay, unless Flush is called first
+func (n *Notifier) deliverLater(delay time.Duration, channel, description string, send func() error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.flushed {
+		log.Printf("[NOTIFY] Warning: %s notification for %s throttled during shutdown, dropping it", channel, description)
+		metrics.RecordNotification(channel, false)
+		return
+	}
+
+	log.Printf("[NOTIFY] %s notification for %s throttled, sending in %v", channel, description, delay.Round(time.Second))
+	d := &deferredDelivery{channel: channel, description: description, send: send}
+	d.timer = time.AfterFunc(delay, func() {
+		n.mu.Lock()
+		delete(n.deferred, d)
+		n.mu.Unlock()
+		n.Deliver(channel, description, send)
+	})
+	n.deferred[d] = struct{}{}
+}
+
+// Flush makes one last attempt at the notifications waiting for the email throttle, for
+// use on shutdown. Those still throttled, or left when ctx expires, are dropped.
+func (n *Notifier) Flush(ctx context.Context) error {
+	n.mu.Lock()
+	n.flushed = true
+	var pending []*deferredDelivery
+	for d := range n.deferred {
+		// A timer that already fired has handed its notification to the worker pool
+		if d.timer.Stop() {
+			pending = append(pending, d)
+		}
+	}
+	n.deferred = make(map[*deferredDelivery]struct{})
+	n.mu.Unlock()
+
+	for _, d := range pending {
+		if ctx.Err() != nil {
+			log.Printf("[NOTIFY] Warning: %s notification for %s dropped on shutdown", d.channel, d.description)
+			metrics.RecordNotification(d.channel, false)
+			continue
+		}
+		if err := d.send(); err != nil {
+			log.Printf("[NOTIFY] Warning: %s notification for %s failed on shutdown, dropping it: %v", d.channel, d.description, err)
+			metrics.RecordNotification(d.channel, false)
+			continue
+		}
+		log.Printf("[NOTIFY] %s notification sent for %s", d.channel, d.description)
+		metrics.RecordNotification(d.channel, true)
+	}
+	return ctx.Err()
+}
+
+// NotifyInvestmentCreated notifies staff of a new investment inquiry
+func (n *Notifier) NotifyInvestmentCreated(inquiry *domain.InvestmentInquiry) {
+	description := fmt.Sprintf("investment inquiry id=%d", inquiry.ID)
+	if n.ChannelEnabled(EventInvestmentCreated, ChannelSlack) {
+		n.Deliver(ChannelSlack, description, func() error {
+			return n.slack.SendNewInquiry(inquiry)
+		})
+	}
+	if n.ChannelEnabled(EventInvestmentCreated, ChannelWebhook) {
+		n.Deliver(ChannelWebhook, description, func() error {
+			return n.sendWebhook(EventInvestmentCreated, inquiry)
+		})
+	}
+}
+
+// NotifyContactSubmitted notifies staff of a new contact inquiry over Slack and webhook.
+// Email is sent by ContactService, which owns the email template.
+func (n *Notifier) NotifyContactSubmitted(inquiry *domain.ContactInquiry) {
+	description := fmt.Sprintf("contact inquiry id=%d", inquiry.ID)
+	if n.ChannelEnabled(EventContactSubmitted, ChannelSlack) {
+		n.Deliver(ChannelSlack, description, func() error {
+			return n.slack.SendContactInquiry(inquiry)
+		})
+	}
+	if n.ChannelEnabled(EventContactSubmitted, ChannelWebhook) {
+		n.Deliver(ChannelWebhook, description, func() error {
+			return n.sendWebhook(EventContactSubmitted, inquiry)
+		})
+	}
+}
+
+// sendWebhook POSTs {"event", "timestamp", "data"} to the generic webhook.
+// When a secret is configured the body is signed with HMAC-SHA256 in X-Springstreet-Signature.
+func (n *Notifier) sendWebhook(event string, data any) error {
+	body, err := json.Marshal(map[string]any{
+		"event":     event,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"data":      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Springstreet-Event", event)
+	if n.cfg.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(n.cfg.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Springstreet-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := doWithBreaker(providerWebhook, n.client, req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SlackNotifier posts staff notifications to a Slack Incoming Webhook
+type SlackNotifier struct {
+	cfg    *config.NotifyConfig
+	client *http.Client
+}
+
+// NewSlackNotifier creates a new Slack notifier
+func NewSlackNotifier(cfg *config.NotifyConfig) *SlackNotifier {
+	return &SlackNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: notifyRequestTimeout},
+	}
+}
+
+// IsEnabled returns whether a Slack webhook is configured
+func (n *SlackNotifier) IsEnabled() bool {
+	return n.cfg.SlackWebhookURL != ""
+}
+
+// SendSlackMessage posts a plain text message to the webhook
+func (n *SlackNotifier) SendSlackMessage(webhookURL, message string) error {
+	return n.send(webhookURL, map[string]any{"text": message})
+}
+
+// SendNewInquiry posts a Block Kit summary of a new investment inquiry
+func (n *SlackNotifier) SendNewInquiry(inquiry *domain.InvestmentInquiry) error {
+	name := strings.TrimSpace(fmt.Sprintf("%s %s", valueOr(inquiry.FirstName, ""), valueOr(inquiry.LastName, "")))
+	if name == "" {
+		name = "Unknown"
+	}
+	link := fmt.Sprintf("%s/investment/%d", strings.TrimRight(n.cfg.DashboardURL, "/"), inquiry.ID)
+
+	return n.send(n.cfg.SlackWebhookURL, slackMessage(
+		"New investment inquiry",
+		fmt.Sprintf("New investment inquiry from %s", slackEscape(name)),
+		[]any{
+			slackField("Name", name),
+			slackField("Phone", valueOr(inquiry.Phone, "Not provided")),
+			slackField("Email", valueOr(inquiry.Email, "Not provided")),
+			slackField("Investment size", valueOr(inquiry.InvestmentSize, "Not provided")),
+		},
+		fmt.Sprintf("<%s|View inquiry #%d>", link, inquiry.ID),
+	))
+}
+
+// SendContactInquiry posts a Block Kit summary of a new contact inquiry
+func (n *SlackNotifier) SendContactInquiry(inquiry *domain.ContactInquiry) error {
+	link := fmt.Sprintf("%s/contact/%d", strings.TrimRight(n.cfg.DashboardURL, "/"), inquiry.ID)
+	linkLine := fmt.Sprintf("<%s|View inquiry #%d>", link, inquiry.ID)
+	// The message is cut short so that it and the link fit in one section
+	message := slackTruncate(inquiry.Message, slackSectionTextLimit-utf8.RuneCountInString(linkLine)-1)
+
+	return n.send(n.cfg.SlackWebhookURL, slackMessage(
+		"New contact inquiry",
+		fmt.Sprintf("New contact inquiry from %s", slackEscape(inquiry.Name)),
+		[]any{
+			slackField("Name", inquiry.Name),
+			slackField("Email", inquiry.Email),
+			slackField("Phone", valueOr(inquiry.Phone, "Not provided")),
+			slackField("Category", inquiry.Category),
+		},
+		message+"\n"+linkLine,
+	))
+}
+
+// send POSTs a JSON payload to the webhook and records the outcome
+func (n *SlackNotifier) send(webhookURL string, payload any) error {
+	err := n.post(webhookURL, payload)
+	metrics.RecordSlackNotification(err == nil)
+	return err
+}
+
+func (n *SlackNotifier) post(webhookURL string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode slack payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := doWithBreaker(providerSlack, n.client, req)
+	if err != nil {
+		return fmt.Errorf("slack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slackMessage builds a Block Kit message with a header, a field section and a text section
+func slackMessage(header, fallback string, fields []any, text string) map[string]any {
+	return map[string]any{
+		"text": fallback,
+		"blocks": []any{
+			map[string]any{
+				"type": "header",
+				"text": map[string]any{"type": "plain_text", "text": header},
+			},
+			map[string]any{
+				"type":   "section",
+				"fields": fields,
+			},
+			map[string]any{
+				"type": "section",
+				"text": map[string]any{"type": "mrkdwn", "text": text},
+			},
+		},
+	}
+}
+
+// slackField builds a Block Kit mrkdwn field, escaping and truncating value
+func slackField(label, value string) map[string]any {
+	value = slackTruncate(value, slackFieldTextLimit-utf8.RuneCountInString(label)-3)
+	return map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", label, value)}
+}
+
+// slackEscaper escapes the characters Slack reads as control sequences in mrkdwn, so user
+// input can't inject links or mentions
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes s for mrkdwn text
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+// slackTruncate escapes s for mrkdwn text and, if that is longer than limit characters, cuts
+// it short with an ellipsis. It never cuts inside an escape sequence.
+func slackTruncate(s string, limit int) string {
+	escaped := slackEscape(s)
+	if utf8.RuneCountInString(escaped) <= limit {
+		return escaped
+	}
+	n := 0
+	for i, r := range s {
+		n += utf8.RuneCountInString(slackEscape(string(r)))
+		if n > limit-1 {
+			return slackEscape(s[:i]) + "…"
+		}
+	}
+	return escaped
+}
+
+// valueOr dereferences s or returns fallback when it is nil or empty
+func valueOr(s *string, fallback string) string {
+	if s == nil || *s == "" {
+		return fallback
+	}
+	return *s
+}
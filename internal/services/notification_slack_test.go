@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+)
+
+func TestSlackContactInquiryEscapesAndTruncatesInput(t *testing.T) {
+	loadTestConfig(t)
+
+	var payload struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Text   *struct{ Text string }  `json:"text"`
+			Fields []struct{ Text string } `json:"fields"`
+		} `json:"blocks"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	n := NewSlackNotifier(&config.NotifyConfig{SlackWebhookURL: ts.URL, DashboardURL: "https://dash.example.com"})
+	inquiry := &domain.ContactInquiry{
+		ID:       7,
+		Name:     "<!channel> Mallory",
+		Email:    "mallory@example.com",
+		Category: "general",
+		Message:  "<https://evil.example.com|click> & " + strings.Repeat("<x>", 2000),
+	}
+	if err := n.SendContactInquiry(inquiry); err != nil {
+		t.Fatalf("SendContactInquiry: %v", err)
+	}
+
+	if payload.Text != "New contact inquiry from &lt;!channel&gt; Mallory" {
+		t.Errorf("fallback text = %q", payload.Text)
+	}
+	if got := payload.Blocks[1].Fields[0].Text; got != "*Name*\n&lt;!channel&gt; Mallory" {
+		t.Errorf("name field = %q", got)
+	}
+	text := payload.Blocks[2].Text.Text
+	if !strings.HasPrefix(text, "&lt;https://evil.example.com|click&gt; &amp; &lt;x&gt;") {
+		t.Errorf("message was not escaped: %q", text[:80])
+	}
+	if !strings.HasSuffix(text, "…\n<https://dash.example.com/contact/7|View inquiry #7>") {
+		t.Errorf("message was not truncated before the link: %q", text[len(text)-80:])
+	}
+	if utf8.RuneCountInString(text) > slackSectionTextLimit {
+		t.Errorf("section text is %d characters, over the %d limit", utf8.RuneCountInString(text), slackSectionTextLimit)
+	}
+	// The cut falls between escape sequences
+	body := strings.TrimSuffix(text[:strings.LastIndex(text, "\n")], "…")
+	if amp := strings.LastIndex(body, "&"); !strings.Contains(body[amp:], ";") {
+		t.Errorf("message was cut inside an escape sequence: %q", body[len(body)-20:])
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"springstreet/gen/organization"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"goa.design/goa/v3/security"
+	"gorm.io/gorm"
+)
+
+// OrganizationService implements the organization service
+type OrganizationService struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(db *gorm.DB, queryTimeout time.Duration) *OrganizationService {
+	return &OrganizationService{db: db, queryTimeout: queryTimeout}
+}
+
+// JWTAuth implements the authorization logic for the JWT security scheme
+func (s *OrganizationService) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	// Validate JWT token and extract claims
+	claims, err := util.ValidateToken(token)
+	if err != nil {
+		return nil, organization.MakeUnauthorized(fmt.Errorf("invalid or expired token"))
+	}
+
+	// Get user from database
+	var user domain.User
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, organization.MakeUnauthorized(fmt.Errorf("user not found"))
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		return nil, organization.MakeUnauthorized(fmt.Errorf("user account is inactive"))
+	}
+
+	// Check the user belongs to the request's organization
+	if !inRequestOrganization(ctx, &user) {
+		return nil, organization.MakeUnauthorized(fmt.Errorf("user does not belong to this organization"))
+	}
+
+	// Check scopes if required
+	if schema != nil && len(schema.RequiredScopes) > 0 {
+		hasScope := false
+		for _, requiredScope := range schema.RequiredScopes {
+			if requiredScope == "superadmin" && isSuperAdmin(&user) {
+				hasScope = true
+				break
+			}
+			if requiredScope == "admin" && user.IsAdmin {
+				hasScope = true
+				break
+			}
+		}
+		if !hasScope {
+			return nil, organization.MakeUnauthorized(fmt.Errorf("insufficient permissions"))
+		}
+	}
+
+	// Add user to context
+	ctx = context.WithValue(ctx, "user", &user)
+	return ctx, nil
+}
+
+// Create implements the create organization method
+func (s *OrganizationService) Create(ctx context.Context, p *organization.CreateOrganizationPayload) (*organization.Organizationresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	name := strings.TrimSpace(p.Name)
+	log.Printf("[ORGANIZATION] Create request: name=%s, slug=%s by user=%s", name, p.Slug, user.Username)
+
+	if name == "" {
+		return nil, organization.MakeBadRequest(fmt.Errorf("name is required"))
+	}
+
+	// Check if slug exists
+	var existing domain.Organization
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("slug = ?", p.Slug).First(&existing).Error; err == nil {
+		log.Printf("[ORGANIZATION] Create failed: slug '%s' already exists", p.Slug)
+		return nil, organization.MakeBadRequest(fmt.Errorf("slug already taken"))
+	}
+
+	org := domain.Organization{Name: name, Slug: p.Slug}
+	if err := s.db.WithContext(qctx).Create(&org).Error; err != nil {
+		log.Printf("[ORGANIZATION] Create failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	log.Printf("[ORGANIZATION] Create successful: id=%d, slug=%s", org.ID, org.Slug)
+	return convertOrganizationToResult(&org), nil
+}
+
+// List implements the list organizations method
+func (s *OrganizationService) List(ctx context.Context, p *organization.ListOrganizationsPayload) ([]*organization.Organizationresult, error) {
+	log.Printf("[ORGANIZATION] List request: skip=%d, limit=%d", p.Skip, p.Limit)
+
+	var orgs []domain.Organization
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := database.GetReadDB().WithContext(qctx).Order("id").Offset(p.Skip).Limit(p.Limit).Find(&orgs).Error; err != nil {
+		log.Printf("[ORGANIZATION] List failed: database error: %v", err)
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	results := make([]*organization.Organizationresult, len(orgs))
+	for i := range orgs {
+		results[i] = convertOrganizationToResult(&orgs[i])
+	}
+
+	log.Printf("[ORGANIZATION] List successful: returned %d organizations", len(results))
+	return results, nil
+}
+
+// convertOrganizationToResult converts domain.Organization to organization.Organizationresult
+func convertOrganizationToResult(org *domain.Organization) *organization.Organizationresult {
+	return &organization.Organizationresult{
+		ID:        int(org.ID),
+		Name:      org.Name,
+		Slug:      org.Slug,
+		CreatedAt: org.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -2,30 +2,162 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strings"
+	"time"
 
 	"springstreet/gen/otp"
 	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
 	"springstreet/internal/metrics"
 	"springstreet/internal/util"
+
+	"gorm.io/gorm"
 )
 
 // OTPService implements the OTP service
 type OTPService struct {
-	emailService *EmailService
-	smsService   *SMSService
-	config       *config.Config
+	emailService    *EmailService
+	smsService      *SMSService
+	whatsappService *WhatsAppService
+	config          *config.Config
+	db              *gorm.DB
+	queryTimeout    time.Duration
+	verify          *twilioVerifyClient // Set when OTP_BACKEND is twilio-verify
 }
 
-// NewOTPService creates a new OTP service
-func NewOTPService(cfg *config.Config) *OTPService {
-	return &OTPService{
-		emailService: NewEmailService(&cfg.Email),
-		smsService:   NewSMSService(&cfg.SMS),
-		config:       cfg,
+// NewOTPService creates a new OTP service.
+// When REDIS_URL is set, sessions and rate limits are kept in Redis so they are
+// shared between replicas. Otherwise (or if Redis is unreachable at startup)
+// sessions are persisted in the database when the otp_persistent feature is on
+// (the default for PostgreSQL) so in-flight OTPs survive restarts, or kept in memory.
+// With the twilio-verify backend, codes are generated, delivered and checked by Twilio Verify;
+// the session store then only holds completed verifications.
+func NewOTPService(cfg *config.Config, db *gorm.DB, emailService *EmailService) *OTPService {
+	if !useRedisOTPBackend(cfg.Redis) {
+		if config.FeatureFlag(config.FeatureOTPPersistent) {
+			log.Println("[OTP] Using database-backed OTP session store")
+			util.SetOTPStore(util.NewDBOTPStore(db))
+		} else {
+			log.Println("[OTP] Using in-memory OTP session store")
+			util.SetOTPStore(util.NewMemoryOTPStore(cfg.OTP.MaxSessions))
+		}
+		util.SetOTPRateLimiter(util.NewMemoryOTPRateLimiter())
+	}
+
+	svc := &OTPService{
+		emailService:    emailService,
+		smsService:      NewSMSService(&cfg.SMS, db),
+		whatsappService: NewWhatsAppService(&cfg.WhatsApp, &cfg.SMS),
+		config:          cfg,
+		db:              db,
+		queryTimeout:    cfg.Database.QueryTimeout(),
+	}
+
+	if cfg.OTP.Backend == OTPBackendTwilioVerify {
+		client, err := newTwilioVerifyClient(&cfg.SMS)
+		if err != nil {
+			log.Printf("[OTP] Warning: Twilio Verify not configured (%v), falling back to local OTP codes", err)
+		} else {
+			log.Println("[OTP] Using Twilio Verify for OTP codes")
+			svc.verify = client
+		}
+	}
+	return svc
+}
+
+// UpdateConfig gives the SMS, WhatsApp and Twilio Verify clients the provider settings in cfg
+// (used when secrets are rotated)
+func (s *OTPService) UpdateConfig(cfg *config.Config) {
+	s.smsService.UpdateConfig(&cfg.SMS)
+	s.whatsappService.UpdateConfig(&cfg.WhatsApp, &cfg.SMS)
+	if s.verify != nil {
+		s.verify.updateConfig(&cfg.SMS)
+	}
+}
+
+// useRedisOTPBackend switches OTP sessions and rate limiting to Redis if it is configured and reachable
+func useRedisOTPBackend(cfg config.RedisConfig) bool {
+	if cfg.URL == "" {
+		return false
+	}
+
+	client, err := util.ConnectRedis(cfg.URL)
+	if err != nil {
+		log.Printf("[OTP] Warning: Redis unreachable (%v), falling back to local OTP session store", err)
+		return false
+	}
+
+	log.Println("[OTP] Using Redis-backed OTP session store and rate limiter")
+	util.SetOTPStore(util.NewRedisOTPStore(client, cfg.KeyPrefix))
+	util.SetOTPRateLimiter(util.NewRedisOTPRateLimiter(client, cfg.KeyPrefix))
+	return true
+}
+
+// RunSessionCleanup removes expired OTP sessions and stale rate limit entries on every
+// tick until ctx is cancelled, and reports what is left to the OTP gauges
+func (s *OTPService) RunSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			util.CleanupExpiredSessions()
+
+			sessions, rateLimitKeys, err := util.CountOTPState()
+			if err != nil {
+				log.Printf("[OTP] Warning: failed to count OTP sessions: %v", err)
+				continue
+			}
+			metrics.UpdateOTPState(sessions, rateLimitKeys)
+		}
+	}
+}
+
+// checkDisposableEmail rejects addresses from disposable email providers unless the check is turned off
+func checkDisposableEmail(email string) error {
+	if email == "" || !config.FeatureFlag(config.FeatureDisposableMail) || !util.IsDisposableEmail(email) {
+		return nil
+	}
+	return fmt.Errorf("disposable email addresses are not accepted. Please use a permanent email address")
+}
+
+// validateOTPChannel checks that the requested delivery channel has a matching contact method
+func validateOTPChannel(channel string, phoneProvided, emailProvided bool) error {
+	switch channel {
+	case "email":
+		if !emailProvided {
+			return fmt.Errorf("email must be provided for channel email")
+		}
+	case "sms", "whatsapp":
+		if !phoneProvided {
+			return fmt.Errorf("phone_number must be provided for channel %s", channel)
+		}
+	}
+	return nil
+}
+
+// sendToPhone delivers a code by SMS, or by WhatsApp when that channel is requested.
+// If WhatsApp fails and fallback is configured, the code is sent by SMS instead.
+// Returns the channel the code was delivered through.
+func (s *OTPService) sendToPhone(channel, phoneNumber, otpCode, locale string) (string, error) {
+	if channel != "whatsapp" {
+		return "sms", s.smsService.SendOTP(phoneNumber, otpCode, locale)
+	}
+
+	err := s.whatsappService.SendOTP(phoneNumber, otpCode)
+	if err == nil || !s.config.WhatsApp.FallbackToSMS {
+		return "whatsapp", err
 	}
+	log.Printf("[OTP] Warning: failed to send OTP via WhatsApp to %s: %v. Falling back to SMS", phoneNumber, err)
+	return "sms", s.smsService.SendOTP(phoneNumber, otpCode, locale)
 }
 
 // Send implements the send OTP method
@@ -42,15 +174,33 @@ func (s *OTPService) Send(ctx context.Context, p *otp.SendOTPPayload) (*otp.Send
 	if emailProvided {
 		email = *p.Email
 	}
-	log.Printf("[OTP] Send request: phone=%s, email=%s", phone, email)
+	channel := ""
+	if p.Channel != nil {
+		channel = *p.Channel
+	}
+	log.Printf("[OTP] Send request: phone=%s, email=%s, channel=%s", phone, email, channel)
 
 	if !phoneProvided && !emailProvided {
 		log.Printf("[OTP] Send failed: no contact method provided")
 		return nil, otp.MakeBadRequest(fmt.Errorf("either phone_number or email must be provided"))
 	}
-
-	// Clean up expired sessions
-	util.CleanupExpiredSessions()
+	if err := validateOTPChannel(channel, phoneProvided, emailProvided); err != nil {
+		log.Printf("[OTP] Send failed: %v", err)
+		return nil, otp.MakeBadRequest(err)
+	}
+	if phoneProvided {
+		if _, err := util.NormalizePhoneE164(phone, s.config.SMS.DefaultRegion); err != nil {
+			log.Printf("[OTP] Send failed: %v", err)
+			return nil, otp.MakeBadRequest(fmt.Errorf("%s is not a valid phone number", phone))
+		}
+	}
+	// A disposable address is only a problem when it is the sole way the code is delivered
+	if emailProvided && (!phoneProvided || channel == "email") {
+		if err := checkDisposableEmail(email); err != nil {
+			log.Printf("[OTP] Send failed: %v", err)
+			return nil, otp.MakeBadRequest(err)
+		}
+	}
 
 	// Use phone as primary identifier, fallback to email
 	var identifier string
@@ -69,44 +219,95 @@ func (s *OTPService) Send(ctx context.Context, p *otp.SendOTPPayload) (*otp.Send
 		phoneIdentifier = *p.PhoneNumber
 	}
 
-	otpCode, normalizedIdentifier, err := util.CreateOTPSessionWithBoth(identifier, emailIdentifier, phoneIdentifier)
+	if s.verify != nil {
+		return s.sendWithVerify(identifier, emailIdentifier, phoneIdentifier, channel)
+	}
+
+	// Without a channel, send to every contact method provided
+	sendEmail := emailProvided && (channel == "" || channel == "email")
+	sendPhone := phoneProvided && channel != "email"
+
+	// Codes typed off a phone stay numeric; email-only codes use the longer email format
+	format := util.EmailOTPFormat()
+	if sendPhone {
+		format = util.PhoneOTPFormat()
+	}
+	otpCode, normalizedIdentifier, err := util.CreateOTPSessionWithBoth(identifier, emailIdentifier, phoneIdentifier, format)
 	if err != nil {
 		log.Printf("[OTP] Send failed: session creation error: %v", err)
+		if errors.Is(err, util.ErrOTPDailyLimit) {
+			metrics.RecordOTPDailyLimitRejection()
+		}
 		return nil, otp.MakeBadRequest(err)
 	}
 
+	// Record the channels that actually delivered the code so callers aren't told it was sent when it wasn't
+	var delivered []string
+	locale := s.messageLocale(ctx, p.Locale, email)
+	message := "OTP sent successfully"
+
 	// Send OTP via email if email is provided
-	if emailProvided {
-		emailErr := s.emailService.SendOTP(*p.Email, otpCode)
+	if sendEmail {
+		emailErr := s.emailService.SendOTP(ctx, *p.Email, otpCode, locale)
 		if emailErr != nil {
 			log.Printf("[OTP] Warning: failed to send OTP via email to %s: %v", *p.Email, emailErr)
 		} else {
 			log.Printf("[OTP] OTP sent via email to %s", *p.Email)
 			metrics.RecordOTPGenerated("email")
+			metrics.RecordOTPDelivery("email", false)
+			delivered = append(delivered, "email")
 		}
 	}
 
-	// Send OTP via SMS if phone is provided
-	if phoneProvided {
-		smsErr := s.smsService.SendOTP(*p.PhoneNumber, otpCode)
-		if smsErr != nil {
-			log.Printf("[OTP] Warning: failed to send OTP via SMS to %s: %v", *p.PhoneNumber, smsErr)
+	// Send OTP via SMS or WhatsApp if phone is provided
+	if sendPhone {
+		sentVia, phoneErr := s.sendToPhone(channel, *p.PhoneNumber, otpCode, locale)
+		if phoneErr != nil {
+			log.Printf("[OTP] Warning: failed to send OTP via %s to %s: %v", sentVia, *p.PhoneNumber, phoneErr)
+			// Fall back to email unless it was already tried
+			if !sendEmail {
+				if fallbackEmail := s.fallbackEmail(ctx, email, *p.PhoneNumber); fallbackEmail != "" {
+					if err := s.emailService.SendOTP(ctx, fallbackEmail, otpCode, s.messageLocale(ctx, p.Locale, fallbackEmail)); err != nil {
+						log.Printf("[OTP] Warning: failed to send fallback OTP via email to %s: %v", fallbackEmail, err)
+					} else {
+						log.Printf("[OTP] OTP sent via email to %s after %s delivery failed", fallbackEmail, sentVia)
+						metrics.RecordOTPGenerated("email")
+						metrics.RecordOTPDelivery("email", true)
+						delivered = append(delivered, "email")
+						failedVia := "SMS"
+						if sentVia == "whatsapp" {
+							failedVia = "WhatsApp"
+						}
+						message = fmt.Sprintf("OTP could not be sent by %s, so it was sent to your email address instead", failedVia)
+					}
+				}
+			}
 		} else {
-			log.Printf("[OTP] OTP sent via SMS to %s", *p.PhoneNumber)
-			metrics.RecordOTPGenerated("sms")
+			log.Printf("[OTP] OTP sent via %s to %s", sentVia, *p.PhoneNumber)
+			metrics.RecordOTPGenerated(sentVia)
+			metrics.RecordOTPDelivery(sentVia, channel == "whatsapp" && sentVia == "sms")
+			delivered = append(delivered, sentVia)
 		}
 	}
 
-	// If both email and SMS failed, return error
-	if emailProvided && phoneProvided {
-		// At least one should succeed, but we already logged warnings
-		// Continue with success response
-	} else if emailProvided && !s.emailService.IsEnabled() {
+	if len(delivered) == 0 {
+		log.Printf("[OTP] Send failed: OTP could not be delivered to identifier=%s", normalizedIdentifier)
+		return nil, otp.MakeBadRequest(fmt.Errorf("failed to deliver OTP. Please check your contact details or try again later"))
+	}
+
+	phoneEnabled := s.smsService.IsEnabled()
+	if channel == "whatsapp" {
+		phoneEnabled = s.whatsappService.IsEnabled()
+	}
+
+	if sendEmail && sendPhone {
+		// Both contact methods were tried and any failure logged above
+	} else if sendEmail && !s.emailService.IsEnabled() {
 		// In dev mode, just log
-		log.Printf("[OTP] DEV MODE - OTP for Email %s: %s (valid for 10 minutes)", *p.Email, otpCode)
-	} else if phoneProvided && !s.smsService.IsEnabled() {
+		log.Printf("[OTP] DEV MODE - OTP for Email %s: %s (valid for %d minutes)", *p.Email, util.LoggableOTP(otpCode), otpExpiryMinutes())
+	} else if sendPhone && !phoneEnabled {
 		// In dev mode, just log
-		log.Printf("[OTP] DEV MODE - OTP for Phone %s: %s (valid for 10 minutes)", normalizedIdentifier, otpCode)
+		log.Printf("[OTP] DEV MODE - OTP for Phone %s: %s (valid for %d minutes)", normalizedIdentifier, util.LoggableOTP(otpCode), otpExpiryMinutes())
 	}
 
 	// Return response
@@ -115,11 +316,212 @@ func (s *OTPService) Send(ctx context.Context, p *otp.SendOTPPayload) (*otp.Send
 		phoneNumber = *p.Email
 	}
 
-	log.Printf("[OTP] Send successful: identifier=%s", phoneNumber)
+	log.Printf("[OTP] Send successful: identifier=%s, channels=%s", phoneNumber, strings.Join(delivered, ","))
 	return &otp.Sendotpresult{
-		Message:          "OTP sent successfully",
+		Message:          message,
 		PhoneNumber:      phoneNumber,
-		ExpiresInMinutes: 10,
+		ExpiresInMinutes: otpExpiryMinutes(),
+		DeliveryChannels: delivered,
+	}, nil
+}
+
+// fallbackEmail returns the address to email a code to when phone delivery fails: the email
+// from the request, or else the one on the most recent inquiry for the phone number.
+// Disposable addresses are skipped.
+func (s *OTPService) fallbackEmail(ctx context.Context, email, phone string) string {
+	if email = strings.TrimSpace(email); email != "" {
+		if checkDisposableEmail(email) != nil {
+			return ""
+		}
+		return email
+	}
+
+	digits := normalizePhone(phone)
+	if len(digits) < 10 {
+		return ""
+	}
+	var inquiry domain.InvestmentInquiry
+	err := s.db.WithContext(database.WithTimeout(ctx, s.queryTimeout)).
+		Where("phone LIKE ? AND email IS NOT NULL AND email <> ''", "%"+digits[len(digits)-10:]+"%").
+		Order("created_at DESC").
+		First(&inquiry).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[OTP] Warning: failed to look up inquiry email for %s: %v", phone, err)
+		}
+		return ""
+	}
+	if checkDisposableEmail(*inquiry.Email) != nil {
+		return ""
+	}
+	return *inquiry.Email
+}
+
+// messageLocale returns the locale to word a code's messages in: the one requested, else the
+// language of the user with the given email address, else English
+func (s *OTPService) messageLocale(ctx context.Context, requested *string, email string) string {
+	if requested != nil && strings.TrimSpace(*requested) != "" {
+		return strings.ToLower(strings.TrimSpace(*requested))
+	}
+	if strings.TrimSpace(email) == "" {
+		return DefaultEmailLanguage
+	}
+	return s.emailLanguage(ctx, email)
+}
+
+// emailLanguage returns the language of the user with the email address, or English
+func (s *OTPService) emailLanguage(ctx context.Context, address string) string {
+	var user domain.User
+	err := s.db.WithContext(database.WithTimeout(ctx, s.queryTimeout)).
+		Select("language").
+		Where("email = ?", strings.ToLower(strings.TrimSpace(address))).
+		First(&user).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[OTP] Warning: failed to look up email language for %s: %v", address, err)
+		}
+		return DefaultEmailLanguage
+	}
+	return user.Language
+}
+
+// Resend implements the resend OTP method.
+// The code of a live session is delivered again, subject to a cooldown and a resend limit, so
+// users aren't left holding a superseded code. Without a live session this behaves like Send.
+func (s *OTPService) Resend(ctx context.Context, p *otp.SendOTPPayload) (*otp.Resendotpresult, error) {
+	phoneProvided := p.PhoneNumber != nil && strings.TrimSpace(*p.PhoneNumber) != ""
+	emailProvided := p.Email != nil && strings.TrimSpace(*p.Email) != ""
+
+	if !phoneProvided && !emailProvided {
+		log.Printf("[OTP] Resend failed: no contact method provided")
+		return nil, otp.MakeBadRequest(fmt.Errorf("either phone_number or email must be provided"))
+	}
+
+	// Use phone as primary identifier, fallback to email
+	var identifier string
+	if phoneProvided {
+		identifier = *p.PhoneNumber
+	} else {
+		identifier = *p.Email
+	}
+	channel := ""
+	if p.Channel != nil {
+		channel = *p.Channel
+	}
+	log.Printf("[OTP] Resend request: identifier=%s, channel=%s", identifier, channel)
+
+	if err := validateOTPChannel(channel, phoneProvided, emailProvided); err != nil {
+		log.Printf("[OTP] Resend failed: %v", err)
+		return nil, otp.MakeBadRequest(err)
+	}
+
+	if s.verify != nil {
+		// Starting a Twilio verification again resends the pending code; Twilio limits resends itself
+		sent, err := s.Send(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[OTP] Resend successful: identifier=%s", sent.PhoneNumber)
+		return &otp.Resendotpresult{
+			Message:          "OTP resent successfully",
+			PhoneNumber:      sent.PhoneNumber,
+			ExpiresInMinutes: sent.ExpiresInMinutes,
+			Resent:           true,
+			DeliveryChannels: sent.DeliveryChannels,
+		}, nil
+	}
+
+	cooldown := time.Duration(s.config.OTP.ResendCooldownSec) * time.Second
+	resend, err := util.ResendOTPSession(identifier, cooldown, s.config.OTP.MaxResends)
+	if errors.Is(err, util.ErrNoResendableSession) {
+		log.Printf("[OTP] Resend: no live session for identifier=%s, sending a new OTP", identifier)
+		sent, err := s.Send(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return &otp.Resendotpresult{
+			Message:                sent.Message,
+			PhoneNumber:            sent.PhoneNumber,
+			ExpiresInMinutes:       sent.ExpiresInMinutes,
+			SecondsUntilNextResend: s.config.OTP.ResendCooldownSec,
+			Resent:                 false,
+			DeliveryChannels:       sent.DeliveryChannels,
+		}, nil
+	}
+	if err != nil {
+		log.Printf("[OTP] Resend failed for identifier=%s: %v", identifier, err)
+		return nil, otp.MakeBadRequest(err)
+	}
+
+	// Deliver through the contact methods the code was originally sent to, or the requested
+	// channel, recording the ones that worked as Send does
+	var delivered []string
+	message := "OTP resent successfully"
+	locale := s.messageLocale(ctx, p.Locale, resend.Email)
+	emailTried := resend.Email != "" && (channel == "" || channel == "email")
+	if emailTried {
+		if err := s.emailService.SendOTP(ctx, resend.Email, resend.Code, locale); err != nil {
+			log.Printf("[OTP] Warning: failed to resend OTP via email to %s: %v", resend.Email, err)
+		} else {
+			log.Printf("[OTP] OTP resent via email to %s", resend.Email)
+			metrics.RecordOTPDelivery("email", false)
+			delivered = append(delivered, "email")
+		}
+	}
+	if resend.PhoneNumber != "" && channel != "email" {
+		// Prefer the number as the user typed it, which keeps its country code prefix
+		phone := resend.PhoneNumber
+		if phoneProvided && util.NormalizeIdentifier(*p.PhoneNumber) == resend.PhoneNumber {
+			phone = *p.PhoneNumber
+		}
+		if sentVia, err := s.sendToPhone(channel, phone, resend.Code, locale); err != nil {
+			log.Printf("[OTP] Warning: failed to resend OTP via %s to %s: %v", sentVia, phone, err)
+			// Fall back to email unless it was already tried
+			if !emailTried {
+				email := resend.Email
+				if emailProvided {
+					email = *p.Email
+				}
+				if fallbackEmail := s.fallbackEmail(ctx, email, phone); fallbackEmail != "" {
+					if err := s.emailService.SendOTP(ctx, fallbackEmail, resend.Code, s.messageLocale(ctx, p.Locale, fallbackEmail)); err != nil {
+						log.Printf("[OTP] Warning: failed to resend fallback OTP via email to %s: %v", fallbackEmail, err)
+					} else {
+						log.Printf("[OTP] OTP resent via email to %s after %s delivery failed", fallbackEmail, sentVia)
+						metrics.RecordOTPDelivery("email", true)
+						delivered = append(delivered, "email")
+						failedVia := "SMS"
+						if sentVia == "whatsapp" {
+							failedVia = "WhatsApp"
+						}
+						message = fmt.Sprintf("OTP could not be resent by %s, so it was sent to your email address instead", failedVia)
+					}
+				}
+			}
+		} else {
+			log.Printf("[OTP] OTP resent via %s to %s", sentVia, phone)
+			metrics.RecordOTPDelivery(sentVia, channel == "whatsapp" && sentVia == "sms")
+			delivered = append(delivered, sentVia)
+		}
+	}
+
+	if len(delivered) == 0 {
+		log.Printf("[OTP] Resend failed: OTP could not be delivered to identifier=%s", resend.Identifier)
+		return nil, otp.MakeBadRequest(fmt.Errorf("failed to deliver OTP. Please check your contact details or try again later"))
+	}
+
+	phoneNumber := resend.Identifier
+	if !phoneProvided && emailProvided {
+		phoneNumber = *p.Email
+	}
+
+	log.Printf("[OTP] Resend successful: identifier=%s, channels=%s", phoneNumber, strings.Join(delivered, ","))
+	return &otp.Resendotpresult{
+		Message:                message,
+		PhoneNumber:            phoneNumber,
+		ExpiresInMinutes:       int(math.Ceil(time.Until(resend.ExpiresAt).Minutes())),
+		SecondsUntilNextResend: int(math.Ceil(time.Until(resend.NextResendAt).Seconds())),
+		Resent:                 true,
+		DeliveryChannels:       delivered,
 	}, nil
 }
 
@@ -133,7 +535,7 @@ func (s *OTPService) Verify(ctx context.Context, p *otp.VerifyOTPPayload) (*otp.
 	if p.Email != nil {
 		email = *p.Email
 	}
-	log.Printf("[OTP] Verify request: phone=%s, email=%s, code=%s", phone, email, p.OtpCode)
+	log.Printf("[OTP] Verify request: phone=%s, email=%s, code=%s", phone, email, util.LoggableOTP(p.OtpCode))
 
 	// Validate that at least one contact method is provided
 	if (p.PhoneNumber == nil || strings.TrimSpace(*p.PhoneNumber) == "") &&
@@ -142,9 +544,6 @@ func (s *OTPService) Verify(ctx context.Context, p *otp.VerifyOTPPayload) (*otp.
 		return nil, otp.MakeBadRequest(fmt.Errorf("either phone_number or email must be provided"))
 	}
 
-	// Clean up expired sessions
-	util.CleanupExpiredSessions()
-
 	// Use phone as primary identifier, fallback to email
 	var identifier string
 	if p.PhoneNumber != nil && strings.TrimSpace(*p.PhoneNumber) != "" {
@@ -154,9 +553,19 @@ func (s *OTPService) Verify(ctx context.Context, p *otp.VerifyOTPPayload) (*otp.
 	}
 
 	// Verify OTP
-	if err := util.VerifyOTPSession(identifier, p.OtpCode); err != nil {
+	var err error
+	if s.verify != nil {
+		err = s.checkWithVerify(identifier, strings.TrimSpace(phone), strings.TrimSpace(email), p.OtpCode)
+	} else if err = s.checkOTPCodeLength(p.OtpCode); err == nil {
+		// A code of the wrong length doesn't use up one of the session's attempts
+		err = util.VerifyOTPSession(identifier, p.OtpCode)
+	}
+	if err != nil {
 		log.Printf("[OTP] Verify failed: verification error for identifier=%s: %v", identifier, err)
 		metrics.RecordOTPVerified(false)
+		if s.verify != nil && !isVerifyClientError(err) {
+			return nil, err
+		}
 		return nil, otp.MakeBadRequest(err)
 	}
 
@@ -175,16 +584,51 @@ func (s *OTPService) Verify(ctx context.Context, p *otp.VerifyOTPPayload) (*otp.
 	}, nil
 }
 
+// checkOTPCodeLength rejects codes that can't have been generated with the configured formats
+func (s *OTPService) checkOTPCodeLength(code string) error {
+	minLength, maxLength := s.config.OTP.PhoneCodeLength, s.config.OTP.EmailCodeLength
+	if minLength > maxLength {
+		minLength, maxLength = maxLength, minLength
+	}
+	if length := len(strings.TrimSpace(code)); length < minLength || length > maxLength {
+		if minLength == maxLength {
+			return fmt.Errorf("OTP code must be %d characters", minLength)
+		}
+		return fmt.Errorf("OTP code must be between %d and %d characters", minLength, maxLength)
+	}
+	return nil
+}
+
 // Check implements the check verification method
 func (s *OTPService) Check(ctx context.Context, p *otp.CheckVerificationPayload) (*otp.Checkverificationresult, error) {
-	log.Printf("[OTP] Check request: phone=%s", p.PhoneNumber)
+	phone := ""
+	email := ""
+	if p.PhoneNumber != nil {
+		phone = *p.PhoneNumber
+	}
+	if p.Email != nil {
+		email = *p.Email
+	}
+	log.Printf("[OTP] Check request: phone=%s, email=%s", phone, email)
 
-	normalizedPhone := util.NormalizeIdentifier(p.PhoneNumber)
-	verified := util.IsVerified(p.PhoneNumber)
+	// Use phone as primary identifier, fallback to email
+	var identifier string
+	if strings.TrimSpace(phone) != "" {
+		identifier = phone
+	} else if strings.TrimSpace(email) != "" {
+		identifier = email
+	} else {
+		log.Printf("[OTP] Check failed: no contact method provided")
+		return nil, otp.MakeBadRequest(fmt.Errorf("either phone_number or email must be provided"))
+	}
+
+	normalizedIdentifier := util.NormalizeIdentifier(identifier)
+	verified := util.IsVerified(identifier)
 
-	log.Printf("[OTP] Check result: phone=%s, verified=%v", normalizedPhone, verified)
+	log.Printf("[OTP] Check result: identifier=%s, verified=%v", normalizedIdentifier, verified)
 	return &otp.Checkverificationresult{
-		PhoneNumber: normalizedPhone,
+		Identifier:  normalizedIdentifier,
+		PhoneNumber: normalizedIdentifier,
 		Verified:    verified,
 	}, nil
 }
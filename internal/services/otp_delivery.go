@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"springstreet/gen/otp"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+
+	"goa.design/goa/v3/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OTPDeliveryCallbackPath receives Twilio message status callbacks.
+// It is served outside Goa because Twilio posts form-encoded bodies that must be signature-checked as sent.
+const OTPDeliveryCallbackPath = "/api/v1/otp/delivery-callback"
+
+// activeDeliveryWindow bounds the search for deliveries to numbers with a live session;
+// no OTP session lasts this long
+const activeDeliveryWindow = 24 * time.Hour
+
+// twilioDeliveryStatuses are the message statuses recorded from Twilio callbacks
+var twilioDeliveryStatuses = map[string]bool{
+	"accepted":                    true,
+	"canceled":                    true,
+	domain.OTPDeliveryQueued:      true,
+	"sending":                     true,
+	domain.OTPDeliverySent:        true,
+	domain.OTPDeliveryDelivered:   true,
+	domain.OTPDeliveryUndelivered: true,
+	domain.OTPDeliveryFailed:      true,
+}
+
+// JWTAuth implements the authorization logic for the JWT security scheme
+func (s *OTPService) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	// Validate JWT token and extract claims
+	claims, err := util.ValidateToken(token)
+	if err != nil {
+		return nil, otp.MakeUnauthorized(fmt.Errorf("invalid or expired token"))
+	}
+
+	// Get user from database
+	var user domain.User
+	qctx := database.WithTimeout(ctx, s.queryTimeout)
+	if err := s.db.WithContext(qctx).Where("username = ?", claims.Username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, otp.MakeUnauthorized(fmt.Errorf("user not found"))
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		return nil, otp.MakeUnauthorized(fmt.Errorf("user account is inactive"))
+	}
+
+	// Check the user belongs to the request's organization
+	if !inRequestOrganization(ctx, &user) {
+		return nil, otp.MakeUnauthorized(fmt.Errorf("user does not belong to this organization"))
+	}
+
+	// Check scopes if required
+	if schema != nil && len(schema.RequiredScopes) > 0 {
+		hasScope := false
+		for _, requiredScope := range schema.RequiredScopes {
+			if requiredScope == "admin" && user.IsAdmin {
+				hasScope = true
+				break
+			}
+			if requiredScope == "staff" && (user.IsStaff || user.IsAdmin) {
+				hasScope = true
+				break
+			}
+		}
+		if !hasScope {
+			return nil, otp.MakeUnauthorized(fmt.Errorf("insufficient permissions"))
+		}
+	}
+
+	// Add user to context
+	ctx = context.WithValue(ctx, "user", &user)
+	return ctx, nil
+}
+
+// recordOTPDelivery stores a message accepted by Twilio so its status callbacks can be matched.
+// A callback may arrive before this runs, in which case the row already exists and is kept.
+func recordOTPDelivery(db *gorm.DB, messageSID, phoneNumber, status string) {
+	if status == "" {
+		status = domain.OTPDeliveryQueued
+	}
+	delivery := &domain.OTPDelivery{
+		MessageSID: messageSID,
+		Provider:   "twilio",
+		Recipient:  util.NormalizeIdentifier(phoneNumber),
+		Status:     status,
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	if result.Error != nil {
+		log.Printf("[SMS] Warning: failed to record delivery %s: %v", messageSID, result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		metrics.RecordOTPDeliveryStatus(status)
+	}
+}
+
+// HandleDeliveryCallback records a Twilio message status callback.
+// Requests must carry a valid X-Twilio-Signature for the configured callback URL.
+func (s *OTPService) HandleDeliveryCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// The SMS service holds the current Twilio token, which changes when secrets are rotated
+	smsCfg := s.smsService.config()
+	if smsCfg.TwilioCallback == "" || smsCfg.TwilioAuth == "" {
+		http.Error(w, "delivery tracking is not configured", http.StatusNotFound)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+	if !validTwilioSignature(smsCfg.TwilioAuth, smsCfg.TwilioCallback, r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		log.Printf("[OTP] Delivery callback rejected: invalid Twilio signature from %s", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	messageSID := r.PostForm.Get("MessageSid")
+	status := strings.ToLower(r.PostForm.Get("MessageStatus"))
+	if messageSID == "" || status == "" {
+		http.Error(w, "MessageSid and MessageStatus are required", http.StatusBadRequest)
+		return
+	}
+	if !twilioDeliveryStatuses[status] {
+		// Acknowledge so Twilio doesn't retry statuses we don't track
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	qctx := database.WithTimeout(r.Context(), s.queryTimeout)
+	if err := s.updateDelivery(s.db.WithContext(qctx), messageSID, status, r.PostForm.Get("ErrorCode"), r.PostForm.Get("To")); err != nil {
+		log.Printf("[OTP] Delivery callback for %s failed: %v", messageSID, err)
+		http.Error(w, "failed to record delivery status", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateDelivery applies a status callback. Twilio may deliver callbacks out of order, so a
+// final status is never replaced by an earlier one.
+func (s *OTPService) updateDelivery(db *gorm.DB, messageSID, status, errorCode, to string) error {
+	var delivery domain.OTPDelivery
+	err := db.Where("message_sid = ?", messageSID).First(&delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The callback beat recordOTPDelivery
+		delivery = domain.OTPDelivery{
+			MessageSID: messageSID,
+			Provider:   "twilio",
+			Recipient:  util.NormalizeIdentifier(to),
+			Status:     status,
+			ErrorCode:  errorCode,
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery).Error; err != nil {
+			return err
+		}
+		metrics.RecordOTPDeliveryStatus(status)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if delivery.Status == status || delivery.IsFinal() {
+		return nil
+	}
+	if err := db.Model(&delivery).Updates(map[string]interface{}{
+		"status":     status,
+		"error_code": errorCode,
+	}).Error; err != nil {
+		return err
+	}
+	metrics.RecordOTPDeliveryStatus(status)
+
+	delivery.Status = status
+	if delivery.IsFinal() && status != domain.OTPDeliveryDelivered {
+		log.Printf("[OTP] SMS %s to %s was not delivered: status=%s, error_code=%s", messageSID, delivery.Recipient, status, errorCode)
+	}
+	return nil
+}
+
+// validTwilioSignature checks a request signature as described in Twilio's webhook security
+// docs: base64(HMAC-SHA1(auth token, URL followed by each POST parameter name and value, sorted by name))
+func validTwilioSignature(authToken, callbackURL string, params url.Values, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var payload strings.Builder
+	payload.WriteString(callbackURL)
+	for _, name := range names {
+		for _, value := range params[name] {
+			payload.WriteString(name)
+			payload.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(payload.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Deliveries implements the deliveries method
+func (s *OTPService) Deliveries(ctx context.Context, p *otp.ListOTPDeliveriesPayload) ([]*otp.Otpdeliveryresult, error) {
+	user := ctx.Value("user").(*domain.User)
+	log.Printf("[OTP] Deliveries request: status=%s, active_only=%v by '%s'", p.Status, p.ActiveOnly, user.Username)
+
+	query := s.db.WithContext(database.WithTimeout(ctx, s.queryTimeout)).
+		Where("status = ?", p.Status).
+		Order("updated_at DESC")
+	if p.ActiveOnly {
+		// Sessions are checked one by one, so only look at recent deliveries
+		query = query.Where("created_at > ?", time.Now().Add(-activeDeliveryWindow))
+	} else {
+		query = query.Limit(p.Limit)
+	}
+
+	var deliveries []domain.OTPDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		log.Printf("[OTP] Deliveries failed: database error: %v", err)
+		return nil, err
+	}
+
+	results := make([]*otp.Otpdeliveryresult, 0, len(deliveries))
+	for _, d := range deliveries {
+		active := util.HasActiveSession(d.Recipient)
+		if p.ActiveOnly && !active {
+			continue
+		}
+		result := &otp.Otpdeliveryresult{
+			MessageSid:    d.MessageSID,
+			Provider:      d.Provider,
+			PhoneNumber:   d.Recipient,
+			Status:        d.Status,
+			SessionActive: active,
+			CreatedAt:     d.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     d.UpdatedAt.Format(time.RFC3339),
+		}
+		if d.ErrorCode != "" {
+			errorCode := d.ErrorCode
+			result.ErrorCode = &errorCode
+		}
+		results = append(results, result)
+		if len(results) == p.Limit {
+			break
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"springstreet/internal/config"
+)
+
+// OTP message channels with a text template
+const (
+	otpMessageSMS          = "sms"
+	otpMessageEmailSubject = "email_subject"
+	otpMessageEmail        = "email" // Plain text part; the HTML part comes from the email templates
+)
+
+// otpExpiryMinutes is how long a code stays valid, as shown in messages
+func otpExpiryMinutes() int {
+	return config.Get().OTP.ValidityMin
+}
+
+//go:embed templates/otp
+var otpMessageFS embed.FS
+
+// otpMessageTemplates holds the OTP message templates by locale. It starts with the embedded
+// templates and is replaced by LoadOTPMessageTemplates at startup.
+var otpMessageTemplates = mustLoadOTPMessageTemplates()
+
+// otpMessageData are the variables available to OTP message templates
+type otpMessageData struct {
+	Code          string
+	ExpiryMinutes int
+	Brand         string
+}
+
+// otpBrandName is the brand shown in OTP messages
+var otpBrandName = "Spring Street"
+
+// LoadOTPMessageTemplates parses the embedded OTP message templates, then the {locale}/{channel}.txt
+// files in dir (if set), which override or add to them. Every template is rendered once with sample
+// data so mistakes surface here rather than when a code is sent.
+func LoadOTPMessageTemplates(dir, brand string) error {
+	templates, err := parseOTPMessageTemplates(otpMessageFS, "templates/otp")
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("OTP_TEMPLATE_DIR %s is not a directory", dir)
+		}
+		overrides, err := parseOTPMessageTemplates(os.DirFS(filepath.Clean(dir)), ".")
+		if err != nil {
+			return fmt.Errorf("OTP_TEMPLATE_DIR: %w", err)
+		}
+		for locale, set := range overrides {
+			base, ok := templates[locale]
+			if !ok {
+				templates[locale] = set
+				continue
+			}
+			for _, tmpl := range set.Templates() {
+				if _, err := base.AddParseTree(tmpl.Name(), tmpl.Tree); err != nil {
+					return fmt.Errorf("failed to override %s/%s: %w", locale, tmpl.Name(), err)
+				}
+			}
+		}
+	}
+
+	for _, channel := range []string{otpMessageSMS, otpMessageEmailSubject, otpMessageEmail} {
+		if templates[DefaultEmailLanguage].Lookup(channel) == nil {
+			return fmt.Errorf("missing OTP message template %s/%s.txt", DefaultEmailLanguage, channel)
+		}
+	}
+	sample := otpMessageData{Code: "123456", ExpiryMinutes: 10, Brand: brand}
+	for locale, set := range templates {
+		for _, tmpl := range set.Templates() {
+			if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+				return fmt.Errorf("OTP message template %s/%s.txt: %w", locale, tmpl.Name(), err)
+			}
+		}
+	}
+
+	otpMessageTemplates = templates
+	otpBrandName = brand
+	return nil
+}
+
+func mustLoadOTPMessageTemplates() map[string]*template.Template {
+	templates, err := parseOTPMessageTemplates(otpMessageFS, "templates/otp")
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// parseOTPMessageTemplates reads root/{locale}/{channel}.txt from fsys into one template set per locale
+func parseOTPMessageTemplates(fsys fs.FS, root string) (map[string]*template.Template, error) {
+	files, err := fs.Glob(fsys, path.Join(root, "*", "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, file := range files {
+		locale := path.Base(path.Dir(file))
+		channel := strings.TrimSuffix(path.Base(file), ".txt")
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OTP message template %s/%s.txt: %w", locale, channel, err)
+		}
+
+		set, ok := templates[locale]
+		if !ok {
+			set = template.New(locale)
+			templates[locale] = set
+		}
+		if _, err := set.New(channel).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("failed to parse OTP message template %s/%s.txt: %w", locale, channel, err)
+		}
+	}
+	return templates, nil
+}
+
+// renderOTPMessage renders the template for channel in locale, falling back to English when the
+// locale or its template for channel doesn't exist
+func renderOTPMessage(channel, locale, code string) (string, error) {
+	tmpl := otpMessageTemplates[strings.ToLower(strings.TrimSpace(locale))]
+	if tmpl == nil || tmpl.Lookup(channel) == nil {
+		tmpl = otpMessageTemplates[DefaultEmailLanguage]
+	}
+
+	var buf bytes.Buffer
+	data := otpMessageData{Code: code, ExpiryMinutes: otpExpiryMinutes(), Brand: otpBrandName}
+	if err := tmpl.ExecuteTemplate(&buf, channel, data); err != nil {
+		return "", fmt.Errorf("failed to render OTP %s message: %w", channel, err)
+	}
+	return buf.String(), nil
+}
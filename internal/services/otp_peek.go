@@ -0,0 +1,79 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"goa.design/goa/v3/security"
+)
+
+// OTPPeekPath returns the pending OTP of an identifier so end-to-end tests can complete OTP flows.
+// It is served outside Goa so that outside debug mode it answers exactly like an unknown path.
+const OTPPeekPath = "/api/v1/otp/peek"
+
+// otpPeekResponse is the body returned by HandlePeek
+type otpPeekResponse struct {
+	Identifier string `json:"identifier"`
+	Code       string `json:"code"`
+	Attempts   int    `json:"attempts"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// HandlePeek serves GET OTPPeekPath?identifier=... for admins, and only when DEBUG is on.
+// Otherwise it responds with a plain 404 before looking at credentials.
+func (s *OTPService) HandlePeek(w http.ResponseWriter, r *http.Request) {
+	if !s.config.App.Debug {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := bearerToken(r.Header.Get("Authorization"))
+	if token == "" {
+		http.Error(w, "missing or invalid Authorization header", http.StatusUnauthorized)
+		return
+	}
+	ctx, err := s.JWTAuth(r.Context(), token, &security.JWTScheme{Name: "jwt", RequiredScopes: []string{"admin"}})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	user := ctx.Value("user").(*domain.User)
+
+	identifier := strings.TrimSpace(r.URL.Query().Get("identifier"))
+	if identifier == "" {
+		http.Error(w, "identifier is required", http.StatusBadRequest)
+		return
+	}
+	log.Printf("[OTP] Peek request: identifier=%s by '%s'", identifier, user.Username)
+
+	peek, err := util.PeekOTPSession(identifier)
+	if err != nil {
+		log.Printf("[OTP] Peek failed for identifier=%s: %v", identifier, err)
+		http.Error(w, "failed to read OTP session", http.StatusInternalServerError)
+		return
+	}
+	if peek == nil {
+		http.Error(w, "no pending OTP for identifier", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(otpPeekResponse{
+		Identifier: peek.Identifier,
+		Code:       peek.Code,
+		Attempts:   peek.Attempts,
+		ExpiresAt:  peek.ExpiresAt.Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+)
+
+// newPeekServer serves the OTP peek endpoint and returns an admin and a staff token for it
+func newPeekServer(t *testing.T) (ts *httptest.Server, adminToken, staffToken string) {
+	t.Helper()
+	db := newTestDB(t)
+	s := NewOTPService(config.Get(), db, nil)
+	ts = httptest.NewServer(http.HandlerFunc(s.HandlePeek))
+	t.Cleanup(ts.Close)
+
+	admin := createTestUser(t, db, domain.User{Username: "admin", IsAdmin: true}, "correct horse")
+	staff := createTestUser(t, db, domain.User{Username: "staff", IsStaff: true}, "correct horse")
+	adminToken, _ = util.GenerateToken(admin)
+	staffToken, _ = util.GenerateToken(staff)
+	return ts, adminToken, staffToken
+}
+
+// peek requests the pending OTP for identifier with an optional bearer token
+func peek(t *testing.T, ts *httptest.Server, identifier, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+OTPPeekPath+"?identifier="+identifier, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET peek: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOTPPeekInDebugMode(t *testing.T) {
+	t.Setenv("DEBUG", "true")
+	ts, adminToken, staffToken := newPeekServer(t)
+	code, identifier, err := util.CreateOTPSession("+919876543210", util.PhoneOTPFormat())
+	if err != nil {
+		t.Fatalf("CreateOTPSession: %v", err)
+	}
+
+	if resp := peek(t, ts, identifier, ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without a token: status = %d, want 401", resp.StatusCode)
+	}
+	if resp := peek(t, ts, identifier, staffToken); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("with a staff token: status = %d, want 401", resp.StatusCode)
+	}
+	if resp := peek(t, ts, "919812345678", adminToken); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("without a pending OTP: status = %d, want 404", resp.StatusCode)
+	}
+
+	resp := peek(t, ts, identifier, adminToken)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("with an admin token: status = %d, want 200", resp.StatusCode)
+	}
+	var body otpPeekResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Code != code || body.Identifier != identifier || body.Attempts != 0 {
+		t.Fatalf("body = %+v, want code %s for %s with no attempts", body, code, identifier)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		t.Error("the code may be cached")
+	}
+}
+
+func TestOTPPeekIsNotFoundOutsideDebugMode(t *testing.T) {
+	t.Setenv("DEBUG", "false")
+	ts, adminToken, _ := newPeekServer(t)
+	_, identifier, err := util.CreateOTPSession("+919876543210", util.PhoneOTPFormat())
+	if err != nil {
+		t.Fatalf("CreateOTPSession: %v", err)
+	}
+
+	if resp := peek(t, ts, identifier, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("without a token: status = %d, want 404", resp.StatusCode)
+	}
+	if resp := peek(t, ts, identifier, adminToken); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("with an admin token: status = %d, want 404", resp.StatusCode)
+	}
+}
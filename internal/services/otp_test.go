@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"springstreet/gen/otp"
+	"springstreet/internal/config"
+	"springstreet/internal/util"
+)
+
+func TestOTPSendRejectsInvalidPhoneNumber(t *testing.T) {
+	db := newTestDB(t)
+	s := NewOTPService(config.Get(), db, nil)
+
+	for _, phone := range []string{"12345", "not a number", "+000000000000"} {
+		_, err := s.Send(context.Background(), &otp.SendOTPPayload{PhoneNumber: &phone})
+		assertServiceError(t, err, "bad_request")
+		if util.HasActiveSession(phone) {
+			t.Errorf("Send created an OTP session for %q", phone)
+		}
+	}
+}
+
+func TestOTPSendAcceptsIndianLocalNumber(t *testing.T) {
+	db := newTestDB(t)
+	s := NewOTPService(config.Get(), db, nil)
+	phone := "098765 43210"
+
+	if _, err := s.Send(context.Background(), &otp.SendOTPPayload{PhoneNumber: &phone}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !util.HasActiveSession(phone) {
+		t.Fatal("Send did not create an OTP session")
+	}
+}
+
+func TestDeliveryCallbackUsesRotatedTwilioToken(t *testing.T) {
+	const callbackURL = "https://api.example.com/api/v1/otp/delivery-callback"
+	t.Setenv("TWILIO_AUTH_TOKEN", "revoked-token")
+	t.Setenv("TWILIO_STATUS_CALLBACK_URL", callbackURL)
+	db := newTestDB(t)
+	s := NewOTPService(config.Get(), db, nil)
+
+	// As main does when the secrets provider reports a rotation
+	t.Setenv("TWILIO_AUTH_TOKEN", "rotated-token")
+	cfg, err := config.ReloadSecrets()
+	if err != nil {
+		t.Fatalf("ReloadSecrets: %v", err)
+	}
+	s.UpdateConfig(cfg)
+
+	form := url.Values{"MessageSid": {"SM0123456789abcdef0123456789abcdef"}, "MessageStatus": {"delivered"}}
+	for _, tc := range []struct {
+		token string
+		want  int
+	}{
+		{"revoked-token", http.StatusForbidden},
+		{"rotated-token", http.StatusNoContent},
+	} {
+		mac := hmac.New(sha1.New, []byte(tc.token))
+		// Twilio signs the callback URL followed by the sorted parameters
+		mac.Write([]byte(callbackURL + "MessageSidSM0123456789abcdef0123456789abcdefMessageStatusdelivered"))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/otp/delivery-callback", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+		s.HandleDeliveryCallback(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("signed with %s: status %d, want %d", tc.token, rec.Code, tc.want)
+		}
+	}
+}
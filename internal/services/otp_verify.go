@@ -0,0 +1,245 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"springstreet/gen/otp"
+	"springstreet/internal/config"
+	"springstreet/internal/metrics"
+	"springstreet/internal/util"
+)
+
+const (
+	// OTPBackendLocal generates, stores and checks codes in this service
+	OTPBackendLocal = "local"
+
+	// OTPBackendTwilioVerify leaves codes, attempt counting and delivery to Twilio Verify
+	OTPBackendTwilioVerify = "twilio-verify"
+)
+
+// twilioVerifyBaseURL is the Twilio Verify v2 API root
+var twilioVerifyBaseURL = "https://verify.twilio.com/v2"
+
+// Twilio Verify error codes we handle specifically
+const (
+	twilioErrNotFound         = 20404 // No pending verification for the recipient (expired, approved or never started)
+	twilioErrMaxCheckAttempts = 60202
+	twilioErrMaxSendAttempts  = 60203
+)
+
+var (
+	errVerifyNotFound         = errors.New("OTP session not found or expired. Please request a new OTP")
+	errVerifyMaxCheckAttempts = errors.New("maximum verification attempts exceeded. Please request a new OTP")
+	errVerifyMaxSendAttempts  = errors.New("too many OTP requests for this contact. Please try again later")
+	errVerifyInvalidCode      = errors.New("invalid OTP. Please try again")
+	errVerifyRecipient        = errors.New("invalid OTP recipient")
+)
+
+// twilioVerifyClient starts and checks verifications with Twilio Verify
+type twilioVerifyClient struct {
+	mu  sync.RWMutex
+	cfg *config.SMSConfig
+}
+
+// newTwilioVerifyClient returns a client for the configured Verify service, or an error
+// if the Twilio credentials or service SID are missing
+func newTwilioVerifyClient(cfg *config.SMSConfig) (*twilioVerifyClient, error) {
+	if cfg.TwilioSID == "" || cfg.TwilioAuth == "" || cfg.TwilioVerifySID == "" {
+		return nil, fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_VERIFY_SERVICE_SID are required")
+	}
+	return &twilioVerifyClient{cfg: cfg}, nil
+}
+
+// updateConfig replaces the Twilio configuration (used when secrets are rotated)
+func (c *twilioVerifyClient) updateConfig(cfg *config.SMSConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = cfg
+}
+
+// config returns the current Twilio configuration
+func (c *twilioVerifyClient) config() *config.SMSConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// twilioVerifyResponse is the part of a Verification or VerificationCheck resource we use
+type twilioVerifyResponse struct {
+	Status string `json:"status"` // pending, approved or canceled
+	Valid  bool   `json:"valid"`
+}
+
+// twilioErrorResponse is the body of a failed Twilio API call
+type twilioErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Start sends a code to to over channel (sms, whatsapp or email). Starting again while a
+// verification is pending resends the same code.
+func (c *twilioVerifyClient) Start(to, channel string) error {
+	resp, err := c.post("Verifications", url.Values{"To": {to}, "Channel": {channel}})
+	if err != nil {
+		return err
+	}
+	if resp.Status != "pending" {
+		return fmt.Errorf("Twilio Verify returned status %q for a new verification", resp.Status)
+	}
+	return nil
+}
+
+// Check reports whether code is the pending code for to
+func (c *twilioVerifyClient) Check(to, code string) (bool, error) {
+	resp, err := c.post("VerificationCheck", url.Values{"To": {to}, "Code": {code}})
+	if err != nil {
+		return false, err
+	}
+	return resp.Status == "approved" && resp.Valid, nil
+}
+
+// post calls a Verify service endpoint and maps Twilio errors we handle to sentinel errors
+func (c *twilioVerifyClient) post(resource string, form url.Values) (*twilioVerifyResponse, error) {
+	cfg := c.config()
+	endpoint := fmt.Sprintf("%s/Services/%s/%s", twilioVerifyBaseURL, cfg.TwilioVerifySID, resource)
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(cfg.TwilioSID, cfg.TwilioAuth)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: smsRequestTimeout}
+	resp, err := doWithBreaker(providerTwilioVerify, client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Twilio Verify request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+		var result twilioVerifyResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("Twilio Verify returned an unreadable response (status %d): %w", resp.StatusCode, err)
+		}
+		return &result, nil
+	}
+
+	var result twilioErrorResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	switch result.Code {
+	case twilioErrNotFound:
+		return nil, errVerifyNotFound
+	case twilioErrMaxCheckAttempts:
+		return nil, errVerifyMaxCheckAttempts
+	case twilioErrMaxSendAttempts:
+		return nil, errVerifyMaxSendAttempts
+	}
+	return nil, fmt.Errorf("Twilio Verify API error (status %d, code %d): %s", resp.StatusCode, result.Code, result.Message)
+}
+
+// isVerifyClientError reports whether err is a Verify outcome to show the user rather than a provider failure
+func isVerifyClientError(err error) bool {
+	for _, clientErr := range []error{errVerifyNotFound, errVerifyMaxCheckAttempts, errVerifyMaxSendAttempts, errVerifyInvalidCode, errVerifyRecipient} {
+		if errors.Is(err, clientErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendWithVerify starts a Twilio verification in place of creating a local OTP session.
+// The request still counts against the local rate limits and daily cap.
+func (s *OTPService) sendWithVerify(identifier, email, phone, channel string) (*otp.Sendotpresult, error) {
+	normalizedIdentifier, err := util.ReserveOTPRequest(identifier, email, phone)
+	if err != nil {
+		log.Printf("[OTP] Send failed: %v", err)
+		if errors.Is(err, util.ErrOTPDailyLimit) {
+			metrics.RecordOTPDailyLimitRejection()
+		}
+		return nil, otp.MakeBadRequest(err)
+	}
+
+	to, verifyChannel, err := s.verifyRecipient(channel, phone, email)
+	if err != nil {
+		log.Printf("[OTP] Send failed: %v", err)
+		return nil, otp.MakeBadRequest(err)
+	}
+	if err := s.verify.Start(to, verifyChannel); err != nil {
+		log.Printf("[OTP] Send failed: Twilio Verify error for %s: %v", to, err)
+		if isVerifyClientError(err) {
+			return nil, otp.MakeBadRequest(err)
+		}
+		return nil, fmt.Errorf("failed to send OTP: %w", err)
+	}
+	log.Printf("[OTP] OTP sent via Twilio Verify (%s) to %s", verifyChannel, to)
+	metrics.RecordOTPGenerated(verifyChannel)
+	metrics.RecordOTPDelivery(verifyChannel, false)
+
+	phoneNumber := normalizedIdentifier
+	if phone == "" {
+		phoneNumber = email
+	}
+	log.Printf("[OTP] Send successful: identifier=%s", phoneNumber)
+	return &otp.Sendotpresult{
+		Message:          "OTP sent successfully",
+		PhoneNumber:      phoneNumber,
+		ExpiresInMinutes: 10, // Twilio Verify's default code lifetime
+		DeliveryChannels: []string{verifyChannel},
+	}, nil
+}
+
+// checkWithVerify checks a code with Twilio Verify and, once approved, records the verification
+// locally so it can be checked and consumed like one made with a local code
+func (s *OTPService) checkWithVerify(identifier, phone, email, code string) error {
+	approved, err := s.verifyCheck(phone, email, code)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return errVerifyInvalidCode
+	}
+	if err := util.MarkVerified(identifier, email, phone); err != nil {
+		return fmt.Errorf("failed to record verification: %w", err)
+	}
+	return nil
+}
+
+// verifyRecipient returns the Twilio Verify recipient and channel for a send. Twilio Verify
+// sends one code per recipient, so a phone number is used unless email delivery is requested.
+func (s *OTPService) verifyRecipient(channel, phone, email string) (to, verifyChannel string, err error) {
+	if phone == "" || channel == "email" {
+		return util.NormalizeIdentifier(email), "email", nil
+	}
+	digits, err := s.whatsappService.recipientNumber(phone)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", errVerifyRecipient, err)
+	}
+	if channel == "whatsapp" {
+		return "+" + digits, "whatsapp", nil
+	}
+	return "+" + digits, "sms", nil
+}
+
+// verifyCheck checks code against the pending verification for the phone number, or for the
+// email if there is none for the phone (the code was sent by email)
+func (s *OTPService) verifyCheck(phone, email, code string) (bool, error) {
+	if phone != "" {
+		to, _, err := s.verifyRecipient("", phone, "")
+		if err != nil {
+			return false, err
+		}
+		approved, err := s.verify.Check(to, code)
+		if !errors.Is(err, errVerifyNotFound) || email == "" {
+			return approved, err
+		}
+		log.Printf("[OTP] No pending Twilio verification for %s, checking %s", to, email)
+	}
+	return s.verify.Check(util.NormalizeIdentifier(email), code)
+}
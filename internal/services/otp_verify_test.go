@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"springstreet/gen/otp"
+	"springstreet/internal/config"
+	"springstreet/internal/util"
+)
+
+// twilioVerifyMock stands in for the Twilio Verify API, answering VerificationCheck with
+// checkCode and checkReply and recording the forms it was sent
+type twilioVerifyMock struct {
+	mu         sync.Mutex
+	forms      map[string]url.Values
+	checkCode  int
+	checkReply string
+}
+
+func (m *twilioVerifyMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resource := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.forms[resource] = r.PostForm
+
+	w.Header().Set("Content-Type", "application/json")
+	if resource == "VerificationCheck" {
+		w.WriteHeader(m.checkCode)
+		w.Write([]byte(m.checkReply))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"status":"pending"}`))
+}
+
+// newVerifyOTPService returns an OTP service using Twilio Verify, with the API served by a mock
+func newVerifyOTPService(t *testing.T) (*OTPService, *twilioVerifyMock) {
+	t.Helper()
+	t.Setenv("OTP_BACKEND", OTPBackendTwilioVerify)
+	t.Setenv("TWILIO_ACCOUNT_SID", "AC00000000000000000000000000000000")
+	t.Setenv("TWILIO_AUTH_TOKEN", "test-auth-token")
+	t.Setenv("TWILIO_VERIFY_SERVICE_SID", "VA00000000000000000000000000000000")
+	db := newTestDB(t)
+	resetProviderBreakers(t)
+
+	mock := &twilioVerifyMock{forms: make(map[string]url.Values), checkCode: http.StatusOK}
+	ts := httptest.NewServer(mock)
+	t.Cleanup(ts.Close)
+	baseURL := twilioVerifyBaseURL
+	twilioVerifyBaseURL = ts.URL
+	t.Cleanup(func() { twilioVerifyBaseURL = baseURL })
+
+	s := NewOTPService(config.Get(), db, nil)
+	if s.verify == nil {
+		t.Fatal("OTP service did not select Twilio Verify")
+	}
+	return s, mock
+}
+
+func TestTwilioVerifySend(t *testing.T) {
+	s, mock := newVerifyOTPService(t)
+	phone := "+919876543210"
+
+	res, err := s.Send(context.Background(), &otp.SendOTPPayload{PhoneNumber: &phone})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(res.DeliveryChannels) != 1 || res.DeliveryChannels[0] != "sms" {
+		t.Errorf("delivery channels = %v, want [sms]", res.DeliveryChannels)
+	}
+	form := mock.forms["Verifications"]
+	if form.Get("To") != phone || form.Get("Channel") != "sms" {
+		t.Errorf("Verifications form = %v, want To=%s Channel=sms", form, phone)
+	}
+	// Twilio holds the code; nothing is stored locally
+	if util.HasActiveSession(phone) {
+		t.Error("Send created a local OTP session with the Twilio Verify backend")
+	}
+}
+
+func TestTwilioVerifyCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		code     int
+		reply    string
+		wantErr  string // empty when the code is accepted
+		verified bool
+	}{
+		{"approved", http.StatusOK, `{"status":"approved","valid":true}`, "", true},
+		{"pending", http.StatusOK, `{"status":"pending","valid":false}`, "invalid OTP", false},
+		{"max attempts", http.StatusTooManyRequests, `{"code":60202,"message":"Max check attempts reached"}`, "maximum verification attempts exceeded", false},
+		{"not found", http.StatusNotFound, `{"code":20404,"message":"The requested resource was not found"}`, "OTP session not found", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := newVerifyOTPService(t)
+			mock.checkCode, mock.checkReply = tc.code, tc.reply
+			phone := "+919876543210"
+
+			_, err := s.Verify(context.Background(), &otp.VerifyOTPPayload{PhoneNumber: &phone, OtpCode: "123456"})
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if tc.wantErr != "" {
+				assertServiceError(t, err, "bad_request")
+				if !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Verify = %v, want %q", err, tc.wantErr)
+				}
+			}
+
+			form := mock.forms["VerificationCheck"]
+			if form.Get("To") != phone || form.Get("Code") != "123456" {
+				t.Errorf("VerificationCheck form = %v, want To=%s Code=123456", form, phone)
+			}
+			if got := util.IsVerified(phone); got != tc.verified {
+				t.Errorf("IsVerified = %v, want %v", got, tc.verified)
+			}
+		})
+	}
+}
+
+func TestTwilioVerifyCheckProviderError(t *testing.T) {
+	s, mock := newVerifyOTPService(t)
+	mock.checkCode, mock.checkReply = http.StatusServiceUnavailable, `{"code":20503,"message":"Service unavailable"}`
+	phone := "+919876543210"
+
+	_, err := s.Verify(context.Background(), &otp.VerifyOTPPayload{PhoneNumber: &phone, OtpCode: "123456"})
+	if err == nil {
+		t.Fatal("Verify succeeded although Twilio Verify failed")
+	}
+	if isVerifyClientError(err) {
+		t.Fatalf("Verify = %v, want a provider error rather than a user-facing one", err)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"springstreet/internal/config"
+	"springstreet/internal/database"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+	goa "goa.design/goa/v3/pkg"
+	"gorm.io/gorm"
+)
+
+// loadTestConfig loads the configuration from the environment, after any t.Setenv calls
+func loadTestConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("SECRET_KEY", "test-secret-key-that-is-long-enough-0123456789")
+	if _, err := config.Load(); err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+}
+
+// newTestDB loads the configuration from the environment, after any t.Setenv calls, and
+// returns a freshly migrated SQLite database
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "sqlite:///"+filepath.Join(t.TempDir(), "test.db"))
+	loadTestConfig(t)
+	if err := database.Init(); err != nil {
+		t.Fatalf("database.Init: %v", err)
+	}
+	db := database.GetDB()
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// createTestUser stores an active user with the given password
+func createTestUser(t *testing.T, db *gorm.DB, user domain.User, password string) *domain.User {
+	t.Helper()
+	hash, err := util.HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user.HashedPassword = hash
+	user.IsActive = true
+	if user.Email == "" {
+		user.Email = user.Username + "@example.com"
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &user
+}
+
+// asUser returns ctx as JWTAuth leaves it for user
+func asUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, "user", user)
+}
+
+// assertServiceError fails the test unless err is a goa service error called name
+func assertServiceError(t *testing.T, err error, name string) {
+	t.Helper()
+	var serviceErr *goa.ServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.Name != name {
+		t.Fatalf("error = %v, want %s", err, name)
+	}
+}
+
+// metricValue returns the current value of the counter or gauge metric name with the given labels
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			if metric.GetGauge() != nil {
+				return metric.GetGauge().GetValue()
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
@@ -4,122 +4,300 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"springstreet/internal/config"
+	"springstreet/internal/util"
+
+	"gorm.io/gorm"
 )
 
+// smsRequestTimeout bounds a single call to an SMS provider
+var smsRequestTimeout = 10 * time.Second
+
+// msg91FlowURL is MSG91's Flow API endpoint for sending template messages
+var msg91FlowURL = "https://control.msg91.com/api/v5/flow/"
+
+// twilioAPIBaseURL is the Twilio REST API root for Programmable Messaging
+var twilioAPIBaseURL = "https://api.twilio.com/2010-04-01"
+
+// twilioAPIError is a failed Twilio API call, carrying Twilio's error code
+// (see https://www.twilio.com/docs/api/errors) and message
+type twilioAPIError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+func (e *twilioAPIError) Error() string {
+	return fmt.Sprintf("Twilio API error (status %d, code %d): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// twilioMessageResponse is the part of a Message resource we use
+type twilioMessageResponse struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+}
+
 // SMSService handles sending SMS messages
 type SMSService struct {
+	mu  sync.RWMutex
 	cfg *config.SMSConfig
+	db  *gorm.DB // Records Twilio messages for delivery tracking
 }
 
 // NewSMSService creates a new SMS service
-func NewSMSService(cfg *config.SMSConfig) *SMSService {
-	return &SMSService{cfg: cfg}
+func NewSMSService(cfg *config.SMSConfig, db *gorm.DB) *SMSService {
+	return &SMSService{cfg: cfg, db: db}
 }
 
-// SendOTP sends an OTP code via SMS
-func (s *SMSService) SendOTP(phoneNumber, otpCode string) error {
-	if !s.cfg.Enabled {
+// UpdateConfig replaces the SMS configuration (used when secrets are rotated)
+func (s *SMSService) UpdateConfig(cfg *config.SMSConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// config returns the current SMS configuration
+func (s *SMSService) config() *config.SMSConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// SendOTP sends an OTP code via SMS, with the message text in locale where a template exists
+func (s *SMSService) SendOTP(phoneNumber, otpCode, locale string) error {
+	cfg := s.config()
+	if !s.IsEnabled() {
 		// In development mode, just log
-		fmt.Printf("[SMS] OTP would be sent to %s: %s\n", phoneNumber, otpCode)
+		fmt.Printf("[SMS] OTP would be sent to %s: %s\n", phoneNumber, util.LoggableOTP(otpCode))
 		return nil
 	}
 
-	message := fmt.Sprintf("Your Spring Street verification code is: %s. Valid for 10 minutes.", otpCode)
+	to, err := util.NormalizePhoneE164(phoneNumber, cfg.DefaultRegion)
+	if err != nil {
+		return err
+	}
+
+	message, err := renderOTPMessage(otpMessageSMS, locale, otpCode)
+	if err != nil {
+		return err
+	}
 
-	switch strings.ToLower(s.cfg.Provider) {
+	switch strings.ToLower(cfg.Provider) {
 	case "twilio":
-		return s.sendViaTwilio(phoneNumber, message)
+		return s.sendViaTwilio(to, phoneNumber, message)
+	case "msg91":
+		return s.sendViaMSG91(to, otpCode)
 	case "aws":
 		// AWS SNS implementation can be added here
 		return fmt.Errorf("AWS SMS provider not yet implemented")
 	case "console", "dev", "development":
 		// Development mode - just log
-		fmt.Printf("[SMS] OTP would be sent to %s: %s\n", phoneNumber, otpCode)
+		fmt.Printf("[SMS] OTP would be sent to %s: %s\n", phoneNumber, util.LoggableOTP(otpCode))
 		return nil
 	default:
-		return fmt.Errorf("unsupported SMS provider: %s", s.cfg.Provider)
+		return fmt.Errorf("unsupported SMS provider: %s", cfg.Provider)
 	}
 }
 
-// sendViaTwilio sends SMS via Twilio API
-func (s *SMSService) sendViaTwilio(phoneNumber, message string) error {
-	if s.cfg.TwilioSID == "" || s.cfg.TwilioAuth == "" || s.cfg.TwilioFrom == "" {
-		return fmt.Errorf("Twilio not properly configured")
+// smsTestMessage is the text SendTest sends
+const smsTestMessage = "This is a test message from Spring Street. Your SMS settings are working."
+
+// SendTest sends a canned message to phoneNumber through the configured provider, to check
+// the SMS settings. It returns a description of the provider's answer. MSG91 only sends
+// registered templates, so it gets the OTP template with the code 000000.
+func (s *SMSService) SendTest(phoneNumber string) (string, error) {
+	cfg := s.config()
+	if !s.IsEnabled() {
+		return "", fmt.Errorf("SMS is disabled (SMS_ENABLED=false)")
 	}
 
-	// Normalize phone number (ensure it starts with +)
-	normalizedPhone := phoneNumber
-	if !strings.HasPrefix(normalizedPhone, "+") {
-		// Assume US number if no country code
-		if strings.HasPrefix(normalizedPhone, "1") {
-			normalizedPhone = "+" + normalizedPhone
-		} else {
-			normalizedPhone = "+1" + normalizedPhone
+	to, err := util.NormalizePhoneE164(phoneNumber, cfg.DefaultRegion)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "twilio":
+		sent, err := s.postTwilioMessage(to, smsTestMessage)
+		if err != nil {
+			return "", err
+		}
+		if sent.SID == "" {
+			return "accepted by Twilio", nil
+		}
+		return fmt.Sprintf("accepted by Twilio as message %s (status %s)", sent.SID, sent.Status), nil
+	case "msg91":
+		if err := s.sendViaMSG91(to, "000000"); err != nil {
+			return "", err
 		}
+		return "accepted by MSG91", nil
+	case "console", "dev", "development":
+		fmt.Printf("[SMS] Test message would be sent to %s: %s\n", to, smsTestMessage)
+		return "console provider: message logged, not sent", nil
+	default:
+		return "", fmt.Errorf("unsupported SMS provider: %s", cfg.Provider)
 	}
+}
 
-	// Twilio API endpoint
-	url := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", s.cfg.TwilioSID)
+// sendViaTwilio sends SMS via Twilio API to an E.164 number. phoneNumber is the number
+// as entered, which delivery tracking is keyed on so it matches the OTP session.
+func (s *SMSService) sendViaTwilio(to, phoneNumber, message string) error {
+	cfg := s.config()
+	sent, err := s.postTwilioMessage(to, message)
+	if err != nil {
+		return err
+	}
+	if sent.SID == "" {
+		// Twilio accepted the message, so this is not a delivery failure
+		log.Printf("[SMS] Warning: Twilio response to %s had no message SID, delivery will not be tracked", phoneNumber)
+		return nil
+	}
+	log.Printf("[SMS] Twilio accepted message %s to %s (status %s)", sent.SID, to, sent.Status)
 
-	// Prepare request data
-	data := map[string]string{
-		"From": s.cfg.TwilioFrom,
-		"To":   normalizedPhone,
-		"Body": message,
+	if cfg.TwilioCallback != "" {
+		recordOTPDelivery(s.db, sent.SID, phoneNumber, sent.Status)
 	}
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request data: %w", err)
+	return nil
+}
+
+// postTwilioMessage creates a Twilio message to an E.164 number. The returned message has
+// no SID if Twilio's answer couldn't be read.
+func (s *SMSService) postTwilioMessage(to, message string) (*twilioMessageResponse, error) {
+	cfg := s.config()
+	if cfg.TwilioSID == "" || cfg.TwilioAuth == "" || cfg.TwilioFrom == "" {
+		return nil, fmt.Errorf("Twilio not properly configured")
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIBaseURL, cfg.TwilioSID)
+
+	// Twilio only accepts form-encoded parameters
+	form := url.Values{
+		"From": {cfg.TwilioFrom},
+		"To":   {to},
+		"Body": {message},
+	}
+	if cfg.TwilioCallback != "" {
+		form.Set("StatusCallback", cfg.TwilioCallback)
 	}
 
-	// Set headers
-	req.SetBasicAuth(s.cfg.TwilioSID, s.cfg.TwilioAuth)
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(cfg.TwilioSID, cfg.TwilioAuth)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	// Send request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	client := &http.Client{Timeout: smsRequestTimeout}
+	resp, err := doWithBreaker(providerTwilioSMS, client, req)
 	if err != nil {
-		return fmt.Errorf("failed to send SMS request: %w", err)
+		return nil, fmt.Errorf("failed to send SMS request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Check response
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errorResp)
-		return fmt.Errorf("Twilio API error (status %d): %v", resp.StatusCode, errorResp)
+		var failure twilioErrorResponse
+		json.NewDecoder(resp.Body).Decode(&failure)
+		if failure.Message == "" {
+			failure.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, &twilioAPIError{StatusCode: resp.StatusCode, Code: failure.Code, Message: failure.Message}
 	}
 
-	return nil
-}
-
-// IsEnabled returns whether SMS service is enabled
-func (s *SMSService) IsEnabled() bool {
-	return s.cfg.Enabled
+	var sent twilioMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sent); err != nil {
+		return &twilioMessageResponse{}, nil
+	}
+	return &sent, nil
 }
 
+// sendViaMSG91 sends an OTP through MSG91's Flow API.
+// Indian regulations (DLT) only allow pre-registered templates, so the message text lives in
+// the template and only the code is sent, as the ##otp## variable.
+func (s *SMSService) sendViaMSG91(phoneNumber, otpCode string) error {
+	cfg := s.config()
+	if cfg.MSG91AuthKey == "" || cfg.MSG91SenderID == "" || cfg.MSG91TemplateID == "" {
+		return fmt.Errorf("MSG91 not properly configured")
+	}
 
+	mobile, err := normalizeIndianMobile(phoneNumber)
+	if err != nil {
+		return err
+	}
 
+	data := map[string]interface{}{
+		"template_id": cfg.MSG91TemplateID,
+		"sender":      cfg.MSG91SenderID,
+		"short_url":   "0",
+		"recipients": []map[string]string{
+			{"mobiles": mobile, "otp": otpCode},
+		},
+	}
 
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %w", err)
+	}
 
+	req, err := http.NewRequest("POST", msg91FlowURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("authkey", cfg.MSG91AuthKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
 
+	client := &http.Client{Timeout: smsRequestTimeout}
+	resp, err := doWithBreaker(providerMSG91, client, req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS request: %w", err)
+	}
+	defer resp.Body.Close()
 
+	// MSG91 answers {"type": "success"|"error", "message": "..."}, sometimes with a 200 for errors
+	var result struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("MSG91 API returned an unreadable response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Type != "success" {
+		return fmt.Errorf("MSG91 API error (status %d): %s", resp.StatusCode, result.Message)
+	}
 
+	return nil
+}
 
+// normalizeIndianMobile converts a phone number to the 91XXXXXXXXXX format MSG91 expects
+func normalizeIndianMobile(phoneNumber string) (string, error) {
+	digits := util.NormalizeIdentifier(phoneNumber)
 
+	switch {
+	case len(digits) == 10:
+		digits = "91" + digits
+	case len(digits) == 11 && strings.HasPrefix(digits, "0"):
+		digits = "91" + digits[1:]
+	case len(digits) == 12 && strings.HasPrefix(digits, "91"):
+	default:
+		return "", fmt.Errorf("%s is not an Indian mobile number", phoneNumber)
+	}
 
+	// Indian mobile numbers start with 6-9 after the country code
+	if digits[2] < '6' {
+		return "", fmt.Errorf("%s is not an Indian mobile number", phoneNumber)
+	}
+	return digits, nil
+}
 
-
+// IsEnabled returns whether SMS service is enabled
+func (s *SMSService) IsEnabled() bool {
+	return config.FeatureFlag(config.FeatureSMS)
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+	"springstreet/internal/util"
+)
+
+// resetProviderBreakers gives every provider a fresh circuit breaker, before and after the test
+func resetProviderBreakers(t *testing.T) {
+	reset := func() {
+		providerBreakersMu.Lock()
+		providerBreakers = make(map[string]*util.CircuitBreaker)
+		providerBreakersMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// newMSG91Service returns an SMS service using MSG91 with its Flow API served by handler
+func newMSG91Service(t *testing.T, handler http.HandlerFunc) *SMSService {
+	t.Helper()
+	t.Setenv("SMS_ENABLED", "true")
+	t.Setenv("SMS_PROVIDER", "msg91")
+	t.Setenv("MSG91_AUTH_KEY", "test-auth-key")
+	t.Setenv("MSG91_SENDER_ID", "SPRSTR")
+	t.Setenv("MSG91_TEMPLATE_ID", "template-1")
+	loadTestConfig(t)
+	resetProviderBreakers(t)
+
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	flowURL := msg91FlowURL
+	msg91FlowURL = ts.URL
+	t.Cleanup(func() { msg91FlowURL = flowURL })
+
+	return NewSMSService(&config.Get().SMS, nil)
+}
+
+func TestSendOTPViaMSG91(t *testing.T) {
+	var request struct {
+		TemplateID string              `json:"template_id"`
+		Sender     string              `json:"sender"`
+		Recipients []map[string]string `json:"recipients"`
+	}
+	var authKey string
+	s := newMSG91Service(t, func(w http.ResponseWriter, r *http.Request) {
+		authKey = r.Header.Get("authkey")
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"type":"success","message":"3763646c3058373530393831"}`))
+	})
+
+	if err := s.SendOTP("098765 43210", "123456", "en"); err != nil {
+		t.Fatalf("SendOTP: %v", err)
+	}
+	if authKey != "test-auth-key" {
+		t.Errorf("authkey header = %q, want the configured key", authKey)
+	}
+	if request.TemplateID != "template-1" || request.Sender != "SPRSTR" {
+		t.Errorf("template_id = %q, sender = %q, want the configured template and sender", request.TemplateID, request.Sender)
+	}
+	if len(request.Recipients) != 1 || request.Recipients[0]["mobiles"] != "919876543210" || request.Recipients[0]["otp"] != "123456" {
+		t.Errorf("recipients = %v, want 919876543210 with the code", request.Recipients)
+	}
+}
+
+func TestSendOTPViaMSG91APIError(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		body   string
+	}{
+		{http.StatusOK, `{"type":"error","message":"Invalid template"}`},
+		{http.StatusUnauthorized, `{"type":"error","message":"Authentication failure"}`},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`},
+	} {
+		s := newMSG91Service(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte(tc.body))
+		})
+
+		err := s.SendOTP("+919876543210", "123456", "en")
+		if err == nil || !strings.Contains(err.Error(), "MSG91 API") {
+			t.Errorf("status %d, body %s: SendOTP = %v, want an MSG91 API error", tc.status, tc.body, err)
+		}
+	}
+}
+
+func TestSendOTPViaMSG91Timeout(t *testing.T) {
+	timeout := smsRequestTimeout
+	smsRequestTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { smsRequestTimeout = timeout })
+
+	release := make(chan struct{})
+	s := newMSG91Service(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	err := s.SendOTP("+919876543210", "123456", "en")
+	if err == nil || !strings.Contains(err.Error(), "failed to send SMS request") {
+		t.Fatalf("SendOTP = %v, want a request error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("SendOTP took %v, want it cut off by the request timeout", elapsed)
+	}
+}
+
+func TestSendOTPRejectsNonIndianNumberForMSG91(t *testing.T) {
+	s := newMSG91Service(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("MSG91 was called for a number outside India")
+	})
+
+	if err := s.SendOTP("+14155552671", "123456", "en"); err == nil {
+		t.Fatal("SendOTP accepted a US number for MSG91")
+	}
+}
+
+func TestSendOTPConsoleIsTheDefault(t *testing.T) {
+	t.Setenv("SMS_ENABLED", "true")
+	loadTestConfig(t)
+	s := NewSMSService(&config.Get().SMS, nil)
+
+	if s.cfg.Provider != "console" {
+		t.Fatalf("default SMS provider = %q, want console", s.cfg.Provider)
+	}
+	if err := s.SendOTP("+919876543210", "123456", "en"); err != nil {
+		t.Fatalf("SendOTP: %v", err)
+	}
+}
+
+// newTwilioSMSService returns an SMS service using Twilio, with its API served by handler
+func newTwilioSMSService(t *testing.T, handler http.HandlerFunc) *SMSService {
+	t.Helper()
+	t.Setenv("SMS_ENABLED", "true")
+	t.Setenv("SMS_PROVIDER", "twilio")
+	t.Setenv("TWILIO_ACCOUNT_SID", "AC00000000000000000000000000000000")
+	t.Setenv("TWILIO_AUTH_TOKEN", "test-auth-token")
+	t.Setenv("TWILIO_PHONE_NUMBER", "+15005550006")
+	t.Setenv("TWILIO_STATUS_CALLBACK_URL", "https://api.example.com/api/v1/otp/delivery-callback")
+	db := newTestDB(t)
+	resetProviderBreakers(t)
+
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	baseURL := twilioAPIBaseURL
+	twilioAPIBaseURL = ts.URL
+	t.Cleanup(func() { twilioAPIBaseURL = baseURL })
+
+	return NewSMSService(&config.Get().SMS, db)
+}
+
+func TestSendOTPViaTwilio(t *testing.T) {
+	var contentType, path, user, password string
+	var form map[string][]string
+	s := newTwilioSMSService(t, func(w http.ResponseWriter, r *http.Request) {
+		contentType, path = r.Header.Get("Content-Type"), r.URL.Path
+		user, password, _ = r.BasicAuth()
+		r.ParseForm()
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM0123456789abcdef0123456789abcdef","status":"queued"}`))
+	})
+
+	if err := s.SendOTP("9876543210", "123456", "en"); err != nil {
+		t.Fatalf("SendOTP: %v", err)
+	}
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q, want a form", contentType)
+	}
+	if path != "/Accounts/AC00000000000000000000000000000000/Messages.json" {
+		t.Errorf("path = %q, want the account's Messages resource", path)
+	}
+	if user != "AC00000000000000000000000000000000" || password != "test-auth-token" {
+		t.Errorf("basic auth = %s:%s, want the account SID and token", user, password)
+	}
+	if form["To"][0] != "+919876543210" || form["From"][0] != "+15005550006" || !strings.Contains(form["Body"][0], "123456") {
+		t.Errorf("form = %v, want To, From and a Body with the code", form)
+	}
+	if form["StatusCallback"][0] != "https://api.example.com/api/v1/otp/delivery-callback" {
+		t.Errorf("StatusCallback = %v, want the configured callback URL", form["StatusCallback"])
+	}
+
+	// The message SID is kept so delivery callbacks can be matched to the OTP
+	var delivery domain.OTPDelivery
+	if err := s.db.Where("message_sid = ?", "SM0123456789abcdef0123456789abcdef").First(&delivery).Error; err != nil {
+		t.Fatalf("delivery for the message SID: %v", err)
+	}
+	if delivery.Recipient != "9876543210" || delivery.Status != "queued" {
+		t.Errorf("delivery = %+v, want recipient 9876543210 and status queued", delivery)
+	}
+}
+
+func TestSendOTPViaTwilioAPIError(t *testing.T) {
+	s := newTwilioSMSService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number.","status":400}`))
+	})
+
+	err := s.SendOTP("+919876543210", "123456", "en")
+	var apiErr *twilioAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("SendOTP = %v, want a Twilio API error", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != 21211 || !strings.Contains(apiErr.Message, "not a valid phone number") {
+		t.Fatalf("error = %+v, want status 400, code 21211 and Twilio's message", apiErr)
+	}
+}
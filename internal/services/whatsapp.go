@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"springstreet/internal/config"
+	"springstreet/internal/util"
+)
+
+// metaGraphURL is the WhatsApp Cloud API base URL
+var metaGraphURL = "https://graph.facebook.com/v21.0/"
+
+// WhatsAppService handles sending OTP codes over WhatsApp.
+// WhatsApp only allows business-initiated messages that use a pre-approved template, so the
+// message text lives in the template and only the code is sent as its variable.
+type WhatsAppService struct {
+	mu     sync.RWMutex
+	cfg    *config.WhatsAppConfig
+	twilio *config.SMSConfig // Account credentials for the Twilio provider
+}
+
+// NewWhatsAppService creates a new WhatsApp service
+func NewWhatsAppService(cfg *config.WhatsAppConfig, smsCfg *config.SMSConfig) *WhatsAppService {
+	return &WhatsAppService{cfg: cfg, twilio: smsCfg}
+}
+
+// UpdateConfig replaces the WhatsApp and Twilio account configuration (used when secrets are rotated)
+func (s *WhatsAppService) UpdateConfig(cfg *config.WhatsAppConfig, smsCfg *config.SMSConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg, s.twilio = cfg, smsCfg
+}
+
+// config returns the current WhatsApp and Twilio account configuration
+func (s *WhatsAppService) config() (*config.WhatsAppConfig, *config.SMSConfig) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg, s.twilio
+}
+
+// SendOTP sends an OTP code via WhatsApp
+func (s *WhatsAppService) SendOTP(phoneNumber, otpCode string) error {
+	cfg, _ := s.config()
+	if !s.IsEnabled() {
+		// In development mode, just log
+		fmt.Printf("[WhatsApp] OTP would be sent to %s: %s\n", phoneNumber, util.LoggableOTP(otpCode))
+		return nil
+	}
+
+	recipient, err := s.recipientNumber(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "twilio":
+		return s.sendViaTwilio(recipient, otpCode)
+	case "meta":
+		return s.sendViaMeta(recipient, otpCode)
+	case "console", "dev", "development":
+		// Development mode - just log
+		fmt.Printf("[WhatsApp] OTP would be sent to %s: %s\n", phoneNumber, util.LoggableOTP(otpCode))
+		return nil
+	default:
+		return fmt.Errorf("unsupported WhatsApp provider: %s", cfg.Provider)
+	}
+}
+
+// recipientNumber converts a phone number to international digits without the leading +.
+// Numbers entered without a country code get the configured default.
+func (s *WhatsAppService) recipientNumber(phoneNumber string) (string, error) {
+	cfg, _ := s.config()
+	digits := util.NormalizeIdentifier(phoneNumber)
+	if !strings.HasPrefix(strings.TrimSpace(phoneNumber), "+") {
+		switch {
+		case len(digits) == 10:
+			digits = cfg.DefaultCountryCode + digits
+		case len(digits) == 11 && strings.HasPrefix(digits, "0"):
+			digits = cfg.DefaultCountryCode + digits[1:]
+		}
+	}
+	// E.164 allows at most 15 digits
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", fmt.Errorf("%s is not a valid international phone number", phoneNumber)
+	}
+	return digits, nil
+}
+
+// sendViaTwilio sends a content template through Twilio's WhatsApp sender
+func (s *WhatsAppService) sendViaTwilio(recipient, otpCode string) error {
+	cfg, twilio := s.config()
+	if twilio.TwilioSID == "" || twilio.TwilioAuth == "" || cfg.TwilioFrom == "" || cfg.TwilioContentSID == "" {
+		return fmt.Errorf("Twilio WhatsApp not properly configured")
+	}
+
+	variables, err := json.Marshal(map[string]string{"1": otpCode})
+	if err != nil {
+		return fmt.Errorf("failed to marshal template variables: %w", err)
+	}
+
+	from := cfg.TwilioFrom
+	if !strings.HasPrefix(from, "+") {
+		from = "+" + from
+	}
+	form := url.Values{
+		"From":             {"whatsapp:" + from},
+		"To":               {"whatsapp:+" + recipient},
+		"ContentSid":       {cfg.TwilioContentSID},
+		"ContentVariables": {string(variables)},
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIBaseURL, twilio.TwilioSID)
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(twilio.TwilioSID, twilio.TwilioAuth)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: smsRequestTimeout}
+	resp, err := doWithBreaker(providerTwilioWhatsApp, client, req)
+	if err != nil {
+		return fmt.Errorf("failed to send WhatsApp request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		var errorResp map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&errorResp)
+		return fmt.Errorf("Twilio WhatsApp API error (status %d): %v", resp.StatusCode, errorResp)
+	}
+
+	return nil
+}
+
+// sendViaMeta sends an authentication template through the WhatsApp Cloud API.
+// Authentication templates take the code as the single body parameter and again for the
+// copy-code button.
+func (s *WhatsAppService) sendViaMeta(recipient, otpCode string) error {
+	cfg, _ := s.config()
+	if cfg.MetaPhoneNumberID == "" || cfg.MetaAccessToken == "" || cfg.TemplateName == "" {
+		return fmt.Errorf("WhatsApp Cloud API not properly configured")
+	}
+
+	codeParam := []map[string]string{{"type": "text", "text": otpCode}}
+	data := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                recipient,
+		"type":              "template",
+		"template": map[string]interface{}{
+			"name":     cfg.TemplateName,
+			"language": map[string]string{"code": cfg.TemplateLanguage},
+			"components": []map[string]interface{}{
+				{"type": "body", "parameters": codeParam},
+				{"type": "button", "sub_type": "url", "index": "0", "parameters": codeParam},
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", metaGraphURL+cfg.MetaPhoneNumberID+"/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.MetaAccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: smsRequestTimeout}
+	resp, err := doWithBreaker(providerMetaWhatsApp, client, req)
+	if err != nil {
+		return fmt.Errorf("failed to send WhatsApp request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResp struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errorResp)
+		return fmt.Errorf("WhatsApp Cloud API error (status %d): %s", resp.StatusCode, errorResp.Error.Message)
+	}
+
+	return nil
+}
+
+// IsEnabled returns whether WhatsApp delivery is enabled
+func (s *WhatsAppService) IsEnabled() bool {
+	return config.FeatureFlag(config.FeatureWhatsApp)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"springstreet/internal/metrics"
+)
+
+// WorkerPool runs background tasks on a fixed number of goroutines.
+// Tasks wait in a bounded queue; when it is full new tasks are dropped so request
+// handlers never block on background work.
+type WorkerPool struct {
+	jobs   chan func()
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewWorkerPool starts size workers reading from a queue of queueSize tasks
+func NewWorkerPool(size, queueSize int) *WorkerPool {
+	if size < 1 {
+		size = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &WorkerPool{jobs: make(chan func(), queueSize)}
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	log.Printf("[WORKER] Started %d workers with a queue of %d", size, queueSize)
+	return p
+}
+
+// Submit queues job without blocking. It returns false if the pool is shut down or the queue is full.
+func (p *WorkerPool) Submit(job func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		log.Println("[WORKER] Warning: pool is shut down, dropping task")
+		metrics.RecordWorkerPoolTask("dropped")
+		return false
+	}
+
+	select {
+	case p.jobs <- job:
+		metrics.SetWorkerPoolQueueDepth(len(p.jobs))
+		return true
+	default:
+		log.Printf("[WORKER] Warning: queue full (%d tasks), dropping task", cap(p.jobs))
+		metrics.RecordWorkerPoolTask("dropped")
+		return false
+	}
+}
+
+// Shutdown stops accepting tasks and waits for queued ones to finish or for ctx to expire
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		metrics.SetWorkerPoolQueueDepth(len(p.jobs))
+		p.run(job)
+	}
+}
+
+// run executes a task, recovering from panics so one bad task doesn't take down a worker
+func (p *WorkerPool) run(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[WORKER] Task panicked: %v", r)
+			metrics.RecordWorkerPoolTask("panicked")
+		}
+	}()
+	job()
+	metrics.RecordWorkerPoolTask("completed")
+}
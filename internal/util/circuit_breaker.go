@@ -0,0 +1,173 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Execute while calls are being rejected
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+// Circuit breaker states; the values are exported as the circuit_breaker_state gauge
+const (
+	CircuitClosed   CircuitState = 0
+	CircuitOpen     CircuitState = 1
+	CircuitHalfOpen CircuitState = 2
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreaker stops calling a failing dependency so callers fail fast instead of waiting
+// for timeouts. After failureThreshold consecutive failures it opens and rejects calls. Once
+// resetTimeout has passed it lets up to halfOpenProbes calls through; if they all succeed it
+// closes again, and any failure reopens it.
+type CircuitBreaker struct {
+	name             string
+	failureThreshold int
+	resetTimeout     time.Duration
+	halfOpenProbes   int
+	onStateChange    func(name string, state CircuitState)
+
+	mu             sync.Mutex
+	state          CircuitState
+	failures       int
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+// Non-positive thresholds and probe counts are treated as 1.
+func NewCircuitBreaker(name string, failureThreshold int, resetTimeout time.Duration, halfOpenProbes int) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	if halfOpenProbes < 1 {
+		halfOpenProbes = 1
+	}
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		resetTimeout:     resetTimeout,
+		halfOpenProbes:   halfOpenProbes,
+	}
+}
+
+// OnStateChange registers a function called whenever the breaker changes state
+func (cb *CircuitBreaker) OnStateChange(fn func(name string, state CircuitState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.onStateChange = fn
+}
+
+// State returns the current state
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Execute runs fn unless the breaker is open, and records whether it succeeded.
+// While open it returns an error wrapping ErrCircuitOpen without calling fn.
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	if !cb.allow() {
+		return fmt.Errorf("%s: %w", cb.name, ErrCircuitOpen)
+	}
+
+	err := fn()
+	cb.record(err == nil)
+	return err
+}
+
+// allow reports whether a call may proceed, moving an open breaker to half-open once the
+// reset timeout has passed
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.resetTimeout {
+			return false
+		}
+		cb.setState(CircuitHalfOpen)
+		cb.probesInFlight = 1
+		return true
+	case CircuitHalfOpen:
+		if cb.probesInFlight+cb.probeSuccesses >= cb.halfOpenProbes {
+			return false
+		}
+		cb.probesInFlight++
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a call
+func (cb *CircuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if cb.probesInFlight > 0 {
+			cb.probesInFlight--
+		}
+		if !success {
+			cb.trip()
+			return
+		}
+		cb.probeSuccesses++
+		if cb.probeSuccesses >= cb.halfOpenProbes {
+			cb.failures = 0
+			cb.setState(CircuitClosed)
+		}
+	case CircuitClosed:
+		if success {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.failureThreshold {
+			cb.trip()
+		}
+	default:
+		// A call that started before the breaker opened; its outcome no longer matters
+	}
+}
+
+// trip opens the breaker. Callers must hold mu.
+func (cb *CircuitBreaker) trip() {
+	cb.openedAt = time.Now()
+	cb.setState(CircuitOpen)
+}
+
+// setState switches state, resets the probe counters and notifies the listener. Callers must hold mu.
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	cb.probesInFlight = 0
+	cb.probeSuccesses = 0
+	if cb.state == state {
+		return
+	}
+	cb.state = state
+	if cb.onStateChange != nil {
+		cb.onStateChange(cb.name, state)
+	}
+}
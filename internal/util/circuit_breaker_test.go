@@ -0,0 +1,117 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errProvider = errors.New("provider unavailable")
+
+// failing and succeeding are calls for CircuitBreaker.Execute
+func failing() error    { return errProvider }
+func succeeding() error { return nil }
+
+// newTestBreaker returns a breaker with a short reset timeout and the states it moved through
+func newTestBreaker(failureThreshold, halfOpenProbes int) (*CircuitBreaker, *[]CircuitState) {
+	cb := NewCircuitBreaker("test", failureThreshold, 20*time.Millisecond, halfOpenProbes)
+	var states []CircuitState
+	cb.OnStateChange(func(name string, state CircuitState) { states = append(states, state) })
+	return cb, &states
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	cb, states := newTestBreaker(3, 1)
+
+	cb.Execute(failing)
+	cb.Execute(failing)
+	// A success in between starts the count again
+	cb.Execute(succeeding)
+	cb.Execute(failing)
+	cb.Execute(failing)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after 2 consecutive failures, want closed", cb.State())
+	}
+
+	if err := cb.Execute(failing); !errors.Is(err, errProvider) {
+		t.Fatalf("Execute = %v, want the call's own error", err)
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s after 3 consecutive failures, want open", cb.State())
+	}
+
+	called := false
+	err := cb.Execute(func() error { called = true; return nil })
+	if !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("Execute while open = %v (called %v), want ErrCircuitOpen without calling", err, called)
+	}
+	if len(*states) != 1 || (*states)[0] != CircuitOpen {
+		t.Fatalf("state changes = %v, want [open]", *states)
+	}
+}
+
+func TestCircuitBreakerClosesAfterSuccessfulProbes(t *testing.T) {
+	cb, states := newTestBreaker(1, 2)
+	cb.Execute(failing)
+
+	time.Sleep(30 * time.Millisecond)
+	if err := cb.Execute(succeeding); err != nil {
+		t.Fatalf("first probe: %v", err)
+	}
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s after 1 of 2 probes, want half-open", cb.State())
+	}
+	if err := cb.Execute(succeeding); err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after 2 successful probes, want closed", cb.State())
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(*states) != len(want) {
+		t.Fatalf("state changes = %v, want %v", *states, want)
+	}
+	for i := range want {
+		if (*states)[i] != want[i] {
+			t.Fatalf("state changes = %v, want %v", *states, want)
+		}
+	}
+}
+
+func TestCircuitBreakerReopensOnFailedProbe(t *testing.T) {
+	cb, _ := newTestBreaker(1, 1)
+	cb.Execute(failing)
+
+	time.Sleep(30 * time.Millisecond)
+	if err := cb.Execute(failing); !errors.Is(err, errProvider) {
+		t.Fatalf("probe = %v, want the call's own error", err)
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s after a failed probe, want open", cb.State())
+	}
+	// The reset timeout starts again from the failed probe
+	if err := cb.Execute(succeeding); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Execute right after a failed probe = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerLimitsHalfOpenProbes(t *testing.T) {
+	cb, _ := newTestBreaker(1, 1)
+	cb.Execute(failing)
+	time.Sleep(30 * time.Millisecond)
+
+	// While the probe is in flight, other calls are rejected
+	err := cb.Execute(func() error {
+		if err := cb.Execute(succeeding); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Execute during the probe = %v, want ErrCircuitOpen", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Fatalf("state = %s after the probe succeeded, want closed", cb.State())
+	}
+}
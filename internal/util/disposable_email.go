@@ -0,0 +1,60 @@
+package util
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed disposable_email_domains.txt
+var disposableDomainList string
+
+var (
+	disposableDomains    map[string]struct{}
+	disposableDomainOnce sync.Once
+)
+
+// LoadDisposableDomains builds the disposable domain set from the built-in list plus extra.
+// Only the first call has any effect; call it at startup so configured domains are included.
+func LoadDisposableDomains(extra []string) {
+	disposableDomainOnce.Do(func() {
+		disposableDomains = make(map[string]struct{})
+		for _, line := range strings.Split(disposableDomainList, "\n") {
+			addDisposableDomain(line)
+		}
+		for _, domain := range extra {
+			addDisposableDomain(domain)
+		}
+	})
+}
+
+func addDisposableDomain(domain string) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" || strings.HasPrefix(domain, "#") {
+		return
+	}
+	disposableDomains[domain] = struct{}{}
+}
+
+// IsDisposableEmail reports whether email belongs to a disposable email provider.
+// Subdomains of a listed domain count as disposable too.
+func IsDisposableEmail(email string) bool {
+	LoadDisposableDomains(nil)
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(email[at+1:])), ".")
+	for domain != "" {
+		if _, ok := disposableDomains[domain]; ok {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return false
+}
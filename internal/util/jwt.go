@@ -21,9 +21,17 @@ type Claims struct {
 	Username string `json:"sub"`
 	IsAdmin  bool   `json:"is_admin"`
 	IsStaff  bool   `json:"is_staff"`
+	OrgID    uint   `json:"org_id,omitempty"`  // The user's organization; tokens issued before multi-tenancy have none
+	Purpose  string `json:"purpose,omitempty"` // Set on single-purpose tokens, which are never accepted as access tokens
 	jwt.RegisteredClaims
 }
 
+// Token purposes
+const (
+	TokenPurposeTOTPChallenge  = "totp_challenge"  // Returned by Login while a TOTP code is still needed
+	TokenPurposeTOTPEnrollment = "totp_enrollment" // Returned by a login refused until the user enrolls TOTP
+)
+
 // GenerateToken generates a JWT token for a user
 func GenerateToken(user *domain.User) (string, error) {
 	cfg := config.Get()
@@ -33,6 +41,7 @@ func GenerateToken(user *domain.User) (string, error) {
 		Username: user.Username,
 		IsAdmin:  user.IsAdmin,
 		IsStaff:  user.IsStaff,
+		OrgID:    user.OrganizationID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -49,8 +58,55 @@ func GenerateToken(user *domain.User) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// GeneratePurposeToken generates a short-lived token for user that is only accepted by
+// ValidatePurposeToken with the same purpose
+func GeneratePurposeToken(user *domain.User, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		Username: user.Username,
+		OrgID:    user.OrganizationID,
+		Purpose:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(config.Get().Auth.SecretKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// ValidateToken validates an access token and returns the claims
 func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ValidatePurposeToken validates a token generated by GeneratePurposeToken for purpose
+func ValidatePurposeToken(tokenString, purpose string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// parseToken checks tokenString's signature and expiry and returns its claims
+func parseToken(tokenString string) (*Claims, error) {
 	cfg := config.Get()
 	claims := &Claims{}
 	
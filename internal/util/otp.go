@@ -1,51 +1,212 @@
 package util
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"math/big"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
+
+	"springstreet/internal/config"
 )
 
 const (
-	OTPValidityMinutes      = 10
-	OTPLength               = 6
 	MaxVerificationAttempts = 3
 	RateLimitMinutes        = 1
 	MaxRequestsPerMinute    = 5 // Maximum OTP requests allowed per minute
+	DailyLimitWindow        = 24 * time.Hour
 )
 
 // OTPSession represents an OTP session
 type OTPSession struct {
-	OTP            string
-	CreatedAt      time.Time
-	ExpiresAt      time.Time
-	Attempts       int
-	Verified       bool
-	Email          string // Email associated with this session
-	PhoneNumber    string // Phone number associated with this session
+	Identifier  string // Normalized primary identifier the session is keyed by
+	OTPHash     string // HMAC-SHA256 of the code, used for verification
+	OTPCipher   string // AES-GCM encrypted code, only decrypted to resend it
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	Attempts    int
+	Verified    bool
+	Email       string // Email associated with this session
+	PhoneNumber string // Phone number associated with this session
+	ResendCount int    // Number of times the code has been resent
+	LastSentAt  time.Time
+}
+
+// OTPResend is a live session's code ready to be delivered again
+type OTPResend struct {
+	Code         string
+	Identifier   string
+	Email        string
+	PhoneNumber  string
+	ExpiresAt    time.Time
+	NextResendAt time.Time
 }
 
+// OTPPeek is the pending code of a live session, for debugging OTP flows
+type OTPPeek struct {
+	Code       string
+	Identifier string
+	Attempts   int
+	ExpiresAt  time.Time
+}
+
+// ErrOTPDailyLimit means an identifier has requested the configured maximum of codes in the last 24 hours
+var ErrOTPDailyLimit = errors.New("daily OTP limit reached")
+
+// ErrNoResendableSession means there is no unexpired, unverified session whose code can be resent,
+// so a fresh code has to be generated instead
+var ErrNoResendableSession = errors.New("no resendable OTP session")
+
 var (
-	otpStorage      = make(map[string]*OTPSession)
-	rateLimitStore  = make(map[string][]time.Time) // Track request timestamps for rate limiting
-	mu              sync.RWMutex
+	otpStore       OTPStore       = newMemoryOTPStore(0)
+	otpRateLimiter OTPRateLimiter = newMemoryOTPRateLimiter()
+	mu             sync.RWMutex
 )
 
-// GenerateOTP generates a random 6-digit OTP
-func GenerateOTP() (string, error) {
-	bytes := make([]byte, OTPLength)
-	if _, err := rand.Read(bytes); err != nil {
+// SetOTPStore replaces the backend used to persist OTP sessions
+func SetOTPStore(store OTPStore) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	otpStore = store
+}
+
+// SetOTPRateLimiter replaces the backend used to rate limit OTP requests
+func SetOTPRateLimiter(limiter OTPRateLimiter) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	otpRateLimiter = limiter
+}
+
+// OTPCodeFormat is the alphabet and length of generated codes
+type OTPCodeFormat struct {
+	Alphabet string
+	Length   int
+}
+
+// PhoneOTPFormat returns the configured format of codes delivered by SMS or WhatsApp (6 digits by default)
+func PhoneOTPFormat() OTPCodeFormat {
+	cfg := config.Get().OTP
+	return OTPCodeFormat{Alphabet: cfg.PhoneCodeAlphabet, Length: cfg.PhoneCodeLength}
+}
+
+// EmailOTPFormat returns the configured format of codes delivered only by email
+// (8 letters and digits by default, as email isn't typed off a phone keypad)
+func EmailOTPFormat() OTPCodeFormat {
+	cfg := config.Get().OTP
+	return OTPCodeFormat{Alphabet: cfg.EmailCodeAlphabet, Length: cfg.EmailCodeLength}
+}
+
+// GenerateOTP generates a random code in format. Each character is drawn uniformly from the
+// alphabet with crypto/rand, without modulo bias.
+func GenerateOTP(format OTPCodeFormat) (string, error) {
+	max := big.NewInt(int64(len(format.Alphabet)))
+	otp := make([]byte, format.Length)
+	for i := range otp {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		otp[i] = format.Alphabet[n.Int64()]
+	}
+	return string(otp), nil
+}
+
+// NormalizeOTPCode prepares an entered code for comparison; letter codes are case-insensitive
+func NormalizeOTPCode(otp string) string {
+	return strings.ToUpper(strings.TrimSpace(otp))
+}
+
+// hashOTP returns the HMAC-SHA256 of an OTP code keyed with the application secret,
+// so a leaked session store does not reveal usable codes
+func hashOTP(otp string) string {
+	mac := hmac.New(sha256.New, []byte(config.Get().Auth.SecretKey))
+	mac.Write([]byte(otp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PlaintextOTPLogging reports whether OTP codes may be logged, which is only for local
+// development with DEBUG and OTP_LOG_PLAINTEXT both on
+func PlaintextOTPLogging() bool {
+	cfg := config.Get()
+	return cfg.App.Debug && cfg.OTP.LogPlaintext
+}
+
+// LoggableOTP returns the code itself only when plaintext logging is enabled, and a
+// placeholder otherwise
+func LoggableOTP(otp string) string {
+	if PlaintextOTPLogging() {
+		return otp
+	}
+	return "[redacted]"
+}
+
+// otpCipherKey derives the AES-256 key used to encrypt codes for resending
+func otpCipherKey() []byte {
+	key := sha256.Sum256([]byte("otp-resend:" + config.Get().Auth.SecretKey))
+	return key[:]
+}
+
+// sealOTP encrypts an OTP code so it can be resent without storing it in plaintext
+func sealOTP(otp string) (string, error) {
+	return sealString(otpCipherKey(), otp)
+}
+
+// openOTP decrypts a code sealed by sealOTP
+func openOTP(sealed string) (string, error) {
+	return openString(otpCipherKey(), sealed)
+}
+
+// sealString encrypts plaintext with AES-GCM under key, prefixing the random nonce
+func sealString(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
 		return "", err
 	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
 
-	otp := ""
-	for i := 0; i < OTPLength; i++ {
-		otp += fmt.Sprintf("%d", bytes[i]%10)
+// openString decrypts a value sealed by sealString under the same key
+func openString(key []byte, sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
 	}
-	return otp, nil
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("sealed value is too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
 }
 
 // NormalizeIdentifier normalizes phone number or email
@@ -61,44 +222,68 @@ func NormalizeIdentifier(identifier string) string {
 }
 
 // checkRateLimit checks if the identifier has exceeded the rate limit
-// Returns true if rate limit is exceeded, false otherwise
+// Returns an error if the rate limit is exceeded, nil otherwise
 func checkRateLimit(normalized string) error {
-	now := time.Now()
-	oneMinuteAgo := now.Add(-RateLimitMinutes * time.Minute)
+	return otpRateLimiter.Allow(normalized, time.Now())
+}
 
-	// Get existing request timestamps
-	requests, exists := rateLimitStore[normalized]
-	if !exists {
-		// First request, initialize
-		rateLimitStore[normalized] = []time.Time{now}
-		return nil
-	}
+// rateLimitError builds the error returned when an identifier must wait before requesting again
+func rateLimitError(wait time.Duration) error {
+	return fmt.Errorf("rate limit exceeded: maximum %d OTP requests per minute. Please wait %v before requesting again", MaxRequestsPerMinute, wait.Round(time.Second))
+}
 
-	// Remove requests older than 1 minute
-	validRequests := []time.Time{}
-	for _, reqTime := range requests {
-		if reqTime.After(oneMinuteAgo) {
-			validRequests = append(validRequests, reqTime)
+// dailyLimit returns the number of codes an identifier may request per DailyLimitWindow (0 disables the cap)
+func dailyLimit() int {
+	return config.Get().OTP.DailyLimit
+}
+
+// dailyLimitError builds the error returned when an identifier has used up its daily OTP requests
+func dailyLimitError(limit int) error {
+	return fmt.Errorf("%w: maximum %d OTP requests per 24 hours. Please try again tomorrow", ErrOTPDailyLimit, limit)
+}
+
+// checkRateLimits applies the rate limit to the primary identifier and to the email and phone,
+// so switching which one is primary doesn't get around it
+func checkRateLimits(normalized, email, phone string) error {
+	identifiersToCheck := []string{normalized}
+	if email != "" {
+		normalizedEmail := NormalizeIdentifier(email)
+		if normalizedEmail != normalized {
+			identifiersToCheck = append(identifiersToCheck, normalizedEmail)
 		}
 	}
-
-	// Check if we've exceeded the limit
-	if len(validRequests) >= MaxRequestsPerMinute {
-		oldestRequest := validRequests[0]
-		timeUntilNextAllowed := oldestRequest.Add(RateLimitMinutes * time.Minute).Sub(now)
-		if timeUntilNextAllowed > 0 {
-			return fmt.Errorf("rate limit exceeded: maximum %d OTP requests per minute. Please wait %v before requesting again", MaxRequestsPerMinute, timeUntilNextAllowed.Round(time.Second))
+	if phone != "" {
+		normalizedPhone := NormalizeIdentifier(phone)
+		if normalizedPhone != normalized {
+			identifiersToCheck = append(identifiersToCheck, normalizedPhone)
 		}
 	}
 
-	// Add current request
-	validRequests = append(validRequests, now)
-	rateLimitStore[normalized] = validRequests
+	// Check rate limit for all identifiers (use the most restrictive)
+	for _, id := range identifiersToCheck {
+		if err := checkRateLimit(id); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// CreateOTPSession creates a new OTP session
-func CreateOTPSession(identifier string) (string, string, error) {
+// ReserveOTPRequest counts an OTP request against the rate limits without creating a session,
+// for codes generated and stored by an external provider. Returns the normalized primary identifier.
+func ReserveOTPRequest(primaryIdentifier, email, phone string) (string, error) {
+	normalized := NormalizeIdentifier(primaryIdentifier)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := checkRateLimits(normalized, email, phone); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// CreateOTPSession creates a new OTP session with a code in format
+func CreateOTPSession(identifier string, format OTPCodeFormat) (string, string, error) {
 	normalized := NormalizeIdentifier(identifier)
 
 	mu.Lock()
@@ -110,60 +295,60 @@ func CreateOTPSession(identifier string) (string, string, error) {
 	}
 
 	// Generate OTP
-	otp, err := GenerateOTP()
+	otp, err := GenerateOTP(format)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate OTP: %w", err)
 	}
 
+	sealed, err := sealOTP(otp)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt OTP: %w", err)
+	}
+
 	// Create session
 	now := time.Now()
-	otpStorage[normalized] = &OTPSession{
-		OTP:       otp,
-		CreatedAt: now,
-		ExpiresAt: now.Add(OTPValidityMinutes * time.Minute),
-		Attempts:  0,
-		Verified:  false,
+	session := &OTPSession{
+		Identifier: normalized,
+		OTPHash:    hashOTP(otp),
+		OTPCipher:  sealed,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(otpValidity()),
+		Attempts:   0,
+		Verified:   false,
+		LastSentAt: now,
+	}
+
+	if err := otpStore.Save(session); err != nil {
+		return "", "", err
 	}
 
 	return otp, normalized, nil
 }
 
 // CreateOTPSessionWithBoth creates a new OTP session with both email and phone
-// The primary identifier is used as the key, but both email and phone are stored
-func CreateOTPSessionWithBoth(primaryIdentifier, email, phone string) (string, string, error) {
+// The primary identifier is used as the key, but both email and phone are stored.
+// The code is generated in format.
+func CreateOTPSessionWithBoth(primaryIdentifier, email, phone string, format OTPCodeFormat) (string, string, error) {
 	normalized := NormalizeIdentifier(primaryIdentifier)
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	// Check rate limiting (check all identifiers to prevent bypass)
-	identifiersToCheck := []string{normalized}
-	if email != "" {
-		normalizedEmail := NormalizeIdentifier(email)
-		if normalizedEmail != normalized {
-			identifiersToCheck = append(identifiersToCheck, normalizedEmail)
-		}
-	}
-	if phone != "" {
-		normalizedPhone := NormalizeIdentifier(phone)
-		if normalizedPhone != normalized {
-			identifiersToCheck = append(identifiersToCheck, normalizedPhone)
-		}
-	}
-
-	// Check rate limit for all identifiers (use the most restrictive)
-	for _, id := range identifiersToCheck {
-		if err := checkRateLimit(id); err != nil {
-			return "", "", err
-		}
+	if err := checkRateLimits(normalized, email, phone); err != nil {
+		return "", "", err
 	}
 
 	// Generate OTP
-	otp, err := GenerateOTP()
+	otp, err := GenerateOTP(format)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate OTP: %w", err)
 	}
 
+	sealed, err := sealOTP(otp)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt OTP: %w", err)
+	}
+
 	// Normalize email and phone
 	normalizedEmail := ""
 	normalizedPhone := ""
@@ -177,26 +362,105 @@ func CreateOTPSessionWithBoth(primaryIdentifier, email, phone string) (string, s
 	// Create session with both email and phone
 	now := time.Now()
 	session := &OTPSession{
-		OTP:         otp,
+		Identifier:  normalized,
+		OTPHash:     hashOTP(otp),
+		OTPCipher:   sealed,
 		CreatedAt:   now,
-		ExpiresAt:   now.Add(OTPValidityMinutes * time.Minute),
-		Attempts:     0,
+		ExpiresAt:   now.Add(otpValidity()),
+		Attempts:    0,
 		Verified:    false,
 		Email:       normalizedEmail,
 		PhoneNumber: normalizedPhone,
+		LastSentAt:  now,
 	}
 
-	otpStorage[normalized] = session
+	// The store also indexes the session by email and phone
+	if err := otpStore.Save(session); err != nil {
+		return "", "", err
+	}
+
+	return otp, normalized, nil
+}
+
+// ResendOTPSession returns the code of the live session for identifier so it can be delivered again.
+// Resends are limited by a per-session cooldown and count instead of the request rate limit.
+// Returns ErrNoResendableSession when a fresh code should be generated instead.
+func ResendOTPSession(identifier string, cooldown time.Duration, maxResends int) (*OTPResend, error) {
+	normalized := NormalizeIdentifier(identifier)
 
-	// Also store the session by email and phone if they're different from primary
-	if normalizedEmail != "" && normalizedEmail != normalized {
-		otpStorage[normalizedEmail] = session
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		return nil, err
 	}
-	if normalizedPhone != "" && normalizedPhone != normalized && normalizedPhone != normalizedEmail {
-		otpStorage[normalizedPhone] = session
+	now := time.Now()
+	if session == nil || session.Verified || now.After(session.ExpiresAt) || session.OTPCipher == "" {
+		return nil, ErrNoResendableSession
 	}
 
-	return otp, normalized, nil
+	lastSent := session.LastSentAt
+	if lastSent.IsZero() {
+		lastSent = session.CreatedAt
+	}
+	if wait := lastSent.Add(cooldown).Sub(now); wait > 0 {
+		return nil, fmt.Errorf("please wait %d seconds before resending the OTP", int(wait.Round(time.Second).Seconds()))
+	}
+	if session.ResendCount >= maxResends {
+		return nil, fmt.Errorf("maximum of %d OTP resends reached. Please request a new OTP", maxResends)
+	}
+
+	otp, err := openOTP(session.OTPCipher)
+	if err != nil {
+		// Most likely SECRET_KEY changed since the code was sent
+		log.Printf("[OTP] Failed to decrypt OTP for %s: %v", session.Identifier, err)
+		return nil, ErrNoResendableSession
+	}
+
+	session.ResendCount++
+	session.LastSentAt = now
+	if err := otpStore.Update(session); err != nil {
+		return nil, err
+	}
+
+	return &OTPResend{
+		Code:         otp,
+		Identifier:   session.Identifier,
+		Email:        session.Email,
+		PhoneNumber:  session.PhoneNumber,
+		ExpiresAt:    session.ExpiresAt,
+		NextResendAt: now.Add(cooldown),
+	}, nil
+}
+
+// PeekOTPSession returns the pending code of the live session for identifier without changing
+// the session, or nil if there is no unexpired, unverified session with a recoverable code.
+// Only for debug tooling: codes are otherwise never read back except to resend them.
+func PeekOTPSession(identifier string) (*OTPPeek, error) {
+	normalized := NormalizeIdentifier(identifier)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.Verified || time.Now().After(session.ExpiresAt) || session.OTPCipher == "" {
+		return nil, nil
+	}
+
+	otp, err := openOTP(session.OTPCipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt OTP for %s: %w", session.Identifier, err)
+	}
+	return &OTPPeek{
+		Code:       otp,
+		Identifier: session.Identifier,
+		Attempts:   session.Attempts,
+		ExpiresAt:  session.ExpiresAt,
+	}, nil
 }
 
 // VerifyOTPSession verifies an OTP code
@@ -206,8 +470,11 @@ func VerifyOTPSession(identifier, otpCode string) error {
 	mu.Lock()
 	defer mu.Unlock()
 
-	session, exists := otpStorage[normalized]
-	if !exists {
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		return err
+	}
+	if session == nil {
 		return fmt.Errorf("OTP session not found. Please request a new OTP")
 	}
 
@@ -216,28 +483,70 @@ func VerifyOTPSession(identifier, otpCode string) error {
 	}
 
 	if time.Now().After(session.ExpiresAt) {
-		delete(otpStorage, normalized)
+		otpStore.Delete(session)
 		return fmt.Errorf("OTP has expired. Please request a new OTP")
 	}
 
 	if session.Attempts >= MaxVerificationAttempts {
-		delete(otpStorage, normalized)
+		otpStore.Delete(session)
 		return fmt.Errorf("maximum verification attempts exceeded. Please request a new OTP")
 	}
 
-	session.Attempts++
+	// Count the attempt in the store so concurrent verifications (possibly on other replicas) can't exceed the limit
+	attempts, err := otpStore.IncrementAttempts(session)
+	if err != nil {
+		return err
+	}
+	if attempts == 0 {
+		return fmt.Errorf("OTP session not found. Please request a new OTP")
+	}
+	if attempts > MaxVerificationAttempts {
+		otpStore.Delete(session)
+		return fmt.Errorf("maximum verification attempts exceeded. Please request a new OTP")
+	}
+	session.Attempts = attempts
 
-	if session.OTP != otpCode {
+	if !hmac.Equal([]byte(session.OTPHash), []byte(hashOTP(NormalizeOTPCode(otpCode)))) {
 		remaining := MaxVerificationAttempts - session.Attempts
 		if remaining > 0 {
 			return fmt.Errorf("invalid OTP. %d attempt(s) remaining", remaining)
 		}
-		delete(otpStorage, normalized)
+		otpStore.Delete(session)
 		return fmt.Errorf("invalid OTP. Maximum attempts exceeded. Please request a new OTP")
 	}
 
+	// The verification stays usable for the configured TTL instead of the OTP's remaining validity
 	session.Verified = true
-	return nil
+	session.ExpiresAt = time.Now().Add(verifiedTTL())
+	return otpStore.Update(session)
+}
+
+// MarkVerified records a verification done by an external provider, so IsVerified and
+// ConsumeVerification treat it like one done through VerifyOTPSession
+func MarkVerified(primaryIdentifier, email, phone string) error {
+	normalized := NormalizeIdentifier(primaryIdentifier)
+	session := &OTPSession{
+		Identifier: normalized,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(verifiedTTL()),
+		Verified:   true,
+	}
+	if email != "" {
+		session.Email = NormalizeIdentifier(email)
+	}
+	if phone != "" {
+		session.PhoneNumber = NormalizeIdentifier(phone)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	return otpStore.Save(session)
+}
+
+// verifiedTTL returns how long a successful verification remains valid
+func verifiedTTL() time.Duration {
+	return time.Duration(config.Get().OTP.VerifiedTTLMin) * time.Minute
 }
 
 // IsVerified checks if an identifier is verified
@@ -247,8 +556,45 @@ func IsVerified(identifier string) bool {
 	mu.RLock()
 	defer mu.RUnlock()
 
-	session, exists := otpStorage[normalized]
-	return exists && session.Verified
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		log.Printf("[OTP] Failed to look up session for %s: %v", normalized, err)
+		return false
+	}
+	return session != nil && session.Verified && !time.Now().After(session.ExpiresAt)
+}
+
+// HasActiveSession reports whether identifier has an OTP session that has not expired
+func HasActiveSession(identifier string) bool {
+	normalized := NormalizeIdentifier(identifier)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		log.Printf("[OTP] Failed to look up session for %s: %v", normalized, err)
+		return false
+	}
+	return session != nil && !time.Now().After(session.ExpiresAt)
+}
+
+// ConsumeVerification reports whether identifier has an unexpired verification and, if so,
+// removes it so the same OTP verification can't authorize another action
+func ConsumeVerification(identifier string) (bool, error) {
+	normalized := NormalizeIdentifier(identifier)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || !session.Verified || time.Now().After(session.ExpiresAt) {
+		return false, nil
+	}
+	return otpStore.ConsumeVerified(session)
 }
 
 // ClearOTPSession clears an OTP session
@@ -258,7 +604,14 @@ func ClearOTPSession(identifier string) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	delete(otpStorage, normalized)
+	session, err := otpStore.Find(normalized)
+	if err != nil {
+		log.Printf("[OTP] Failed to look up session for %s: %v", normalized, err)
+		return
+	}
+	if session != nil {
+		otpStore.Delete(session)
+	}
 }
 
 // CleanupExpiredSessions removes expired sessions
@@ -267,29 +620,32 @@ func CleanupExpiredSessions() {
 	defer mu.Unlock()
 
 	now := time.Now()
-	oneMinuteAgo := now.Add(-RateLimitMinutes * time.Minute)
 
 	// Clean up expired OTP sessions
-	for key, session := range otpStorage {
-		if now.After(session.ExpiresAt) {
-			delete(otpStorage, key)
-		}
+	if err := otpStore.DeleteExpired(now); err != nil {
+		log.Printf("[OTP] Failed to clean up expired sessions: %v", err)
 	}
 
 	// Clean up old rate limit entries (older than 1 minute)
-	for key, requests := range rateLimitStore {
-		validRequests := []time.Time{}
-		for _, reqTime := range requests {
-			if reqTime.After(oneMinuteAgo) {
-				validRequests = append(validRequests, reqTime)
-			}
-		}
-		if len(validRequests) == 0 {
-			delete(rateLimitStore, key)
-		} else {
-			rateLimitStore[key] = validRequests
-		}
-	}
+	otpRateLimiter.Cleanup(now)
 }
 
+// otpValidity returns how long a new code stays valid. It is read per session so a reloaded
+// OTP_EXPIRY_MINUTES applies to the next code sent.
+func otpValidity() time.Duration {
+	return time.Duration(config.Get().OTP.ValidityMin) * time.Minute
+}
 
+// CountOTPState returns the number of live OTP sessions and of identifiers tracked by the rate limiter
+func CountOTPState() (sessions, rateLimitKeys int, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if sessions, err = otpStore.CountActive(time.Now()); err != nil {
+		return 0, 0, err
+	}
+	if rateLimitKeys, err = otpRateLimiter.CountKeys(); err != nil {
+		return 0, 0, err
+	}
+	return sessions, rateLimitKeys, nil
+}
@@ -0,0 +1,332 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisTimeout bounds every Redis round trip made on behalf of an OTP request
+const redisTimeout = 2 * time.Second
+
+// ConnectRedis parses a redis:// URL and verifies the server is reachable
+func ConnectRedis(url string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return client, nil
+}
+
+// incrementAttemptsScript bumps the attempt counter only while the session still exists,
+// so a verification racing with expiry or deletion can't recreate a partial session
+var incrementAttemptsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// consumeVerifiedScript deletes a session and its aliases only if it is verified, so two
+// concurrent consumers can't both use the same verification
+var consumeVerifiedScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "verified") ~= "1" then
+	return 0
+end
+redis.call("DEL", unpack(KEYS))
+return 1
+`)
+
+// rateLimitScript counts a request in a fixed per-minute window that starts with the first
+// request (KEYS[1]) and, when ARGV[4] > 0, in a rolling daily window kept as a sorted set of
+// request times (KEYS[2]). A request is only recorded in the daily window if both limits allow it.
+// ARGV: minute window ms, max requests per minute, now ms, daily limit, daily window ms.
+// Returns {0, 0} if allowed, {1, ms until the minute window resets} or {2, 0} for the daily cap.
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[3])
+local daily_limit = tonumber(ARGV[4])
+local day = tonumber(ARGV[5])
+if daily_limit > 0 then
+	redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now - day)
+	if redis.call("ZCARD", KEYS[2]) >= daily_limit then
+		return {2, 0}
+	end
+end
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+if count > tonumber(ARGV[2]) then
+	return {1, redis.call("PTTL", KEYS[1])}
+end
+if daily_limit > 0 then
+	redis.call("ZADD", KEYS[2], now, now .. ":" .. count)
+	redis.call("PEXPIRE", KEYS[2], day)
+end
+return {0, 0}
+`)
+
+// redisOTPStore keeps OTP sessions in Redis so they are shared between replicas.
+// Sessions are hashes that expire with the OTP; email and phone aliases point at the
+// session's identifier and expire with it.
+type redisOTPStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisOTPStore creates an OTP store backed by Redis
+func NewRedisOTPStore(client *redis.Client, keyPrefix string) OTPStore {
+	return &redisOTPStore{client: client, prefix: keyPrefix}
+}
+
+func (r *redisOTPStore) sessionKey(identifier string) string {
+	return r.prefix + "otp:session:" + identifier
+}
+
+func (r *redisOTPStore) aliasKey(alias string) string {
+	return r.prefix + "otp:alias:" + alias
+}
+
+// aliases returns the email and phone keys a session can also be found by
+func (r *redisOTPStore) aliases(session *OTPSession) []string {
+	var keys []string
+	if session.Email != "" && session.Email != session.Identifier {
+		keys = append(keys, r.aliasKey(session.Email))
+	}
+	if session.PhoneNumber != "" && session.PhoneNumber != session.Identifier && session.PhoneNumber != session.Email {
+		keys = append(keys, r.aliasKey(session.PhoneNumber))
+	}
+	return keys
+}
+
+func (r *redisOTPStore) Save(session *OTPSession) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := r.sessionKey(session.Identifier)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, map[string]interface{}{
+			"otp_hash":     session.OTPHash,
+			"otp_cipher":   session.OTPCipher,
+			"email":        session.Email,
+			"phone":        session.PhoneNumber,
+			"attempts":     session.Attempts,
+			"verified":     session.Verified,
+			"resend_count": session.ResendCount,
+			"created_at":   session.CreatedAt.UnixMilli(),
+			"expires_at":   session.ExpiresAt.UnixMilli(),
+			"last_sent_at": session.LastSentAt.UnixMilli(),
+		})
+		pipe.PExpireAt(ctx, key, session.ExpiresAt)
+		for _, alias := range r.aliases(session) {
+			pipe.Set(ctx, alias, session.Identifier, 0)
+			pipe.PExpireAt(ctx, alias, session.ExpiresAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save OTP session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisOTPStore) Find(identifier string) (*OTPSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	session, err := r.load(ctx, identifier)
+	if err != nil || session != nil {
+		return session, err
+	}
+
+	// Fall back to the email/phone alias
+	primary, err := r.client.Get(ctx, r.aliasKey(identifier)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OTP session: %w", err)
+	}
+	return r.load(ctx, primary)
+}
+
+// load reads the session stored under a primary identifier, or nil if there is none
+func (r *redisOTPStore) load(ctx context.Context, identifier string) (*OTPSession, error) {
+	fields, err := r.client.HGetAll(ctx, r.sessionKey(identifier)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OTP session: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	attempts, _ := strconv.Atoi(fields["attempts"])
+	verified, _ := strconv.ParseBool(fields["verified"])
+	resendCount, _ := strconv.Atoi(fields["resend_count"])
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	expiresAt, _ := strconv.ParseInt(fields["expires_at"], 10, 64)
+	lastSentAt, _ := strconv.ParseInt(fields["last_sent_at"], 10, 64)
+
+	return &OTPSession{
+		Identifier:  identifier,
+		OTPHash:     fields["otp_hash"],
+		OTPCipher:   fields["otp_cipher"],
+		CreatedAt:   time.UnixMilli(createdAt),
+		ExpiresAt:   time.UnixMilli(expiresAt),
+		Attempts:    attempts,
+		Verified:    verified,
+		Email:       fields["email"],
+		PhoneNumber: fields["phone"],
+		ResendCount: resendCount,
+		LastSentAt:  time.UnixMilli(lastSentAt),
+	}, nil
+}
+
+func (r *redisOTPStore) Update(session *OTPSession) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	// HSET would recreate an expired session, so only touch it while it exists
+	key := r.sessionKey(session.Identifier)
+	exists, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to update OTP session: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"attempts", session.Attempts,
+			"verified", session.Verified,
+			"resend_count", session.ResendCount,
+			"last_sent_at", session.LastSentAt.UnixMilli(),
+			"expires_at", session.ExpiresAt.UnixMilli(),
+		)
+		// Verification extends the expiry, so move the keys' TTL with it
+		pipe.PExpireAt(ctx, key, session.ExpiresAt)
+		for _, alias := range r.aliases(session) {
+			pipe.PExpireAt(ctx, alias, session.ExpiresAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update OTP session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisOTPStore) IncrementAttempts(session *OTPSession) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	attempts, err := incrementAttemptsScript.Run(ctx, r.client, []string{r.sessionKey(session.Identifier)}).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record OTP attempt: %w", err)
+	}
+	return attempts, nil
+}
+
+func (r *redisOTPStore) Delete(session *OTPSession) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	keys := append([]string{r.sessionKey(session.Identifier)}, r.aliases(session)...)
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete OTP session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisOTPStore) ConsumeVerified(session *OTPSession) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	keys := append([]string{r.sessionKey(session.Identifier)}, r.aliases(session)...)
+	consumed, err := consumeVerifiedScript.Run(ctx, r.client, keys).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume OTP verification: %w", err)
+	}
+	return consumed == 1, nil
+}
+
+func (r *redisOTPStore) DeleteExpired(now time.Time) error {
+	// Keys carry the session's expiry, so Redis removes them itself
+	return nil
+}
+
+func (r *redisOTPStore) CountActive(now time.Time) (int, error) {
+	// Expired sessions are already gone, so every session key is live
+	return countRedisKeys(r.client, r.prefix+"otp:session:*")
+}
+
+// countRedisKeys counts the keys matching pattern.
+// SCAN is used rather than KEYS so large databases aren't blocked.
+func countRedisKeys(client *redis.Client, pattern string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	count := 0
+	iter := client.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		return 0, fmt.Errorf("failed to count keys: %w", err)
+	}
+	return count, nil
+}
+
+// redisOTPRateLimiter counts OTP requests in Redis so the limit holds across replicas
+type redisOTPRateLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisOTPRateLimiter creates an OTP rate limiter backed by Redis
+func NewRedisOTPRateLimiter(client *redis.Client, keyPrefix string) OTPRateLimiter {
+	return &redisOTPRateLimiter{client: client, prefix: keyPrefix}
+}
+
+func (r *redisOTPRateLimiter) Allow(identifier string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	keys := []string{r.prefix + "otp:ratelimit:" + identifier, r.prefix + "otp:daily:" + identifier}
+	limit := dailyLimit()
+	result, err := rateLimitScript.Run(ctx, r.client, keys,
+		(RateLimitMinutes * time.Minute).Milliseconds(), MaxRequestsPerMinute,
+		now.UnixMilli(), limit, DailyLimitWindow.Milliseconds()).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("failed to check OTP rate limit: %w", err)
+	}
+
+	switch result[0] {
+	case 1:
+		return rateLimitError(time.Duration(result[1]) * time.Millisecond)
+	case 2:
+		return dailyLimitError(limit)
+	}
+	return nil
+}
+
+func (r *redisOTPRateLimiter) Cleanup(now time.Time) {
+	// Counters expire with their window; daily sets are trimmed on use and expire a day after the last request
+}
+
+func (r *redisOTPRateLimiter) CountKeys() (int, error) {
+	return countRedisKeys(r.client, r.prefix+"otp:ratelimit:*")
+}
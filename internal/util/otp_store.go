@@ -0,0 +1,436 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"springstreet/internal/domain"
+	"springstreet/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+// OTPStore persists OTP sessions.
+// A session is keyed by its normalized primary identifier and can also be
+// looked up by the email or phone number it was created with.
+type OTPStore interface {
+	// Save stores a session, replacing any existing session for the same identifier
+	Save(session *OTPSession) error
+	// Find returns the session for an identifier or alias, or nil if none exists
+	Find(identifier string) (*OTPSession, error)
+	// Update persists changes to attempts, verification, expiry and resend state
+	Update(session *OTPSession) error
+	// IncrementAttempts atomically records a verification attempt and returns the new count,
+	// or 0 if the session no longer exists
+	IncrementAttempts(session *OTPSession) (int, error)
+	// Delete removes a session and all of its aliases
+	Delete(session *OTPSession) error
+	// ConsumeVerified atomically deletes a session if it is still verified,
+	// reporting whether it did, so each verification can only be used once
+	ConsumeVerified(session *OTPSession) (bool, error)
+	// DeleteExpired removes all sessions that expired before now
+	DeleteExpired(now time.Time) error
+	// CountActive returns the number of sessions that have not expired at now
+	CountActive(now time.Time) (int, error)
+}
+
+// ErrOTPStoreFull means the in-memory store is at its session limit and every session left is a
+// verification still waiting to be used, so none can be evicted
+var ErrOTPStoreFull = errors.New("too many OTP requests in progress. Please try again in a few minutes")
+
+// memoryOTPStore keeps OTP sessions in process memory.
+// Each session is stored once under its identifier; its email and phone number are aliases
+// pointing at that identifier. Callers must hold mu while using it.
+type memoryOTPStore struct {
+	sessions    map[string]*OTPSession // Keyed by OTPSession.Identifier
+	aliases     map[string]string      // Email or phone number -> Identifier
+	maxSessions int                    // 0 means unbounded
+}
+
+func newMemoryOTPStore(maxSessions int) *memoryOTPStore {
+	return &memoryOTPStore{
+		sessions:    make(map[string]*OTPSession),
+		aliases:     make(map[string]string),
+		maxSessions: maxSessions,
+	}
+}
+
+// NewMemoryOTPStore creates an in-memory OTP store (sessions are lost on restart).
+// Once maxSessions sessions are stored, saving a new one evicts expired sessions and then
+// the unverified session closest to expiry. Verified sessions are never evicted.
+func NewMemoryOTPStore(maxSessions int) OTPStore {
+	return newMemoryOTPStore(maxSessions)
+}
+
+// sessionAliases returns the keys other than the identifier a session can be found by
+func sessionAliases(session *OTPSession) []string {
+	var aliases []string
+	if session.Email != "" && session.Email != session.Identifier {
+		aliases = append(aliases, session.Email)
+	}
+	if session.PhoneNumber != "" && session.PhoneNumber != session.Identifier && session.PhoneNumber != session.Email {
+		aliases = append(aliases, session.PhoneNumber)
+	}
+	return aliases
+}
+
+func (m *memoryOTPStore) Save(session *OTPSession) error {
+	// The new session replaces any session stored under its identifier or aliases
+	aliases := sessionAliases(session)
+	for _, key := range append([]string{session.Identifier}, aliases...) {
+		if existing, _ := m.Find(key); existing != nil {
+			m.Delete(existing)
+		}
+	}
+
+	if m.maxSessions > 0 && len(m.sessions) >= m.maxSessions && !m.evict(time.Now()) {
+		return ErrOTPStoreFull
+	}
+
+	m.sessions[session.Identifier] = session
+	for _, alias := range aliases {
+		m.aliases[alias] = session.Identifier
+	}
+	return nil
+}
+
+// evict makes room for one session, reporting whether it could
+func (m *memoryOTPStore) evict(now time.Time) bool {
+	m.DeleteExpired(now)
+	if len(m.sessions) < m.maxSessions {
+		return true
+	}
+
+	var oldest *OTPSession
+	for _, session := range m.sessions {
+		// A verified session is someone part way through a form; evicting it would make them start over
+		if session.Verified {
+			continue
+		}
+		if oldest == nil || session.ExpiresAt.Before(oldest.ExpiresAt) {
+			oldest = session
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	m.Delete(oldest)
+	metrics.RecordOTPSessionEvicted()
+	return true
+}
+
+func (m *memoryOTPStore) Find(identifier string) (*OTPSession, error) {
+	if session, ok := m.sessions[identifier]; ok {
+		return session, nil
+	}
+	if primary, ok := m.aliases[identifier]; ok {
+		return m.sessions[primary], nil
+	}
+	return nil, nil
+}
+
+func (m *memoryOTPStore) Update(session *OTPSession) error {
+	// Sessions are shared pointers, so changes are already visible
+	return nil
+}
+
+func (m *memoryOTPStore) IncrementAttempts(session *OTPSession) (int, error) {
+	// Callers hold mu, which serializes attempts within the process
+	session.Attempts++
+	return session.Attempts, nil
+}
+
+func (m *memoryOTPStore) Delete(session *OTPSession) error {
+	if m.sessions[session.Identifier] != session {
+		return nil
+	}
+	delete(m.sessions, session.Identifier)
+	for _, alias := range sessionAliases(session) {
+		if m.aliases[alias] == session.Identifier {
+			delete(m.aliases, alias)
+		}
+	}
+	return nil
+}
+
+func (m *memoryOTPStore) ConsumeVerified(session *OTPSession) (bool, error) {
+	// Callers hold mu, so the check and delete can't interleave with another consumer
+	if m.sessions[session.Identifier] != session || !session.Verified {
+		return false, nil
+	}
+	return true, m.Delete(session)
+}
+
+func (m *memoryOTPStore) DeleteExpired(now time.Time) error {
+	for _, session := range m.sessions {
+		if now.After(session.ExpiresAt) {
+			m.Delete(session)
+		}
+	}
+	return nil
+}
+
+func (m *memoryOTPStore) CountActive(now time.Time) (int, error) {
+	count := 0
+	for _, session := range m.sessions {
+		if !now.After(session.ExpiresAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// OTPRateLimiter limits how often OTPs can be requested for an identifier
+type OTPRateLimiter interface {
+	// Allow records a request at now and returns an error if the identifier is over the limit
+	Allow(identifier string, now time.Time) error
+	// Cleanup drops request history that is outside the per-minute and daily windows
+	Cleanup(now time.Time)
+	// CountKeys returns the number of identifiers with tracked requests
+	CountKeys() (int, error)
+}
+
+// memoryOTPRateLimiter tracks request timestamps in process memory.
+// Callers must hold mu while using it.
+type memoryOTPRateLimiter struct {
+	requests map[string][]time.Time
+}
+
+func newMemoryOTPRateLimiter() *memoryOTPRateLimiter {
+	return &memoryOTPRateLimiter{requests: make(map[string][]time.Time)}
+}
+
+// NewMemoryOTPRateLimiter creates an in-memory OTP rate limiter (limits are per process)
+func NewMemoryOTPRateLimiter() OTPRateLimiter {
+	return newMemoryOTPRateLimiter()
+}
+
+func (m *memoryOTPRateLimiter) Allow(identifier string, now time.Time) error {
+	minuteStart := now.Add(-RateLimitMinutes * time.Minute)
+	limit := dailyLimit()
+
+	// Keep only requests inside the longest window
+	validRequests := []time.Time{}
+	for _, reqTime := range m.requests[identifier] {
+		if reqTime.After(rateLimitWindowStart(now, limit)) {
+			validRequests = append(validRequests, reqTime)
+		}
+	}
+
+	// Check if we've exceeded the per-minute limit
+	var lastMinute []time.Time
+	for i, reqTime := range validRequests {
+		if reqTime.After(minuteStart) {
+			lastMinute = validRequests[i:]
+			break
+		}
+	}
+	if len(lastMinute) >= MaxRequestsPerMinute {
+		timeUntilNextAllowed := lastMinute[0].Add(RateLimitMinutes * time.Minute).Sub(now)
+		if timeUntilNextAllowed > 0 {
+			return rateLimitError(timeUntilNextAllowed)
+		}
+	}
+
+	// Check the daily cap
+	if limit > 0 && len(validRequests) >= limit {
+		return dailyLimitError(limit)
+	}
+
+	m.requests[identifier] = append(validRequests, now)
+	return nil
+}
+
+// rateLimitWindowStart returns how far back requests must be kept: a day while the daily cap is on
+func rateLimitWindowStart(now time.Time, limit int) time.Time {
+	if limit > 0 {
+		return now.Add(-DailyLimitWindow)
+	}
+	return now.Add(-RateLimitMinutes * time.Minute)
+}
+
+func (m *memoryOTPRateLimiter) Cleanup(now time.Time) {
+	windowStart := rateLimitWindowStart(now, dailyLimit())
+	for key, requests := range m.requests {
+		validRequests := []time.Time{}
+		for _, reqTime := range requests {
+			if reqTime.After(windowStart) {
+				validRequests = append(validRequests, reqTime)
+			}
+		}
+		if len(validRequests) == 0 {
+			delete(m.requests, key)
+		} else {
+			m.requests[key] = validRequests
+		}
+	}
+}
+
+func (m *memoryOTPRateLimiter) CountKeys() (int, error) {
+	return len(m.requests), nil
+}
+
+// dbOTPStore keeps OTP sessions in the otp_sessions table so they survive restarts
+type dbOTPStore struct {
+	db *gorm.DB
+}
+
+// NewDBOTPStore creates an OTP store backed by the database
+func NewDBOTPStore(db *gorm.DB) OTPStore {
+	return &dbOTPStore{db: db}
+}
+
+func (d *dbOTPStore) Save(session *OTPSession) error {
+	record := toDomainOTPSession(session)
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := matchingSessions(tx, session).Delete(&domain.OTPSession{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save OTP session: %w", err)
+	}
+	return nil
+}
+
+// matchingSessions scopes db to every row Find could return for one of session's keys, so Save
+// and Delete don't leave rows behind that are still reachable through an alias
+func matchingSessions(db *gorm.DB, session *OTPSession) *gorm.DB {
+	keys := append([]string{session.Identifier}, sessionAliases(session)...)
+	return db.Where("identifier IN ? OR email IN ? OR phone IN ?", keys, keys, keys)
+}
+
+func (d *dbOTPStore) Find(identifier string) (*OTPSession, error) {
+	var record domain.OTPSession
+	err := d.db.Where("identifier = ? OR email = ? OR phone = ?", identifier, identifier, identifier).
+		Order("created_at DESC").
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OTP session: %w", err)
+	}
+	return fromDomainOTPSession(&record), nil
+}
+
+func (d *dbOTPStore) Update(session *OTPSession) error {
+	err := d.db.Model(&domain.OTPSession{}).
+		Where("identifier = ?", session.Identifier).
+		Updates(map[string]interface{}{
+			"attempts":     session.Attempts,
+			"verified":     session.Verified,
+			"resend_count": session.ResendCount,
+			"last_sent_at": session.LastSentAt,
+			"expires_at":   session.ExpiresAt,
+			"updated_at":   time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update OTP session: %w", err)
+	}
+	return nil
+}
+
+func (d *dbOTPStore) IncrementAttempts(session *OTPSession) (int, error) {
+	var attempts int
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.OTPSession{}).
+			Where("identifier = ?", session.Identifier).
+			Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&domain.OTPSession{}).
+			Where("identifier = ?", session.Identifier).
+			Pluck("attempts", &attempts).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record OTP attempt: %w", err)
+	}
+	return attempts, nil
+}
+
+func (d *dbOTPStore) Delete(session *OTPSession) error {
+	if err := matchingSessions(d.db, session).Delete(&domain.OTPSession{}).Error; err != nil {
+		return fmt.Errorf("failed to delete OTP session: %w", err)
+	}
+	return nil
+}
+
+func (d *dbOTPStore) ConsumeVerified(session *OTPSession) (bool, error) {
+	result := d.db.Where("identifier = ? AND verified = ?", session.Identifier, true).Delete(&domain.OTPSession{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to consume OTP verification: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (d *dbOTPStore) DeleteExpired(now time.Time) error {
+	if err := d.db.Where("expires_at < ?", now).Delete(&domain.OTPSession{}).Error; err != nil {
+		return fmt.Errorf("failed to delete expired OTP sessions: %w", err)
+	}
+	return nil
+}
+
+func (d *dbOTPStore) CountActive(now time.Time) (int, error) {
+	var count int64
+	if err := d.db.Model(&domain.OTPSession{}).Where("expires_at >= ?", now).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count OTP sessions: %w", err)
+	}
+	return int(count), nil
+}
+
+// Helper functions to convert between the store and database representations
+func toDomainOTPSession(session *OTPSession) *domain.OTPSession {
+	record := &domain.OTPSession{
+		Identifier:  session.Identifier,
+		OTPHash:     session.OTPHash,
+		OTPCipher:   session.OTPCipher,
+		Attempts:    session.Attempts,
+		Verified:    session.Verified,
+		ResendCount: session.ResendCount,
+		ExpiresAt:   session.ExpiresAt,
+		CreatedAt:   session.CreatedAt,
+	}
+	if !session.LastSentAt.IsZero() {
+		lastSent := session.LastSentAt
+		record.LastSentAt = &lastSent
+	}
+	if session.Email != "" {
+		email := session.Email
+		record.Email = &email
+	}
+	if session.PhoneNumber != "" {
+		phone := session.PhoneNumber
+		record.Phone = &phone
+	}
+	return record
+}
+
+func fromDomainOTPSession(record *domain.OTPSession) *OTPSession {
+	session := &OTPSession{
+		Identifier:  record.Identifier,
+		OTPHash:     record.OTPHash,
+		OTPCipher:   record.OTPCipher,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+		Attempts:    record.Attempts,
+		Verified:    record.Verified,
+		ResendCount: record.ResendCount,
+	}
+	if record.LastSentAt != nil {
+		session.LastSentAt = *record.LastSentAt
+	}
+	if record.Email != nil {
+		session.Email = *record.Email
+	}
+	if record.Phone != nil {
+		session.PhoneNumber = *record.Phone
+	}
+	return session
+}
@@ -0,0 +1,259 @@
+package util
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"springstreet/internal/config"
+	"springstreet/internal/domain"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	_ "modernc.org/sqlite"
+)
+
+// loadTestConfig loads the configuration, which supplies the key OTP codes are hashed with
+func loadTestConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("SECRET_KEY", "test-secret-key-that-is-long-enough-0123456789")
+	if _, err := config.Load(); err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+}
+
+// openTestOTPDB returns a SQLite database file holding the otp_sessions table. Opening the
+// same path again stands in for a restarted server.
+func openTestOTPDB(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open SQLite: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite", DSN: path, Conn: sqlDB}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	if err := db.AutoMigrate(&domain.OTPSession{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	return db
+}
+
+// useOTPBackends swaps in store and a fresh rate limiter for the rest of the test
+func useOTPBackends(t *testing.T, store OTPStore) {
+	t.Helper()
+	SetOTPStore(store)
+	SetOTPRateLimiter(NewMemoryOTPRateLimiter())
+	t.Cleanup(func() {
+		SetOTPStore(NewMemoryOTPStore(0))
+		SetOTPRateLimiter(NewMemoryOTPRateLimiter())
+	})
+}
+
+// hasSession reports whether the store holds a session for identifier
+func hasSession(t *testing.T, identifier string) bool {
+	t.Helper()
+	session, err := otpStore.Find(NormalizeIdentifier(identifier))
+	if err != nil {
+		t.Fatalf("Find(%s): %v", identifier, err)
+	}
+	return session != nil
+}
+
+func TestDBOTPStore(t *testing.T) {
+	loadTestConfig(t)
+	path := filepath.Join(t.TempDir(), "otp.db")
+	useOTPBackends(t, NewDBOTPStore(openTestOTPDB(t, path)))
+
+	otp, identifier, err := CreateOTPSessionWithBoth("investor@example.com", "investor@example.com", "+919876543210", EmailOTPFormat())
+	if err != nil {
+		t.Fatalf("CreateOTPSessionWithBoth: %v", err)
+	}
+
+	// A new store on the same database stands in for a restarted server
+	useOTPBackends(t, NewDBOTPStore(openTestOTPDB(t, path)))
+
+	if !hasSession(t, identifier) {
+		t.Fatal("session did not survive the restart")
+	}
+	if !hasSession(t, "+919876543210") {
+		t.Fatal("session could not be found by its phone number after the restart")
+	}
+	if err := VerifyOTPSession(identifier, otp); err != nil {
+		t.Fatalf("VerifyOTPSession after the restart: %v", err)
+	}
+	if !IsVerified(identifier) {
+		t.Fatal("IsVerified = false after verifying")
+	}
+}
+
+func TestDBOTPStoreDeleteRemovesAliasedRows(t *testing.T) {
+	db := openTestOTPDB(t, filepath.Join(t.TempDir(), "otp.db"))
+	useOTPBackends(t, NewDBOTPStore(db))
+
+	if _, _, err := CreateOTPSession("+919876543210", PhoneOTPFormat()); err != nil {
+		t.Fatalf("CreateOTPSession: %v", err)
+	}
+	// A later session keyed by email but sharing the phone replaces the first one
+	if _, _, err := CreateOTPSessionWithBoth("investor@example.com", "investor@example.com", "+919876543210", EmailOTPFormat()); err != nil {
+		t.Fatalf("CreateOTPSessionWithBoth: %v", err)
+	}
+	var count int64
+	db.Model(&domain.OTPSession{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("otp_sessions rows = %d, want 1", count)
+	}
+
+	ClearOTPSession("investor@example.com")
+	if hasSession(t, "+919876543210") {
+		t.Fatal("session is still reachable through its phone number after being cleared")
+	}
+}
+
+// forEachOTPBackend runs test against the in-memory and the database OTP store
+func forEachOTPBackend(t *testing.T, test func(t *testing.T, store OTPStore)) {
+	backends := map[string]func(t *testing.T) OTPStore{
+		"memory": func(t *testing.T) OTPStore { return NewMemoryOTPStore(0) },
+		"db": func(t *testing.T) OTPStore {
+			return NewDBOTPStore(openTestOTPDB(t, filepath.Join(t.TempDir(), "otp.db")))
+		},
+	}
+	for name, newStore := range backends {
+		t.Run(name, func(t *testing.T) {
+			loadTestConfig(t)
+			store := newStore(t)
+			useOTPBackends(t, store)
+			test(t, store)
+		})
+	}
+}
+
+func TestOTPStoreAttemptCounting(t *testing.T) {
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		otp, identifier, err := CreateOTPSession("+919876543210", PhoneOTPFormat())
+		if err != nil {
+			t.Fatalf("CreateOTPSession: %v", err)
+		}
+		wrong := "000000"
+		if wrong == otp {
+			wrong = "111111"
+		}
+
+		for i := 1; i < MaxVerificationAttempts; i++ {
+			err := VerifyOTPSession(identifier, wrong)
+			want := fmt.Sprintf("invalid OTP. %d attempt(s) remaining", MaxVerificationAttempts-i)
+			if err == nil || err.Error() != want {
+				t.Fatalf("attempt %d: VerifyOTPSession = %v, want %q", i, err, want)
+			}
+		}
+		session, err := store.Find(identifier)
+		if err != nil || session == nil || session.Attempts != MaxVerificationAttempts-1 {
+			t.Fatalf("stored session = %+v, %v, want %d attempts", session, err, MaxVerificationAttempts-1)
+		}
+
+		// The last attempt uses up the session, even with the right code after it
+		if err := VerifyOTPSession(identifier, wrong); err == nil || !strings.Contains(err.Error(), "Maximum attempts exceeded") {
+			t.Fatalf("last attempt: VerifyOTPSession = %v, want maximum attempts exceeded", err)
+		}
+		if err := VerifyOTPSession(identifier, otp); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("right code after the last attempt: VerifyOTPSession = %v, want session not found", err)
+		}
+		if IsVerified(identifier) {
+			t.Fatal("IsVerified = true after running out of attempts")
+		}
+	})
+}
+
+func TestOTPStoreConcurrentAttemptsStayWithinLimit(t *testing.T) {
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		otp, identifier, err := CreateOTPSession("+919876543210", PhoneOTPFormat())
+		if err != nil {
+			t.Fatalf("CreateOTPSession: %v", err)
+		}
+		wrong := "000000"
+		if wrong == otp {
+			wrong = "111111"
+		}
+
+		const guesses = 3 * MaxVerificationAttempts
+		var counted atomic.Int32
+		var wg sync.WaitGroup
+		for range guesses {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := VerifyOTPSession(identifier, wrong); err != nil && strings.HasPrefix(err.Error(), "invalid OTP.") {
+					counted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := counted.Load(); got != MaxVerificationAttempts {
+			t.Fatalf("%d guesses were checked, want %d", got, MaxVerificationAttempts)
+		}
+		if hasSession(t, identifier) {
+			t.Fatal("session is still stored after running out of attempts")
+		}
+	})
+}
+
+func TestOTPStoreExpiry(t *testing.T) {
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		otp, identifier, err := CreateOTPSession("+919876543210", PhoneOTPFormat())
+		if err != nil {
+			t.Fatalf("CreateOTPSession: %v", err)
+		}
+		session, err := store.Find(identifier)
+		if err != nil || session == nil {
+			t.Fatalf("Find = %+v, %v", session, err)
+		}
+		session.ExpiresAt = time.Now().Add(-time.Second)
+		if err := store.Update(session); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		if err := VerifyOTPSession(identifier, otp); err == nil || !strings.Contains(err.Error(), "expired") {
+			t.Fatalf("VerifyOTPSession = %v, want expired", err)
+		}
+		if IsVerified(identifier) {
+			t.Fatal("IsVerified = true for an expired session")
+		}
+	})
+}
+
+func TestOTPStoreCleanupRemovesOnlyExpiredSessions(t *testing.T) {
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		_, active, err := CreateOTPSession("+919876543210", PhoneOTPFormat())
+		if err != nil {
+			t.Fatalf("CreateOTPSession: %v", err)
+		}
+		_, expired, err := CreateOTPSession("+919812345678", PhoneOTPFormat())
+		if err != nil {
+			t.Fatalf("CreateOTPSession: %v", err)
+		}
+		session, _ := store.Find(expired)
+		session.ExpiresAt = time.Now().Add(-time.Second)
+		if err := store.Update(session); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		CleanupExpiredSessions()
+
+		if session, _ := store.Find(expired); session != nil {
+			t.Fatal("CleanupExpiredSessions kept the expired session")
+		}
+		if !hasSession(t, active) {
+			t.Fatal("CleanupExpiredSessions removed an active session")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// verifyTestSession creates and verifies an OTP session for identifier
+func verifyTestSession(t *testing.T, identifier string) string {
+	t.Helper()
+	otp, normalized, err := CreateOTPSession(identifier, PhoneOTPFormat())
+	if err != nil {
+		t.Fatalf("CreateOTPSession: %v", err)
+	}
+	if err := VerifyOTPSession(normalized, otp); err != nil {
+		t.Fatalf("VerifyOTPSession: %v", err)
+	}
+	return normalized
+}
+
+func TestVerificationExpiresAfterVerifiedTTL(t *testing.T) {
+	t.Setenv("OTP_VERIFIED_TTL_MINUTES", "30")
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		identifier := verifyTestSession(t, "+919876543210")
+
+		session, err := store.Find(identifier)
+		if err != nil || session == nil {
+			t.Fatalf("Find = %+v, %v", session, err)
+		}
+		// The verification lasts OTP_VERIFIED_TTL_MINUTES, not the code's remaining validity
+		if remaining := time.Until(session.ExpiresAt); remaining < 29*time.Minute || remaining > 30*time.Minute {
+			t.Fatalf("verification expires in %v, want 30m", remaining)
+		}
+		if !IsVerified(identifier) {
+			t.Fatal("IsVerified = false right after verifying")
+		}
+
+		session.ExpiresAt = time.Now().Add(-time.Second)
+		if err := store.Update(session); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if IsVerified(identifier) {
+			t.Fatal("IsVerified = true after the verified TTL passed")
+		}
+		if ok, err := ConsumeVerification(identifier); ok || err != nil {
+			t.Fatalf("ConsumeVerification = %v, %v after the verified TTL passed, want false", ok, err)
+		}
+	})
+}
+
+func TestConsumeVerificationOnlyOnce(t *testing.T) {
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		identifier := verifyTestSession(t, "+919876543210")
+
+		ok, err := ConsumeVerification(identifier)
+		if !ok || err != nil {
+			t.Fatalf("first ConsumeVerification = %v, %v, want true", ok, err)
+		}
+		ok, err = ConsumeVerification(identifier)
+		if ok || err != nil {
+			t.Fatalf("second ConsumeVerification = %v, %v, want false", ok, err)
+		}
+		if IsVerified(identifier) {
+			t.Fatal("IsVerified = true after the verification was consumed")
+		}
+	})
+}
+
+func TestConsumeVerificationConcurrently(t *testing.T) {
+	forEachOTPBackend(t, func(t *testing.T, store OTPStore) {
+		identifier := verifyTestSession(t, "+919876543210")
+
+		var consumed atomic.Int32
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := ConsumeVerification(identifier)
+				if err != nil {
+					t.Errorf("ConsumeVerification: %v", err)
+				}
+				if ok {
+					consumed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := consumed.Load(); got != 1 {
+			t.Fatalf("the verification was consumed %d times, want once", got)
+		}
+	})
+}
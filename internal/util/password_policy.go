@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"springstreet/internal/config"
+)
+
+// passwordSpecialChars are the characters that satisfy the special character rule
+const passwordSpecialChars = "!@#$%^&*"
+
+// PasswordPolicyError lists every password rule a password failed
+type PasswordPolicyError struct {
+	Unmet []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	rules := e.Unmet
+	if len(rules) > 1 {
+		rules = append(append([]string{}, rules[:len(rules)-1]...), "and "+rules[len(rules)-1])
+	}
+	separator := ", "
+	if len(rules) == 2 {
+		separator = " "
+	}
+	return "password must " + strings.Join(rules, separator)
+}
+
+// ValidatePasswordStrength checks a password against the policy: a minimum length
+// (PASSWORD_MIN_LENGTH) and at least one uppercase letter, digit and special character.
+// All unmet rules are reported together so users can fix them in one go.
+func ValidatePasswordStrength(password string) error {
+	minLength := config.Get().Auth.PasswordMinLength
+
+	var hasUpper, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case strings.ContainsRune(passwordSpecialChars, r):
+			hasSpecial = true
+		}
+	}
+
+	var unmet []string
+	if len([]rune(password)) < minLength {
+		unmet = append(unmet, fmt.Sprintf("be at least %d characters long", minLength))
+	}
+	if !hasUpper {
+		unmet = append(unmet, "contain an uppercase letter")
+	}
+	if !hasDigit {
+		unmet = append(unmet, "contain a digit")
+	}
+	if !hasSpecial {
+		unmet = append(unmet, fmt.Sprintf("contain one of %s", passwordSpecialChars))
+	}
+
+	if len(unmet) > 0 {
+		return &PasswordPolicyError{Unmet: unmet}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidatePasswordStrength(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "8")
+	loadTestConfig(t)
+
+	for _, tc := range []struct {
+		password string
+		want     string // empty when the password is accepted
+	}{
+		{"Abcdef1!", ""},
+		{"Pässwörd9#", ""},
+		{"Abcde1!", "password must be at least 8 characters long"},
+		{"abcdefg1!", "password must contain an uppercase letter"},
+		{"Abcdefgh!", "password must contain a digit"},
+		{"Abcdefgh1", "password must contain one of !@#$%^&*"},
+		{"Abcdefgh1?", "password must contain one of !@#$%^&*"},
+		{"abcdefg1", "password must contain an uppercase letter and contain one of !@#$%^&*"},
+		{"abcdefgh", "password must contain an uppercase letter, contain a digit, and contain one of !@#$%^&*"},
+		{"ABCDEFG1", "password must contain one of !@#$%^&*"},
+		{"abc", "password must be at least 8 characters long, contain an uppercase letter, contain a digit, and contain one of !@#$%^&*"},
+		{"", "password must be at least 8 characters long, contain an uppercase letter, contain a digit, and contain one of !@#$%^&*"},
+	} {
+		err := ValidatePasswordStrength(tc.password)
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("ValidatePasswordStrength(%q) = %v, want nil", tc.password, err)
+		case tc.want != "" && (err == nil || err.Error() != tc.want):
+			t.Errorf("ValidatePasswordStrength(%q) = %v, want %q", tc.password, err, tc.want)
+		}
+	}
+}
+
+func TestValidatePasswordStrengthMinLength(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	loadTestConfig(t)
+
+	if err := ValidatePasswordStrength("Abcdefghij1!"); err != nil {
+		t.Errorf("12 characters: %v, want nil", err)
+	}
+	err := ValidatePasswordStrength("Abcdefghi1!")
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Unmet) != 1 {
+		t.Fatalf("11 characters: %v, want only the length rule unmet", err)
+	}
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrInvalidPhoneNumber is returned for numbers that can't be dialled
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// NormalizePhoneE164 returns phone in E.164 format (+919876543210). Numbers without a leading
+// "+" are read as local to defaultRegion, an ISO 3166 country code such as "IN".
+func NormalizePhoneE164(phone, defaultRegion string) (string, error) {
+	number, err := phonenumbers.Parse(strings.TrimSpace(phone), strings.ToUpper(defaultRegion))
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPhoneNumber, phone)
+	}
+	return phonenumbers.Format(number, phonenumbers.E164), nil
+}
+
+// PhoneRegion returns the ISO 3166 country code of phone, such as "IN", or "" if it can't
+// be told. Numbers without a leading "+" are read as local to defaultRegion.
+func PhoneRegion(phone, defaultRegion string) string {
+	number, err := phonenumbers.Parse(strings.TrimSpace(phone), strings.ToUpper(defaultRegion))
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		return ""
+	}
+	region := phonenumbers.GetRegionCodeForNumber(number)
+	if region == phonenumbers.UNKNOWN_REGION {
+		return ""
+	}
+	return region
+}
+
+// IsRegionCode reports whether region is an ISO 3166 country code with a calling code
+func IsRegionCode(region string) bool {
+	return len(region) == 2 && phonenumbers.GetCountryCodeForRegion(strings.ToUpper(region)) != 0
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhoneE164(t *testing.T) {
+	for _, tc := range []struct {
+		phone  string
+		region string
+		want   string
+	}{
+		// Indian local formats
+		{"9876543210", "IN", "+919876543210"},
+		{"098765 43210", "IN", "+919876543210"},
+		{"98765-43210", "IN", "+919876543210"},
+		{" 9876543210 ", "in", "+919876543210"},
+
+		// Already E.164, whatever the default region
+		{"+919876543210", "IN", "+919876543210"},
+		{"+91 98765 43210", "US", "+919876543210"},
+		{"+14155552671", "IN", "+14155552671"},
+		{"+44 20 7946 0958", "IN", "+442079460958"},
+
+		// Local to another default region
+		{"(415) 555-2671", "US", "+14155552671"},
+	} {
+		got, err := NormalizePhoneE164(tc.phone, tc.region)
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizePhoneE164(%q, %s) = %q, %v, want %q", tc.phone, tc.region, got, err, tc.want)
+		}
+	}
+}
+
+func TestNormalizePhoneE164RejectsGarbage(t *testing.T) {
+	for _, phone := range []string{
+		"",
+		"not a number",
+		"12345",
+		"98765432101234",
+		"+000000000000",
+		"0000000000",
+	} {
+		if got, err := NormalizePhoneE164(phone, "IN"); !errors.Is(err, ErrInvalidPhoneNumber) {
+			t.Errorf("NormalizePhoneE164(%q) = %q, %v, want ErrInvalidPhoneNumber", phone, got, err)
+		}
+	}
+}
+
+func TestPhoneRegion(t *testing.T) {
+	for _, tc := range []struct {
+		phone string
+		want  string
+	}{
+		{"9876543210", "IN"},
+		{"+14155552671", "US"},
+		{"garbage", ""},
+	} {
+		if got := PhoneRegion(tc.phone, "IN"); got != tc.want {
+			t.Errorf("PhoneRegion(%q) = %q, want %q", tc.phone, got, tc.want)
+		}
+	}
+}
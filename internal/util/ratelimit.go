@@ -0,0 +1,101 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is an in-memory sliding window rate limiter keyed by an arbitrary string.
+// Stale keys are pruned at most once per window so memory stays bounded.
+type RateLimiter struct {
+	limit       int
+	window      time.Duration
+	mu          sync.Mutex
+	requests    map[string][]time.Time
+	lastCleanup time.Time
+}
+
+// NewRateLimiter creates a rate limiter that allows limit events per window for each key
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:       limit,
+		window:      window,
+		requests:    make(map[string][]time.Time),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+// A non-positive limit disables rate limiting.
+func (l *RateLimiter) Allow(key string) bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastCleanup) > l.window {
+		l.cleanup(now)
+	}
+
+	valid := l.prune(key, now)
+	if len(valid) >= l.limit {
+		l.requests[key] = valid
+		return false
+	}
+
+	l.requests[key] = append(valid, now)
+	return true
+}
+
+// RetryAfter returns how long until key may have another event, or 0 if it may now
+func (l *RateLimiter) RetryAfter(key string) time.Duration {
+	if l.limit <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	valid := l.prune(key, now)
+	if len(valid) < l.limit {
+		return 0
+	}
+	// The window frees a slot when the event limit places back expires
+	return valid[len(valid)-l.limit].Add(l.window).Sub(now)
+}
+
+// Cleanup removes keys with no events inside the window
+func (l *RateLimiter) Cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cleanup(time.Now())
+}
+
+// cleanup removes stale keys; callers must hold mu
+func (l *RateLimiter) cleanup(now time.Time) {
+	l.lastCleanup = now
+	for key := range l.requests {
+		if valid := l.prune(key, now); len(valid) == 0 {
+			delete(l.requests, key)
+		} else {
+			l.requests[key] = valid
+		}
+	}
+}
+
+// prune returns the events for key that are still inside the window
+func (l *RateLimiter) prune(key string, now time.Time) []time.Time {
+	cutoff := now.Add(-l.window)
+	valid := []time.Time{}
+	for _, t := range l.requests[key] {
+		if t.After(cutoff) {
+			valid = append(valid, t)
+		}
+	}
+	return valid
+}
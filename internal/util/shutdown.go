@@ -0,0 +1,120 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ShutdownManager stops the components of a server together when it shuts down.
+// Components register a shutdown function at startup; Shutdown calls them all at once, except
+// that a component registered to stop after others waits for them first.
+type ShutdownManager struct {
+	mu         sync.Mutex
+	components []shutdownComponent
+}
+
+type shutdownComponent struct {
+	name  string
+	fn    func(ctx context.Context) error
+	after []string
+}
+
+// NewShutdownManager creates a shutdown manager with no components
+func NewShutdownManager() *ShutdownManager {
+	return &ShutdownManager{}
+}
+
+// Register adds a component to stop on shutdown, once the components named in after have
+// stopped. Names must be unique, and those in after already registered. fn should return once the component has
+// stopped, or with ctx's error once ctx expires.
+func (m *ShutdownManager) Register(name string, fn func(ctx context.Context) error, after ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registered := func(name string) bool {
+		return slices.ContainsFunc(m.components, func(c shutdownComponent) bool { return c.name == name })
+	}
+	if registered(name) {
+		panic(fmt.Sprintf("shutdown component %q registered twice", name))
+	}
+	for _, dep := range after {
+		if !registered(dep) {
+			panic(fmt.Sprintf("shutdown component %q registered after unknown component %q", name, dep))
+		}
+	}
+	m.components = append(m.components, shutdownComponent{name: name, fn: fn, after: after})
+}
+
+// Shutdown calls every registered function concurrently with ctx, each as soon as the
+// components it comes after have stopped (or failed to), and logs how long each took. It
+// returns once all of them have returned, or when ctx expires, in which case the components
+// still stopping are logged and ctx's error is returned. Otherwise it returns the first error
+// a component returned.
+func (m *ShutdownManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	components := append([]shutdownComponent(nil), m.components...)
+	m.mu.Unlock()
+
+	var (
+		mu      sync.Mutex
+		pending = make(map[string]bool, len(components))
+		stopped = make(map[string]chan struct{}, len(components))
+		g       errgroup.Group
+		start   = time.Now()
+	)
+	for _, c := range components {
+		pending[c.name] = true
+		stopped[c.name] = make(chan struct{})
+	}
+	for _, c := range components {
+		g.Go(func() error {
+			defer close(stopped[c.name])
+			for _, dep := range c.after {
+				select {
+				case <-stopped[dep]:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			err := c.fn(ctx)
+			elapsed := time.Since(start).Round(time.Millisecond)
+			mu.Lock()
+			delete(pending, c.name)
+			mu.Unlock()
+
+			if err != nil {
+				log.Printf("[SHUTDOWN] %s failed after %v: %v", c.name, elapsed, err)
+			} else {
+				log.Printf("[SHUTDOWN] %s stopped in %v", c.name, elapsed)
+			}
+			return err
+		})
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		mu.Lock()
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		mu.Unlock()
+		sort.Strings(names)
+		log.Printf("[SHUTDOWN] Warning: timed out after %v waiting for %v", time.Since(start).Round(time.Millisecond), names)
+		return ctx.Err()
+	}
+}
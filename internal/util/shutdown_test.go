@@ -0,0 +1,77 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownManagerStopsComponentsInOrder(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		stopped []string
+	)
+	stop := func(name string, delay time.Duration, err error) func(context.Context) error {
+		return func(context.Context) error {
+			time.Sleep(delay)
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+			return err
+		}
+	}
+
+	m := NewShutdownManager()
+	m.Register("background tasks", stop("background tasks", 20*time.Millisecond, nil))
+	m.Register("metrics", stop("metrics", 0, nil))
+	m.Register("worker pool", stop("worker pool", 0, errors.New("pool failed")), "background tasks")
+	// A failed dependency still lets the components after it stop
+	m.Register("email queue", stop("email queue", 0, nil), "worker pool")
+	m.Register("database", stop("database", 0, nil), "email queue", "metrics")
+
+	if err := m.Shutdown(context.Background()); err == nil || err.Error() != "pool failed" {
+		t.Fatalf("Shutdown = %v, want the worker pool's error", err)
+	}
+	// metrics depends on nothing, so it doesn't wait for the background tasks
+	want := []string{"metrics", "background tasks", "worker pool", "email queue", "database"}
+	if !slices.Equal(stopped, want) {
+		t.Fatalf("stopped %v, want %v", stopped, want)
+	}
+}
+
+func TestShutdownManagerTimesOutWaitingForDependency(t *testing.T) {
+	m := NewShutdownManager()
+	m.Register("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Err()
+	})
+	var closed atomic.Bool
+	m.Register("database", func(context.Context) error {
+		closed.Store(true)
+		return nil
+	}, "stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want the context's deadline error", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if closed.Load() {
+		t.Fatal("database was stopped although the component it comes after never stopped in time")
+	}
+}
+
+func TestShutdownManagerRejectsUnknownDependency(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Register after an unregistered component did not panic")
+		}
+	}()
+	NewShutdownManager().Register("database", func(context.Context) error { return nil }, "email queue")
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"springstreet/internal/config"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes; 6 digit codes are their last 6 digits
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		if code != tc.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tc.unix, code, tc.want)
+		}
+	}
+}
+
+func TestValidateTOTPAllowsClockDrift(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	previous, _ := TOTPCode(rfc6238Secret, TOTPStep(now)-1)
+	stale, _ := TOTPCode(rfc6238Secret, TOTPStep(now)-2)
+
+	if step, ok := ValidateTOTP(rfc6238Secret, previous, now, 0); !ok || step != TOTPStep(now)-1 {
+		t.Fatalf("ValidateTOTP(previous step) = %d, %v", step, ok)
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, stale, now, 0); ok {
+		t.Fatal("ValidateTOTP accepted a code two steps old")
+	}
+}
+
+func TestValidateTOTPRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := TOTPCode(rfc6238Secret, TOTPStep(now))
+
+	step, ok := ValidateTOTP(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("ValidateTOTP rejected the current code")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, code, now, step); ok {
+		t.Fatal("ValidateTOTP accepted a code that was already used")
+	}
+}
+
+func TestGenerateTOTPSecretRoundTrips(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	code, err := TOTPCode(secret, TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	if _, ok := ValidateTOTP(secret, code, time.Now(), 0); !ok {
+		t.Fatal("ValidateTOTP rejected a code for a generated secret")
+	}
+}
+
+func TestSealTOTPSecret(t *testing.T) {
+	loadTestConfig(t)
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+
+	sealed, err := SealTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("SealTOTPSecret: %v", err)
+	}
+	if strings.Contains(sealed, secret) {
+		t.Fatal("sealed secret contains the plaintext secret")
+	}
+	opened, err := OpenTOTPSecret(sealed)
+	if err != nil || opened != secret {
+		t.Fatalf("OpenTOTPSecret = %q, %v, want the original secret", opened, err)
+	}
+
+	// A different SECRET_KEY can't open it
+	t.Setenv("SECRET_KEY", "another-secret-key-that-is-long-enough-9876543210")
+	if _, err := config.Load(); err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	if _, err := OpenTOTPSecret(sealed); err == nil {
+		t.Fatal("OpenTOTPSecret opened a secret sealed under another key")
+	}
+}